@@ -0,0 +1,160 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/hantaowang/dispatch/pkg/client"
+	"github.com/hantaowang/dispatch/pkg/client/informers/externalversions"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+	"github.com/hantaowang/dispatch/pkg/proxy"
+	"github.com/hantaowang/dispatch/pkg/server"
+)
+
+func main() {
+	cfg := server.Config{ClaimMapping: oidc.DefaultClaimMapping()}
+	var address, sessionKeyFile, kubeconfig, master string
+	var proxyAddress, proxyAuditDir, proxyCertFile, proxyKeyFile string
+	var proxyInsecure bool
+	var logLevel, logFormat string
+
+	pflag.StringVar(&address, "address", ":8080", "address to serve on")
+	pflag.StringVar(&kubeconfig, "kubeconfig", "", "path to a kubeconfig, defaults to the in-cluster config or ~/.kube/config")
+	pflag.StringVar(&master, "master", "", "address of the Kubernetes API server, overrides the kubeconfig")
+	pflag.StringVar(&cfg.DispatchNamespace, "dispatch-namespace", "dispatch", "namespace holding DispatchUsers")
+	pflag.StringVar(&cfg.Issuer, "oidc-issuer", "https://accounts.google.com", "OpenID Connect issuer URL")
+	pflag.StringVar(&cfg.ClientID, "oidc-client-id", "", "OAuth2 client ID")
+	pflag.StringVar(&cfg.RedirectURL, "oidc-redirect-url", "http://localhost:8080/callback", "URL of the /callback endpoint registered with the provider")
+	pflag.StringSliceVar(&cfg.Scopes, "oidc-extra-scopes", nil, "scopes to request besides openid, email and profile")
+	pflag.StringVar(&cfg.ClaimMapping.UserIDClaim, "oidc-userid-claim", cfg.ClaimMapping.UserIDClaim, "claim mapped to DispatchUser userID")
+	pflag.StringVar(&cfg.ClaimMapping.EmailClaim, "oidc-email-claim", cfg.ClaimMapping.EmailClaim, "claim holding the email address")
+	pflag.StringVar(&cfg.ClaimMapping.GroupsClaim, "oidc-groups-claim", cfg.ClaimMapping.GroupsClaim, "claim mapped to DispatchUser groups")
+	pflag.StringVar(&cfg.ClaimMapping.GroupsPrefix, "oidc-groups-prefix", "", "prefix added to every group name")
+	pflag.StringVar(&sessionKeyFile, "session-key-file", "", "file holding the key used to sign session cookies")
+	pflag.BoolVar(&cfg.SecureCookies, "secure-cookies", true, "only send session cookies over HTTPS, needs an https --oidc-redirect-url")
+	pflag.StringVar(&cfg.DefaultRole, "default-role", "edit", "role of grants without one, the defaultRole of the controller")
+	pflag.StringSliceVar(&cfg.ReportViewers, "report-viewers", nil, "users that may read the cost reports of everyone")
+	pflag.StringSliceVar(&cfg.ReportViewerGroups, "report-viewer-groups", nil, "groups that may read the cost reports of everyone")
+	pflag.StringVar(&proxyAddress, "proxy-address", "", "address to serve the Kubernetes API proxy on, disabled if empty")
+	pflag.StringVar(&proxyAuditDir, "proxy-audit-dir", "", "directory for the per-user request audit trail of the proxy")
+	pflag.StringVar(&proxyCertFile, "proxy-tls-cert-file", "", "TLS certificate of the proxy")
+	pflag.StringVar(&proxyKeyFile, "proxy-tls-key-file", "", "TLS key of the proxy")
+	pflag.BoolVar(&proxyInsecure, "proxy-insecure", false, "serve the proxy over plain HTTP, tokens travel in the clear")
+	pflag.StringVar(&logLevel, "log-level", "info", "debug, info, warn or error")
+	pflag.StringVar(&logFormat, "log-format", "text", "text or json")
+	pflag.Parse()
+
+	level, err := logging.ParseLevel(logLevel)
+	if err != nil {
+		logging.Error("Invalid log level", "error", err)
+		os.Exit(1)
+	}
+	if err := logging.Configure(os.Stderr, level, logFormat); err != nil {
+		logging.Error("Invalid log format", "error", err)
+		os.Exit(1)
+	}
+
+	// keep the client secret out of the process arguments
+	cfg.ClientSecret = os.Getenv("DISPATCH_OIDC_CLIENT_SECRET")
+
+	if sessionKeyFile == "" {
+		logging.Error("--session-key-file is required")
+		os.Exit(1)
+	}
+	if proxyAddress != "" && !proxyInsecure && (proxyCertFile == "" || proxyKeyFile == "") {
+		// the proxy takes bearer tokens, never accept them over plain HTTP by accident
+		logging.Error("--proxy-tls-cert-file and --proxy-tls-key-file are required, or --proxy-insecure to serve plain HTTP")
+		os.Exit(1)
+	}
+	key, err := ioutil.ReadFile(sessionKeyFile)
+	if err != nil {
+		logging.Error("Reading session key failed", "file", sessionKeyFile, "error", err)
+		os.Exit(1)
+	}
+	cfg.SessionKey = []byte(strings.TrimSpace(string(key)))
+
+	restConfig, err := client.GetKubernetesConfig(kubeconfig, master)
+	if err != nil {
+		logging.Error("Building Kubernetes config failed", "error", err)
+		os.Exit(1)
+	}
+	clientsets, err := client.GetKubernetesClient(restConfig)
+	if err != nil {
+		logging.Error("Building Kubernetes clients failed", "error", err)
+		os.Exit(1)
+	}
+
+	stopCh := make(chan struct{})
+	informerFactory := externalversions.NewFilteredSharedInformerFactory(clientsets.NetsysClient, 0, cfg.DispatchNamespace, nil)
+	duInformer := informerFactory.Netsys().V1().DispatchUsers()
+	go duInformer.Informer().Run(stopCh)
+	// an empty cache would turn every user away as unknown
+	if !cache.WaitForCacheSync(stopCh, duInformer.Informer().HasSynced) {
+		logging.Error("Syncing DispatchUsers failed", "namespace", cfg.DispatchNamespace)
+		os.Exit(1)
+	}
+
+	s, err := server.NewServer(cfg, clientsets, duInformer.Lister())
+	if err != nil {
+		logging.Error("Creating server failed", "error", err)
+		os.Exit(1)
+	}
+
+	if proxyAddress != "" {
+		go runProxy(cfg, restConfig, clientsets, duInformer.Lister(), proxyAddress, proxyAuditDir, proxyCertFile, proxyKeyFile)
+	}
+
+	logging.Info("Serving", "address", address)
+	if err := http.ListenAndServe(address, s.Handler()); err != nil {
+		logging.Error("Serving failed", "address", address, "error", err)
+		os.Exit(1)
+	}
+}
+
+// runProxy serves the Kubernetes API proxy until it fails
+func runProxy(cfg server.Config, restConfig *rest.Config, clientsets client.ClientSets, duLister netsys_lister.DispatchUserLister,
+	address, auditDir, certFile, keyFile string) {
+	provider, err := oidc.Discover(nil, cfg.Issuer)
+	if err != nil {
+		logging.Error("Discovering identity provider for proxy failed", "issuer", cfg.Issuer, "error", err)
+		os.Exit(1)
+	}
+
+	var audit proxy.AuditTrail
+	if auditDir != "" {
+		trail, err := proxy.NewFileAuditTrail(auditDir)
+		if err != nil {
+			logging.Error("Opening proxy audit trail failed", "dir", auditDir, "error", err)
+			os.Exit(1)
+		}
+		defer trail.Close()
+		audit = trail
+	}
+
+	p, err := proxy.NewProxy(restConfig, cfg.DispatchNamespace, duLister, audit,
+		proxy.OIDCTokenAuthenticator{Verifier: provider.Verifier(cfg.ClientID), Mapping: cfg.ClaimMapping},
+		proxy.NewServiceAccountTokenAuthenticator(clientsets.OriginalClient, cfg.DispatchNamespace),
+	)
+	if err != nil {
+		logging.Error("Creating proxy failed", "error", err)
+		os.Exit(1)
+	}
+
+	if certFile != "" {
+		logging.Info("Serving Kubernetes API proxy", "address", address)
+		err = http.ListenAndServeTLS(address, certFile, keyFile, p)
+	} else {
+		logging.Warn("Serving Kubernetes API proxy over plain HTTP, tokens are sent in the clear", "address", address)
+		err = http.ListenAndServe(address, p)
+	}
+	logging.Error("Serving Kubernetes API proxy failed", "address", address, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/hantaowang/dispatch/pkg/dispatchctl"
+)
+
+func main() {
+	// installed as kubectl-dispatch the tool also works as "kubectl dispatch"
+	name := filepath.Base(os.Args[0])
+	if name == "kubectl-dispatch" {
+		name = "kubectl dispatch"
+	}
+	os.Exit(dispatchctl.Run(name, os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -1,7 +1,119 @@
 package main
 
-import "github.com/hantaowang/dispatch/pkg/cmd"
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/hantaowang/dispatch/pkg/client"
+	"github.com/hantaowang/dispatch/pkg/cmd"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
 
 func main() {
-	cmd.Start(make(chan struct{}))
+	var kubeconfig, master, configFile string
+	var namespace string
+	var duWorkers, onWorkers int
+	var leaderElect bool
+	var leaseDuration, renewDeadline, retryPeriod time.Duration
+	var identity, metricsAddress, healthAddress string
+	var logLevel, logFormat string
+
+	pflag.StringVar(&kubeconfig, "kubeconfig", "", "path to a kubeconfig, defaults to the in-cluster config or ~/.kube/config")
+	pflag.StringVar(&master, "master", "", "address of the Kubernetes API server, overrides the kubeconfig")
+	pflag.StringVar(&configFile, "config", "", "path to the dispatch configuration file")
+	pflag.StringVar(&namespace, "dispatch-namespace", "", "namespace holding DispatchUsers, overrides the configuration file")
+	pflag.IntVar(&duWorkers, "dispatchuser-workers", 0, "number of DispatchUser workers, overrides the configuration file")
+	pflag.IntVar(&onWorkers, "ownednamespace-workers", 0, "number of OwnedNamespace workers, overrides the configuration file")
+	pflag.BoolVar(&leaderElect, "leader-elect", false, "only reconcile while holding the leader lease, overrides the configuration file")
+	pflag.DurationVar(&leaseDuration, "leader-elect-lease-duration", 0, "how long standbys wait before taking over an unrenewed lease")
+	pflag.DurationVar(&renewDeadline, "leader-elect-renew-deadline", 0, "how long the leader retries renewing before giving up")
+	pflag.DurationVar(&retryPeriod, "leader-elect-retry-period", 0, "how often to try acquiring or renewing the lease")
+	pflag.StringVar(&identity, "leader-elect-identity", "", "identity of this replica, defaults to the hostname")
+	pflag.StringVar(&metricsAddress, "metrics-address", "", "address serving /metrics, overrides the configuration file")
+	pflag.StringVar(&healthAddress, "health-address", "", "address serving /healthz, /readyz and /debug, overrides the configuration file")
+	pflag.StringVar(&logLevel, "log-level", "", "debug, info, warn or error, overrides the configuration file")
+	pflag.StringVar(&logFormat, "log-format", "", "text or json, overrides the configuration file")
+	pflag.Parse()
+
+	cfg := config.Default()
+	if configFile != "" {
+		var err error
+		if cfg, err = config.Load(configFile); err != nil {
+			logging.Error("Loading configuration failed", "file", configFile, "error", err)
+			os.Exit(1)
+		}
+	}
+	if namespace != "" {
+		cfg.DispatchNamespace = namespace
+	}
+	if duWorkers > 0 {
+		cfg.Workers.DispatchUser = duWorkers
+	}
+	if onWorkers > 0 {
+		cfg.Workers.OwnedNamespace = onWorkers
+	}
+	if leaderElect {
+		cfg.LeaderElection.Enabled = true
+	}
+	if leaseDuration > 0 {
+		cfg.LeaderElection.LeaseDuration.Duration = leaseDuration
+	}
+	if renewDeadline > 0 {
+		cfg.LeaderElection.RenewDeadline.Duration = renewDeadline
+	}
+	if retryPeriod > 0 {
+		cfg.LeaderElection.RetryPeriod.Duration = retryPeriod
+	}
+	if identity != "" {
+		cfg.LeaderElection.Identity = identity
+	}
+	if metricsAddress != "" {
+		cfg.MetricsAddress = metricsAddress
+	}
+	if healthAddress != "" {
+		cfg.HealthAddress = healthAddress
+	}
+	if logLevel != "" {
+		cfg.LogLevel = logLevel
+	}
+	if logFormat != "" {
+		cfg.LogFormat = logFormat
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	level, _ := logging.ParseLevel(cfg.LogLevel)
+	logging.Configure(os.Stderr, level, cfg.LogFormat)
+
+	restConfig, err := client.GetKubernetesConfig(kubeconfig, master)
+	if err != nil {
+		logging.Error("Building Kubernetes config failed", "error", err)
+		os.Exit(1)
+	}
+	clientsets, err := client.GetKubernetesClient(restConfig)
+	if err != nil {
+		logging.Error("Building Kubernetes clients failed", "error", err)
+		os.Exit(1)
+	}
+
+	// stop on SIGTERM so a leader releases its lease before exiting
+	stopCh := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-signals
+		logging.Info("Shutting down")
+		close(stopCh)
+	}()
+
+	if err := cmd.Start(cfg, clientsets, stopCh); err != nil {
+		logging.Error("Running controllers failed", "error", err)
+		os.Exit(1)
+	}
 }
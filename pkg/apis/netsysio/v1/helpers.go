@@ -0,0 +1,696 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// roles a namespace can be granted with, named after the default ClusterRoles
+	RoleView  = "view"
+	RoleEdit  = "edit"
+	RoleAdmin = "admin"
+
+	// DefaultRole is granted for namespaces listed without a role, unless
+	// the controller is configured with another default
+	DefaultRole = RoleEdit
+
+	// ReasonAnnotation on a DispatchUser says why its last change was made.
+	// The controller copies it into the audit records of the change.
+	ReasonAnnotation = "netsys.io/change-reason"
+	// ExtensionRequestsAnnotation on a DispatchUser holds its pending
+	// ExtensionRequests as a JSON list. Kept out of status, which only the
+	// controller writes.
+	ExtensionRequestsAnnotation = "netsys.io/extension-requests"
+
+	// ReasonGenerationAnnotation is the generation of the DispatchUser the
+	// reason was given for, it does not apply to later changes
+	ReasonGenerationAnnotation = "netsys.io/change-reason-generation"
+
+	// states of a RecertificationItem
+	RecertificationPending   = "Pending"
+	RecertificationConfirmed = "Confirmed"
+	RecertificationRevoked   = "Revoked"
+	// the grant was removed or its role changed before the deadline
+	RecertificationDropped = "Dropped"
+
+	// phases of an AccessElevation
+	ElevationPending  = "Pending"
+	ElevationApproved = "Approved"
+	ElevationActive   = "Active"
+	ElevationExpired  = "Expired"
+	ElevationRevoked  = "Revoked"
+	ElevationDenied   = "Denied"
+
+	// ElevationPolicy decided an AccessElevation without a reviewer
+	ElevationPolicy = "policy"
+
+	// states of a NamespaceLease
+	LeaseActive   = "Active"
+	LeaseExpiring = "Expiring"
+	// access was revoked, the namespace is reclaimed unless renewed
+	LeaseExpired   = "Expired"
+	LeaseReclaimed = "Reclaimed"
+
+	// CreatedByLabel marks namespaces created by dispatch, only those are
+	// ever deleted
+	CreatedByLabel = "netsys.io/created-by"
+
+	// ParentLabel names the parent of a sub-namespace. A namespace that
+	// exists before its SubNamespace is only adopted if it carries it.
+	ParentLabel = "netsys.io/parent"
+
+	// InheritedFromLabel marks the RoleBindings, NetworkPolicies and
+	// Secrets copied into a sub-namespace from its parent
+	InheritedFromLabel = "netsys.io/inherited-from"
+
+	// PropagateAnnotation set to "true" on a Secret copies it into the
+	// sub-namespaces of its namespace
+	PropagateAnnotation = "netsys.io/propagate"
+
+	// phases of a SubNamespace
+	SubNamespacePending  = "Pending"
+	SubNamespaceReady    = "Ready"
+	SubNamespaceRefused  = "Refused"
+	SubNamespaceOrphaned = "Orphaned"
+
+	// what happens to a sub-namespace when its parent is deleted
+	CascadeDelete = "Delete"
+	CascadeOrphan = "Orphan"
+
+	// TenantLabel on a DispatchUser names the DispatchTenant it belongs to
+	TenantLabel = "netsys.io/tenant"
+
+	// phases of a DispatchTenant
+	TenantActive = "Active"
+	// the tenant's prefix is missing or overlaps an older tenant's, its
+	// users get no grants
+	TenantRefused = "Refused"
+
+	// phases of a NamespaceInvitation
+	InvitationPending  = "Pending"
+	InvitationAccepted = "Accepted"
+	InvitationRevoked  = "Revoked"
+	// reported for invitations past their expiry, never stored
+	InvitationExpired = "Expired"
+
+	// RobotLabel names the DispatchRobot the ServiceAccount, token Secrets
+	// and RoleBindings of a robot belong to
+	RobotLabel = "netsys.io/robot"
+
+	// UserLabel names the DispatchUser a ServiceAccount was created for.
+	// Only ServiceAccounts carrying it are ever deleted.
+	UserLabel = "netsys.io/user"
+
+	// phases of a DispatchRobot
+	RobotActive = "Active"
+	// no owner is active, the robot has no ServiceAccount or bindings
+	RobotDisabled = "Disabled"
+)
+
+// ValidRole returns true if role can be granted by dispatch
+func ValidRole(role string) bool {
+	return role == RoleView || role == RoleEdit || role == RoleAdmin
+}
+
+// ReservedUserID returns true if id names a ServiceAccount of dispatch
+// itself or of a robot, which no DispatchUser may take
+func ReservedUserID(id string) bool {
+	return id == "default" || strings.HasPrefix(id, "dispatch-") || strings.HasPrefix(id, RobotServiceAccount(""))
+}
+
+// RoleIncludes returns true if holding role held grants at least role
+func RoleIncludes(held, role string) bool {
+	rank := map[string]int{RoleView: 1, RoleEdit: 2, RoleAdmin: 3}
+	return rank[held] >= rank[role] && rank[role] > 0
+}
+
+// RoleOrDefault returns role, or DefaultRole if role is empty. OwnedNamespaces
+// created before roles existed have no role and were granted DefaultRole.
+func RoleOrDefault(role string) string {
+	if role == "" {
+		return DefaultRole
+	}
+	return role
+}
+
+// GrantKey identifies the grant of namespace in cluster. It is the
+// namespace in the cluster dispatch runs in and cluster/namespace in a
+// member cluster.
+func GrantKey(cluster, namespace string) string {
+	if cluster == "" {
+		return namespace
+	}
+	return cluster + "/" + namespace
+}
+
+// ParseGrantKey splits a grant key into its cluster and namespace
+func ParseGrantKey(key string) (cluster, namespace string) {
+	if i := strings.Index(key, "/"); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
+
+// Key identifies the grant among the grants of a user
+func (g NamespaceGrant) Key() string {
+	return GrantKey(g.Cluster, g.Namespace)
+}
+
+// Key identifies the grant the OwnedNamespace was created for
+func (on *OwnedNamespace) Key() string {
+	return GrantKey(on.Spec.Cluster, on.Spec.Namespace)
+}
+
+// EffectiveGrants merges Namespaces and Grants into one grant per namespace
+// and cluster. An entry in Grants takes precedence over the same namespace
+// in Namespaces. The role is left empty where none was given, the
+// controller then grants its configured default role.
+func (s *DispatchUserSpec) EffectiveGrants() []NamespaceGrant {
+	grants := make([]NamespaceGrant, 0, len(s.Namespaces)+len(s.Grants))
+	index := make(map[string]int, len(s.Namespaces)+len(s.Grants))
+
+	for _, n := range s.Namespaces {
+		if _, ok := index[n]; ok {
+			continue
+		}
+		index[n] = len(grants)
+		cluster, namespace := ParseGrantKey(n)
+		grants = append(grants, NamespaceGrant{Namespace: namespace, Cluster: cluster})
+	}
+	for _, g := range s.Grants {
+		if g.Cluster == "" {
+			// also written as cluster/namespace
+			g.Cluster, g.Namespace = ParseGrantKey(g.Namespace)
+		}
+		if i, ok := index[g.Key()]; ok {
+			grants[i] = g
+			continue
+		}
+		index[g.Key()] = len(grants)
+		grants = append(grants, g)
+	}
+	return grants
+}
+
+// HasNamespace returns true if the spec grants any role in the namespace
+// of key
+func (s *DispatchUserSpec) HasNamespace(key string) bool {
+	for _, g := range s.EffectiveGrants() {
+		if g.Key() == key {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGrant grants role in the namespace of key, replacing any earlier
+// grant for it
+func (s *DispatchUserSpec) SetGrant(key, role string) {
+	s.RemoveNamespace(key)
+	cluster, namespace := ParseGrantKey(key)
+	s.Grants = append(s.Grants, NamespaceGrant{Namespace: namespace, Cluster: cluster, Role: role})
+}
+
+// Grant returns the entry of the namespace of key in Grants, or nil
+func (s *DispatchUserSpec) Grant(key string) *NamespaceGrant {
+	for i := range s.Grants {
+		if s.Grants[i].Key() == key {
+			return &s.Grants[i]
+		}
+	}
+	return nil
+}
+
+// RemoveNamespace removes the namespace of key from both Namespaces and
+// Grants and returns true if it was present
+func (s *DispatchUserSpec) RemoveNamespace(key string) bool {
+	found := false
+	namespaces := s.Namespaces[:0]
+	for _, n := range s.Namespaces {
+		if n == key {
+			found = true
+			continue
+		}
+		namespaces = append(namespaces, n)
+	}
+	s.Namespaces = namespaces
+
+	grants := s.Grants[:0]
+	for _, g := range s.Grants {
+		if g.Key() == key {
+			found = true
+			continue
+		}
+		grants = append(grants, g)
+	}
+	s.Grants = grants
+	return found
+}
+
+// SetReason records why the change about to be written to u is made, or
+// removes the reason if it is empty. The reason applies to the generation
+// the change creates only, so it is never attributed to a later change.
+func (u *DispatchUser) SetReason(reason string) {
+	if reason == "" {
+		delete(u.Annotations, ReasonAnnotation)
+		delete(u.Annotations, ReasonGenerationAnnotation)
+		return
+	}
+	if u.Annotations == nil {
+		u.Annotations = map[string]string{}
+	}
+	u.Annotations[ReasonAnnotation] = reason
+	u.Annotations[ReasonGenerationAnnotation] = strconv.FormatInt(u.Generation+1, 10)
+}
+
+// Reason returns why the current generation of u was made, or "" if no
+// reason was given for it
+func (u *DispatchUser) Reason() string {
+	if u.Annotations[ReasonGenerationAnnotation] != strconv.FormatInt(u.Generation, 10) {
+		return ""
+	}
+	return u.Annotations[ReasonAnnotation]
+}
+
+// ExpiryTime returns when the user's access ends, or nil if it does not
+func (u *DispatchUser) ExpiryTime() *meta_v1.Time {
+	if u.Spec.ExpiresAt != nil {
+		return u.Spec.ExpiresAt
+	}
+	if u.Spec.TTL != nil {
+		t := meta_v1.NewTime(u.CreationTimestamp.Add(u.Spec.TTL.Duration))
+		return &t
+	}
+	return nil
+}
+
+// Expired returns true if the user's access ended before now
+func (u *DispatchUser) Expired(now time.Time) bool {
+	expiry := u.ExpiryTime()
+	return expiry != nil && !now.Before(expiry.Time)
+}
+
+// ExpiryTime returns when the grant ends, or nil if it does not. grantedAt
+// is when the grant was first provisioned.
+func (g NamespaceGrant) ExpiryTime(grantedAt time.Time) *meta_v1.Time {
+	if g.ExpiresAt != nil {
+		return g.ExpiresAt
+	}
+	if g.TTL != nil {
+		t := meta_v1.NewTime(grantedAt.Add(g.TTL.Duration))
+		return &t
+	}
+	return nil
+}
+
+// GrantStatus returns the status of the grant in namespace, or nil
+func (s *DispatchUserStatus) GrantStatus(namespace string) *GrantStatus {
+	for i := range s.Grants {
+		if s.Grants[i].Namespace == namespace {
+			return &s.Grants[i]
+		}
+	}
+	return nil
+}
+
+// ExtensionRequests returns the pending extension requests of u. An
+// annotation that cannot be read holds none.
+func (u *DispatchUser) ExtensionRequests() []ExtensionRequest {
+	var requests []ExtensionRequest
+	if s := u.Annotations[ExtensionRequestsAnnotation]; s != "" {
+		if err := json.Unmarshal([]byte(s), &requests); err != nil {
+			return nil
+		}
+	}
+	return requests
+}
+
+// AddExtensionRequest adds r to the pending extension requests of u,
+// replacing an older one for the same namespace
+func (u *DispatchUser) AddExtensionRequest(r ExtensionRequest) {
+	u.RemoveExtensionRequest(r.Namespace)
+	u.setExtensionRequests(append(u.ExtensionRequests(), r))
+}
+
+// RemoveExtensionRequest drops the request for namespace, empty for the
+// user itself, and returns it
+func (u *DispatchUser) RemoveExtensionRequest(namespace string) *ExtensionRequest {
+	requests := u.ExtensionRequests()
+	for i, r := range requests {
+		if r.Namespace == namespace {
+			u.setExtensionRequests(append(requests[:i], requests[i+1:]...))
+			return &r
+		}
+	}
+	return nil
+}
+
+func (u *DispatchUser) setExtensionRequests(requests []ExtensionRequest) {
+	if len(requests) == 0 {
+		delete(u.Annotations, ExtensionRequestsAnnotation)
+		return
+	}
+	b, _ := json.Marshal(requests)
+	if u.Annotations == nil {
+		u.Annotations = map[string]string{}
+	}
+	u.Annotations[ExtensionRequestsAnnotation] = string(b)
+}
+
+// Bound returns true if the owner should hold its role in the namespace,
+// i.e. it is not suspended and its lease did not expire
+func (on *OwnedNamespace) Bound() bool {
+	if on.Spec.Suspended {
+		return false
+	}
+	if l := on.Status.Lease; l != nil && (l.State == LeaseExpired || l.State == LeaseReclaimed) {
+		return false
+	}
+	return true
+}
+
+// EffectiveRole returns the role the owner holds right now, view outside
+// the availability window of a schedule that revokes write access
+func (on *OwnedNamespace) EffectiveRole() string {
+	if s := on.Status.Schedule; s != nil && !s.Awake && on.Spec.Schedule != nil && on.Spec.Schedule.RevokeWrite {
+		return RoleView
+	}
+	return RoleOrDefault(on.Spec.Role)
+}
+
+// RenewLease extends the lease for another lease duration from now. An
+// expired lease can be renewed until its namespace is reclaimed.
+func (on *OwnedNamespace) RenewLease(now time.Time) error {
+	if on.Spec.Lease == nil {
+		return fmt.Errorf("namespace %s has no lease", on.Spec.Namespace)
+	}
+	if on.Status.Lease != nil && on.Status.Lease.State == LeaseReclaimed {
+		return fmt.Errorf("namespace %s was already reclaimed", on.Spec.Namespace)
+	}
+	// the API server stores times in seconds
+	renewed := meta_v1.NewTime(now.Truncate(time.Second))
+	state := LeaseActive
+	if on.Status.Lease != nil {
+		// the controller moves the state on
+		state = on.Status.Lease.State
+	}
+	on.Status.Lease = &NamespaceLease{
+		RenewedAt: renewed,
+		ExpiresAt: meta_v1.NewTime(renewed.Add(on.Spec.Lease.Duration)),
+		State:     state,
+	}
+	return nil
+}
+
+// Item returns the current item of the grant of userID in namespace, or
+// nil. A grant carried into the campaign again has its latest item current.
+func (c *RecertificationCampaign) Item(userID, namespace string) *RecertificationItem {
+	for i := len(c.Status.Items) - 1; i >= 0; i-- {
+		if c.Status.Items[i].UserID == userID && c.Status.Items[i].Namespace == namespace {
+			return &c.Status.Items[i]
+		}
+	}
+	return nil
+}
+
+// Confirm marks the grant of userID in namespace as confirmed by reviewer.
+// Confirming a grant twice is not an error.
+func (c *RecertificationCampaign) Confirm(userID, namespace, reviewer string, now meta_v1.Time) error {
+	if c.Status.Completed {
+		return fmt.Errorf("campaign %s is completed", c.Name)
+	}
+	item := c.Item(userID, namespace)
+	if item == nil {
+		return fmt.Errorf("campaign %s has no grant of %s in %s", c.Name, userID, namespace)
+	}
+	switch item.State {
+	case RecertificationConfirmed:
+		return nil
+	case RecertificationPending:
+	default:
+		return fmt.Errorf("grant of %s in %s is %s", userID, namespace, item.State)
+	}
+	item.State = RecertificationConfirmed
+	item.ReviewedBy = reviewer
+	item.ReviewedAt = &now
+	return nil
+}
+
+// Progress counts the items of the campaign by state
+func (c *RecertificationCampaign) Progress() map[string]int {
+	counts := map[string]int{}
+	for _, item := range c.Status.Items {
+		counts[item.State]++
+	}
+	return counts
+}
+
+// IsApprover returns true if userID or one of groups may confirm any grant
+// of the campaign
+func (c *RecertificationCampaign) IsApprover(userID string, groups []string) bool {
+	return isApprover(c.Spec.Approvers, c.Spec.ApproverGroups, userID, groups)
+}
+
+func isApprover(approvers, approverGroups []string, userID string, groups []string) bool {
+	for _, a := range approvers {
+		if a == userID {
+			return true
+		}
+	}
+	for _, a := range approverGroups {
+		for _, g := range groups {
+			if a == g {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsApprover returns true if userID or one of groups may approve the
+// elevation. Nobody approves their own elevation.
+func (e *AccessElevation) IsApprover(userID string, groups []string) bool {
+	return userID != e.Spec.UserID && isApprover(e.Status.Approvers, e.Status.ApproverGroups, userID, groups)
+}
+
+// MayDecide returns true if userID, a member of groups, may approve or deny
+// the elevation. u is the DispatchUser of userID and owned its
+// OwnedNamespace of the elevation's namespace, either nil if there is none.
+// Approvers may decide on any elevation, users with the admin role in the
+// namespace on the elevations of others in that namespace. defaultRole is
+// the role of grants without one.
+func (e *AccessElevation) MayDecide(userID string, groups []string, u *DispatchUser, owned *OwnedNamespace, defaultRole string, now time.Time) bool {
+	if userID == e.Spec.UserID {
+		return false
+	}
+	if e.IsApprover(userID, groups) {
+		return true
+	}
+	return u != nil && u.IsAdmin(e.Spec.Namespace, owned, defaultRole, now)
+}
+
+// IsAdmin returns true if u holds the admin role in the namespace key at
+// now: u is neither suspended nor expired, and neither is its grant of key
+// nor the lease of owned, its OwnedNamespace of key. defaultRole is the role
+// of grants without one.
+func (u *DispatchUser) IsAdmin(key string, owned *OwnedNamespace, defaultRole string, now time.Time) bool {
+	if u.Spec.Suspended || u.Expired(now) {
+		return false
+	}
+	for _, g := range u.Spec.EffectiveGrants() {
+		if g.Key() != key {
+			continue
+		}
+		role := g.Role
+		if role == "" {
+			role = defaultRole
+		}
+		if role != RoleAdmin {
+			return false
+		}
+		// a TTL counts from when the grant was provisioned
+		expiry := g.ExpiresAt
+		if gs := u.Status.GrantStatus(key); gs != nil {
+			expiry = g.ExpiryTime(gs.GrantedAt.Time)
+		}
+		if expiry != nil && !now.Before(expiry.Time) {
+			return false
+		}
+		if g.Lease != nil && (owned == nil || !owned.Bound()) {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// Approve approves a pending elevation
+func (e *AccessElevation) Approve(approver string, now meta_v1.Time) error {
+	if e.Status.Phase != ElevationPending {
+		return fmt.Errorf("elevation %s is %s, not %s", e.Name, e.phase(), ElevationPending)
+	}
+	e.Status.Phase = ElevationApproved
+	e.Status.DecidedBy = approver
+	e.Status.DecidedAt = &now
+	return nil
+}
+
+// Deny denies a pending elevation
+func (e *AccessElevation) Deny(approver, message string, now meta_v1.Time) error {
+	if e.Status.Phase != ElevationPending {
+		return fmt.Errorf("elevation %s is %s, not %s", e.Name, e.phase(), ElevationPending)
+	}
+	e.Status.Phase = ElevationDenied
+	e.Status.DecidedBy = approver
+	e.Status.DecidedAt = &now
+	e.Status.Message = message
+	return nil
+}
+
+// Revoke ends an approved or active elevation before its time is up
+func (e *AccessElevation) Revoke(by, message string, now meta_v1.Time) error {
+	if e.Status.Phase != ElevationApproved && e.Status.Phase != ElevationActive {
+		return fmt.Errorf("elevation %s is %s, not %s or %s", e.Name, e.phase(), ElevationApproved, ElevationActive)
+	}
+	e.Status.Phase = ElevationRevoked
+	e.Status.DecidedBy = by
+	e.Status.DecidedAt = &now
+	e.Status.Message = message
+	return nil
+}
+
+// Finished returns true once the elevation can no longer grant a role
+func (e *AccessElevation) Finished() bool {
+	switch e.Status.Phase {
+	case ElevationExpired, ElevationRevoked, ElevationDenied:
+		return true
+	}
+	return false
+}
+
+func (e *AccessElevation) phase() string {
+	if e.Status.Phase == "" {
+		return "new"
+	}
+	return e.Status.Phase
+}
+
+// ValidCascade returns true if cascade is a policy for sub-namespaces
+func ValidCascade(cascade string) bool {
+	return cascade == CascadeDelete || cascade == CascadeOrphan
+}
+
+// IsOwner returns true if userID holds the parent of the sub-namespace
+func (sn *SubNamespace) IsOwner(userID string) bool {
+	for _, o := range sn.Status.Owners {
+		if o == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin returns true if userID or one of groups administers the tenant
+func (t *DispatchTenant) IsAdmin(userID string, groups []string) bool {
+	return isApprover(t.Spec.Admins, t.Spec.AdminGroups, userID, groups)
+}
+
+// Allows returns why the tenant's users cannot hold g, or "" if they can
+func (t *DispatchTenant) Allows(g NamespaceGrant) string {
+	if !strings.HasPrefix(g.Namespace, t.Spec.Prefix) {
+		return fmt.Sprintf("namespace %s does not start with %s, the prefix of tenant %s", g.Key(), t.Spec.Prefix, t.Name)
+	}
+	if len(t.Spec.AllowedClasses) == 0 {
+		return ""
+	}
+	if g.Schedule != nil {
+		return fmt.Sprintf("namespace %s has a schedule of its own, tenant %s only allows the classes %s",
+			g.Key(), t.Name, strings.Join(t.Spec.AllowedClasses, ", "))
+	}
+	for _, c := range t.Spec.AllowedClasses {
+		if c == g.ScheduleClass {
+			return ""
+		}
+	}
+	return fmt.Sprintf("namespace %s has schedule class %q, tenant %s only allows the classes %s",
+		g.Key(), g.ScheduleClass, t.Name, strings.Join(t.Spec.AllowedClasses, ", "))
+}
+
+// Overlaps returns true if a namespace could start with the prefixes of
+// both tenants
+func (t *DispatchTenant) Overlaps(other *DispatchTenant) bool {
+	return strings.HasPrefix(t.Spec.Prefix, other.Spec.Prefix) || strings.HasPrefix(other.Spec.Prefix, t.Spec.Prefix)
+}
+
+// Conflict returns why t cannot be active next to tenants, or "" if it
+// can. Of two tenants with overlapping prefixes the older one stays.
+func (t *DispatchTenant) Conflict(tenants []*DispatchTenant) string {
+	if t.Spec.Prefix == "" {
+		return "a prefix is required"
+	}
+	for _, other := range tenants {
+		if other.Name == t.Name || other.Spec.Prefix == "" || !t.Overlaps(other) {
+			continue
+		}
+		if other.CreationTimestamp.Before(&t.CreationTimestamp) ||
+			(other.CreationTimestamp.Equal(&t.CreationTimestamp) && other.Name < t.Name) {
+			return fmt.Sprintf("prefix %s overlaps %s of tenant %s", t.Spec.Prefix, other.Spec.Prefix, other.Name)
+		}
+	}
+	return ""
+}
+
+// PhaseAt returns the phase of the invitation at now, Expired once a
+// pending or accepted invitation is past its expiry
+func (inv *NamespaceInvitation) PhaseAt(now time.Time) string {
+	phase := inv.Status.Phase
+	if phase == "" {
+		phase = InvitationPending
+	}
+	if phase != InvitationRevoked && !now.Before(inv.Spec.ExpiresAt.Time) {
+		return InvitationExpired
+	}
+	return phase
+}
+
+// GrantedRole returns the role the invitee is granted, view if none was
+// given
+func (inv *NamespaceInvitation) GrantedRole() string {
+	if inv.Spec.Role == "" {
+		return RoleView
+	}
+	return inv.Spec.Role
+}
+
+// RobotServiceAccount returns the name of the ServiceAccount of the robot
+// name
+func RobotServiceAccount(name string) string {
+	return "robot-" + name
+}
+
+// GrantedRole returns the role of the grant, view if none was given
+func (g RobotGrant) GrantedRole() string {
+	if g.Role == "" {
+		return RoleView
+	}
+	return g.Role
+}
+
+// IsOwner returns true if the user with userID and groups owns the robot
+func (r *DispatchRobot) IsOwner(userID string, groups []string) bool {
+	if r.Spec.Owner != "" {
+		return r.Spec.Owner == userID
+	}
+	for _, g := range groups {
+		if g == r.Spec.OwnerGroup {
+			return true
+		}
+	}
+	return false
+}
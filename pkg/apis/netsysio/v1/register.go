@@ -31,6 +31,18 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&DispatchUserList{},
 		&OwnedNamespace{},
 		&OwnedNamespaceList{},
+		&RecertificationCampaign{},
+		&RecertificationCampaignList{},
+		&AccessElevation{},
+		&AccessElevationList{},
+		&SubNamespace{},
+		&SubNamespaceList{},
+		&DispatchTenant{},
+		&DispatchTenantList{},
+		&NamespaceInvitation{},
+		&NamespaceInvitationList{},
+		&DispatchRobot{},
+		&DispatchRobotList{},
 	)
 
 	// register the type in the scheme
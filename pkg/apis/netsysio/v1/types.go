@@ -1,6 +1,7 @@
 package v1
 
 import (
+	core_v1 "k8s.io/api/core/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -13,13 +14,139 @@ type DispatchUser struct {
 	meta_v1.TypeMeta `json:",inline"`
 	meta_v1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec DispatchUserSpec `json:"spec"`
+	Spec	DispatchUserSpec	`json:"spec"`
+	Status	DispatchUserStatus	`json:"status,omitempty"`
 }
 
 // DispatchUserSpec is the spec for a DispatchUser resource
 type DispatchUserSpec struct {
 	UserID		string	`json:"userID"`
+	// Namespaces are granted with the default role. Namespaces of member
+	// clusters are written as cluster/namespace.
 	Namespaces	[]string	`json:"namespaces"`
+	// Groups are the identity provider groups the user belongs to
+	Groups		[]string	`json:"groups,omitempty"`
+	// Grants give access to namespaces with a role other than the default.
+	// Namespaces listed here do not need to be listed in Namespaces.
+	Grants		[]NamespaceGrant	`json:"grants,omitempty"`
+	// ExpiresAt ends the user's access, its grants and credentials are
+	// revoked but the DispatchUser is kept
+	ExpiresAt	*meta_v1.Time	`json:"expiresAt,omitempty"`
+	// TTL ends the user's access this long after the DispatchUser was
+	// created. Ignored if ExpiresAt is set.
+	TTL		*meta_v1.Duration	`json:"ttl,omitempty"`
+	// Suspended cuts all access of the user until it is cleared. The
+	// credentials are revoked and the RoleBindings removed, but grants and
+	// OwnedNamespaces are kept so access is restored when resumed.
+	Suspended	bool	`json:"suspended,omitempty"`
+}
+
+// NamespaceGrant gives a DispatchUser a role in a namespace
+type NamespaceGrant struct {
+	Namespace	string	`json:"namespace"`
+	// Cluster is the member cluster the namespace is in, empty for the
+	// cluster dispatch runs in
+	Cluster		string	`json:"cluster,omitempty"`
+	// Role is the ClusterRole bound in the namespace, defaults to edit
+	Role		string	`json:"role,omitempty"`
+	// ExpiresAt revokes the grant
+	ExpiresAt	*meta_v1.Time	`json:"expiresAt,omitempty"`
+	// TTL revokes the grant this long after it was first provisioned.
+	// Ignored if ExpiresAt is set.
+	TTL		*meta_v1.Duration	`json:"ttl,omitempty"`
+	// Lease must be renewed by the owner within this long, or the grant is
+	// revoked and the namespace eventually reclaimed
+	Lease		*meta_v1.Duration	`json:"lease,omitempty"`
+	// LeaseRenewedAt carries the lease of a transferred grant over. The
+	// lease counts from here instead of from when the OwnedNamespace was
+	// created. Only read when the OwnedNamespace is created.
+	LeaseRenewedAt	*meta_v1.Time	`json:"leaseRenewedAt,omitempty"`
+	// Schedule keeps the namespace running only within a window
+	Schedule	*AvailabilitySchedule	`json:"schedule,omitempty"`
+	// ScheduleClass names a schedule of the controller configuration.
+	// Ignored if Schedule is set.
+	ScheduleClass	string	`json:"scheduleClass,omitempty"`
+}
+
+// AvailabilitySchedule is the window in which a namespace runs. Outside of
+// it the namespace is hibernated.
+type AvailabilitySchedule struct {
+	// Start and Stop are cron expressions of when the window opens and
+	// closes, e.g. "0 8 * * 1-5" and "0 19 * * 1-5"
+	Start		string	`json:"start"`
+	Stop		string	`json:"stop"`
+	// TimeZone of Start and Stop, e.g. Europe/Berlin. UTC if empty.
+	TimeZone	string	`json:"timeZone,omitempty"`
+	// RevokeWrite lowers the role to view outside the window
+	RevokeWrite	bool	`json:"revokeWrite,omitempty"`
+}
+
+// DispatchUserStatus is the state of a DispatchUser as seen by the controller
+type DispatchUserStatus struct {
+	// ExpiresAt is when the user's access ends, from spec.expiresAt or spec.ttl
+	ExpiresAt	*meta_v1.Time	`json:"expiresAt,omitempty"`
+	// Expired is set once the user's grants and credentials are revoked
+	Expired		bool	`json:"expired,omitempty"`
+	// ExpiryWarned is set once the user was warned of the expiry
+	ExpiryWarned	bool	`json:"expiryWarned,omitempty"`
+	// Grants lists the grants that expire, including expired ones
+	Grants		[]GrantStatus	`json:"grants,omitempty"`
+	// SuspendedAt is when the suspension took effect, cleared once resumed
+	SuspendedAt	*meta_v1.Time	`json:"suspendedAt,omitempty"`
+	// Usage is what the user's namespaces consume
+	Usage		*UsageStatus	`json:"usage,omitempty"`
+	// RefusedGrants are grants of new namespaces the cluster had no
+	// capacity for
+	RefusedGrants	[]RefusedGrant	`json:"refusedGrants,omitempty"`
+	// Clusters are the member clusters the user has a ServiceAccount in
+	Clusters	[]string	`json:"clusters,omitempty"`
+}
+
+// RefusedGrant is a grant that was not provisioned since the quota of its
+// namespace would overcommit the cluster
+type RefusedGrant struct {
+	Namespace	string	`json:"namespace"`
+	// Reason lists the resources that would be overcommitted
+	Reason		string	`json:"reason"`
+	RefusedAt	meta_v1.Time	`json:"refusedAt"`
+	// Queued grants are provisioned once there is capacity, others are
+	// only retried after they were removed and granted again
+	Queued		bool	`json:"queued,omitempty"`
+}
+
+// UsageStatus is what a user consumes across their namespaces. Resources
+// are named as in a ResourceQuota, e.g. requests.cpu or count/services.
+type UsageStatus struct {
+	// Total is the sum of the namespaces
+	Total		core_v1.ResourceList	`json:"total,omitempty"`
+	// Namespaces is the user's share of every namespace they hold
+	Namespaces	map[string]core_v1.ResourceList	`json:"namespaces,omitempty"`
+	// UpdatedAt is when the usage last changed
+	UpdatedAt	meta_v1.Time	`json:"updatedAt"`
+}
+
+// GrantStatus is the expiry of a grant
+type GrantStatus struct {
+	// Namespace of the grant, cluster/namespace in member clusters
+	Namespace	string	`json:"namespace"`
+	Role		string	`json:"role"`
+	// GrantedAt is when the grant was first provisioned, a TTL counts from here
+	GrantedAt	meta_v1.Time	`json:"grantedAt"`
+	ExpiresAt	*meta_v1.Time	`json:"expiresAt,omitempty"`
+	// Expired is set once the grant's RoleBinding is revoked
+	Expired		bool	`json:"expired,omitempty"`
+	// ExpiryWarned is set once the user was warned of the expiry
+	ExpiryWarned	bool	`json:"expiryWarned,omitempty"`
+}
+
+// ExtensionRequest asks to move the expiry of the user or of one grant.
+// Pending requests are kept in the ExtensionRequestsAnnotation of the user.
+type ExtensionRequest struct {
+	// Namespace of the grant to extend, empty for the user itself
+	Namespace	string	`json:"namespace,omitempty"`
+	Until		meta_v1.Time	`json:"until"`
+	Reason		string	`json:"reason,omitempty"`
+	RequestedAt	meta_v1.Time	`json:"requestedAt"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -41,13 +168,50 @@ type OwnedNamespace struct {
 	meta_v1.TypeMeta `json:",inline"`
 	meta_v1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec OwnedNamespaceSpec `json:"spec"`
+	Spec	OwnedNamespaceSpec	`json:"spec"`
+	Status	OwnedNamespaceStatus	`json:"status,omitempty"`
 }
 
 // OwnedNamespaceSpec is the spec for a OwnedNamespace resource
 type OwnedNamespaceSpec struct {
 	OwnerID		string	`json:"ownerID"`
 	Namespace	string	`json:"namespace"`
+	// Cluster is the member cluster the namespace is in, from the grant
+	Cluster		string	`json:"cluster,omitempty"`
+	Role		string	`json:"role,omitempty"`
+	// Suspended removes the RoleBinding while the owner is suspended
+	Suspended	bool	`json:"suspended,omitempty"`
+	// Lease is how long the namespace is held before it has to be renewed,
+	// from the grant
+	Lease		*meta_v1.Duration	`json:"lease,omitempty"`
+	// Schedule is the availability window from the grant
+	Schedule	*AvailabilitySchedule	`json:"schedule,omitempty"`
+}
+
+// OwnedNamespaceStatus is the state of an OwnedNamespace
+type OwnedNamespaceStatus struct {
+	Lease		*NamespaceLease	`json:"lease,omitempty"`
+	Schedule	*ScheduleStatus	`json:"schedule,omitempty"`
+}
+
+// ScheduleStatus is the state of the availability window of an
+// OwnedNamespace
+type ScheduleStatus struct {
+	// Awake is true within the window or while kept awake
+	Awake		bool		`json:"awake"`
+	// NextTransition is when Awake changes next
+	NextTransition	*meta_v1.Time	`json:"nextTransition,omitempty"`
+	// KeepAwakeUntil overrides the end of the window, set by the owner
+	KeepAwakeUntil	*meta_v1.Time	`json:"keepAwakeUntil,omitempty"`
+}
+
+// NamespaceLease is the state of the lease of an OwnedNamespace
+type NamespaceLease struct {
+	// RenewedAt is when the lease was taken or last renewed
+	RenewedAt	meta_v1.Time	`json:"renewedAt"`
+	ExpiresAt	meta_v1.Time	`json:"expiresAt"`
+	// State is Active, Expiring, Expired or Reclaimed
+	State		string		`json:"state"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -58,4 +222,349 @@ type OwnedNamespaceList struct {
 	meta_v1.ListMeta `json:"metadata"`
 
 	Items []OwnedNamespace `json:"items"`
-}
\ No newline at end of file
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RecertificationCampaign asks for every grant that existed when it started
+// to be confirmed before its deadline. Unconfirmed grants are revoked.
+type RecertificationCampaign struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec	RecertificationCampaignSpec	`json:"spec"`
+	Status	RecertificationCampaignStatus	`json:"status,omitempty"`
+}
+
+// RecertificationCampaignSpec is the spec for a RecertificationCampaign resource
+type RecertificationCampaignSpec struct {
+	// Deadline after which unconfirmed grants are revoked
+	Deadline	meta_v1.Time	`json:"deadline"`
+	// Approvers and members of ApproverGroups can confirm any grant. Users
+	// with the admin role in a namespace can confirm the grants of others
+	// in that namespace.
+	Approvers	[]string	`json:"approvers,omitempty"`
+	ApproverGroups	[]string	`json:"approverGroups,omitempty"`
+}
+
+// RecertificationCampaignStatus is the progress of a RecertificationCampaign
+type RecertificationCampaignStatus struct {
+	// Completed is set once the deadline passed and unconfirmed grants
+	// were revoked
+	Completed	bool	`json:"completed,omitempty"`
+	Items		[]RecertificationItem	`json:"items"`
+}
+
+// RecertificationItem is a grant to be confirmed
+type RecertificationItem struct {
+	UserID		string	`json:"userID"`
+	Namespace	string	`json:"namespace"`
+	Role		string	`json:"role"`
+	// State is Pending, Confirmed, Revoked or Dropped
+	State		string	`json:"state"`
+	// ReviewedBy is the user who confirmed the grant
+	ReviewedBy	string	`json:"reviewedBy,omitempty"`
+	// ReviewedAt is when the grant was confirmed or revoked
+	ReviewedAt	*meta_v1.Time	`json:"reviewedAt,omitempty"`
+	// AddedAt is when a grant changed or added again after the campaign
+	// started was carried into it
+	AddedAt	*meta_v1.Time	`json:"addedAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RecertificationCampaignList is a list of RecertificationCampaign resources
+type RecertificationCampaignList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []RecertificationCampaign `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AccessElevation asks for a role in a namespace for a short time, e.g.
+// admin for an hour to debug an incident. Once approved, dispatch binds the
+// role and removes the binding again when the duration is up.
+type AccessElevation struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec	AccessElevationSpec	`json:"spec"`
+	Status	AccessElevationStatus	`json:"status,omitempty"`
+}
+
+// AccessElevationSpec is the spec for an AccessElevation resource
+type AccessElevationSpec struct {
+	// UserID of the DispatchUser asking for the role
+	UserID		string		`json:"userID"`
+	Namespace	string		`json:"namespace"`
+	Role		string		`json:"role"`
+	// Duration the role is held, counted from when it was bound
+	Duration	meta_v1.Duration	`json:"duration"`
+	Reason		string		`json:"reason"`
+}
+
+// AccessElevationStatus is the progress of an AccessElevation
+type AccessElevationStatus struct {
+	// Phase is Pending, Approved, Active, Expired, Revoked or Denied
+	Phase		string		`json:"phase,omitempty"`
+	// Approvers and members of ApproverGroups can approve the elevation,
+	// copied from the policy when the elevation was requested
+	Approvers	[]string	`json:"approvers,omitempty"`
+	ApproverGroups	[]string	`json:"approverGroups,omitempty"`
+	// DecidedBy is the user who approved, denied or revoked the elevation,
+	// or policy when dispatch decided by itself
+	DecidedBy	string		`json:"decidedBy,omitempty"`
+	DecidedAt	*meta_v1.Time	`json:"decidedAt,omitempty"`
+	// StartedAt and ExpiresAt bound the time the role is held
+	StartedAt	*meta_v1.Time	`json:"startedAt,omitempty"`
+	ExpiresAt	*meta_v1.Time	`json:"expiresAt,omitempty"`
+	// EndedAt is set once the elevation is over and its RoleBinding removed
+	EndedAt		*meta_v1.Time	`json:"endedAt,omitempty"`
+	Message		string		`json:"message,omitempty"`
+	// ObservedGeneration is the generation of the spec that was requested,
+	// elevations whose spec changes after are revoked
+	ObservedGeneration	int64	`json:"observedGeneration,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AccessElevationList is a list of AccessElevation resources
+type AccessElevationList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []AccessElevation `json:"items"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SubNamespace is a child namespace created under a namespace its owners
+// hold, e.g. team-a-feature-x under team-a. It is named after the child
+// namespace, which inherits the owners, RoleBindings, NetworkPolicies,
+// propagated Secrets and a slice of the quota of its parent.
+type SubNamespace struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec	SubNamespaceSpec	`json:"spec"`
+	Status	SubNamespaceStatus	`json:"status,omitempty"`
+}
+
+// SubNamespaceSpec is the spec for a SubNamespace resource
+type SubNamespaceSpec struct {
+	// Parent namespace, the name of the child starts with Parent-
+	Parent		string	`json:"parent"`
+	// Quota is the slice of the parent's quota the child gets, required if
+	// the parent has one
+	Quota		core_v1.ResourceList	`json:"quota,omitempty"`
+	// Cascade is Delete or Orphan, what happens to the child when its
+	// parent is deleted. The controller's default is used if empty.
+	Cascade		string	`json:"cascade,omitempty"`
+	// CreatedBy is the user ID of the owner who asked for the child
+	CreatedBy	string	`json:"createdBy,omitempty"`
+}
+
+// SubNamespaceStatus is the state of a SubNamespace
+type SubNamespaceStatus struct {
+	// Phase is Pending, Ready, Refused or Orphaned
+	Phase		string		`json:"phase,omitempty"`
+	Message		string		`json:"message,omitempty"`
+	// Owners are the user IDs holding the parent, directly or inherited
+	Owners		[]string	`json:"owners,omitempty"`
+	UpdatedAt	*meta_v1.Time	`json:"updatedAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SubNamespaceList is a list of SubNamespace resources
+type SubNamespaceList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []SubNamespace `json:"items"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DispatchTenant delegates the administration of a group of users to
+// tenant admins. Users belong to the tenant named by their
+// netsys.io/tenant label.
+type DispatchTenant struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec	DispatchTenantSpec	`json:"spec"`
+	Status	DispatchTenantStatus	`json:"status,omitempty"`
+}
+
+// DispatchTenantSpec is the spec for a DispatchTenant resource, the budget
+// its admins manage users within
+type DispatchTenantSpec struct {
+	// Admins are the user IDs that can manage the tenant's DispatchUsers
+	Admins		[]string	`json:"admins,omitempty"`
+	// AdminGroups are identity provider groups whose members are admins
+	AdminGroups	[]string	`json:"adminGroups,omitempty"`
+	// Prefix every namespace granted to the tenant's users starts with,
+	// e.g. team-a-. It must not overlap the prefix of another tenant.
+	Prefix		string	`json:"prefix"`
+	// MaxUsers and MaxNamespaces limit how many DispatchUsers and distinct
+	// namespaces the tenant has, 0 for no limit
+	MaxUsers	int	`json:"maxUsers,omitempty"`
+	MaxNamespaces	int	`json:"maxNamespaces,omitempty"`
+	// AllowedClasses are the schedule classes grants of the tenant's users
+	// can use. Grants with a schedule of their own are refused unless
+	// empty, which allows any.
+	AllowedClasses	[]string	`json:"allowedClasses,omitempty"`
+}
+
+// DispatchTenantStatus is what a DispatchTenant uses of its budget
+type DispatchTenantStatus struct {
+	// Phase is Active or Refused
+	Phase		string	`json:"phase,omitempty"`
+	Message		string	`json:"message,omitempty"`
+	Users		int	`json:"users"`
+	// Namespaces are the distinct namespaces the tenant's users hold
+	Namespaces	int	`json:"namespaces"`
+	UpdatedAt	*meta_v1.Time	`json:"updatedAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DispatchTenantList is a list of DispatchTenant resources
+type DispatchTenantList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []DispatchTenant `json:"items"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamespaceInvitation shares a namespace its owner holds with another user.
+// Accepting it grants the invitee the namespace until it expires.
+type NamespaceInvitation struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec	NamespaceInvitationSpec	`json:"spec"`
+	Status	NamespaceInvitationStatus	`json:"status,omitempty"`
+}
+
+// NamespaceInvitationSpec is the spec for a NamespaceInvitation resource
+type NamespaceInvitationSpec struct {
+	// Namespace to share, cluster/namespace in member clusters
+	Namespace	string	`json:"namespace"`
+	// Invitee is the user ID of the DispatchUser invited
+	Invitee		string	`json:"invitee"`
+	// Role the invitee is granted, at most the role of the owner.
+	// Defaults to view.
+	Role		string	`json:"role,omitempty"`
+	// ExpiresAt ends the share. An invitation that is not accepted by then
+	// can no longer be.
+	ExpiresAt	meta_v1.Time	`json:"expiresAt"`
+	// InvitedBy is the user ID of the owner sharing the namespace
+	InvitedBy	string	`json:"invitedBy"`
+}
+
+// NamespaceInvitationStatus is the state of a NamespaceInvitation
+type NamespaceInvitationStatus struct {
+	// Phase is Pending, Accepted or Revoked. Invitations past their expiry
+	// are reported as Expired.
+	Phase		string	`json:"phase,omitempty"`
+	Message		string	`json:"message,omitempty"`
+	AcceptedAt	*meta_v1.Time	`json:"acceptedAt,omitempty"`
+	RevokedAt	*meta_v1.Time	`json:"revokedAt,omitempty"`
+	RevokedBy	string	`json:"revokedBy,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamespaceInvitationList is a list of NamespaceInvitation resources
+type NamespaceInvitationList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []NamespaceInvitation `json:"items"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DispatchRobot is an identity for CI pipelines and other automation. It
+// has its own ServiceAccount with rotating tokens and holds namespaces its
+// owner holds, at most with the owner's role.
+type DispatchRobot struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec	DispatchRobotSpec	`json:"spec"`
+	Status	DispatchRobotStatus	`json:"status,omitempty"`
+}
+
+// DispatchRobotSpec is the spec for a DispatchRobot resource
+type DispatchRobotSpec struct {
+	// Owner is the user ID of the DispatchUser owning the robot
+	Owner		string	`json:"owner,omitempty"`
+	// OwnerGroup owns the robot instead of a user. Exactly one of Owner and
+	// OwnerGroup is set.
+	OwnerGroup	string	`json:"ownerGroup,omitempty"`
+	// CreatedBy is the user ID of the member who created a robot of
+	// OwnerGroup. The robot may hold what they hold and is disabled while
+	// they are not an active member of the group.
+	CreatedBy	string	`json:"createdBy,omitempty"`
+	// Grants are the namespaces the robot is bound in, only of the cluster
+	// dispatch runs in
+	Grants		[]RobotGrant	`json:"grants,omitempty"`
+	// TokenTTL is how long a token is used before it is rotated,
+	// robots.tokenTTL if not set
+	TokenTTL	*meta_v1.Duration	`json:"tokenTTL,omitempty"`
+}
+
+// RobotGrant gives a DispatchRobot a role in a namespace
+type RobotGrant struct {
+	Namespace	string	`json:"namespace"`
+	// Role is the ClusterRole bound in the namespace, defaults to view
+	Role		string	`json:"role,omitempty"`
+}
+
+// DispatchRobotStatus is the state of a DispatchRobot
+type DispatchRobotStatus struct {
+	// Phase is Active, or Disabled while no owner is active
+	Phase		string	`json:"phase,omitempty"`
+	// Message says why the robot is disabled or grants were refused
+	Message		string	`json:"message,omitempty"`
+	ServiceAccount	string	`json:"serviceAccount,omitempty"`
+	// Grants are the grants the robot is bound with
+	Grants		[]RobotGrant	`json:"grants,omitempty"`
+	// TokenSecret holds the current token of the ServiceAccount
+	TokenSecret	string	`json:"tokenSecret,omitempty"`
+	// TokenIssuedAt is when the current token was issued, it is rotated
+	// once its TTL has passed
+	TokenIssuedAt	*meta_v1.Time	`json:"tokenIssuedAt,omitempty"`
+	// PreviousTokenSecret holds the token before the last rotation, valid
+	// for robots.tokenOverlap after it
+	PreviousTokenSecret	string	`json:"previousTokenSecret,omitempty"`
+	UpdatedAt	*meta_v1.Time	`json:"updatedAt,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DispatchRobotList is a list of DispatchRobot resources
+type DispatchRobotList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []DispatchRobot `json:"items"`
+}
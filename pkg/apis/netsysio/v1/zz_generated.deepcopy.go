@@ -21,30 +21,746 @@ limitations under the License.
 package v1
 
 import (
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessElevation) DeepCopyInto(out *AccessElevation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessElevation.
+func (in *AccessElevation) DeepCopy() *AccessElevation {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessElevation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessElevation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessElevationList) DeepCopyInto(out *AccessElevationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AccessElevation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessElevationList.
+func (in *AccessElevationList) DeepCopy() *AccessElevationList {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessElevationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessElevationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessElevationSpec) DeepCopyInto(out *AccessElevationSpec) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessElevationSpec.
+func (in *AccessElevationSpec) DeepCopy() *AccessElevationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessElevationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessElevationStatus) DeepCopyInto(out *AccessElevationStatus) {
+	*out = *in
+	if in.Approvers != nil {
+		in, out := &in.Approvers, &out.Approvers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ApproverGroups != nil {
+		in, out := &in.ApproverGroups, &out.ApproverGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DecidedAt != nil {
+		in, out := &in.DecidedAt, &out.DecidedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.EndedAt != nil {
+		in, out := &in.EndedAt, &out.EndedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessElevationStatus.
+func (in *AccessElevationStatus) DeepCopy() *AccessElevationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessElevationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AvailabilitySchedule) DeepCopyInto(out *AvailabilitySchedule) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AvailabilitySchedule.
+func (in *AvailabilitySchedule) DeepCopy() *AvailabilitySchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(AvailabilitySchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchRobot) DeepCopyInto(out *DispatchRobot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchRobot.
+func (in *DispatchRobot) DeepCopy() *DispatchRobot {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchRobot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DispatchRobot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchRobotList) DeepCopyInto(out *DispatchRobotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DispatchRobot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchRobotList.
+func (in *DispatchRobotList) DeepCopy() *DispatchRobotList {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchRobotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DispatchRobotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchRobotSpec) DeepCopyInto(out *DispatchRobotSpec) {
+	*out = *in
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]RobotGrant, len(*in))
+		copy(*out, *in)
+	}
+	if in.TokenTTL != nil {
+		in, out := &in.TokenTTL, &out.TokenTTL
+		*out = new(meta_v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchRobotSpec.
+func (in *DispatchRobotSpec) DeepCopy() *DispatchRobotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchRobotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchRobotStatus) DeepCopyInto(out *DispatchRobotStatus) {
+	*out = *in
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]RobotGrant, len(*in))
+		copy(*out, *in)
+	}
+	if in.TokenIssuedAt != nil {
+		in, out := &in.TokenIssuedAt, &out.TokenIssuedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.UpdatedAt != nil {
+		in, out := &in.UpdatedAt, &out.UpdatedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchRobotStatus.
+func (in *DispatchRobotStatus) DeepCopy() *DispatchRobotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchRobotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchTenant) DeepCopyInto(out *DispatchTenant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchTenant.
+func (in *DispatchTenant) DeepCopy() *DispatchTenant {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchTenant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DispatchTenant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchTenantList) DeepCopyInto(out *DispatchTenantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DispatchTenant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchTenantList.
+func (in *DispatchTenantList) DeepCopy() *DispatchTenantList {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchTenantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DispatchTenantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchTenantSpec) DeepCopyInto(out *DispatchTenantSpec) {
+	*out = *in
+	if in.Admins != nil {
+		in, out := &in.Admins, &out.Admins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdminGroups != nil {
+		in, out := &in.AdminGroups, &out.AdminGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedClasses != nil {
+		in, out := &in.AllowedClasses, &out.AllowedClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchTenantSpec.
+func (in *DispatchTenantSpec) DeepCopy() *DispatchTenantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchTenantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchTenantStatus) DeepCopyInto(out *DispatchTenantStatus) {
+	*out = *in
+	if in.UpdatedAt != nil {
+		in, out := &in.UpdatedAt, &out.UpdatedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchTenantStatus.
+func (in *DispatchTenantStatus) DeepCopy() *DispatchTenantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchTenantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DispatchUser) DeepCopyInto(out *DispatchUser) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchUser.
+func (in *DispatchUser) DeepCopy() *DispatchUser {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DispatchUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchUserList) DeepCopyInto(out *DispatchUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DispatchUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchUserList.
+func (in *DispatchUserList) DeepCopy() *DispatchUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DispatchUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchUserSpec) DeepCopyInto(out *DispatchUserSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]NamespaceGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(meta_v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchUserSpec.
+func (in *DispatchUserSpec) DeepCopy() *DispatchUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DispatchUserStatus) DeepCopyInto(out *DispatchUserStatus) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]GrantStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SuspendedAt != nil {
+		in, out := &in.SuspendedAt, &out.SuspendedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(UsageStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RefusedGrants != nil {
+		in, out := &in.RefusedGrants, &out.RefusedGrants
+		*out = make([]RefusedGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchUserStatus.
+func (in *DispatchUserStatus) DeepCopy() *DispatchUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DispatchUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtensionRequest) DeepCopyInto(out *ExtensionRequest) {
+	*out = *in
+	in.Until.DeepCopyInto(&out.Until)
+	in.RequestedAt.DeepCopyInto(&out.RequestedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtensionRequest.
+func (in *ExtensionRequest) DeepCopy() *ExtensionRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtensionRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GrantStatus) DeepCopyInto(out *GrantStatus) {
+	*out = *in
+	in.GrantedAt.DeepCopyInto(&out.GrantedAt)
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GrantStatus.
+func (in *GrantStatus) DeepCopy() *GrantStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GrantStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceGrant) DeepCopyInto(out *NamespaceGrant) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(meta_v1.Duration)
+		**out = **in
+	}
+	if in.Lease != nil {
+		in, out := &in.Lease, &out.Lease
+		*out = new(meta_v1.Duration)
+		**out = **in
+	}
+	if in.LeaseRenewedAt != nil {
+		in, out := &in.LeaseRenewedAt, &out.LeaseRenewedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(AvailabilitySchedule)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceGrant.
+func (in *NamespaceGrant) DeepCopy() *NamespaceGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceInvitation) DeepCopyInto(out *NamespaceInvitation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceInvitation.
+func (in *NamespaceInvitation) DeepCopy() *NamespaceInvitation {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceInvitation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespaceInvitation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceInvitationList) DeepCopyInto(out *NamespaceInvitationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NamespaceInvitation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceInvitationList.
+func (in *NamespaceInvitationList) DeepCopy() *NamespaceInvitationList {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceInvitationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NamespaceInvitationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceInvitationSpec) DeepCopyInto(out *NamespaceInvitationSpec) {
+	*out = *in
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceInvitationSpec.
+func (in *NamespaceInvitationSpec) DeepCopy() *NamespaceInvitationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceInvitationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceInvitationStatus) DeepCopyInto(out *NamespaceInvitationStatus) {
+	*out = *in
+	if in.AcceptedAt != nil {
+		in, out := &in.AcceptedAt, &out.AcceptedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.RevokedAt != nil {
+		in, out := &in.RevokedAt, &out.RevokedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceInvitationStatus.
+func (in *NamespaceInvitationStatus) DeepCopy() *NamespaceInvitationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceInvitationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceLease) DeepCopyInto(out *NamespaceLease) {
+	*out = *in
+	in.RenewedAt.DeepCopyInto(&out.RenewedAt)
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceLease.
+func (in *NamespaceLease) DeepCopy() *NamespaceLease {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceLease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnedNamespace) DeepCopyInto(out *OwnedNamespace) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchUser.
-func (in *DispatchUser) DeepCopy() *DispatchUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnedNamespace.
+func (in *OwnedNamespace) DeepCopy() *OwnedNamespace {
 	if in == nil {
 		return nil
 	}
-	out := new(DispatchUser)
+	out := new(OwnedNamespace)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DispatchUser) DeepCopyObject() runtime.Object {
+func (in *OwnedNamespace) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -52,13 +768,13 @@ func (in *DispatchUser) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DispatchUserList) DeepCopyInto(out *DispatchUserList) {
+func (in *OwnedNamespaceList) DeepCopyInto(out *OwnedNamespaceList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]DispatchUser, len(*in))
+		*out = make([]OwnedNamespace, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -66,18 +782,18 @@ func (in *DispatchUserList) DeepCopyInto(out *DispatchUserList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchUserList.
-func (in *DispatchUserList) DeepCopy() *DispatchUserList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnedNamespaceList.
+func (in *OwnedNamespaceList) DeepCopy() *OwnedNamespaceList {
 	if in == nil {
 		return nil
 	}
-	out := new(DispatchUserList)
+	out := new(OwnedNamespaceList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DispatchUserList) DeepCopyObject() runtime.Object {
+func (in *OwnedNamespaceList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -85,47 +801,271 @@ func (in *DispatchUserList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DispatchUserSpec) DeepCopyInto(out *DispatchUserSpec) {
+func (in *OwnedNamespaceSpec) DeepCopyInto(out *OwnedNamespaceSpec) {
 	*out = *in
-	if in.Namespaces != nil {
-		in, out := &in.Namespaces, &out.Namespaces
+	if in.Lease != nil {
+		in, out := &in.Lease, &out.Lease
+		*out = new(meta_v1.Duration)
+		**out = **in
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(AvailabilitySchedule)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnedNamespaceSpec.
+func (in *OwnedNamespaceSpec) DeepCopy() *OwnedNamespaceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnedNamespaceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OwnedNamespaceStatus) DeepCopyInto(out *OwnedNamespaceStatus) {
+	*out = *in
+	if in.Lease != nil {
+		in, out := &in.Lease, &out.Lease
+		*out = new(NamespaceLease)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(ScheduleStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnedNamespaceStatus.
+func (in *OwnedNamespaceStatus) DeepCopy() *OwnedNamespaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OwnedNamespaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecertificationCampaign) DeepCopyInto(out *RecertificationCampaign) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecertificationCampaign.
+func (in *RecertificationCampaign) DeepCopy() *RecertificationCampaign {
+	if in == nil {
+		return nil
+	}
+	out := new(RecertificationCampaign)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RecertificationCampaign) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecertificationCampaignList) DeepCopyInto(out *RecertificationCampaignList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RecertificationCampaign, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecertificationCampaignList.
+func (in *RecertificationCampaignList) DeepCopy() *RecertificationCampaignList {
+	if in == nil {
+		return nil
+	}
+	out := new(RecertificationCampaignList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RecertificationCampaignList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecertificationCampaignSpec) DeepCopyInto(out *RecertificationCampaignSpec) {
+	*out = *in
+	in.Deadline.DeepCopyInto(&out.Deadline)
+	if in.Approvers != nil {
+		in, out := &in.Approvers, &out.Approvers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ApproverGroups != nil {
+		in, out := &in.ApproverGroups, &out.ApproverGroups
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DispatchUserSpec.
-func (in *DispatchUserSpec) DeepCopy() *DispatchUserSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecertificationCampaignSpec.
+func (in *RecertificationCampaignSpec) DeepCopy() *RecertificationCampaignSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(DispatchUserSpec)
+	out := new(RecertificationCampaignSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OwnedNamespace) DeepCopyInto(out *OwnedNamespace) {
+func (in *RecertificationCampaignStatus) DeepCopyInto(out *RecertificationCampaignStatus) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RecertificationItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecertificationCampaignStatus.
+func (in *RecertificationCampaignStatus) DeepCopy() *RecertificationCampaignStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RecertificationCampaignStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecertificationItem) DeepCopyInto(out *RecertificationItem) {
+	*out = *in
+	if in.ReviewedAt != nil {
+		in, out := &in.ReviewedAt, &out.ReviewedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.AddedAt != nil {
+		in, out := &in.AddedAt, &out.AddedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecertificationItem.
+func (in *RecertificationItem) DeepCopy() *RecertificationItem {
+	if in == nil {
+		return nil
+	}
+	out := new(RecertificationItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RefusedGrant) DeepCopyInto(out *RefusedGrant) {
+	*out = *in
+	in.RefusedAt.DeepCopyInto(&out.RefusedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RefusedGrant.
+func (in *RefusedGrant) DeepCopy() *RefusedGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(RefusedGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RobotGrant) DeepCopyInto(out *RobotGrant) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RobotGrant.
+func (in *RobotGrant) DeepCopy() *RobotGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(RobotGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleStatus) DeepCopyInto(out *ScheduleStatus) {
+	*out = *in
+	if in.NextTransition != nil {
+		in, out := &in.NextTransition, &out.NextTransition
+		*out = (*in).DeepCopy()
+	}
+	if in.KeepAwakeUntil != nil {
+		in, out := &in.KeepAwakeUntil, &out.KeepAwakeUntil
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleStatus.
+func (in *ScheduleStatus) DeepCopy() *ScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubNamespace) DeepCopyInto(out *SubNamespace) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnedNamespace.
-func (in *OwnedNamespace) DeepCopy() *OwnedNamespace {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubNamespace.
+func (in *SubNamespace) DeepCopy() *SubNamespace {
 	if in == nil {
 		return nil
 	}
-	out := new(OwnedNamespace)
+	out := new(SubNamespace)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *OwnedNamespace) DeepCopyObject() runtime.Object {
+func (in *SubNamespace) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -133,13 +1073,13 @@ func (in *OwnedNamespace) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OwnedNamespaceList) DeepCopyInto(out *OwnedNamespaceList) {
+func (in *SubNamespaceList) DeepCopyInto(out *SubNamespaceList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]OwnedNamespace, len(*in))
+		*out = make([]SubNamespace, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -147,18 +1087,18 @@ func (in *OwnedNamespaceList) DeepCopyInto(out *OwnedNamespaceList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnedNamespaceList.
-func (in *OwnedNamespaceList) DeepCopy() *OwnedNamespaceList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubNamespaceList.
+func (in *SubNamespaceList) DeepCopy() *SubNamespaceList {
 	if in == nil {
 		return nil
 	}
-	out := new(OwnedNamespaceList)
+	out := new(SubNamespaceList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *OwnedNamespaceList) DeepCopyObject() runtime.Object {
+func (in *SubNamespaceList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -166,17 +1106,80 @@ func (in *OwnedNamespaceList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OwnedNamespaceSpec) DeepCopyInto(out *OwnedNamespaceSpec) {
+func (in *SubNamespaceSpec) DeepCopyInto(out *SubNamespaceSpec) {
 	*out = *in
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = make(core_v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OwnedNamespaceSpec.
-func (in *OwnedNamespaceSpec) DeepCopy() *OwnedNamespaceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubNamespaceSpec.
+func (in *SubNamespaceSpec) DeepCopy() *SubNamespaceSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(OwnedNamespaceSpec)
+	out := new(SubNamespaceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubNamespaceStatus) DeepCopyInto(out *SubNamespaceStatus) {
+	*out = *in
+	if in.Owners != nil {
+		in, out := &in.Owners, &out.Owners
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UpdatedAt != nil {
+		in, out := &in.UpdatedAt, &out.UpdatedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubNamespaceStatus.
+func (in *SubNamespaceStatus) DeepCopy() *SubNamespaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SubNamespaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UsageStatus) DeepCopyInto(out *UsageStatus) {
+	*out = *in
+	if in.Total != nil {
+		in, out := &in.Total, &out.Total
+		*out = make(core_v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make(map[string]core_v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UsageStatus.
+func (in *UsageStatus) DeepCopy() *UsageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UsageStatus)
 	in.DeepCopyInto(out)
 	return out
 }
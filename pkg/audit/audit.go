@@ -0,0 +1,208 @@
+// Package audit keeps a tamper-evident record of access changes.
+//
+// Every record carries the hash of the record before it, so removing,
+// reordering or editing a record breaks the chain and is caught by Verify.
+// With a key the hashes are HMACs, which whoever edits the log cannot
+// recompute. Records are written to pluggable sinks; the head of the chain
+// is kept in a ChainStore so a restarted or newly elected controller
+// continues it, and Verify compares the last record with it to catch
+// records removed from the end.
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionGrant             = "grant"
+	ActionRevoke            = "revoke"
+	ActionRoleChange        = "role-change"
+	ActionCredentialIssued  = "credential-issued"
+	ActionCredentialRevoked = "credential-revoked"
+	ActionNamespaceCreated  = "namespace-created"
+	ActionNamespaceDeleted  = "namespace-deleted"
+	ActionSuspend           = "suspend"
+	ActionResume            = "resume"
+
+	// the steps of a temporary elevated role
+	ActionElevationRequested = "elevation-requested"
+	ActionElevationApproved  = "elevation-approved"
+	ActionElevationDenied    = "elevation-denied"
+	ActionElevationStarted   = "elevation-started"
+	ActionElevationEnded     = "elevation-ended"
+)
+
+// Record is one access change
+type Record struct {
+	Sequence uint64    `json:"sequence"`
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+
+	// dispatch user ID the change applies to
+	User      string `json:"user,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// member cluster of the namespace or credential, empty for the
+	// cluster dispatch runs in
+	Cluster string `json:"cluster,omitempty"`
+	Role    string `json:"role,omitempty"`
+	// role before a role change
+	PreviousRole string `json:"previousRole,omitempty"`
+	// why the change was made, from the object that asked for it
+	Reason string `json:"reason,omitempty"`
+	// object whose change caused the record, e.g. DispatchUser/dispatch/willwang
+	Source string `json:"source,omitempty"`
+
+	PreviousHash string `json:"previousHash"`
+	Hash         string `json:"hash"`
+}
+
+// computeHash hashes the record with its Hash field cleared, as an
+// HMAC-SHA256 with key unless key is empty
+func (r Record) computeHash(key []byte) string {
+	r.Hash = ""
+	b, _ := json.Marshal(r)
+	if len(key) == 0 {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sink receives every record
+type Sink interface {
+	Write(r *Record) error
+	Close() error
+}
+
+// Auditor records access changes
+type Auditor interface {
+	Record(r Record)
+}
+
+// Discard is an Auditor that drops every record
+var Discard Auditor = discard{}
+
+type discard struct{}
+
+func (discard) Record(Record) {}
+
+// Log chains records and writes them to its sinks
+type Log struct {
+	lock  sync.Mutex
+	sinks []Sink
+	store ChainStore
+	head  ChainHead
+	key   []byte
+}
+
+// NewLog continues the chain whose head is in store. Records are hashed
+// with key as HMACs, or plain SHA-256 if key is empty.
+func NewLog(store ChainStore, key []byte, sinks ...Sink) (*Log, error) {
+	head, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("loading audit chain head: %v", err)
+	}
+	return &Log{sinks: sinks, store: store, head: head, key: key}, nil
+}
+
+// Record fills in the sequence, time and hashes of r and writes it to every
+// sink. Sink failures are logged, the chain still advances so the gap shows.
+func (l *Log) Record(r Record) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	r.Sequence = l.head.Sequence + 1
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	r.PreviousHash = l.head.Hash
+	r.Hash = r.computeHash(l.key)
+
+	for _, s := range l.sinks {
+		if err := s.Write(&r); err != nil {
+			logging.Error("Writing audit record failed", "sequence", r.Sequence, "action", r.Action, "error", err)
+		}
+	}
+
+	l.head = ChainHead{Sequence: r.Sequence, Hash: r.Hash}
+	if err := l.store.Save(l.head); err != nil {
+		logging.Error("Saving audit chain head failed", "sequence", r.Sequence, "error", err)
+	}
+}
+
+// Close closes every sink
+func (l *Log) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var first error
+	for _, s := range l.sinks {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Verification is what Verify checks the records against besides each
+// other
+type Verification struct {
+	// Key the records were hashed with, empty for plain SHA-256
+	Key []byte
+	// Anchor is the head the first record continues, the zero ChainHead if
+	// the records start the chain. nil does not check the first record.
+	Anchor *ChainHead
+	// Head is the head the last record must be, e.g. from the ChainStore.
+	// nil does not check the last record.
+	Head *ChainHead
+}
+
+// Verify checks that records form an unbroken chain from v.Anchor to
+// v.Head, so edited, removed, reordered and rehashed records are caught as
+// well as records dropped from the start or the end
+func Verify(records []Record, v Verification) error {
+	if len(records) == 0 {
+		if v.Head != nil && v.Anchor != nil && v.Head.Sequence != v.Anchor.Sequence {
+			return fmt.Errorf("no records, but the chain head is record %d", v.Head.Sequence)
+		}
+		return nil
+	}
+	if first := records[0]; v.Anchor != nil && (first.Sequence != v.Anchor.Sequence+1 || first.PreviousHash != v.Anchor.Hash) {
+		if first.Sequence > v.Anchor.Sequence+1 {
+			return fmt.Errorf("records %d to %d are missing from the start", v.Anchor.Sequence+1, first.Sequence-1)
+		}
+		return fmt.Errorf("record %d does not follow record %d", first.Sequence, v.Anchor.Sequence)
+	}
+	for i, r := range records {
+		if r.computeHash(v.Key) != r.Hash {
+			return fmt.Errorf("record %d has been modified", r.Sequence)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := records[i-1]
+		if r.Sequence != prev.Sequence+1 {
+			return fmt.Errorf("records %d to %d are missing", prev.Sequence+1, r.Sequence-1)
+		}
+		if r.PreviousHash != prev.Hash {
+			return fmt.Errorf("record %d does not follow record %d", r.Sequence, prev.Sequence)
+		}
+	}
+	if last := records[len(records)-1]; v.Head != nil && (last.Sequence != v.Head.Sequence || last.Hash != v.Head.Hash) {
+		if last.Sequence < v.Head.Sequence {
+			return fmt.Errorf("records %d to %d are missing from the end", last.Sequence+1, v.Head.Sequence)
+		}
+		return fmt.Errorf("record %d is not the chain head, record %d", last.Sequence, v.Head.Sequence)
+	}
+	return nil
+}
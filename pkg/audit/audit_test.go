@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+)
+
+// memorySink keeps every record written to it
+type memorySink struct {
+	records []Record
+}
+
+func (s *memorySink) Write(r *Record) error {
+	s.records = append(s.records, *r)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+// chain records n records with key, continuing the chain at anchor
+func chain(t *testing.T, key []byte, anchor ChainHead, n int) ([]Record, ChainHead) {
+	store := &MemoryChainStore{head: anchor}
+	sink := &memorySink{}
+	log, err := NewLog(store, key, sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		log.Record(Record{Action: ActionGrant, User: "alice", Namespace: "dev", Role: "edit"})
+	}
+	head, _ := store.Load()
+	return sink.records, head
+}
+
+func TestVerify(t *testing.T) {
+	key := []byte("secret")
+	anchor := ChainHead{Sequence: 7, Hash: "cafe"}
+	records, head := chain(t, key, anchor, 5)
+
+	tests := []struct {
+		name    string
+		records func([]Record) []Record
+		v       Verification
+		err     string
+	}{{
+		name: "intact",
+		v:    Verification{Key: key, Anchor: &anchor, Head: &head},
+	}, {
+		name: "intact without anchor or head",
+		v:    Verification{Key: key},
+	}, {
+		name: "edited",
+		records: func(r []Record) []Record {
+			r[2].Role = "admin"
+			return r
+		},
+		v:   Verification{Key: key},
+		err: "record 10 has been modified",
+	}, {
+		name: "rehashed without the key",
+		records: func(r []Record) []Record {
+			r[2].Role = "admin"
+			r[2].Hash = r[2].computeHash(nil)
+			return r
+		},
+		v:   Verification{Key: key},
+		err: "record 10 has been modified",
+	}, {
+		name: "wrong key",
+		v:    Verification{Key: []byte("guess")},
+		err:  "record 8 has been modified",
+	}, {
+		name: "removed",
+		records: func(r []Record) []Record {
+			return append(r[:2:2], r[3:]...)
+		},
+		v:   Verification{Key: key},
+		err: "records 10 to 10 are missing",
+	}, {
+		name: "reordered",
+		records: func(r []Record) []Record {
+			r[1], r[2] = r[2], r[1]
+			return r
+		},
+		v:   Verification{Key: key},
+		err: "missing",
+	}, {
+		name: "dropped from the start",
+		records: func(r []Record) []Record {
+			return r[2:]
+		},
+		v:   Verification{Key: key, Anchor: &anchor},
+		err: "records 8 to 9 are missing from the start",
+	}, {
+		name: "another anchor",
+		v:    Verification{Key: key, Anchor: &ChainHead{Sequence: 7, Hash: "beef"}},
+		err:  "record 8 does not follow record 7",
+	}, {
+		name: "dropped from the end",
+		records: func(r []Record) []Record {
+			return r[:3]
+		},
+		v:   Verification{Key: key, Head: &head},
+		err: "records 11 to 12 are missing from the end",
+	}, {
+		name: "head mismatch",
+		v:    Verification{Key: key, Head: &ChainHead{Sequence: 12, Hash: "beef"}},
+		err:  "record 12 is not the chain head",
+	}, {
+		name: "no records but a head",
+		records: func(r []Record) []Record {
+			return nil
+		},
+		v:   Verification{Key: key, Anchor: &anchor, Head: &head},
+		err: "no records, but the chain head is record 12",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := append([]Record(nil), records...)
+			if test.records != nil {
+				r = test.records(r)
+			}
+			err := Verify(r, test.v)
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("expected the chain to verify, got %v", err)
+			case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyUnkeyed(t *testing.T) {
+	records, head := chain(t, nil, ChainHead{}, 3)
+	if err := Verify(records, Verification{Anchor: &ChainHead{}, Head: &head}); err != nil {
+		t.Errorf("expected the chain to verify, got %v", err)
+	}
+	if err := Verify(records, Verification{Key: []byte("secret")}); err == nil {
+		t.Error("expected records hashed without a key to fail verification with one")
+	}
+}
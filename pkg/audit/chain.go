@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"strconv"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ChainHead is the sequence number and hash of the last record
+type ChainHead struct {
+	Sequence uint64
+	Hash     string
+}
+
+// ChainStore persists the chain head between runs
+type ChainStore interface {
+	Load() (ChainHead, error)
+	Save(head ChainHead) error
+}
+
+// MemoryChainStore keeps the chain head in memory only, a restart starts a
+// new chain
+type MemoryChainStore struct {
+	head ChainHead
+}
+
+func (s *MemoryChainStore) Load() (ChainHead, error) { return s.head, nil }
+
+func (s *MemoryChainStore) Save(head ChainHead) error {
+	s.head = head
+	return nil
+}
+
+const (
+	sequenceKey = "sequence"
+	hashKey     = "hash"
+)
+
+// ConfigMapChainStore keeps the chain head in a ConfigMap, so the leader
+// that takes over continues the chain of the previous one
+type ConfigMapChainStore struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+func (s ConfigMapChainStore) Load() (ChainHead, error) {
+	cm, err := s.Client.CoreV1().ConfigMaps(s.Namespace).Get(s.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return ChainHead{}, nil
+	}
+	if err != nil {
+		return ChainHead{}, err
+	}
+	seq, err := strconv.ParseUint(cm.Data[sequenceKey], 10, 64)
+	if err != nil && cm.Data[sequenceKey] != "" {
+		return ChainHead{}, err
+	}
+	return ChainHead{Sequence: seq, Hash: cm.Data[hashKey]}, nil
+}
+
+func (s ConfigMapChainStore) Save(head ChainHead) error {
+	data := map[string]string{
+		sequenceKey: strconv.FormatUint(head.Sequence, 10),
+		hashKey:     head.Hash,
+	}
+	configMaps := s.Client.CoreV1().ConfigMaps(s.Namespace)
+	cm, err := configMaps.Get(s.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(&core_v1.ConfigMap{
+			ObjectMeta: meta_v1.ObjectMeta{Name: s.Name, Namespace: s.Namespace},
+			Data:       data,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	cm = cm.DeepCopy()
+	cm.Data = data
+	_, err = configMaps.Update(cm)
+	return err
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// WriterSink writes records as JSON lines, e.g. to stdout
+type WriterSink struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewWriterSink writes to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Write(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+func (s *WriterSink) Close() error { return nil }
+
+// FileSink appends records as JSON lines to a file
+type FileSink struct {
+	WriterSink
+	f *os.File
+}
+
+// NewFileSink opens path for appending, creating it if needed
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{WriterSink: WriterSink{w: f}, f: f}, nil
+}
+
+func (s *FileSink) Write(r *Record) error {
+	if err := s.WriterSink.Write(r); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+func (s *FileSink) Close() error { return s.f.Close() }
+
+// WebhookSink POSTs every record as JSON to a URL
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookSink posts to url, giving up on a record after timeout
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{URL: url, Client: &http.Client{Timeout: timeout}}
+}
+
+func (s *WebhookSink) Write(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	resp, err := s.Client.Post(s.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error { return nil }
+
+// ReadRecords reads JSON-line records from r. Lines that are not records,
+// such as log lines interleaved on stdout, are skipped.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil || rec.Hash == "" {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
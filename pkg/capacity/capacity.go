@@ -0,0 +1,188 @@
+// Package capacity compares the quotas dispatch hands out with what the
+// nodes of the cluster can hold, so that namespaces are not promised more
+// than the cluster has
+package capacity
+
+import (
+	"fmt"
+	"sort"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// nodeResources maps the quota resources that can be compared to the node
+// resource backing them
+var nodeResources = map[core_v1.ResourceName]core_v1.ResourceName{
+	core_v1.ResourceCPU:                      core_v1.ResourceCPU,
+	core_v1.ResourceRequestsCPU:              core_v1.ResourceCPU,
+	core_v1.ResourceLimitsCPU:                core_v1.ResourceCPU,
+	core_v1.ResourceMemory:                   core_v1.ResourceMemory,
+	core_v1.ResourceRequestsMemory:           core_v1.ResourceMemory,
+	core_v1.ResourceLimitsMemory:             core_v1.ResourceMemory,
+	core_v1.ResourceEphemeralStorage:         core_v1.ResourceEphemeralStorage,
+	core_v1.ResourceRequestsEphemeralStorage: core_v1.ResourceEphemeralStorage,
+	core_v1.ResourceLimitsEphemeralStorage:   core_v1.ResourceEphemeralStorage,
+	core_v1.ResourcePods:                     core_v1.ResourcePods,
+}
+
+// Comparable returns true if the quota resource name can be compared to
+// the capacity of the nodes
+func Comparable(name core_v1.ResourceName) bool {
+	_, ok := nodeResources[name]
+	return ok
+}
+
+// Resource is the capacity left of one quota resource
+type Resource struct {
+	Name core_v1.ResourceName `json:"name"`
+	// Allocatable is the sum of the allocatable capacity of the nodes
+	Allocatable resource.Quantity `json:"allocatable"`
+	// Overcommit is the ratio Allocatable may be committed by
+	Overcommit float64 `json:"overcommit"`
+	// Capacity is Allocatable times Overcommit
+	Capacity resource.Quantity `json:"capacity"`
+	// Committed is the sum of the hard limits of the managed quotas
+	Committed resource.Quantity `json:"committed"`
+	// Available is Capacity minus Committed, negative when overcommitted
+	Available resource.Quantity `json:"available"`
+}
+
+// Report is the capacity of the cluster and how much of it is committed
+type Report struct {
+	// Nodes is the number of schedulable nodes counted
+	Nodes int `json:"nodes"`
+	// Quotas is the number of managed quotas counted
+	Quotas    int        `json:"quotas"`
+	Resources []Resource `json:"resources"`
+
+	// to report on resources nothing is committed of yet
+	allocatable core_v1.ResourceList
+	overcommit  map[core_v1.ResourceName]float64
+	// namespaces of the quotas
+	namespaces map[string]bool
+}
+
+// Load lists the schedulable nodes and the quotas called quotaName in every
+// namespace, and reports the capacity of every comparable resource they
+// limit. Resources not in overcommit have a ratio of 1.
+func Load(client kubernetes.Interface, quotaName string, overcommit map[core_v1.ResourceName]float64) (*Report, error) {
+	nodes, err := client.CoreV1().Nodes().List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %v", err)
+	}
+	quotas, err := client.CoreV1().ResourceQuotas(meta_v1.NamespaceAll).List(meta_v1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", quotaName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing quotas: %v", err)
+	}
+	return Compute(nodes.Items, quotas.Items, overcommit), nil
+}
+
+// Compute reports the capacity of nodes and the commitments of quotas
+func Compute(nodes []core_v1.Node, quotas []core_v1.ResourceQuota, overcommit map[core_v1.ResourceName]float64) *Report {
+	allocatable := core_v1.ResourceList{}
+	report := &Report{Quotas: len(quotas), allocatable: allocatable, overcommit: overcommit,
+		namespaces: map[string]bool{}}
+	for _, n := range nodes {
+		if n.Spec.Unschedulable {
+			continue
+		}
+		report.Nodes++
+		for name, q := range n.Status.Allocatable {
+			sum := allocatable[name]
+			sum.Add(q)
+			allocatable[name] = sum
+		}
+	}
+
+	committed := core_v1.ResourceList{}
+	for _, rq := range quotas {
+		report.namespaces[rq.Namespace] = true
+		for name, q := range rq.Spec.Hard {
+			if !Comparable(name) {
+				continue
+			}
+			sum := committed[name]
+			sum.Add(q)
+			committed[name] = sum
+		}
+	}
+	for name, used := range committed {
+		report.Resources = append(report.Resources, newResource(name, allocatable, used, overcommit))
+	}
+	sort.Slice(report.Resources, func(i, j int) bool { return report.Resources[i].Name < report.Resources[j].Name })
+	return report
+}
+
+func newResource(name core_v1.ResourceName, allocatable core_v1.ResourceList, committed resource.Quantity,
+	overcommit map[core_v1.ResourceName]float64) Resource {
+	ratio, ok := overcommit[name]
+	if !ok {
+		ratio = 1
+	}
+	alloc := allocatable[nodeResources[name]]
+	r := Resource{
+		Name:        name,
+		Allocatable: alloc.DeepCopy(),
+		Overcommit:  ratio,
+		Capacity:    *resource.NewMilliQuantity(int64(float64(alloc.MilliValue())*ratio), alloc.Format),
+		Committed:   committed.DeepCopy(),
+	}
+	r.Available = r.Capacity.DeepCopy()
+	r.Available.Sub(committed)
+	return r
+}
+
+// HasQuota returns true if one of the quotas counted is in namespace
+func (r *Report) HasQuota(namespace string) bool {
+	return r.namespaces[namespace]
+}
+
+// Exceeded returns a description of every resource request would
+// overcommit, none if it fits
+func (r *Report) Exceeded(request core_v1.ResourceList) []string {
+	byName := make(map[core_v1.ResourceName]Resource, len(r.Resources))
+	for _, res := range r.Resources {
+		byName[res.Name] = res
+	}
+	var exceeded []string
+	for name, q := range request {
+		if !Comparable(name) {
+			continue
+		}
+		res, ok := byName[name]
+		if !ok {
+			res = newResource(name, r.allocatable, resource.Quantity{}, r.overcommit)
+		}
+		if q.Cmp(res.Available) > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("%s needs %s but %s of %s is available",
+				name, q.String(), res.Available.String(), res.Capacity.String()))
+		}
+	}
+	sort.Strings(exceeded)
+	return exceeded
+}
+
+// Commit adds request to the committed resources, e.g. for a namespace
+// whose quota is not created yet
+func (r *Report) Commit(request core_v1.ResourceList) {
+	for name, q := range request {
+		if !Comparable(name) {
+			continue
+		}
+		i := sort.Search(len(r.Resources), func(i int) bool { return r.Resources[i].Name >= name })
+		if i == len(r.Resources) || r.Resources[i].Name != name {
+			r.Resources = append(r.Resources, Resource{})
+			copy(r.Resources[i+1:], r.Resources[i:])
+			r.Resources[i] = newResource(name, r.allocatable, resource.Quantity{}, r.overcommit)
+		}
+		res := &r.Resources[i]
+		res.Committed.Add(q)
+		res.Available.Sub(q)
+	}
+}
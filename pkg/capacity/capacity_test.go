@@ -0,0 +1,170 @@
+package capacity
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func node(cpu, memory string, unschedulable bool) core_v1.Node {
+	return core_v1.Node{
+		Spec: core_v1.NodeSpec{Unschedulable: unschedulable},
+		Status: core_v1.NodeStatus{Allocatable: core_v1.ResourceList{
+			core_v1.ResourceCPU:    resource.MustParse(cpu),
+			core_v1.ResourceMemory: resource.MustParse(memory),
+		}},
+	}
+}
+
+func quota(namespace string, hard core_v1.ResourceList) core_v1.ResourceQuota {
+	return core_v1.ResourceQuota{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "dispatch-quota", Namespace: namespace},
+		Spec:       core_v1.ResourceQuotaSpec{Hard: hard},
+	}
+}
+
+// testReport commits 6 of 8 CPUs requested and 8 of 16 overcommitted CPUs
+// limited
+func testReport() *Report {
+	nodes := []core_v1.Node{
+		node("4", "8Gi", false),
+		node("4", "8Gi", false),
+		// cordoned nodes do not count
+		node("32", "64Gi", true),
+	}
+	quotas := []core_v1.ResourceQuota{
+		quota("alice", core_v1.ResourceList{
+			core_v1.ResourceRequestsCPU: resource.MustParse("4"),
+			core_v1.ResourceLimitsCPU:   resource.MustParse("6"),
+			// not backed by a node resource
+			core_v1.ResourceServices: resource.MustParse("10"),
+		}),
+		quota("bob", core_v1.ResourceList{
+			core_v1.ResourceRequestsCPU: resource.MustParse("2"),
+			core_v1.ResourceLimitsCPU:   resource.MustParse("2"),
+		}),
+	}
+	return Compute(nodes, quotas, map[core_v1.ResourceName]float64{core_v1.ResourceLimitsCPU: 2})
+}
+
+func TestCompute(t *testing.T) {
+	report := testReport()
+	if report.Nodes != 2 || report.Quotas != 2 {
+		t.Errorf("expected 2 nodes and 2 quotas, got %d and %d", report.Nodes, report.Quotas)
+	}
+	if !report.HasQuota("alice") || report.HasQuota("carol") {
+		t.Error("expected only the namespaces of the quotas to have one")
+	}
+
+	tests := []struct {
+		name                                    core_v1.ResourceName
+		overcommit                              float64
+		allocatable, capacity, committed, avail string
+	}{
+		{core_v1.ResourceLimitsCPU, 2, "8", "16", "8", "8"},
+		{core_v1.ResourceRequestsCPU, 1, "8", "8", "6", "2"},
+	}
+	if len(report.Resources) != len(tests) {
+		t.Fatalf("expected %d resources, got %+v", len(tests), report.Resources)
+	}
+	for i, test := range tests {
+		r := report.Resources[i]
+		if r.Name != test.name || r.Overcommit != test.overcommit {
+			t.Errorf("resource %d: expected %s with ratio %g, got %s with %g", i, test.name, test.overcommit, r.Name, r.Overcommit)
+			continue
+		}
+		for _, q := range []struct {
+			what     string
+			got      resource.Quantity
+			expected string
+		}{
+			{"allocatable", r.Allocatable, test.allocatable},
+			{"capacity", r.Capacity, test.capacity},
+			{"committed", r.Committed, test.committed},
+			{"available", r.Available, test.avail},
+		} {
+			if q.got.Cmp(resource.MustParse(q.expected)) != 0 {
+				t.Errorf("%s: expected %s %s, got %s", r.Name, q.what, q.expected, q.got.String())
+			}
+		}
+	}
+}
+
+func TestExceeded(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  core_v1.ResourceList
+		exceeded []string
+	}{{
+		name:    "fits",
+		request: core_v1.ResourceList{core_v1.ResourceRequestsCPU: resource.MustParse("2")},
+	}, {
+		name:     "over the rest",
+		request:  core_v1.ResourceList{core_v1.ResourceRequestsCPU: resource.MustParse("2500m")},
+		exceeded: []string{"requests.cpu"},
+	}, {
+		name:    "within the overcommit ratio",
+		request: core_v1.ResourceList{core_v1.ResourceLimitsCPU: resource.MustParse("8")},
+	}, {
+		name: "every resource over",
+		request: core_v1.ResourceList{
+			core_v1.ResourceRequestsCPU: resource.MustParse("3"),
+			core_v1.ResourceLimitsCPU:   resource.MustParse("9"),
+		},
+		exceeded: []string{"limits.cpu", "requests.cpu"},
+	}, {
+		name:    "nothing committed yet",
+		request: core_v1.ResourceList{core_v1.ResourceRequestsMemory: resource.MustParse("16Gi")},
+	}, {
+		name:     "nothing committed yet but over",
+		request:  core_v1.ResourceList{core_v1.ResourceMemory: resource.MustParse("17Gi")},
+		exceeded: []string{"memory"},
+	}, {
+		name:    "not comparable",
+		request: core_v1.ResourceList{core_v1.ResourceServices: resource.MustParse("1000")},
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var names []string
+			for _, e := range testReport().Exceeded(test.request) {
+				names = append(names, strings.Fields(e)[0])
+			}
+			if !reflect.DeepEqual(names, test.exceeded) {
+				t.Errorf("expected %v exceeded, got %v", test.exceeded, names)
+			}
+		})
+	}
+}
+
+func TestCommit(t *testing.T) {
+	report := testReport()
+	request := core_v1.ResourceList{
+		core_v1.ResourceRequestsCPU:    resource.MustParse("2"),
+		core_v1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+	}
+	if exceeded := report.Exceeded(request); len(exceeded) != 0 {
+		t.Fatalf("expected the first request to fit, got %v", exceeded)
+	}
+	report.Commit(request)
+
+	// the same request no longer fits once committed
+	if exceeded := report.Exceeded(request); len(exceeded) != 1 || !strings.HasPrefix(exceeded[0], "requests.cpu") {
+		t.Errorf("expected requests.cpu exceeded, got %v", exceeded)
+	}
+	var names []core_v1.ResourceName
+	for _, r := range report.Resources {
+		names = append(names, r.Name)
+	}
+	expected := []core_v1.ResourceName{core_v1.ResourceLimitsCPU, core_v1.ResourceRequestsCPU, core_v1.ResourceRequestsMemory}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected resources %v in order, got %v", expected, names)
+	}
+	if memory := report.Resources[2]; memory.Available.Cmp(resource.MustParse("12Gi")) != 0 {
+		t.Errorf("expected 12Gi of memory available, got %s", memory.Available.String())
+	}
+}
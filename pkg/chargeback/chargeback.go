@@ -0,0 +1,97 @@
+// Package chargeback prices what users consume over time. The usage
+// controller samples the share of every namespace each user holds into
+// daily records, which reports add up per user, group or namespace.
+package chargeback
+
+import (
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+)
+
+// bytes in a GB as priced, memory and storage are billed in GiB
+const gigabyte = 1 << 30
+
+// Prices are the unit prices of requested resources
+type Prices struct {
+	CPUHour       float64 `json:"cpuHour"`
+	MemoryGBHour  float64 `json:"memoryGBHour"`
+	StorageGBHour float64 `json:"storageGBHour"`
+	// Currency is only shown in reports, e.g. EUR
+	Currency string `json:"currency,omitempty"`
+}
+
+// Record is what one user consumed of one namespace on one day. The cost
+// is priced when sampled, so price changes do not rewrite history.
+type Record struct {
+	Namespace string `json:"namespace"`
+	User      string `json:"user"`
+	// Groups of the user when last sampled
+	Groups         []string `json:"groups,omitempty"`
+	CPUHours       float64  `json:"cpuHours"`
+	MemoryGBHours  float64  `json:"memoryGBHours"`
+	StorageGBHours float64  `json:"storageGBHours"`
+	Cost           float64  `json:"cost"`
+}
+
+// Sample returns the record of a user holding share of namespace for d
+func Sample(namespace, user string, groups []string, share core_v1.ResourceList, d time.Duration, prices Prices) Record {
+	hours := d.Hours()
+	cpu, memory, storage := share[core_v1.ResourceRequestsCPU], share[core_v1.ResourceRequestsMemory],
+		share[core_v1.ResourceRequestsStorage]
+	r := Record{
+		Namespace:      namespace,
+		User:           user,
+		Groups:         groups,
+		CPUHours:       float64(cpu.MilliValue()) / 1000 * hours,
+		MemoryGBHours:  float64(memory.Value()) / gigabyte * hours,
+		StorageGBHours: float64(storage.Value()) / gigabyte * hours,
+	}
+	r.Cost = r.CPUHours*prices.CPUHour + r.MemoryGBHours*prices.MemoryGBHour + r.StorageGBHours*prices.StorageGBHour
+	return r
+}
+
+// add adds the consumption of o to r
+func (r *Record) add(o Record) {
+	r.CPUHours += o.CPUHours
+	r.MemoryGBHours += o.MemoryGBHours
+	r.StorageGBHours += o.StorageGBHours
+	r.Cost += o.Cost
+}
+
+// scaled returns the consumption of r times f
+func (r Record) scaled(f float64) Record {
+	r.CPUHours *= f
+	r.MemoryGBHours *= f
+	r.StorageGBHours *= f
+	r.Cost *= f
+	return r
+}
+
+// Day holds the records of one UTC day
+type Day struct {
+	// Date is formatted as 2006-01-02
+	Date     string   `json:"date"`
+	Currency string   `json:"currency,omitempty"`
+	Records  []Record `json:"records"`
+}
+
+// Merge adds records to the day, summing those of the same user and
+// namespace
+func (d *Day) Merge(records []Record) {
+	index := make(map[[2]string]int, len(d.Records))
+	for i, r := range d.Records {
+		index[[2]string{r.Namespace, r.User}] = i
+	}
+	for _, r := range records {
+		key := [2]string{r.Namespace, r.User}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(d.Records)
+			d.Records = append(d.Records, r)
+			continue
+		}
+		d.Records[i].add(r)
+		d.Records[i].Groups = r.Groups
+	}
+}
@@ -0,0 +1,145 @@
+package chargeback
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// What a report is broken down by
+const (
+	ByUser      = "user"
+	ByGroup     = "group"
+	ByNamespace = "namespace"
+)
+
+// noGroup is the group of users without groups
+const noGroup = "<none>"
+
+// ValidBy returns true if by is a known breakdown
+func ValidBy(by string) bool {
+	return by == ByUser || by == ByGroup || by == ByNamespace
+}
+
+// Row is the consumption and cost of one user, group or namespace
+type Row struct {
+	Key            string  `json:"key"`
+	CPUHours       float64 `json:"cpuHours"`
+	MemoryGBHours  float64 `json:"memoryGBHours"`
+	StorageGBHours float64 `json:"storageGBHours"`
+	Cost           float64 `json:"cost"`
+}
+
+func (row *Row) add(r Record) {
+	row.CPUHours += r.CPUHours
+	row.MemoryGBHours += r.MemoryGBHours
+	row.StorageGBHours += r.StorageGBHours
+	row.Cost += r.Cost
+}
+
+// Report is the cost of a date range
+type Report struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	By       string `json:"by"`
+	Currency string `json:"currency,omitempty"`
+	Rows     []Row  `json:"rows"`
+	Total    Row    `json:"total"`
+}
+
+// Generate adds up the records of days by user, group or namespace. The
+// records of a user in several groups are split evenly among them. Only
+// records keep returns true for are counted, all if keep is nil.
+func Generate(days []Day, from, to time.Time, by string, keep func(Record) bool) (*Report, error) {
+	if !ValidBy(by) {
+		return nil, fmt.Errorf("invalid breakdown %q, use user, group or namespace", by)
+	}
+	report := &Report{From: from.Format(DateLayout), To: to.Format(DateLayout), By: by, Total: Row{Key: "total"}}
+	rows := map[string]*Row{}
+	charge := func(key string, r Record) {
+		if rows[key] == nil {
+			rows[key] = &Row{Key: key}
+		}
+		rows[key].add(r)
+	}
+	for _, day := range days {
+		if day.Currency != "" {
+			report.Currency = day.Currency
+		}
+		for _, r := range day.Records {
+			if keep != nil && !keep(r) {
+				continue
+			}
+			report.Total.add(r)
+			switch by {
+			case ByUser:
+				charge(r.User, r)
+			case ByNamespace:
+				charge(r.Namespace, r)
+			case ByGroup:
+				if len(r.Groups) == 0 {
+					charge(noGroup, r)
+					continue
+				}
+				share := r.scaled(1 / float64(len(r.Groups)))
+				for _, g := range r.Groups {
+					charge(g, share)
+				}
+			}
+		}
+	}
+	report.Rows = make([]Row, 0, len(rows))
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Key < report.Rows[j].Key })
+	return report, nil
+}
+
+// WriteCSV writes one line per row of the report, without the total
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{r.By, "cpu_hours", "memory_gb_hours", "storage_gb_hours", "cost", "currency"})
+	for _, row := range r.Rows {
+		cw.Write([]string{
+			row.Key,
+			strconv.FormatFloat(row.CPUHours, 'f', 4, 64),
+			strconv.FormatFloat(row.MemoryGBHours, 'f', 4, 64),
+			strconv.FormatFloat(row.StorageGBHours, 'f', 4, 64),
+			strconv.FormatFloat(row.Cost, 'f', 2, 64),
+			r.Currency,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ParseRange parses the dates of a report, formatted as 2006-01-02. An
+// empty from defaults to the first day of the previous month and an empty
+// to to the last day of the month of from.
+func ParseRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	if from != "" {
+		t, err := time.Parse(DateLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", from)
+		}
+		start = t
+	}
+	end := time.Date(start.Year(), start.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	if to != "" {
+		t, err := time.Parse(DateLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", to)
+		}
+		end = t
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the report ends before it starts")
+	}
+	return start, end, nil
+}
@@ -0,0 +1,141 @@
+package chargeback
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// ConfigMaps holding days are named this plus the date as 20060102
+	configMapPrefix = "dispatch-chargeback-"
+
+	// label holding the date of a day's ConfigMap
+	dateLabel = "netsys.io/chargeback-date"
+
+	// key of the records in a day's ConfigMap
+	recordsKey = "records.json"
+
+	// DateLayout is the layout of dates in reports and ConfigMaps
+	DateLayout = "2006-01-02"
+)
+
+// Store keeps the daily records of the samples
+type Store interface {
+	// Add merges records into the day of date
+	Add(date time.Time, currency string, records []Record) error
+	// Load returns the days from from to to, both inclusive
+	Load(from, to time.Time) ([]Day, error)
+	// Prune removes the days before date
+	Prune(date time.Time) error
+}
+
+// ConfigMapStore keeps every day in a ConfigMap of the dispatch namespace,
+// so both dispatchctl and the self-service server can report on them
+type ConfigMapStore struct {
+	Client    kubernetes.Interface
+	Namespace string
+}
+
+// Add merges records into the ConfigMap of date, creating it if needed
+func (s ConfigMapStore) Add(date time.Time, currency string, records []Record) error {
+	date = date.UTC()
+	name := configMapPrefix + date.Format("20060102")
+	configMaps := s.Client.CoreV1().ConfigMaps(s.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(name, meta_v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			day := Day{Date: date.Format(DateLayout), Currency: currency}
+			day.Merge(records)
+			b, err := json.Marshal(day)
+			if err != nil {
+				return err
+			}
+			_, err = configMaps.Create(&core_v1.ConfigMap{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      name,
+					Namespace: s.Namespace,
+					Labels:    map[string]string{dateLabel: date.Format(DateLayout)},
+				},
+				Data: map[string]string{recordsKey: string(b)},
+			})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		day, err := decodeDay(cm)
+		if err != nil {
+			return err
+		}
+		day.Merge(records)
+		if currency != "" {
+			day.Currency = currency
+		}
+		b, err := json.Marshal(day)
+		if err != nil {
+			return err
+		}
+		cm = cm.DeepCopy()
+		cm.Data = map[string]string{recordsKey: string(b)}
+		_, err = configMaps.Update(cm)
+		return err
+	})
+}
+
+// Load returns the days from from to to that have records, in order
+func (s ConfigMapStore) Load(from, to time.Time) ([]Day, error) {
+	first, last := from.UTC().Format(DateLayout), to.UTC().Format(DateLayout)
+	list, err := s.Client.CoreV1().ConfigMaps(s.Namespace).List(meta_v1.ListOptions{LabelSelector: dateLabel})
+	if err != nil {
+		return nil, err
+	}
+	var days []Day
+	for i := range list.Items {
+		date := list.Items[i].Labels[dateLabel]
+		if date < first || date > last {
+			continue
+		}
+		day, err := decodeDay(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+	return days, nil
+}
+
+// Prune deletes the ConfigMaps of the days before date
+func (s ConfigMapStore) Prune(date time.Time) error {
+	before := date.UTC().Format(DateLayout)
+	configMaps := s.Client.CoreV1().ConfigMaps(s.Namespace)
+	list, err := configMaps.List(meta_v1.ListOptions{LabelSelector: dateLabel})
+	if err != nil {
+		return err
+	}
+	for _, cm := range list.Items {
+		if cm.Labels[dateLabel] >= before {
+			continue
+		}
+		if err := configMaps.Delete(cm.Name, &meta_v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeDay(cm *core_v1.ConfigMap) (Day, error) {
+	var day Day
+	if err := json.Unmarshal([]byte(cm.Data[recordsKey]), &day); err != nil {
+		return Day{}, fmt.Errorf("decoding %s: %v", cm.Name, err)
+	}
+	return day, nil
+}
@@ -3,9 +3,12 @@ package client
 import (
 	"os"
 	"fmt"
+	"path/filepath"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/rest"
 	netsys_client "github.com/hantaowang/dispatch/pkg/client/clientset/versioned"
+	"github.com/hantaowang/dispatch/pkg/logging"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 
 )
@@ -13,40 +16,71 @@ import (
 type ClientSets struct {
 	OriginalClient			kubernetes.Interface
 	NetsysClient 			netsys_client.Interface
+	// Members are the member clusters, nil unless multi-cluster is enabled
+	Members				*Clusters
 }
 
-// retrieve the Kubernetes cluster client from outside of the cluster
-func GetKubernetesClient() ClientSets {
-	// construct the path to resolve to `~/.kube/config`
-	kubeConfigPath := os.Getenv("HOME") + "/.kube/config"
+// ForCluster returns the client of cluster, the cluster dispatch runs in if
+// cluster is empty
+func (cs ClientSets) ForCluster(cluster string) (kubernetes.Interface, error) {
+	if cluster == "" {
+		return cs.OriginalClient, nil
+	}
+	if cs.Members == nil {
+		return nil, UnknownClusterError{Name: cluster}
+	}
+	return cs.Members.Client(cluster)
+}
+
+// retrieve the Kubernetes cluster config. An explicit kubeconfig or master
+// URL wins, otherwise the in-cluster config is used when running in a pod
+// and `~/.kube/config` when running outside of the cluster.
+func GetKubernetesConfig(kubeconfig, master string) (*rest.Config, error) {
+	if kubeconfig == "" && master == "" {
+		// pods always have the API server address in their environment
+		if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+			config, err := rest.InClusterConfig()
+			if err != nil {
+				return nil, fmt.Errorf("GetClusterConfig in-cluster config: %v", err)
+			}
+			logging.Info("Constructed in-cluster config", "host", config.Host)
+			return config, nil
+		}
+
+		// construct the path to resolve to `~/.kube/config`
+		kubeconfig = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
 
 	// create the config from the path
-	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	config, err := clientcmd.BuildConfigFromFlags(master, kubeconfig)
 	if err != nil {
-		panic(fmt.Sprintf("GetClusterConfig config: %v", err))
-
+		return nil, fmt.Errorf("GetClusterConfig config: %v", err)
 	}
 
-	fmt.Println("Successfully constructed config")
+	logging.Info("Constructed config", "kubeconfig", kubeconfig, "host", config.Host)
+	return config, nil
+}
 
+// generate the Kubernetes and dispatch clients from a config
+func GetKubernetesClient(config *rest.Config) (ClientSets, error) {
 	// generate the client based off of the config
 	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		panic(fmt.Sprintf("GetClusterConfig originalClient: %v", err))
+		return ClientSets{}, fmt.Errorf("GetClusterConfig originalClient: %v", err)
 	}
 
-	fmt.Println("Successfully constructed k8s client")
+	logging.Debug("Constructed Kubernetes client")
 
 
 	customClient, err := netsys_client.NewForConfig(config)
 	if err != nil {
-		panic(fmt.Sprintf("GetClusterConfig customClient: %v", err))
+		return ClientSets{}, fmt.Errorf("GetClusterConfig customClient: %v", err)
 	}
 
-	fmt.Println("Successfully constructed custom client")
+	logging.Debug("Constructed dispatch client")
 
 	return ClientSets{
 		OriginalClient: client,
 		NetsysClient: customClient,
-	}
+	}, nil
 }
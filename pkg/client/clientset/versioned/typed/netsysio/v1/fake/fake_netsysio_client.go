@@ -28,14 +28,38 @@ type FakeNetsysV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeNetsysV1) AccessElevations(namespace string) v1.AccessElevationInterface {
+	return &FakeAccessElevations{c, namespace}
+}
+
+func (c *FakeNetsysV1) DispatchRobots(namespace string) v1.DispatchRobotInterface {
+	return &FakeDispatchRobots{c, namespace}
+}
+
+func (c *FakeNetsysV1) DispatchTenants(namespace string) v1.DispatchTenantInterface {
+	return &FakeDispatchTenants{c, namespace}
+}
+
 func (c *FakeNetsysV1) DispatchUsers(namespace string) v1.DispatchUserInterface {
 	return &FakeDispatchUsers{c, namespace}
 }
 
+func (c *FakeNetsysV1) NamespaceInvitations(namespace string) v1.NamespaceInvitationInterface {
+	return &FakeNamespaceInvitations{c, namespace}
+}
+
 func (c *FakeNetsysV1) OwnedNamespaces(namespace string) v1.OwnedNamespaceInterface {
 	return &FakeOwnedNamespaces{c, namespace}
 }
 
+func (c *FakeNetsysV1) RecertificationCampaigns(namespace string) v1.RecertificationCampaignInterface {
+	return &FakeRecertificationCampaigns{c, namespace}
+}
+
+func (c *FakeNetsysV1) SubNamespaces(namespace string) v1.SubNamespaceInterface {
+	return &FakeSubNamespaces{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeNetsysV1) RESTClient() rest.Interface {
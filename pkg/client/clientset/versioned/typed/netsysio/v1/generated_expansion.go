@@ -18,6 +18,18 @@ limitations under the License.
 
 package v1
 
+type AccessElevationExpansion interface{}
+
+type DispatchRobotExpansion interface{}
+
+type DispatchTenantExpansion interface{}
+
 type DispatchUserExpansion interface{}
 
+type NamespaceInvitationExpansion interface{}
+
 type OwnedNamespaceExpansion interface{}
+
+type RecertificationCampaignExpansion interface{}
+
+type SubNamespaceExpansion interface{}
@@ -27,8 +27,14 @@ import (
 
 type NetsysV1Interface interface {
 	RESTClient() rest.Interface
+	AccessElevationsGetter
+	DispatchRobotsGetter
+	DispatchTenantsGetter
 	DispatchUsersGetter
+	NamespaceInvitationsGetter
 	OwnedNamespacesGetter
+	RecertificationCampaignsGetter
+	SubNamespacesGetter
 }
 
 // NetsysV1Client is used to interact with features provided by the netsys.io group.
@@ -36,14 +42,38 @@ type NetsysV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *NetsysV1Client) AccessElevations(namespace string) AccessElevationInterface {
+	return newAccessElevations(c, namespace)
+}
+
+func (c *NetsysV1Client) DispatchRobots(namespace string) DispatchRobotInterface {
+	return newDispatchRobots(c, namespace)
+}
+
+func (c *NetsysV1Client) DispatchTenants(namespace string) DispatchTenantInterface {
+	return newDispatchTenants(c, namespace)
+}
+
 func (c *NetsysV1Client) DispatchUsers(namespace string) DispatchUserInterface {
 	return newDispatchUsers(c, namespace)
 }
 
+func (c *NetsysV1Client) NamespaceInvitations(namespace string) NamespaceInvitationInterface {
+	return newNamespaceInvitations(c, namespace)
+}
+
 func (c *NetsysV1Client) OwnedNamespaces(namespace string) OwnedNamespaceInterface {
 	return newOwnedNamespaces(c, namespace)
 }
 
+func (c *NetsysV1Client) RecertificationCampaigns(namespace string) RecertificationCampaignInterface {
+	return newRecertificationCampaigns(c, namespace)
+}
+
+func (c *NetsysV1Client) SubNamespaces(namespace string) SubNamespaceInterface {
+	return newSubNamespaces(c, namespace)
+}
+
 // NewForConfig creates a new NetsysV1Client for the given config.
 func NewForConfig(c *rest.Config) (*NetsysV1Client, error) {
 	config := *c
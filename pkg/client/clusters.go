@@ -0,0 +1,140 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// ClusterLabel names the member cluster whose kubeconfig a Secret of
+	// the dispatch namespace holds
+	ClusterLabel = "netsys.io/cluster"
+
+	// KubeconfigKey is the key of the kubeconfig in a member cluster Secret
+	KubeconfigKey = "kubeconfig"
+)
+
+// UnknownClusterError is returned for clusters no Secret is labeled with
+type UnknownClusterError struct {
+	Name string
+}
+
+func (e UnknownClusterError) Error() string {
+	return fmt.Sprintf("unknown cluster %q", e.Name)
+}
+
+// IsUnknownCluster returns true if err says a cluster is not registered
+func IsUnknownCluster(err error) bool {
+	_, ok := err.(UnknownClusterError)
+	return ok
+}
+
+// Clusters builds clients for the member clusters from their kubeconfig
+// Secrets. The Secrets are read on every lookup, so clusters can be added
+// and credentials rotated without a restart, but a client is only built
+// again when its Secret changed.
+type Clusters struct {
+	client    kubernetes.Interface
+	namespace string
+
+	lock   sync.Mutex
+	cached map[string]*member
+}
+
+// member is the client of a cluster built from a version of its Secret
+type member struct {
+	uid             types.UID
+	resourceVersion string
+	config          *rest.Config
+	client          kubernetes.Interface
+}
+
+// NewClusters returns the member clusters registered in namespace
+func NewClusters(client kubernetes.Interface, namespace string) *Clusters {
+	return &Clusters{client: client, namespace: namespace, cached: map[string]*member{}}
+}
+
+// Names returns the names of the registered member clusters
+func (c *Clusters) Names() ([]string, error) {
+	list, err := c.client.CoreV1().Secrets(c.namespace).List(meta_v1.ListOptions{LabelSelector: ClusterLabel})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range list.Items {
+		names = append(names, s.Labels[ClusterLabel])
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Client returns the client of the member cluster name
+func (c *Clusters) Client(name string) (kubernetes.Interface, error) {
+	m, err := c.member(name)
+	if err != nil {
+		return nil, err
+	}
+	return m.client, nil
+}
+
+// Config returns the client configuration of the member cluster name
+func (c *Clusters) Config(name string) (*rest.Config, error) {
+	m, err := c.member(name)
+	if err != nil {
+		return nil, err
+	}
+	return rest.CopyConfig(m.config), nil
+}
+
+func (c *Clusters) member(name string) (*member, error) {
+	selector := labels.SelectorFromSet(labels.Set{ClusterLabel: name}).String()
+	list, err := c.client.CoreV1().Secrets(c.namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	switch len(list.Items) {
+	case 0:
+		return nil, UnknownClusterError{Name: name}
+	case 1:
+	default:
+		return nil, fmt.Errorf("%d Secrets are labeled %s=%s", len(list.Items), ClusterLabel, name)
+	}
+	secret := &list.Items[0]
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if m, ok := c.cached[name]; ok && m.uid == secret.UID && m.resourceVersion == secret.ResourceVersion {
+		return m, nil
+	}
+	m, err := newMember(secret)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s: %v", name, err)
+	}
+	c.cached[name] = m
+	return m, nil
+}
+
+func newMember(secret *core_v1.Secret) (*member, error) {
+	kubeconfig, ok := secret.Data[KubeconfigKey]
+	if !ok {
+		return nil, fmt.Errorf("Secret %s has no %s", secret.Name, KubeconfigKey)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("Secret %s: %v", secret.Name, err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &member{uid: secret.UID, resourceVersion: secret.ResourceVersion, config: config, client: client}, nil
+}
@@ -53,10 +53,22 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=netsys.io, Version=v1
+	case v1.SchemeGroupVersion.WithResource("accesselevations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Netsys().V1().AccessElevations().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("dispatchrobots"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Netsys().V1().DispatchRobots().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("dispatchtenants"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Netsys().V1().DispatchTenants().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("dispatchusers"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Netsys().V1().DispatchUsers().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("namespaceinvitations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Netsys().V1().NamespaceInvitations().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("ownednamespaces"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Netsys().V1().OwnedNamespaces().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("recertificationcampaigns"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Netsys().V1().RecertificationCampaigns().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("subnamespaces"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Netsys().V1().SubNamespaces().Informer()}, nil
 
 	}
 
@@ -24,10 +24,22 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// AccessElevations returns a AccessElevationInformer.
+	AccessElevations() AccessElevationInformer
+	// DispatchRobots returns a DispatchRobotInformer.
+	DispatchRobots() DispatchRobotInformer
+	// DispatchTenants returns a DispatchTenantInformer.
+	DispatchTenants() DispatchTenantInformer
 	// DispatchUsers returns a DispatchUserInformer.
 	DispatchUsers() DispatchUserInformer
+	// NamespaceInvitations returns a NamespaceInvitationInformer.
+	NamespaceInvitations() NamespaceInvitationInformer
 	// OwnedNamespaces returns a OwnedNamespaceInformer.
 	OwnedNamespaces() OwnedNamespaceInformer
+	// RecertificationCampaigns returns a RecertificationCampaignInformer.
+	RecertificationCampaigns() RecertificationCampaignInformer
+	// SubNamespaces returns a SubNamespaceInformer.
+	SubNamespaces() SubNamespaceInformer
 }
 
 type version struct {
@@ -41,12 +53,42 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// AccessElevations returns a AccessElevationInformer.
+func (v *version) AccessElevations() AccessElevationInformer {
+	return &accessElevationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// DispatchRobots returns a DispatchRobotInformer.
+func (v *version) DispatchRobots() DispatchRobotInformer {
+	return &dispatchRobotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// DispatchTenants returns a DispatchTenantInformer.
+func (v *version) DispatchTenants() DispatchTenantInformer {
+	return &dispatchTenantInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // DispatchUsers returns a DispatchUserInformer.
 func (v *version) DispatchUsers() DispatchUserInformer {
 	return &dispatchUserInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// NamespaceInvitations returns a NamespaceInvitationInformer.
+func (v *version) NamespaceInvitations() NamespaceInvitationInformer {
+	return &namespaceInvitationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // OwnedNamespaces returns a OwnedNamespaceInformer.
 func (v *version) OwnedNamespaces() OwnedNamespaceInformer {
 	return &ownedNamespaceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// RecertificationCampaigns returns a RecertificationCampaignInformer.
+func (v *version) RecertificationCampaigns() RecertificationCampaignInformer {
+	return &recertificationCampaignInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// SubNamespaces returns a SubNamespaceInformer.
+func (v *version) SubNamespaces() SubNamespaceInformer {
+	return &subNamespaceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
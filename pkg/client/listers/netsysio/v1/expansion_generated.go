@@ -18,6 +18,30 @@ limitations under the License.
 
 package v1
 
+// AccessElevationListerExpansion allows custom methods to be added to
+// AccessElevationLister.
+type AccessElevationListerExpansion interface{}
+
+// AccessElevationNamespaceListerExpansion allows custom methods to be added to
+// AccessElevationNamespaceLister.
+type AccessElevationNamespaceListerExpansion interface{}
+
+// DispatchRobotListerExpansion allows custom methods to be added to
+// DispatchRobotLister.
+type DispatchRobotListerExpansion interface{}
+
+// DispatchRobotNamespaceListerExpansion allows custom methods to be added to
+// DispatchRobotNamespaceLister.
+type DispatchRobotNamespaceListerExpansion interface{}
+
+// DispatchTenantListerExpansion allows custom methods to be added to
+// DispatchTenantLister.
+type DispatchTenantListerExpansion interface{}
+
+// DispatchTenantNamespaceListerExpansion allows custom methods to be added to
+// DispatchTenantNamespaceLister.
+type DispatchTenantNamespaceListerExpansion interface{}
+
 // DispatchUserListerExpansion allows custom methods to be added to
 // DispatchUserLister.
 type DispatchUserListerExpansion interface{}
@@ -26,6 +50,14 @@ type DispatchUserListerExpansion interface{}
 // DispatchUserNamespaceLister.
 type DispatchUserNamespaceListerExpansion interface{}
 
+// NamespaceInvitationListerExpansion allows custom methods to be added to
+// NamespaceInvitationLister.
+type NamespaceInvitationListerExpansion interface{}
+
+// NamespaceInvitationNamespaceListerExpansion allows custom methods to be added to
+// NamespaceInvitationNamespaceLister.
+type NamespaceInvitationNamespaceListerExpansion interface{}
+
 // OwnedNamespaceListerExpansion allows custom methods to be added to
 // OwnedNamespaceLister.
 type OwnedNamespaceListerExpansion interface{}
@@ -33,3 +65,19 @@ type OwnedNamespaceListerExpansion interface{}
 // OwnedNamespaceNamespaceListerExpansion allows custom methods to be added to
 // OwnedNamespaceNamespaceLister.
 type OwnedNamespaceNamespaceListerExpansion interface{}
+
+// RecertificationCampaignListerExpansion allows custom methods to be added to
+// RecertificationCampaignLister.
+type RecertificationCampaignListerExpansion interface{}
+
+// RecertificationCampaignNamespaceListerExpansion allows custom methods to be added to
+// RecertificationCampaignNamespaceLister.
+type RecertificationCampaignNamespaceListerExpansion interface{}
+
+// SubNamespaceListerExpansion allows custom methods to be added to
+// SubNamespaceLister.
+type SubNamespaceListerExpansion interface{}
+
+// SubNamespaceNamespaceListerExpansion allows custom methods to be added to
+// SubNamespaceNamespaceLister.
+type SubNamespaceNamespaceListerExpansion interface{}
@@ -1,42 +1,398 @@
 package cmd
 
 import (
+	"github.com/hantaowang/dispatch/pkg/audit"
 	"github.com/hantaowang/dispatch/pkg/client"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/controller/availability"
 	"github.com/hantaowang/dispatch/pkg/controller/dispatchuser"
+	"github.com/hantaowang/dispatch/pkg/controller/elevation"
+	"github.com/hantaowang/dispatch/pkg/controller/hierarchy"
+	"github.com/hantaowang/dispatch/pkg/controller/hibernation"
+	invitationcontroller "github.com/hantaowang/dispatch/pkg/controller/invitation"
+	"github.com/hantaowang/dispatch/pkg/controller/lease"
 	"github.com/hantaowang/dispatch/pkg/controller/ownednamespace"
+	"github.com/hantaowang/dispatch/pkg/controller/recertification"
+	"github.com/hantaowang/dispatch/pkg/controller/robot"
+	"github.com/hantaowang/dispatch/pkg/controller/tenant"
+	usagecontroller "github.com/hantaowang/dispatch/pkg/controller/usage"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/leaderelection"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+	"github.com/hantaowang/dispatch/pkg/notify"
+	"github.com/hantaowang/dispatch/pkg/usage"
+	"github.com/hantaowang/dispatch/pkg/webhook"
 
 	"github.com/hantaowang/dispatch/pkg/client/informers/externalversions"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
 	"k8s.io/client-go/informers"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
 )
 
-func Start(stopCh chan struct{}) {
+// Start runs the dispatch controllers until stopCh is closed. With leader
+// election enabled the informers run right away so a standby is warm, but
+// the controllers only start once this replica holds the lease.
+func Start(cfg *config.Config, clientsets client.ClientSets, stopCh chan struct{}) error {
+	if cfg.Clusters.Enabled {
+		clientsets.Members = client.NewClusters(clientsets.OriginalClient, cfg.DispatchNamespace)
+	}
 
-	clientsets := client.GetKubernetesClient()
+	logging.Debug("Creating informer factories")
 
-	fmt.Println("Creating Informer Factories")
+	// everything dispatch watches lives in the dispatch namespace
+	netsysInformerFactory := externalversions.NewFilteredSharedInformerFactory(clientsets.NetsysClient,
+		cfg.ResyncPeriod.Duration, cfg.DispatchNamespace, nil)
+	originalInformerFactory := informers.NewFilteredSharedInformerFactory(clientsets.OriginalClient,
+		cfg.ResyncPeriod.Duration, cfg.DispatchNamespace, nil)
 
-	netsysInformerFactory := externalversions.NewSharedInformerFactory(clientsets.NetsysClient, 0)
-	originalInformerFactory := informers.NewSharedInformerFactory(clientsets.OriginalClient, 0)
-
-	fmt.Println("Creating Informers")
+	logging.Debug("Creating informers")
 	sharedDispatchUserInformer := netsysInformerFactory.Netsys().V1().DispatchUsers()
 	sharedOwnedNamespaceInformer := netsysInformerFactory.Netsys().V1().OwnedNamespaces()
 	sharedServiceAccountInformer := originalInformerFactory.Core().V1().ServiceAccounts()
 
-	fmt.Println("Starting Informers")
+	logging.Info("Starting informers", "namespace", cfg.DispatchNamespace)
 	go sharedServiceAccountInformer.Informer().Run(stopCh)
 	go sharedOwnedNamespaceInformer.Informer().Run(stopCh)
 	go sharedDispatchUserInformer.Informer().Run(stopCh)
 
-	fmt.Println("Creating Controllers")
-	duc := dispatchuser.NewDispatchUserController(sharedDispatchUserInformer, sharedOwnedNamespaceInformer,
-		sharedServiceAccountInformer, clientsets)
-	onc := ownednamespace.NewOwnedNamespaceController(sharedOwnedNamespaceInformer, clientsets)
+	// only watched when enabled, so clusters without the CRD keep working
+	var sharedCampaignInformer netsys_informer.RecertificationCampaignInformer
+	campaignsSynced := func() bool { return true }
+	if cfg.Recertification.Enabled() {
+		sharedCampaignInformer = netsysInformerFactory.Netsys().V1().RecertificationCampaigns()
+		campaignsSynced = sharedCampaignInformer.Informer().HasSynced
+		go sharedCampaignInformer.Informer().Run(stopCh)
+	}
+	var sharedElevationInformer netsys_informer.AccessElevationInformer
+	elevationsSynced := func() bool { return true }
+	if cfg.Elevation.Enabled() {
+		sharedElevationInformer = netsysInformerFactory.Netsys().V1().AccessElevations()
+		elevationsSynced = sharedElevationInformer.Informer().HasSynced
+		go sharedElevationInformer.Informer().Run(stopCh)
+	}
+	var sharedSubNamespaceInformer netsys_informer.SubNamespaceInformer
+	subNamespacesSynced := func() bool { return true }
+	if cfg.Hierarchy.Enabled() {
+		sharedSubNamespaceInformer = netsysInformerFactory.Netsys().V1().SubNamespaces()
+		subNamespacesSynced = sharedSubNamespaceInformer.Informer().HasSynced
+		go sharedSubNamespaceInformer.Informer().Run(stopCh)
+	}
+	var sharedTenantInformer netsys_informer.DispatchTenantInformer
+	tenantsSynced := func() bool { return true }
+	if cfg.Tenants.Enabled() {
+		sharedTenantInformer = netsysInformerFactory.Netsys().V1().DispatchTenants()
+		tenantsSynced = sharedTenantInformer.Informer().HasSynced
+		go sharedTenantInformer.Informer().Run(stopCh)
+	}
+	var sharedRobotInformer netsys_informer.DispatchRobotInformer
+	robotsSynced := func() bool { return true }
+	if cfg.Robots.Enabled() {
+		sharedRobotInformer = netsysInformerFactory.Netsys().V1().DispatchRobots()
+		robotsSynced = sharedRobotInformer.Informer().HasSynced
+		go sharedRobotInformer.Informer().Run(stopCh)
+	}
+	var sharedInvitationInformer netsys_informer.NamespaceInvitationInformer
+	invitationsSynced := func() bool { return true }
+	if cfg.Invitations.Enabled() {
+		sharedInvitationInformer = netsysInformerFactory.Netsys().V1().NamespaceInvitations()
+		invitationsSynced = sharedInvitationInformer.Informer().HasSynced
+		go sharedInvitationInformer.Informer().Run(stopCh)
+	}
+	// usage is counted in every namespace, so these are not limited to the
+	// dispatch namespace
+	var usageInformers usage.Informers
+	usageSynced := func() bool { return true }
+	if cfg.Usage.Enabled() {
+		usageInformers = usage.NewInformers(informers.NewSharedInformerFactory(clientsets.OriginalClient,
+			cfg.ResyncPeriod.Duration))
+		usageSynced = usageInformers.HasSynced
+		usageInformers.Run(stopCh)
+	}
+
+	metrics.RegisterStateMetrics(cfg.DispatchNamespace, sharedDispatchUserInformer.Lister(),
+		sharedOwnedNamespaceInformer.Lister(), sharedServiceAccountInformer.Lister())
+	if cfg.MetricsAddress != "" {
+		go serveMetrics(cfg.MetricsAddress)
+	}
+
+	checker := health.NewChecker()
+	checker.AddLivenessCheck("ping", func() error { return nil })
+	checker.AddReadinessCheck("informers", func() error {
+		if !(sharedDispatchUserInformer.Informer().HasSynced() &&
+			sharedOwnedNamespaceInformer.Informer().HasSynced() &&
+			sharedServiceAccountInformer.Informer().HasSynced() &&
+			campaignsSynced() &&
+			elevationsSynced() &&
+			subNamespacesSynced() &&
+			tenantsSynced() &&
+			robotsSynced() &&
+			invitationsSynced() &&
+			usageSynced()) {
+			return fmt.Errorf("informer caches not synced")
+		}
+		return nil
+	})
+	certificateFiles := cfg.CertificateFiles
+	if cfg.Webhook.Enabled() && !contains(certificateFiles, cfg.Webhook.CertFile) {
+		certificateFiles = append(certificateFiles, cfg.Webhook.CertFile)
+	}
+	for _, file := range certificateFiles {
+		checker.AddReadinessCheck("certificate:"+file, health.CertificateCheck(file))
+	}
+
+	// every replica answers admission reviews, not only the leader
+	if cfg.Webhook.Enabled() {
+		go webhook.NewServer(sharedTenantInformer, sharedDispatchUserInformer, sharedOwnedNamespaceInformer, cfg).Run()
+	}
+
+	sinks, err := auditSinks(cfg)
+	if err != nil {
+		return err
+	}
+	var auditKey []byte
+	if cfg.Audit.KeyFile != "" {
+		if auditKey, err = ioutil.ReadFile(cfg.Audit.KeyFile); err != nil {
+			return fmt.Errorf("reading audit key: %v", err)
+		}
+	}
+
+	// controllers register their event handlers when created, informers
+	// that already started replay their cache to late handlers
+	run := func(stop <-chan struct{}) {
+		logging.Debug("Creating controllers")
+		recorder := controller.NewEventRecorder(clientsets.OriginalClient)
+		auditor := audit.Discard
+		if len(sinks) > 0 {
+			// continue the chain of the previous leader
+			store := audit.ConfigMapChainStore{Client: clientsets.OriginalClient,
+				Namespace: cfg.DispatchNamespace, Name: cfg.Audit.ChainConfigMap}
+			var auditLog *audit.Log
+			wait.PollImmediateUntil(5*time.Second, func() (bool, error) {
+				var err error
+				if auditLog, err = audit.NewLog(store, auditKey, sinks...); err != nil {
+					logging.Error("Opening audit log failed", "error", err)
+					return false, nil
+				}
+				return true, nil
+			}, stop)
+			if auditLog == nil {
+				return
+			}
+			defer auditLog.Close()
+			auditor = auditLog
+		}
+		duc := dispatchuser.NewDispatchUserController(sharedDispatchUserInformer, sharedOwnedNamespaceInformer,
+			sharedServiceAccountInformer, clientsets, cfg, recorder, auditor, notifier(cfg))
+		onc := ownednamespace.NewOwnedNamespaceController(sharedOwnedNamespaceInformer, clientsets, cfg, recorder)
+		lc := lease.NewLeaseController(sharedOwnedNamespaceInformer, sharedDispatchUserInformer, clientsets, cfg,
+			recorder, auditor, notifier(cfg))
+		ac := availability.NewAvailabilityController(sharedOwnedNamespaceInformer, sharedDispatchUserInformer,
+			clientsets, cfg, recorder, auditor)
+
+		for _, t := range []*health.Tracker{duc.Tracker(), onc.Tracker(), lc.Tracker(), ac.Tracker()} {
+			checker.AddTracker(t)
+		}
+		checker.AddLivenessCheck("dispatchuser-workers", duc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+		checker.AddLivenessCheck("ownednamespace-workers", onc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+		checker.AddLivenessCheck("lease", lc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+		checker.AddLivenessCheck("availability", ac.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+
+		// run returns once every controller did, so a leader keeps its lease
+		// while any of them is still reconciling
+		var controllers sync.WaitGroup
+		startController := func(runController func(stop <-chan struct{})) {
+			controllers.Add(1)
+			go func() {
+				defer controllers.Done()
+				runController(stop)
+			}()
+		}
+
+		logging.Info("Running controllers")
+		startController(duc.Run)
+		startController(onc.Run)
+		startController(lc.Run)
+		startController(ac.Run)
+
+		if cfg.Recertification.Enabled() {
+			rc := recertification.NewRecertificationController(sharedDispatchUserInformer,
+				sharedOwnedNamespaceInformer, sharedCampaignInformer,
+				clientsets, cfg, recorder)
+			checker.AddTracker(rc.Tracker())
+			checker.AddLivenessCheck("recertification", rc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+			startController(rc.Run)
+		}
+
+		if cfg.Hibernation.Enabled() {
+			hc := hibernation.NewHibernationController(sharedOwnedNamespaceInformer, sharedDispatchUserInformer,
+				clientsets, cfg, recorder, notifier(cfg))
+			checker.AddTracker(hc.Tracker())
+			checker.AddLivenessCheck("hibernation", hc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+			startController(hc.Run)
+		}
+
+		if cfg.Elevation.Enabled() {
+			ec := elevation.NewElevationController(sharedElevationInformer, sharedDispatchUserInformer,
+				clientsets, cfg, recorder, auditor)
+			checker.AddTracker(ec.Tracker())
+			checker.AddLivenessCheck("elevation-workers", ec.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+			startController(ec.Run)
+		}
+
+		if cfg.Hierarchy.Enabled() {
+			hc := hierarchy.NewHierarchyController(sharedSubNamespaceInformer, sharedOwnedNamespaceInformer,
+				clientsets, cfg, recorder, auditor)
+			checker.AddTracker(hc.Tracker())
+			checker.AddLivenessCheck("hierarchy", hc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+			startController(hc.Run)
+		}
+
+		if cfg.Tenants.Enabled() {
+			tc := tenant.NewTenantController(sharedTenantInformer, sharedDispatchUserInformer,
+				sharedOwnedNamespaceInformer, clientsets, cfg, recorder)
+			checker.AddTracker(tc.Tracker())
+			checker.AddLivenessCheck("tenant", tc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+			startController(tc.Run)
+		}
+
+		if cfg.Robots.Enabled() {
+			rc := robot.NewRobotController(sharedRobotInformer, sharedDispatchUserInformer,
+				sharedOwnedNamespaceInformer, clientsets, cfg, recorder, auditor)
+			checker.AddTracker(rc.Tracker())
+			checker.AddLivenessCheck("robot", rc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+			startController(rc.Run)
+		}
 
-	fmt.Println("Running Controllers")
-	go duc.Run(1, stopCh)
-	go onc.Run(1, stopCh)
+		if cfg.Invitations.Enabled() {
+			ic := invitationcontroller.NewInvitationController(sharedInvitationInformer, sharedDispatchUserInformer,
+				clientsets, cfg, recorder)
+			checker.AddTracker(ic.Tracker())
+			checker.AddLivenessCheck("invitation", ic.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+			startController(ic.Run)
+		}
+
+		if cfg.Usage.Enabled() {
+			uc := usagecontroller.NewUsageController(sharedOwnedNamespaceInformer, sharedDispatchUserInformer,
+				usageInformers, clientsets, cfg)
+			checker.AddTracker(uc.Tracker())
+			checker.AddLivenessCheck("usage", uc.Tracker().StuckCheck(cfg.StuckWorkerTimeout.Duration))
+			startController(uc.Run)
+		}
+
+		<-stop
+		controllers.Wait()
+		logging.Info("Controllers stopped")
+	}
+
+	if !cfg.LeaderElection.Enabled {
+		checker.SetLeaderStatus(func() bool { return true })
+		if cfg.HealthAddress != "" {
+			go serveHealth(cfg.HealthAddress, checker, clientsets)
+		}
+		run(stopCh)
+		return nil
+	}
+
+	identity := cfg.LeaderElection.Identity
+	if identity == "" {
+		if identity, err = os.Hostname(); err != nil {
+			return err
+		}
+	}
+	le, err := leaderelection.NewLeaderElector(leaderelection.Config{
+		Client:        clientsets.OriginalClient,
+		Namespace:     cfg.DispatchNamespace,
+		Name:          cfg.LeaderElection.LockName,
+		Identity:      identity,
+		LeaseDuration: cfg.LeaderElection.LeaseDuration.Duration,
+		RenewDeadline: cfg.LeaderElection.RenewDeadline.Duration,
+		RetryPeriod:   cfg.LeaderElection.RetryPeriod.Duration,
+	})
+	if err != nil {
+		return err
+	}
+
+	// standbys are ready as long as they can reach the lease, so rollouts
+	// do not wait for a replica that is never going to lead
+	checker.AddReadinessCheck("leader-election", le.Check)
+	checker.SetLeaderStatus(le.IsLeader)
+	metrics.Register(metrics.NewGaugeFunc("dispatch_leader",
+		"1 if this replica holds the leader lease.", nil,
+		func() []metrics.Sample {
+			if le.IsLeader() {
+				return []metrics.Sample{{Value: 1}}
+			}
+			return []metrics.Sample{{Value: 0}}
+		}))
+	if cfg.HealthAddress != "" {
+		go serveHealth(cfg.HealthAddress, checker, clientsets)
+	}
+
+	return le.Run(stopCh, run)
+}
+
+func serveMetrics(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	logging.Info("Serving metrics", "address", address)
+	if err := http.ListenAndServe(address, mux); err != nil {
+		logging.Error("Serving metrics failed", "error", err)
+	}
+}
+
+// serveHealth serves the probes and the debug endpoint. /debug is only
+// answered for users allowed to get the non-resource URL /debug.
+func serveHealth(address string, checker *health.Checker, clientsets client.ClientSets) {
+	mux := http.NewServeMux()
+	checker.Install(mux)
+	mux.Handle("/debug", checker.DebugHandler(health.KubernetesAuthorizer{Client: clientsets.OriginalClient}))
+	logging.Info("Serving health checks", "address", address)
+	if err := http.ListenAndServe(address, mux); err != nil {
+		logging.Error("Serving health checks failed", "error", err)
+	}
+}
+
+// notifier sends notifications to the configured webhook, if any
+func notifier(cfg *config.Config) notify.Notifier {
+	if cfg.Expiry.NotifyWebhookURL == "" {
+		return notify.Discard
+	}
+	return notify.NewWebhookNotifier(cfg.Expiry.NotifyWebhookURL, cfg.Expiry.NotifyWebhookTimeout.Duration)
+}
+
+// auditSinks opens the configured audit sinks
+func auditSinks(cfg *config.Config) ([]audit.Sink, error) {
+	var sinks []audit.Sink
+	if cfg.Audit.File != "" {
+		f, err := audit.NewFileSink(cfg.Audit.File)
+		if err != nil {
+			return nil, fmt.Errorf("opening audit file: %v", err)
+		}
+		sinks = append(sinks, f)
+	}
+	if cfg.Audit.Stdout {
+		sinks = append(sinks, audit.NewWriterSink(os.Stdout))
+	}
+	if cfg.Audit.WebhookURL != "" {
+		sinks = append(sinks, audit.NewWebhookSink(cfg.Audit.WebhookURL, cfg.Audit.WebhookTimeout.Duration))
+	}
+	return sinks, nil
+}
 
-	<- stopCh
+// contains returns true if s is in list
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
 }
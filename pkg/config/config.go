@@ -0,0 +1,650 @@
+// Package config loads the dispatch controller configuration file
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ghodss/yaml"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/capacity"
+	"github.com/hantaowang/dispatch/pkg/chargeback"
+	"github.com/hantaowang/dispatch/pkg/hibernate"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/schedule"
+	"github.com/hantaowang/dispatch/pkg/usage"
+)
+
+// Config holds the settings of the dispatch controllers. It is read from a
+// YAML file and individual fields can be overridden by command line flags.
+type Config struct {
+	// namespace holding DispatchUsers, OwnedNamespaces and ServiceAccounts
+	DispatchNamespace string `json:"dispatchNamespace"`
+
+	Workers Workers `json:"workers"`
+
+	// role granted for namespaces listed without one
+	DefaultRole string `json:"defaultRole"`
+
+	// hard limits of the ResourceQuota created in every owned namespace,
+	// no quota is created if empty
+	DefaultQuota core_v1.ResourceList `json:"defaultQuota,omitempty"`
+
+	// namespaces that can never be granted to a user
+	ProtectedNamespaces []string `json:"protectedNamespaces"`
+
+	// how often informers resync their caches, 0 disables resyncs
+	ResyncPeriod meta_v1.Duration `json:"resyncPeriod"`
+
+	LeaderElection LeaderElection `json:"leaderElection"`
+
+	// address serving /metrics, empty disables it
+	MetricsAddress string `json:"metricsAddress"`
+
+	// address serving /healthz, /readyz and /debug, empty disables it
+	HealthAddress string `json:"healthAddress"`
+
+	// liveness fails when a worker spends longer than this on one event
+	StuckWorkerTimeout meta_v1.Duration `json:"stuckWorkerTimeout"`
+
+	// debug, info, warn or error
+	LogLevel string `json:"logLevel"`
+	// text or json
+	LogFormat string `json:"logFormat"`
+
+	Audit Audit `json:"audit"`
+
+	Recertification Recertification `json:"recertification"`
+
+	Expiry Expiry `json:"expiry"`
+
+	Elevation Elevation `json:"elevation"`
+
+	Leases Leases `json:"leases"`
+
+	Hibernation Hibernation `json:"hibernation"`
+
+	Usage Usage `json:"usage"`
+
+	Chargeback Chargeback `json:"chargeback"`
+
+	Capacity Capacity `json:"capacity"`
+
+	Clusters Clusters `json:"clusters"`
+
+	Hierarchy Hierarchy `json:"hierarchy"`
+
+	Tenants Tenants `json:"tenants"`
+
+	Webhook Webhook `json:"webhook"`
+
+	Robots Robots `json:"robots"`
+
+	Invitations Invitations `json:"invitations"`
+
+	// named availability windows grants refer to with scheduleClass
+	ScheduleClasses map[string]netsys_v1.AvailabilitySchedule `json:"scheduleClasses,omitempty"`
+
+	// PEM certificates dispatch serves, e.g. of an admission webhook.
+	// Readiness fails when one cannot be read or is not valid.
+	CertificateFiles []string `json:"certificateFiles,omitempty"`
+}
+
+// LeaderElection configures the lease that lets several replicas run with
+// only one of them reconciling
+type LeaderElection struct {
+	Enabled bool `json:"enabled"`
+	// name of the lock ConfigMap in the dispatch namespace
+	LockName string `json:"lockName"`
+	// identity of this replica, defaults to the hostname
+	Identity      string           `json:"identity,omitempty"`
+	LeaseDuration meta_v1.Duration `json:"leaseDuration"`
+	RenewDeadline meta_v1.Duration `json:"renewDeadline"`
+	RetryPeriod   meta_v1.Duration `json:"retryPeriod"`
+}
+
+// Audit selects where audit records of access changes are written. No
+// records are written unless a sink is configured.
+type Audit struct {
+	// append JSON lines to this file
+	File string `json:"file,omitempty"`
+	// write JSON lines to stdout
+	Stdout bool `json:"stdout,omitempty"`
+	// POST every record to this URL
+	WebhookURL     string           `json:"webhookURL,omitempty"`
+	WebhookTimeout meta_v1.Duration `json:"webhookTimeout"`
+	// ConfigMap in the dispatch namespace holding the head of the hash chain
+	ChainConfigMap string `json:"chainConfigMap"`
+	// file holding the key records are hashed with as HMACs, so the chain
+	// cannot be recomputed without it. Plain SHA-256 if empty.
+	KeyFile string `json:"keyFile,omitempty"`
+}
+
+// Enabled returns true if any sink is configured
+func (a Audit) Enabled() bool {
+	return a.File != "" || a.Stdout || a.WebhookURL != ""
+}
+
+// Recertification makes owners or approvers confirm every grant
+// periodically. Grants not confirmed within the grace period are revoked.
+type Recertification struct {
+	// days between the start of two campaigns, 0 disables recertification
+	IntervalDays int `json:"intervalDays"`
+	// days to confirm a grant before it is revoked
+	GracePeriodDays int `json:"gracePeriodDays"`
+	// users and groups that can confirm any grant
+	Approvers      []string `json:"approvers,omitempty"`
+	ApproverGroups []string `json:"approverGroups,omitempty"`
+}
+
+// Enabled returns true if campaigns are started
+func (r Recertification) Enabled() bool {
+	return r.IntervalDays > 0
+}
+
+// Expiry configures warnings before users and grants expire
+type Expiry struct {
+	// warn users this long before their access expires
+	WarnBefore meta_v1.Duration `json:"warnBefore"`
+	// POST a notification to this URL when access is about to expire and
+	// when it expired
+	NotifyWebhookURL     string           `json:"notifyWebhookURL,omitempty"`
+	NotifyWebhookTimeout meta_v1.Duration `json:"notifyWebhookTimeout"`
+}
+
+// Leases configures grants that expire unless their owner renews them
+type Leases struct {
+	// warn owners this long before their lease expires
+	WarnBefore meta_v1.Duration `json:"warnBefore"`
+	// reclaim the namespace this long after the lease expired
+	ReclaimAfter meta_v1.Duration `json:"reclaimAfter"`
+	// delete reclaimed namespaces that dispatch created and nobody else
+	// holds, otherwise only the grant is removed
+	DeleteNamespaces bool `json:"deleteNamespaces"`
+}
+
+// Hibernation finds granted namespaces nobody used for a while and scales
+// them down
+type Hibernation struct {
+	// a namespace without activity for this long is idle, 0 disables
+	// idle detection
+	IdleAfter meta_v1.Duration `json:"idleAfter"`
+	// what counts as activity: pods, deployments and events
+	Signals []string `json:"signals,omitempty"`
+	// hibernate idle namespaces, otherwise they are only reported
+	Hibernate bool `json:"hibernate"`
+}
+
+// Enabled returns true if idle namespaces are detected
+func (h Hibernation) Enabled() bool {
+	return h.IdleAfter.Duration > 0
+}
+
+// Usage reports what every DispatchUser consumes in its status
+type Usage struct {
+	// how often usage is added up, 0 disables usage reporting
+	Interval meta_v1.Duration `json:"interval"`
+	// how namespaces held by several users are charged: even or full
+	Split string `json:"split"`
+}
+
+// Enabled returns true if usage is reported
+func (u Usage) Enabled() bool {
+	return u.Interval.Duration > 0
+}
+
+// Chargeback samples usage into priced daily records for cost reports
+type Chargeback struct {
+	// sample usage, needs usage reporting enabled
+	Enabled bool `json:"enabled"`
+	// unit prices of requested resources
+	Prices chargeback.Prices `json:"prices"`
+	// days records are kept
+	RetentionDays int `json:"retentionDays"`
+}
+
+// Capacity refuses new namespaces whose quota would commit more than the
+// nodes of the cluster can hold
+type Capacity struct {
+	// check the capacity before creating namespaces, needs defaultQuota
+	Enabled bool `json:"enabled"`
+	// ratio the allocatable capacity of the nodes may be committed by,
+	// per quota resource like limits.cpu, 1 if not listed
+	Overcommit map[core_v1.ResourceName]float64 `json:"overcommit,omitempty"`
+	// reject grants that do not fit, or queue them until they do
+	Action string `json:"action"`
+}
+
+// Actions on grants that do not fit the capacity
+const (
+	CapacityReject = "reject"
+	CapacityQueue  = "queue"
+)
+
+// Clusters lets grants target member clusters. The kubeconfig of every
+// member is kept in a Secret of the dispatch namespace labeled
+// netsys.io/cluster with the name of the cluster.
+type Clusters struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Hierarchy lets owners create SubNamespaces, child namespaces that
+// inherit the owners, policy and a slice of the quota of their parent
+type Hierarchy struct {
+	// how often sub-namespaces are synced, 0 disables them
+	Interval meta_v1.Duration `json:"interval"`
+	// what happens to a child when its parent is deleted, unless its
+	// SubNamespace says otherwise: Delete or Orphan
+	Cascade string `json:"cascade"`
+}
+
+// Enabled returns true if sub-namespaces are synced
+func (h Hierarchy) Enabled() bool {
+	return h.Interval.Duration > 0
+}
+
+// Tenants lets DispatchTenants delegate the administration of users to
+// tenant admins, within a budget of users, namespaces and schedule classes
+type Tenants struct {
+	// how often tenants are synced, 0 disables them
+	Interval meta_v1.Duration `json:"interval"`
+}
+
+// Enabled returns true if tenants are synced and their budgets enforced
+func (t Tenants) Enabled() bool {
+	return t.Interval.Duration > 0
+}
+
+// Webhook serves the validating admission webhook that keeps tenant admins
+// to the DispatchUsers of their tenants
+type Webhook struct {
+	// address serving the webhook over TLS, empty disables it
+	Address string `json:"address,omitempty"`
+	// PEM certificate and key the webhook is served with
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// requests of members of these groups are never restricted
+	ExemptGroups []string `json:"exemptGroups,omitempty"`
+}
+
+// Enabled returns true if the webhook is served
+func (w Webhook) Enabled() bool {
+	return w.Address != ""
+}
+
+// Robots gives CI pipelines and other automation DispatchRobots, identities
+// with rotating tokens that hold namespaces of their owners
+type Robots struct {
+	// how often robots are synced and their tokens rotated, 0 disables them
+	Interval meta_v1.Duration `json:"interval"`
+	// how long a token is used before it is rotated, unless the robot sets
+	// its own
+	TokenTTL meta_v1.Duration `json:"tokenTTL"`
+	// how long the previous token stays valid after a rotation, so running
+	// jobs can finish
+	TokenOverlap meta_v1.Duration `json:"tokenOverlap"`
+}
+
+// Enabled returns true if robots are synced
+func (r Robots) Enabled() bool {
+	return r.Interval.Duration > 0
+}
+
+// Invitations withdraws accepted NamespaceInvitations whose owner no longer
+// holds the namespace at the role shared
+type Invitations struct {
+	// how often accepted invitations are checked, 0 disables it
+	Interval meta_v1.Duration `json:"interval"`
+}
+
+// Enabled returns true if accepted invitations are checked
+func (i Invitations) Enabled() bool {
+	return i.Interval.Duration > 0
+}
+
+// Elevation is the policy for AccessElevations, temporary roles users ask for
+type Elevation struct {
+	// longest duration an elevation can be held, 0 disables elevations
+	MaxDuration meta_v1.Duration `json:"maxDuration"`
+	// users and groups that can approve any elevation
+	Approvers      []string `json:"approvers,omitempty"`
+	ApproverGroups []string `json:"approverGroups,omitempty"`
+	// elevations matching one of these rules are approved without review
+	AutoApprove []ElevationRule `json:"autoApprove,omitempty"`
+}
+
+// ElevationRule matches elevations that are approved without review. Empty
+// lists match anything.
+type ElevationRule struct {
+	Roles      []string `json:"roles,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	// groups one of which the user must belong to
+	Groups []string `json:"groups,omitempty"`
+	// longest duration approved by the rule, maxDuration if not set
+	MaxDuration meta_v1.Duration `json:"maxDuration"`
+	// only match users that already hold a grant in the namespace
+	RequireGrant bool `json:"requireGrant,omitempty"`
+}
+
+// Enabled returns true if users can ask for elevations
+func (e Elevation) Enabled() bool {
+	return e.MaxDuration.Duration > 0
+}
+
+// AutoApproves returns true if an elevation to role in namespace for d,
+// asked for by a user in groups, is approved without review. granted
+// says whether the user already holds a grant in the namespace.
+func (e Elevation) AutoApproves(role, namespace string, d time.Duration, groups []string, granted bool) bool {
+	for _, r := range e.AutoApprove {
+		max := r.MaxDuration.Duration
+		if max == 0 {
+			max = e.MaxDuration.Duration
+		}
+		if d <= max && (granted || !r.RequireGrant) && matches(r.Roles, role) &&
+			matches(r.Namespaces, namespace) && matchesAny(r.Groups, groups) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if list is empty or contains s
+func matches(list []string, s string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny returns true if list is empty or contains one of values
+func matchesAny(list []string, values []string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if matches(list, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Workers sets the number of workers of each controller
+type Workers struct {
+	DispatchUser   int `json:"dispatchUser"`
+	OwnedNamespace int `json:"ownedNamespace"`
+}
+
+// Default returns the configuration used when no file is given
+func Default() *Config {
+	return &Config{
+		DispatchNamespace: "dispatch",
+		Workers: Workers{
+			DispatchUser:   1,
+			OwnedNamespace: 1,
+		},
+		DefaultRole:         netsys_v1.DefaultRole,
+		ProtectedNamespaces: []string{"kube-system", "kube-public", "default"},
+		ResyncPeriod:        meta_v1.Duration{Duration: 10 * time.Minute},
+		MetricsAddress:      ":9090",
+		HealthAddress:       ":8081",
+		StuckWorkerTimeout:  meta_v1.Duration{Duration: 5 * time.Minute},
+		LogLevel:            "info",
+		LogFormat:           "text",
+		Audit: Audit{
+			WebhookTimeout: meta_v1.Duration{Duration: 10 * time.Second},
+			ChainConfigMap: "dispatch-audit",
+		},
+		Recertification: Recertification{
+			GracePeriodDays: 14,
+		},
+		Expiry: Expiry{
+			WarnBefore:           meta_v1.Duration{Duration: 72 * time.Hour},
+			NotifyWebhookTimeout: meta_v1.Duration{Duration: 10 * time.Second},
+		},
+		Leases: Leases{
+			WarnBefore:   meta_v1.Duration{Duration: 72 * time.Hour},
+			ReclaimAfter: meta_v1.Duration{Duration: 7 * 24 * time.Hour},
+		},
+		Hibernation: Hibernation{
+			Signals: append([]string(nil), hibernate.Signals...),
+		},
+		Usage: Usage{
+			Split: usage.SplitEven,
+		},
+		Chargeback: Chargeback{
+			RetentionDays: 400,
+		},
+		Capacity: Capacity{
+			Action: CapacityReject,
+		},
+		Hierarchy: Hierarchy{
+			Cascade: netsys_v1.CascadeDelete,
+		},
+		Webhook: Webhook{
+			ExemptGroups: []string{"system:masters"},
+		},
+		Robots: Robots{
+			TokenTTL:     meta_v1.Duration{Duration: 24 * time.Hour},
+			TokenOverlap: meta_v1.Duration{Duration: time.Hour},
+		},
+		Invitations: Invitations{
+			Interval: meta_v1.Duration{Duration: time.Minute},
+		},
+		LeaderElection: LeaderElection{
+			LockName:      "dispatch-controller",
+			LeaseDuration: meta_v1.Duration{Duration: 15 * time.Second},
+			RenewDeadline: meta_v1.Duration{Duration: 10 * time.Second},
+			RetryPeriod:   meta_v1.Duration{Duration: 2 * time.Second},
+		},
+	}
+}
+
+// Load reads a configuration file. Fields missing from the file keep their
+// default values.
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse reads a configuration from YAML, e.g. the config.yaml of the
+// controllers' ConfigMap
+func Parse(b []byte) (*Config, error) {
+	cfg := Default()
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks the configuration for values the controllers cannot use
+func (c *Config) Validate() error {
+	if c.DispatchNamespace == "" {
+		return fmt.Errorf("dispatchNamespace must not be empty")
+	}
+	if c.Workers.DispatchUser < 1 || c.Workers.OwnedNamespace < 1 {
+		return fmt.Errorf("every controller needs at least one worker")
+	}
+	if !netsys_v1.ValidRole(c.DefaultRole) {
+		return fmt.Errorf("invalid defaultRole %q", c.DefaultRole)
+	}
+	if c.ResyncPeriod.Duration < 0 {
+		return fmt.Errorf("resyncPeriod must not be negative")
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("logFormat must be text or json")
+	}
+	if c.Audit.Enabled() && c.Audit.ChainConfigMap == "" {
+		return fmt.Errorf("audit.chainConfigMap must not be empty")
+	}
+	if r := c.Recertification; r.IntervalDays < 0 {
+		return fmt.Errorf("recertification.intervalDays must not be negative")
+	} else if r.Enabled() && (r.GracePeriodDays < 1 || r.GracePeriodDays > r.IntervalDays) {
+		return fmt.Errorf("recertification.gracePeriodDays must be between 1 and intervalDays")
+	}
+	if c.Expiry.WarnBefore.Duration < 0 {
+		return fmt.Errorf("expiry.warnBefore must not be negative")
+	}
+	if c.Elevation.MaxDuration.Duration < 0 {
+		return fmt.Errorf("elevation.maxDuration must not be negative")
+	}
+	for _, r := range c.Elevation.AutoApprove {
+		if r.MaxDuration.Duration < 0 || r.MaxDuration.Duration > c.Elevation.MaxDuration.Duration {
+			return fmt.Errorf("elevation.autoApprove maxDuration must be between 0 and elevation.maxDuration")
+		}
+		for _, role := range r.Roles {
+			if !netsys_v1.ValidRole(role) {
+				return fmt.Errorf("elevation.autoApprove role %q must be view, edit or admin", role)
+			}
+		}
+	}
+	if c.Leases.WarnBefore.Duration < 0 || c.Leases.ReclaimAfter.Duration < 0 {
+		return fmt.Errorf("leases.warnBefore and leases.reclaimAfter must not be negative")
+	}
+	if c.Hibernation.IdleAfter.Duration < 0 {
+		return fmt.Errorf("hibernation.idleAfter must not be negative")
+	}
+	for _, signal := range c.Hibernation.Signals {
+		if !hibernate.ValidSignal(signal) {
+			return fmt.Errorf("hibernation signal %q must be pods, deployments or events", signal)
+		}
+	}
+	if c.Hibernation.Enabled() && len(c.Hibernation.Signals) == 0 {
+		return fmt.Errorf("hibernation.signals must not be empty")
+	}
+	if c.Usage.Interval.Duration < 0 {
+		return fmt.Errorf("usage.interval must not be negative")
+	}
+	if !usage.ValidSplit(c.Usage.Split) {
+		return fmt.Errorf("usage.split %q must be even or full", c.Usage.Split)
+	}
+	if cb := c.Chargeback; cb.Enabled {
+		if !c.Usage.Enabled() {
+			return fmt.Errorf("chargeback needs usage.interval to be set")
+		}
+		if cb.Prices.CPUHour < 0 || cb.Prices.MemoryGBHour < 0 || cb.Prices.StorageGBHour < 0 {
+			return fmt.Errorf("chargeback.prices must not be negative")
+		}
+		if cb.RetentionDays < 1 {
+			return fmt.Errorf("chargeback.retentionDays must be at least 1")
+		}
+	}
+	if c.Capacity.Enabled && len(c.DefaultQuota) == 0 {
+		return fmt.Errorf("capacity needs defaultQuota to be set")
+	}
+	for name, ratio := range c.Capacity.Overcommit {
+		if !capacity.Comparable(name) {
+			return fmt.Errorf("capacity.overcommit: %s cannot be compared to node capacity", name)
+		}
+		if ratio <= 0 {
+			return fmt.Errorf("capacity.overcommit of %s must be positive", name)
+		}
+	}
+	if a := c.Capacity.Action; a != CapacityReject && a != CapacityQueue {
+		return fmt.Errorf("capacity.action %q must be reject or queue", a)
+	}
+	if c.Hierarchy.Interval.Duration < 0 {
+		return fmt.Errorf("hierarchy.interval must not be negative")
+	}
+	if !netsys_v1.ValidCascade(c.Hierarchy.Cascade) {
+		return fmt.Errorf("hierarchy.cascade %q must be Delete or Orphan", c.Hierarchy.Cascade)
+	}
+	if c.Tenants.Interval.Duration < 0 {
+		return fmt.Errorf("tenants.interval must not be negative")
+	}
+	if w := c.Webhook; w.Enabled() {
+		if w.CertFile == "" || w.KeyFile == "" {
+			return fmt.Errorf("webhook needs certFile and keyFile")
+		}
+		if !c.Tenants.Enabled() {
+			return fmt.Errorf("webhook needs tenants.interval to be set")
+		}
+	}
+	if c.Invitations.Interval.Duration < 0 {
+		return fmt.Errorf("invitations.interval must not be negative")
+	}
+	if r := c.Robots; r.Interval.Duration < 0 || r.TokenTTL.Duration <= 0 || r.TokenOverlap.Duration < 0 {
+		return fmt.Errorf("robots.interval and robots.tokenOverlap must not be negative, robots.tokenTTL must be positive")
+	}
+	if r := c.Robots; r.TokenOverlap.Duration >= r.TokenTTL.Duration {
+		return fmt.Errorf("robots.tokenOverlap must be shorter than robots.tokenTTL")
+	}
+	for name, s := range c.ScheduleClasses {
+		if _, err := schedule.NewWindow(s); err != nil {
+			return fmt.Errorf("scheduleClasses.%s: %v", name, err)
+		}
+	}
+	if c.StuckWorkerTimeout.Duration <= 0 {
+		return fmt.Errorf("stuckWorkerTimeout must be positive")
+	}
+	if le := c.LeaderElection; le.Enabled {
+		if le.LockName == "" {
+			return fmt.Errorf("leaderElection.lockName must not be empty")
+		}
+		if le.LeaseDuration.Duration <= le.RenewDeadline.Duration || le.RenewDeadline.Duration <= le.RetryPeriod.Duration {
+			return fmt.Errorf("leaderElection needs leaseDuration > renewDeadline > retryPeriod")
+		}
+	}
+	return nil
+}
+
+// IsProtected returns true if namespace must never be granted. The
+// dispatch namespace itself is always protected, since access to it would
+// expose every user's ServiceAccount token.
+func (c *Config) IsProtected(namespace string) bool {
+	if namespace == c.DispatchNamespace {
+		return true
+	}
+	for _, n := range c.ProtectedNamespaces {
+		if n == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Schedule returns the availability window of g, resolving its schedule
+// class, or nil if it has none
+func (c *Config) Schedule(g netsys_v1.NamespaceGrant) (*netsys_v1.AvailabilitySchedule, error) {
+	if g.Schedule != nil {
+		if _, err := schedule.NewWindow(*g.Schedule); err != nil {
+			return nil, err
+		}
+		return g.Schedule, nil
+	}
+	if g.ScheduleClass == "" {
+		return nil, nil
+	}
+	s, ok := c.ScheduleClasses[g.ScheduleClass]
+	if !ok {
+		return nil, fmt.Errorf("unknown schedule class %q", g.ScheduleClass)
+	}
+	return &s, nil
+}
+
+// RoleOrDefault returns role, or the configured default role if role is empty
+func (c *Config) RoleOrDefault(role string) string {
+	if role == "" {
+		return c.DefaultRole
+	}
+	return role
+}
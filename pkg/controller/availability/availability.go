@@ -0,0 +1,281 @@
+// Package availability runs scheduled namespaces only within their
+// availability windows. Outside of its window a namespace is hibernated,
+// and owners whose schedule revokes write access hold view.
+package availability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/audit"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/hibernate"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+	"github.com/hantaowang/dispatch/pkg/schedule"
+)
+
+const (
+	// label of this controller's metrics
+	controllerName = "availability"
+
+	// how often windows are checked, cron expressions have minutes
+	syncPeriod = time.Minute
+)
+
+// AvailabilityController opens and closes the availability windows of
+// scheduled OwnedNamespaces
+type AvailabilityController struct {
+	onLister netsys_lister.OwnedNamespaceLister
+	duLister netsys_lister.DispatchUserLister
+
+	onListerSynced cache.InformerSynced
+	duListerSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on DispatchUsers
+	recorder record.EventRecorder
+
+	// records roles lowered and restored by windows
+	auditor audit.Auditor
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+}
+
+// NewAvailabilityController creates a new AvailabilityController
+func NewAvailabilityController(
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+	auditor audit.Auditor,
+) *AvailabilityController {
+	return &AvailabilityController{
+		onLister:       onInformer.Lister(),
+		duLister:       duInformer.Lister(),
+		onListerSynced: onInformer.Informer().HasSynced,
+		duListerSynced: duInformer.Informer().HasSynced,
+		clientsets:     clientSets,
+		config:         cfg,
+		recorder:       recorder,
+		auditor:        auditor,
+		tracker:        health.NewTracker(controllerName),
+	}
+}
+
+// Run syncs the windows every minute until stopCh is closed
+func (ac *AvailabilityController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, ac.onListerSynced, ac.duListerSynced) {
+		return
+	}
+	wait.Until(ac.sync, syncPeriod, stopCh)
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (ac *AvailabilityController) Tracker() *health.Tracker {
+	return ac.tracker
+}
+
+func (ac *AvailabilityController) sync() {
+	start := time.Now()
+	id := ac.tracker.Started("sync", "windows")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := ac.syncWindows(time.Now(), logger)
+
+	ac.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// syncWindows updates the window of every scheduled OwnedNamespace, then
+// hibernates the namespaces all of whose owners are outside their window
+// and wakes those hibernated by a window that opened again
+func (ac *AvailabilityController) syncWindows(now time.Time, logger *logging.Logger) error {
+	owned, err := ac.onLister.OwnedNamespaces(ac.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var errs []string
+	// namespaces with a scheduled owner, true if one needs it awake
+	awake := map[string]bool{}
+	scheduled := map[string]bool{}
+	for _, on := range owned {
+		if on.Spec.Cluster != "" {
+			// workloads are only scaled in the cluster dispatch runs in
+			continue
+		}
+		if on.Spec.Schedule == nil {
+			awake[on.Spec.Namespace] = true
+			continue
+		}
+		scheduled[on.Spec.Namespace] = true
+		l := logger.With("user", on.Spec.OwnerID, "namespace", on.Spec.Namespace)
+		open, err := ac.syncWindow(on, now, l)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", on.Name, err))
+			// rather run outside the window than stop within it
+			open = true
+		}
+		awake[on.Spec.Namespace] = awake[on.Spec.Namespace] || open
+	}
+
+	namespaces := make([]string, 0, len(scheduled))
+	for ns := range scheduled {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		if err := ac.syncNamespace(ns, awake[ns], now, logger.With("namespace", ns)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", ns, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// syncWindow writes whether the window of on is open at now, or kept
+// awake, and when that changes next. It returns true if on is awake.
+func (ac *AvailabilityController) syncWindow(on *netsys_v1.OwnedNamespace, now time.Time, logger *logging.Logger) (bool, error) {
+	w, err := schedule.NewWindow(*on.Spec.Schedule)
+	if err != nil {
+		return true, err
+	}
+	open, next := w.At(now)
+
+	status := &netsys_v1.ScheduleStatus{}
+	if on.Status.Schedule != nil {
+		status = on.Status.Schedule.DeepCopy()
+	}
+	if k := status.KeepAwakeUntil; k != nil && !now.Before(k.Time) {
+		status.KeepAwakeUntil = nil
+	}
+	status.Awake = open || status.KeepAwakeUntil != nil
+	if !open && status.KeepAwakeUntil != nil {
+		next = status.KeepAwakeUntil.Time
+	}
+	t := meta_v1.NewTime(next.Truncate(time.Second))
+	status.NextTransition = &t
+
+	updated := on.DeepCopy()
+	updated.Status.Schedule = status
+	if equality.Semantic.DeepEqual(on.Status, updated.Status) {
+		return status.Awake, nil
+	}
+	if _, err := ac.clientsets.NetsysClient.NetsysV1().OwnedNamespaces(on.Namespace).Update(updated); err != nil {
+		return status.Awake, err
+	}
+
+	wasAwake := on.Status.Schedule == nil || on.Status.Schedule.Awake
+	if status.Awake == wasAwake {
+		return status.Awake, nil
+	}
+	nextTransition := next.In(w.Location()).Format(time.RFC3339)
+	if status.Awake {
+		logger.Info("Availability window opened", "until", nextTransition)
+		ac.event(on, core_v1.EventTypeNormal, controller.WindowOpened,
+			"Namespace %s is available until %s", on.Spec.Namespace, nextTransition)
+	} else {
+		logger.Info("Availability window closed", "next", nextTransition)
+		ac.event(on, core_v1.EventTypeNormal, controller.WindowClosed,
+			"Namespace %s is unavailable until %s", on.Spec.Namespace, nextTransition)
+	}
+	if before, after := on.EffectiveRole(), updated.EffectiveRole(); before != after {
+		// the OwnedNamespace controller replaces the RoleBinding
+		reason := "availability window closed"
+		if status.Awake {
+			reason = "availability window opened"
+		}
+		ac.auditor.Record(audit.Record{
+			Action:       audit.ActionRoleChange,
+			User:         on.Spec.OwnerID,
+			Namespace:    on.Spec.Namespace,
+			Role:         after,
+			PreviousRole: before,
+			Reason:       reason,
+			Source:       "OwnedNamespace/" + on.Namespace + "/" + on.Name,
+		})
+	}
+	return status.Awake, nil
+}
+
+// syncNamespace hibernates namespace when nobody needs it awake, and wakes
+// it when somebody does and it was hibernated by a schedule. Namespaces
+// hibernated by hand or for being idle are left alone.
+func (ac *AvailabilityController) syncNamespace(namespace string, awake bool, now time.Time, logger *logging.Logger) error {
+	if ac.config.IsProtected(namespace) {
+		return nil
+	}
+	ns, err := ac.clientsets.OriginalClient.CoreV1().Namespaces().Get(namespace, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	hibernated := hibernate.Hibernated(ns)
+	switch {
+	case !awake && !hibernated:
+		r, err := hibernate.Hibernate(ac.clientsets.OriginalClient, namespace, hibernate.BySchedule, now)
+		if err != nil {
+			return err
+		}
+		logger.Info("Hibernated namespace outside its window", "deployments", r.Deployments,
+			"statefulsets", r.StatefulSets, "cronjobs", r.CronJobs)
+	case awake && hibernated && hibernate.HibernatedBy(ns) == hibernate.BySchedule:
+		r, err := hibernate.Wake(ac.clientsets.OriginalClient, namespace, now)
+		if err != nil {
+			return err
+		}
+		logger.Info("Woke namespace within its window", "deployments", r.Deployments,
+			"statefulsets", r.StatefulSets, "cronjobs", r.CronJobs)
+	}
+	return nil
+}
+
+// event records an Event on the owner of on
+func (ac *AvailabilityController) event(on *netsys_v1.OwnedNamespace, eventType, reason, format string, args ...interface{}) {
+	users, err := ac.duLister.DispatchUsers(ac.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return
+	}
+	for _, u := range users {
+		if u.Spec.UserID == on.Spec.OwnerID {
+			ac.recorder.Eventf(u, eventType, reason, format, args...)
+			return
+		}
+	}
+}
@@ -1,7 +1,19 @@
 package controller
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+// QuotaName is the name of the ResourceQuota dispatch creates in owned namespaces
+const QuotaName = "dispatch-quota"
 
 func NameFunc(owner, namespace string) string {
 	return fmt.Sprintf("%s-%s", owner, namespace)
 }
+
+// OwnedNamespaceName is the name of the OwnedNamespace of the grant of
+// owner with key, cluster.namespace in member clusters
+func OwnedNamespaceName(owner, key string) string {
+	return NameFunc(owner, strings.Replace(key, "/", ".", 1))
+}
@@ -0,0 +1,79 @@
+package dispatchuser
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// adoptServiceAccounts labels the ServiceAccounts dispatch created before
+// it labeled them with the user they belong to. Only unlabeled
+// ServiceAccounts named after the userID of an existing DispatchUser are
+// adopted, in the dispatch namespace and in the member clusters the user
+// has credentials in. Once labeled there is nothing left to adopt, so this
+// is a no-op on every start after the first.
+func (duc *DispatchUserController) adoptServiceAccounts() error {
+	users, err := duc.duLister.DispatchUsers(duc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, u := range users {
+		id := u.Spec.UserID
+		if id == "" || netsys_v1.ReservedUserID(id) {
+			continue
+		}
+		if err := adoptServiceAccount(duc.clientsets.OriginalClient, duc.config.DispatchNamespace, id, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("ServiceAccount %s: %v", id, err))
+		}
+		for _, cluster := range u.Status.Clusters {
+			c, err := duc.clientsets.ForCluster(cluster)
+			if client.IsUnknownCluster(err) {
+				continue
+			}
+			if err == nil {
+				err = adoptServiceAccount(c, duc.config.DispatchNamespace, id, cluster)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("ServiceAccount %s in cluster %s: %v", id, cluster, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// adoptServiceAccount labels the ServiceAccount name in namespace with the
+// user of the same name unless it carries a user label already
+func adoptServiceAccount(c kubernetes.Interface, namespace, name, cluster string) error {
+	sa, err := c.CoreV1().ServiceAccounts(namespace).Get(name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := sa.Labels[netsys_v1.UserLabel]; ok {
+		return nil
+	}
+	sa = sa.DeepCopy()
+	if sa.Labels == nil {
+		sa.Labels = map[string]string{}
+	}
+	sa.Labels[netsys_v1.UserLabel] = name
+	if _, err := c.CoreV1().ServiceAccounts(namespace).Update(sa); err != nil {
+		return err
+	}
+	logging.Info("Adopted ServiceAccount", "controller", controllerName, "serviceaccount", name, "cluster", cluster)
+	return nil
+}
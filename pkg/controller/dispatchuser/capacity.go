@@ -0,0 +1,123 @@
+package dispatchuser
+
+import (
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/capacity"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// admit returns true if the grant of namespace to u can be provisioned. A
+// namespace that already has the dispatch quota takes no more capacity,
+// others are refused or queued when their quota would overcommit the
+// cluster. Refusals are recorded in status. Namespaces of member clusters
+// are always admitted.
+func (duc *DispatchUserController) admit(u *netsys_v1.DispatchUser, status *netsys_v1.DispatchUserStatus,
+	namespace string, now time.Time, logger *logging.Logger) (bool, error) {
+	if !duc.config.Capacity.Enabled {
+		return true, nil
+	}
+	if cluster, _ := netsys_v1.ParseGrantKey(namespace); cluster != "" {
+		return true, nil
+	}
+	previous := refusedGrant(status, namespace)
+	if previous != nil && !previous.Queued {
+		// retried once the grant is removed and granted again
+		return false, nil
+	}
+
+	// workers admitting at the same time would both see the same capacity
+	duc.capacityLock.Lock()
+	defer duc.capacityLock.Unlock()
+
+	report, err := capacity.Load(duc.clientsets.OriginalClient, controller.QuotaName, duc.config.Capacity.Overcommit)
+	if err != nil {
+		return false, err
+	}
+	if report.HasQuota(namespace) {
+		forgetRefusedGrant(status, namespace)
+		return true, nil
+	}
+	if err := duc.commitPending(report, namespace); err != nil {
+		return false, err
+	}
+	exceeded := report.Exceeded(duc.config.DefaultQuota)
+	if len(exceeded) == 0 {
+		forgetRefusedGrant(status, namespace)
+		return true, nil
+	}
+	if previous != nil {
+		// still queued
+		return false, nil
+	}
+
+	reason := strings.Join(exceeded, ", ")
+	queued := duc.config.Capacity.Action == config.CapacityQueue
+	status.RefusedGrants = append(status.RefusedGrants, netsys_v1.RefusedGrant{
+		Namespace: namespace,
+		Reason:    reason,
+		RefusedAt: meta_v1.NewTime(now),
+		Queued:    queued,
+	})
+	if queued {
+		logger.Warn("Queued grant until the cluster has capacity", "namespace", namespace, "reason", reason)
+		duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantQueued,
+			"Namespace %s is queued until the cluster has capacity: %s", namespace, reason)
+	} else {
+		logger.Warn("Refusing grant that would overcommit the cluster", "namespace", namespace, "reason", reason)
+		duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantRefused,
+			"Namespace %s would overcommit the cluster and was refused: %s", namespace, reason)
+	}
+	return false, nil
+}
+
+// commitPending commits the default quota for every granted namespace
+// other than namespace whose quota the OwnedNamespace controller has not
+// created yet
+func (duc *DispatchUserController) commitPending(report *capacity.Report, namespace string) error {
+	owned, err := duc.onLister.OwnedNamespaces(duc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{namespace: true}
+	for _, on := range owned {
+		ns := on.Spec.Namespace
+		if on.Spec.Cluster != "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		if !report.HasQuota(ns) {
+			report.Commit(duc.config.DefaultQuota)
+		}
+	}
+	return nil
+}
+
+// refusedGrant returns the refusal of namespace in status, nil if none
+func refusedGrant(status *netsys_v1.DispatchUserStatus, namespace string) *netsys_v1.RefusedGrant {
+	for i := range status.RefusedGrants {
+		if status.RefusedGrants[i].Namespace == namespace {
+			return &status.RefusedGrants[i]
+		}
+	}
+	return nil
+}
+
+// forgetRefusedGrant removes the refusal of namespace from status
+func forgetRefusedGrant(status *netsys_v1.DispatchUserStatus, namespace string) {
+	var refused []netsys_v1.RefusedGrant
+	for _, r := range status.RefusedGrants {
+		if r.Namespace != namespace {
+			refused = append(refused, r)
+		}
+	}
+	status.RefusedGrants = refused
+}
@@ -0,0 +1,148 @@
+package dispatchuser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/audit"
+	"github.com/hantaowang/dispatch/pkg/client"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// syncMemberServiceAccounts creates the ServiceAccount of u in every member
+// cluster u is granted a namespace in and deletes it from the clusters u no
+// longer is. The clusters u has a ServiceAccount in are kept in status,
+// grants in clusters that are not registered are left out.
+func (duc *DispatchUserController) syncMemberServiceAccounts(u *netsys_v1.DispatchUser, status *netsys_v1.DispatchUserStatus, logger *logging.Logger) error {
+	wanted := map[string]bool{}
+	for _, g := range u.Spec.EffectiveGrants() {
+		if g.Cluster != "" {
+			wanted[g.Cluster] = true
+		}
+	}
+
+	var clusters, errs []string
+	for cluster := range wanted {
+		err := duc.ensureMemberServiceAccount(u, cluster, logger)
+		if client.IsUnknownCluster(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cluster %s: %v", cluster, err))
+		}
+		clusters = append(clusters, cluster)
+	}
+	for _, cluster := range status.Clusters {
+		if wanted[cluster] {
+			continue
+		}
+		err := duc.deleteMemberServiceAccount(u, cluster, u.Reason(), logger)
+		if client.IsUnknownCluster(err) {
+			logger.Warn("Cannot delete ServiceAccount of removed cluster", "cluster", cluster)
+			continue
+		}
+		if isNotOwned(err) {
+			// not dispatch's to delete, nor to keep track of
+			duc.warnNotRevoked(u, err, logger)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cluster %s: %v", cluster, err))
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Strings(clusters)
+	status.Clusters = clusters
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ensureMemberServiceAccount creates the ServiceAccount of u in the
+// dispatch namespace of cluster, and the namespace if needed
+func (duc *DispatchUserController) ensureMemberServiceAccount(u *netsys_v1.DispatchUser, cluster string, logger *logging.Logger) error {
+	c, err := duc.clientsets.ForCluster(cluster)
+	if err != nil {
+		return err
+	}
+	namespace := duc.config.DispatchNamespace
+	_, err = c.CoreV1().Namespaces().Get(namespace, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = c.CoreV1().Namespaces().Create(&core_v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: namespace}})
+		if errors.IsAlreadyExists(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	sa := &core_v1.ServiceAccount{ObjectMeta: meta_v1.ObjectMeta{
+		Name:      u.Spec.UserID,
+		Namespace: namespace,
+		Labels:    map[string]string{netsys_v1.UserLabel: u.Spec.UserID},
+	}}
+	_, err = c.CoreV1().ServiceAccounts(namespace).Create(sa)
+	if errors.IsAlreadyExists(err) {
+		// never adopt a ServiceAccount dispatch did not create
+		sa, err = c.CoreV1().ServiceAccounts(namespace).Get(u.Spec.UserID, meta_v1.GetOptions{})
+		if err == nil && sa.Labels[netsys_v1.UserLabel] != u.Spec.UserID {
+			err = fmt.Errorf("ServiceAccount %s/%s in cluster %s was not created by dispatch", namespace, u.Spec.UserID, cluster)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("Created ServiceAccount", "serviceaccount", u.Spec.UserID, "cluster", cluster)
+	duc.audit(u, audit.ActionCredentialIssued, netsys_v1.GrantKey(cluster, ""), "", "")
+	duc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.ServiceAccountCreated,
+		"Created ServiceAccount %s/%s in cluster %s", namespace, u.Spec.UserID, cluster)
+	return nil
+}
+
+// deleteMemberServiceAccount deletes the ServiceAccount of u in cluster,
+// returning a notOwnedError if dispatch did not create it
+func (duc *DispatchUserController) deleteMemberServiceAccount(u *netsys_v1.DispatchUser, cluster, reason string, logger *logging.Logger) error {
+	c, err := duc.clientsets.ForCluster(cluster)
+	if err != nil {
+		return err
+	}
+	namespace := duc.config.DispatchNamespace
+	sa, err := c.CoreV1().ServiceAccounts(namespace).Get(u.Spec.UserID, meta_v1.GetOptions{})
+	if err == nil && sa.Labels[netsys_v1.UserLabel] != u.Spec.UserID {
+		return notOwnedError{namespace: namespace, name: u.Spec.UserID, cluster: cluster}
+	}
+	if err == nil {
+		err = c.CoreV1().ServiceAccounts(namespace).Delete(u.Spec.UserID, nil)
+	}
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("Deleted ServiceAccount", "serviceaccount", u.Spec.UserID, "cluster", cluster)
+	duc.auditReason(u, reason, audit.ActionCredentialRevoked, netsys_v1.GrantKey(cluster, ""), "", "")
+	duc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.ServiceAccountDeleted,
+		"Deleted ServiceAccount %s/%s in cluster %s", namespace, u.Spec.UserID, cluster)
+	return nil
+}
+
+// contains returns true if s is in list
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,11 @@ package dispatchuser
 import (
 	"time"
 	"fmt"
+	"hash/fnv"
+	"sync"
 
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -11,14 +15,29 @@ import (
 	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
 	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
 	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	informer_v1 "k8s.io/client-go/informers/core/v1"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 
+	"github.com/hantaowang/dispatch/pkg/audit"
 	"github.com/hantaowang/dispatch/pkg/client"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+	"github.com/hantaowang/dispatch/pkg/notify"
 )
 
 const (
-	dispatchNamespace = "dispatch"
+	// label of this controller's metrics
+	controllerName = "dispatchuser"
+
+	// failed events are retried this often, backing off linearly
+	maxRetries = 5
+	retryBackoff = 2 * time.Second
 )
 
 // NamespaceController is responsible for performing actions dependent upon a namespace phase
@@ -44,14 +63,45 @@ type DispatchUserController struct {
 	// clients to modify resources
 	clientsets	client.ClientSets
 
-	// Buffered channel of events to be done
-	workqueue 	chan DispatchUserEvent
+	// controller configuration
+	config		*config.Config
+
+	// records Events on DispatchUsers
+	recorder	record.EventRecorder
+
+	// records access changes in the audit log
+	auditor		audit.Auditor
+
+	// tells users their access is about to expire
+	notifier	notify.Notifier
+
+	// admits one new namespace at a time against the cluster capacity
+	capacityLock	sync.Mutex
+
+	// syncs the grants of one tenant user at a time against the budget of
+	// their tenant
+	tenantLock	sync.Mutex
+
+	// sync users again at their next expiry, by DispatchUser key
+	timersLock	sync.Mutex
+	timers		map[string]*time.Timer
+
+	// Buffered channels of events to be done, one per worker. The events of
+	// a DispatchUser always go to the same one.
+	workqueues 	[]chan DispatchUserEvent
+
+	// follows queued and in-flight events for health checks
+	tracker		*health.Tracker
 }
 
 type DispatchUserEvent struct {
 	action		string
 	old			*netsys_v1.DispatchUser
 	new			*netsys_v1.DispatchUser
+
+	// when the change was seen and how often processing it failed
+	queued		time.Time
+	retries		int
 }
 
 // NewNamespaceController creates a new NamespaceController
@@ -60,42 +110,59 @@ func NewDispatchUserController(
 	onInformer  netsys_informer.OwnedNamespaceInformer,
 	saInformer	informer_v1.ServiceAccountInformer,
 	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+	auditor audit.Auditor,
+	notifier notify.Notifier,
 	) *DispatchUserController {
 
 	duc := &DispatchUserController{
 		GroupVersionKind: netsys_v1.SchemeGroupVersion.WithKind("DispatchUser"),
 		clientsets: clientSets,
-		workqueue: make(chan DispatchUserEvent, 100),
+		config: cfg,
+		recorder: recorder,
+		auditor: auditor,
+		notifier: notifier,
+		timers: map[string]*time.Timer{},
+		workqueues: make([]chan DispatchUserEvent, cfg.Workers.DispatchUser),
+		tracker: health.NewTracker(controllerName),
 	}
+	for i := range duc.workqueues {
+		duc.workqueues[i] = make(chan DispatchUserEvent, 100)
+	}
+	dispatchNamespace := cfg.DispatchNamespace
 
 	duInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) {
 			if obj.(*netsys_v1.DispatchUser).Namespace != dispatchNamespace {
 				return
 			}
-			duc.workqueue <- DispatchUserEvent{
+			duc.enqueue(DispatchUserEvent{
 				action: "add",
 				new: obj.(*netsys_v1.DispatchUser),
-			}
+				queued: time.Now(),
+			})
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			if newObj.(*netsys_v1.DispatchUser).Namespace != dispatchNamespace {
 				return
 			}
-			duc.workqueue <- DispatchUserEvent{
+			duc.enqueue(DispatchUserEvent{
 				action: "update",
 				old: oldObj.(*netsys_v1.DispatchUser),
 				new: newObj.(*netsys_v1.DispatchUser),
-			}
+				queued: time.Now(),
+			})
 		},
 		DeleteFunc:    func(obj interface{}) {
 			if obj.(*netsys_v1.DispatchUser).Namespace != dispatchNamespace {
 				return
 			}
-			duc.workqueue <- DispatchUserEvent{
+			duc.enqueue(DispatchUserEvent{
 				action: "delete",
 				old: obj.(*netsys_v1.DispatchUser),
-			}
+				queued: time.Now(),
+			})
 		},
 	})
 
@@ -108,88 +175,316 @@ func NewDispatchUserController(
 	duc.saControl = RealServiceAccountControl{
 		saLister: saInformer.Lister().ServiceAccounts(dispatchNamespace),
 		client: clientSets.OriginalClient,
+		namespace: dispatchNamespace,
 	}
 
 	duc.onControl = RealOwnedNamespaceControl{
 		onLister: onInformer.Lister().OwnedNamespaces(dispatchNamespace),
-		original_client: clientSets.OriginalClient,
+		clientsets: clientSets,
 		netsys_client: clientSets.NetsysClient,
+		namespace: dispatchNamespace,
 	}
 
 	duc.saListerSynced = saInformer.Informer().HasSynced
 
+	metrics.RegisterWorkqueue(controllerName, func() int {
+		n := 0
+		for _, q := range duc.workqueues {
+			n += len(q)
+		}
+		return n
+	})
+
 	return duc
 }
 
-// Run begins watching and syncing.
-func (duc *DispatchUserController) Run(workers int, stopCh <-chan struct{}) {
+// Run begins watching and syncing with one worker per work queue, as many
+// as workers.dispatchUser.
+func (duc *DispatchUserController) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 
-	fmt.Printf("Starting %s controller\n", duc.Kind)
-	defer fmt.Printf("Shutting down %v controller\n", duc.Kind)
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
 
-	for !(duc.duListerSynced() && duc.onListerSynced() && duc.saListerSynced()) {
-		time.Sleep(time.Second)
+	if !cache.WaitForCacheSync(stopCh, duc.duListerSynced, duc.onListerSynced, duc.saListerSynced) {
+		return
+	}
+	if err := duc.adoptServiceAccounts(); err != nil {
+		// the users concerned fail to sync until their ServiceAccount is labeled
+		logging.Error("Adopting ServiceAccounts failed", "controller", controllerName, "error", err)
 	}
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(duc.worker, time.Second, stopCh)
+	var workers sync.WaitGroup
+	for _, queue := range duc.workqueues {
+		queue := queue
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.Until(func() { duc.worker(queue, stopCh) }, time.Second, stopCh)
+		}()
 	}
 
 	<-stopCh
+	// return only once the events in flight are done, a leader keeps its
+	// lease until then
+	workers.Wait()
 }
 
-// worker runs a worker thread that just dequeues items, processes them, and marks them done.
-// It enforces that the syncHandler is never invoked concurrently with the same key.
-func (duc *DispatchUserController) worker() {
-	fmt.Printf("Starting a %s worker\n", duc.Kind)
-	for duc.processNextWorkItem() {
+// worker runs a worker thread that just dequeues items of its queue and
+// processes them. As every key has a single queue, the handlers are never
+// invoked concurrently with the same key. It returns once stopCh is closed.
+func (duc *DispatchUserController) worker(queue chan DispatchUserEvent, stopCh <-chan struct{}) {
+	logging.Debug("Starting worker", "controller", controllerName)
+	for duc.processNextWorkItem(queue, stopCh) {
 	}
 }
 
-func (duc *DispatchUserController) processNextWorkItem() bool {
-	event := <- duc.workqueue
+func (duc *DispatchUserController) processNextWorkItem(queue chan DispatchUserEvent, stopCh <-chan struct{}) bool {
+	var event DispatchUserEvent
+	select {
+	case event = <- queue:
+	case <-stopCh:
+		return false
+	}
+	start := time.Now()
+	id := duc.tracker.Started(event.action, event.key())
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID(),
+		"action", event.action, "user", event.userID())
 
 	var err error
 	if event.action == "add" {
-		err = duc.addHandler(event)
+		err = duc.addHandler(event, logger)
 	} else if event.action == "update" {
-		err = duc.syncOwnedNamespaces(event.new)
+		err = duc.syncUser(event.new, event.queued, logger)
 	} else if event.action == "delete" {
-		err = duc.deleteHandler(event)
+		err = duc.deleteHandler(event, logger)
 	} else {
 		err = fmt.Errorf("event action not recoginized %s", event.action)
 	}
 
+	duc.tracker.Done(id, err, event.retries)
+	metrics.ReconcileTotal.Inc(controllerName, event.action)
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+
 	if err != nil {
-		fmt.Printf("Error processing DispatchUser %s: %s", event.action, err)
-		return false
+		logger.Error("Reconcile failed", "error", err, "retries", event.retries)
+		if event.new != nil {
+			duc.recorder.Eventf(event.new, core_v1.EventTypeWarning, controller.SyncFailed, "Sync failed: %v", err)
+		}
+		metrics.ReconcileErrors.Inc(controllerName, event.action)
+		duc.retry(event)
 	}
 
 	return true
 }
 
-func (duc *DispatchUserController) addHandler(e DispatchUserEvent) error {
-	_, err := duc.saControl.Create(e.new.Spec.UserID)
-	if err != nil && err.Error() != "already exists" {
+// retry queues a failed event again after a backoff. Adds and updates are
+// retried with the latest DispatchUser from the cache, so a retry never
+// reverts a newer change.
+func (duc *DispatchUserController) retry(e DispatchUserEvent) {
+	if e.retries >= maxRetries {
+		logging.Error("Giving up after retries", "controller", controllerName, "action", e.action,
+			"user", e.userID(), "retries", e.retries)
+		return
+	}
+	e.retries++
+	metrics.WorkqueueRetries.Inc(controllerName)
+
+	go func() {
+		time.Sleep(time.Duration(e.retries) * retryBackoff)
+		if e.new != nil {
+			latest, err := duc.duLister.DispatchUsers(e.new.Namespace).Get(e.new.Name)
+			if err != nil {
+				// deleted in the meantime, the delete event cleans up
+				return
+			}
+			e.new = latest
+		}
+		duc.enqueue(e)
+	}()
+}
+
+// enqueue puts an event on the work queue of its key
+func (duc *DispatchUserController) enqueue(e DispatchUserEvent) {
+	duc.tracker.Queued(e.action, e.key())
+	duc.workqueues[shard(e.key(), len(duc.workqueues))] <- e
+}
+
+// shard returns the work queue of key out of n
+func shard(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// Tracker returns the tracker of the controller's work items
+func (duc *DispatchUserController) Tracker() *health.Tracker {
+	return duc.tracker
+}
+
+// key identifies the object of an event in health checks
+func (e DispatchUserEvent) key() string {
+	obj := e.new
+	if obj == nil {
+		obj = e.old
+	}
+	return obj.Namespace + "/" + obj.Name
+}
+
+// userID is the user of an event for log lines
+func (e DispatchUserEvent) userID() string {
+	if e.new != nil {
+		return e.new.Spec.UserID
+	}
+	return e.old.Spec.UserID
+}
+
+func (duc *DispatchUserController) addHandler(e DispatchUserEvent, logger *logging.Logger) error {
+	return duc.syncUser(e.new, e.queued, logger)
+}
+
+// syncUser provisions the credentials and grants of u, revokes them all
+// once u expired or suspends them while u is suspended. changed is when the change being synced was seen.
+func (duc *DispatchUserController) syncUser(u *netsys_v1.DispatchUser, changed time.Time, logger *logging.Logger) error {
+	now := time.Now()
+	status, err := duc.expiryStatus(u, now)
+	if err != nil {
+		return err
+	}
+	// whether the credentials of an expired or suspended user are gone
+	revoked := true
+	if status.Expired {
+		revoked, err = duc.revokeAll(u, "user expired", logger)
+	} else if u.Spec.Suspended {
+		revoked, err = duc.suspend(u, logger)
+	} else if netsys_v1.ReservedUserID(u.Spec.UserID) {
+		logger.Warn("Refusing reserved user ID", "user", u.Spec.UserID)
+		duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantRefused,
+			"User ID %s is reserved for dispatch, the user gets no credentials or grants", u.Spec.UserID)
+	} else if err = duc.ensureServiceAccount(u, logger); err == nil {
+		if err = duc.syncMemberServiceAccounts(u, &status, logger); err == nil {
+			err = duc.syncOwnedNamespaces(u, &status, changed, expiredGrants(status), logger)
+		}
+	}
+	if err != nil {
 		return err
 	}
-	return duc.syncOwnedNamespaces(e.new)
+	if status.Expired || u.Spec.Suspended {
+		// revoked in the member clusters with the other credentials
+		status.Clusters = nil
+	}
+	duc.recordSuspension(u, &status, now, revoked, logger)
+
+	duc.warnExpiry(u, &status, now, revoked, logger)
+	if err := duc.updateStatus(u, status); err != nil {
+		return err
+	}
+	duc.scheduleExpiry(u, status, now)
+	return nil
 }
 
-func (duc *DispatchUserController) syncOwnedNamespaces(u *netsys_v1.DispatchUser) error {
+func (duc *DispatchUserController) ensureServiceAccount(u *netsys_v1.DispatchUser, logger *logging.Logger) error {
+	_, err := duc.saControl.Create(u.Spec.UserID)
+	if err != nil && err.Error() != "already exists" && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	if err == nil {
+		logger.Info("Created ServiceAccount", "serviceaccount", u.Spec.UserID)
+		duc.audit(u, audit.ActionCredentialIssued, "", "", "")
+		duc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.ServiceAccountCreated,
+			"Created ServiceAccount %s/%s", duc.config.DispatchNamespace, u.Spec.UserID)
+	}
+	return nil
+}
+
+// syncOwnedNamespaces creates, updates and deletes OwnedNamespaces to match
+// the grants of u, leaving out the expired ones, those outside the budget of
+// the user's tenant and those the cluster has no capacity for, which are
+// recorded in status. changed is when the change being synced was seen.
+func (duc *DispatchUserController) syncOwnedNamespaces(u *netsys_v1.DispatchUser, status *netsys_v1.DispatchUserStatus, changed time.Time, expired map[string]bool, logger *logging.Logger) error {
+	if u.Labels[netsys_v1.TenantLabel] != "" {
+		// workers syncing users of the same tenant would both see the same budget
+		duc.tenantLock.Lock()
+		defer duc.tenantLock.Unlock()
+	}
+	budget, err := duc.tenantBudget(u)
+	if err != nil {
+		return err
+	}
 	currentNamespaces, err := duc.onControl.ListForUser(u.Spec.UserID)
 	if err != nil {
 		return err
 	}
-	currentSet := make(map[string]bool, len(currentNamespaces))
-	futureSet := make(map[string]bool, len(u.Spec.Namespaces))
-
+	grants := u.Spec.EffectiveGrants()
+	currentSet := make(map[string]string, len(currentNamespaces))
+	futureSet := make(map[string]string, len(grants))
+
+	suspended := map[string]bool{}
+	currentLeases := map[string]*meta_v1.Duration{}
+	futureLeases := map[string]*meta_v1.Duration{}
+	renewedAt := map[string]*meta_v1.Time{}
+	currentSchedules := map[string]*netsys_v1.AvailabilitySchedule{}
+	futureSchedules := map[string]*netsys_v1.AvailabilitySchedule{}
 	for _, n := range currentNamespaces {
-		currentSet[n.Spec.Namespace] = true
+		currentSet[n.Key()] = netsys_v1.RoleOrDefault(n.Spec.Role)
+		suspended[n.Key()] = n.Spec.Suspended
+		currentLeases[n.Key()] = n.Spec.Lease
+		currentSchedules[n.Key()] = n.Spec.Schedule
+	}
+	for _, g := range grants {
+		k := g.Key()
+		if duc.config.IsProtected(g.Namespace) {
+			logger.Warn("Refusing to grant protected namespace", "namespace", k)
+			duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantRefused,
+				"Namespace %s is protected and cannot be granted", k)
+			continue
+		}
+		if role := duc.config.RoleOrDefault(g.Role); !netsys_v1.ValidRole(role) {
+			logger.Warn("Refusing grant with an invalid role", "namespace", k, "role", role)
+			duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantRefused,
+				"Namespace %s was refused: role %q must be view, edit or admin", k, role)
+			continue
+		}
+		if g.Cluster != "" && !contains(status.Clusters, g.Cluster) {
+			// syncMemberServiceAccounts found no such cluster
+			logger.Warn("Refusing grant in unknown cluster", "namespace", k, "cluster", g.Cluster)
+			duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantRefused,
+				"Namespace %s is in cluster %s, which is not a member cluster", k, g.Cluster)
+			continue
+		}
+		if budget != nil {
+			if reason := budget.refuse(g); reason != "" {
+				logger.Warn("Refusing grant outside the tenant's budget", "namespace", k, "reason", reason)
+				duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantRefused,
+					"Namespace %s was refused: %s", k, reason)
+				continue
+			}
+		}
+		if expired[k] {
+			continue
+		}
+		schedule, err := duc.config.Schedule(g)
+		if err != nil {
+			logger.Warn("Invalid schedule", "namespace", k, "error", err)
+			duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.ScheduleInvalid,
+				"Schedule of namespace %s is invalid, the grant is left as it is: %v", k, err)
+			if role, ok := currentSet[k]; ok {
+				futureSet[k] = role
+				futureLeases[k] = currentLeases[k]
+				futureSchedules[k] = currentSchedules[k]
+			}
+			continue
+		}
+		futureSet[k] = duc.config.RoleOrDefault(g.Role)
+		futureLeases[k] = g.Lease
+		renewedAt[k] = g.LeaseRenewedAt
+		futureSchedules[k] = schedule
 	}
-	for _, n := range u.Spec.Namespaces {
-		futureSet[n] = true
+	for _, r := range status.RefusedGrants {
+		if _, ok := futureSet[r.Namespace]; !ok {
+			// removed, a new grant is checked again
+			forgetRefusedGrant(status, r.Namespace)
+		}
 	}
 
 	for k := range currentSet {
@@ -198,32 +493,171 @@ func (duc *DispatchUserController) syncOwnedNamespaces(u *netsys_v1.DispatchUser
 			if err != nil {
 				return err
 			}
+			metrics.ProvisioningCancelled(u.Spec.UserID, k)
+			logger.Info("Revoked grant", "namespace", k, "role", currentSet[k])
+			if expired[k] {
+				// warnExpiry records the GrantExpired Event
+				duc.auditReason(u, "grant expired", audit.ActionRevoke, k, currentSet[k], "")
+				continue
+			}
+			duc.audit(u, audit.ActionRevoke, k, currentSet[k], "")
+			duc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.GrantRevoked,
+				"Revoked %s access to namespace %s", currentSet[k], k)
 		}
 	}
 
-	for k := range futureSet {
-		if _, ok := currentSet[k]; !ok {
-			_, err = duc.onControl.Create(u.Spec.UserID, k)
+	for k, role := range futureSet {
+		currentRole, ok := currentSet[k]
+		lease, schedule := futureLeases[k], futureSchedules[k]
+		if ok && currentRole == role && sameLease(currentLeases[k], lease) && equality.Semantic.DeepEqual(currentSchedules[k], schedule) {
+			if suspended[k] {
+				// the user was resumed
+				if _, err = duc.onControl.SetSuspended(u.Spec.UserID, k, false); err != nil {
+					return err
+				}
+				logger.Info("Resumed grant", "namespace", k, "role", role)
+			}
+			continue
+		}
+		if !ok {
+			admitted, err := duc.admit(u, status, k, time.Now(), logger)
 			if err != nil {
 				return err
 			}
+			if !admitted {
+				continue
+			}
+			created, err := duc.onControl.EnsureNamespace(k)
+			if err != nil {
+				return err
+			}
+			if created {
+				logger.Info("Created namespace", "namespace", k)
+				duc.audit(u, audit.ActionNamespaceCreated, k, "", "")
+				duc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.NamespaceCreated, "Created namespace %s", k)
+			}
+			if _, err = duc.onControl.Create(u.Spec.UserID, k, role, lease, renewedAt[k], schedule); err != nil {
+				return err
+			}
+			logger.Info("Added grant", "namespace", k, "role", role)
+			duc.audit(u, audit.ActionGrant, k, role, "")
+			duc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.GrantAdded,
+				"Granted %s access to namespace %s", role, k)
+		} else {
+			if _, err = duc.onControl.Update(u.Spec.UserID, k, role, lease, schedule); err != nil {
+				return err
+			}
+			if currentRole == role {
+				logger.Info("Changed lease or schedule", "namespace", k)
+				continue
+			}
+			logger.Info("Changed role", "namespace", k, "from", currentRole, "role", role)
+			duc.audit(u, audit.ActionRoleChange, k, role, currentRole)
+			duc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.RoleChanged,
+				"Changed role in namespace %s from %s to %s", k, currentRole, role)
 		}
+		metrics.ProvisioningStarted(u.Spec.UserID, []string{k}, changed)
 	}
 
 	return nil
 }
 
-func (duc *DispatchUserController) deleteHandler(e DispatchUserEvent) error {
-	err := duc.saControl.Delete(e.new.Spec.UserID)
+// sameLease returns true if both grants have the same lease or none
+func sameLease(a, b *meta_v1.Duration) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Duration == b.Duration
+}
+
+func (duc *DispatchUserController) deleteHandler(e DispatchUserEvent, logger *logging.Logger) error {
+	duc.stopExpiryTimer(e.key())
+	// a reason on the user was given for an earlier change
+	_, err := duc.revokeAll(e.old, "user deleted", logger)
+	return err
+}
+
+// revokeAll deletes the ServiceAccount and all OwnedNamespaces of u.
+// revoked is as returned by revokeCredentials.
+func (duc *DispatchUserController) revokeAll(u *netsys_v1.DispatchUser, reason string, logger *logging.Logger) (revoked bool, err error) {
+	metrics.ProvisioningCancelled(u.Spec.UserID)
+	if revoked, err = duc.revokeCredentials(u, reason, logger); err != nil {
+		return false, err
+	}
+	currentNamespaces, err := duc.onControl.ListForUser(u.Spec.UserID)
 	if err != nil {
-		return err
+		return false, err
 	}
-	currentNamespaces, err := duc.onControl.ListForUser(e.old.Spec.UserID)
 	for _, n := range currentNamespaces {
-		err = duc.onControl.Delete(e.old.Spec.UserID, n.Spec.Namespace)
+		err = duc.onControl.Delete(u.Spec.UserID, n.Key())
 		if err != nil {
-			return err
+			return false, err
 		}
+		logger.Info("Revoked grant", "namespace", n.Key(), "role", netsys_v1.RoleOrDefault(n.Spec.Role))
+		duc.auditReason(u, reason, audit.ActionRevoke, n.Key(), netsys_v1.RoleOrDefault(n.Spec.Role), "")
 	}
-	return nil
-}
\ No newline at end of file
+	return revoked, nil
+}
+
+// revokeCredentials deletes the ServiceAccounts of u, which invalidates
+// its tokens. revoked is false if a ServiceAccount dispatch did not create
+// was left in place.
+func (duc *DispatchUserController) revokeCredentials(u *netsys_v1.DispatchUser, reason string, logger *logging.Logger) (revoked bool, err error) {
+	revoked = true
+	for _, cluster := range u.Status.Clusters {
+		err := duc.deleteMemberServiceAccount(u, cluster, reason, logger)
+		if isNotOwned(err) {
+			duc.warnNotRevoked(u, err, logger)
+			revoked = false
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	_, getErr := duc.saControl.Get(u.Spec.UserID)
+	err = duc.saControl.Delete(u.Spec.UserID)
+	if isNotOwned(err) {
+		duc.warnNotRevoked(u, err, logger)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if getErr == nil {
+		logger.Info("Deleted ServiceAccount", "serviceaccount", u.Spec.UserID)
+		duc.auditReason(u, reason, audit.ActionCredentialRevoked, "", "", "")
+		duc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.ServiceAccountDeleted,
+			"Deleted ServiceAccount %s/%s", duc.config.DispatchNamespace, u.Spec.UserID)
+	}
+	return revoked, nil
+}
+
+// warnNotRevoked records that the credentials of u were left in place
+// because dispatch did not create them
+func (duc *DispatchUserController) warnNotRevoked(u *netsys_v1.DispatchUser, err error, logger *logging.Logger) {
+	logger.Warn("Credentials not revoked", "error", err)
+	duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.CredentialsNotRevoked, "Credentials not revoked: %v", err)
+}
+
+// audit records an access change of u, with the reason given on u
+func (duc *DispatchUserController) audit(u *netsys_v1.DispatchUser, action, namespace, role, previousRole string) {
+	duc.auditReason(u, u.Reason(), action, namespace, role, previousRole)
+}
+
+// auditReason records an access change of u made for reason. key is the
+// grant key of the namespace, or cluster/ for credentials in a member
+// cluster.
+func (duc *DispatchUserController) auditReason(u *netsys_v1.DispatchUser, reason, action, key, role, previousRole string) {
+	cluster, namespace := netsys_v1.ParseGrantKey(key)
+	duc.auditor.Record(audit.Record{
+		Action:       action,
+		User:         u.Spec.UserID,
+		Namespace:    namespace,
+		Cluster:      cluster,
+		Role:         role,
+		PreviousRole: previousRole,
+		Reason:       reason,
+		Source:       "DispatchUser/" + u.Namespace + "/" + u.Name,
+	})
+}
@@ -0,0 +1,198 @@
+package dispatchuser
+
+import (
+	"fmt"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/notify"
+)
+
+// expiryStatus computes the expiry of u and its grants at now. Warnings
+// already sent are kept unless the expiry moved out of the warning window.
+func (duc *DispatchUserController) expiryStatus(u *netsys_v1.DispatchUser, now time.Time) (netsys_v1.DispatchUserStatus, error) {
+	warnBefore := duc.config.Expiry.WarnBefore.Duration
+	status := *u.Status.DeepCopy()
+	status.ExpiresAt = u.ExpiryTime()
+	status.Expired = u.Expired(now)
+	if status.ExpiresAt == nil || now.Before(status.ExpiresAt.Add(-warnBefore)) {
+		status.ExpiryWarned = false
+	}
+
+	owned, err := duc.onControl.ListForUser(u.Spec.UserID)
+	if err != nil {
+		return status, err
+	}
+	provisioned := make(map[string]meta_v1.Time, len(owned))
+	for _, on := range owned {
+		provisioned[on.Key()] = on.CreationTimestamp
+	}
+
+	var grants []netsys_v1.GrantStatus
+	for _, g := range u.Spec.EffectiveGrants() {
+		if g.ExpiresAt == nil && g.TTL == nil {
+			continue
+		}
+		// the API server stores times in seconds
+		gs := netsys_v1.GrantStatus{
+			Namespace: g.Key(),
+			Role:      duc.config.RoleOrDefault(g.Role),
+			GrantedAt: meta_v1.NewTime(now.Truncate(time.Second)),
+		}
+		if prev := u.Status.GrantStatus(g.Key()); prev != nil {
+			gs.GrantedAt = prev.GrantedAt
+			gs.ExpiryWarned = prev.ExpiryWarned
+		} else if t, ok := provisioned[g.Key()]; ok {
+			gs.GrantedAt = t
+		}
+		gs.ExpiresAt = g.ExpiryTime(gs.GrantedAt.Time)
+		gs.Expired = !now.Before(gs.ExpiresAt.Time)
+		if now.Before(gs.ExpiresAt.Add(-warnBefore)) {
+			gs.ExpiryWarned = false
+		}
+		grants = append(grants, gs)
+	}
+	status.Grants = grants
+	return status, nil
+}
+
+// expiredGrants returns the keys of the grants that expired
+func expiredGrants(status netsys_v1.DispatchUserStatus) map[string]bool {
+	expired := map[string]bool{}
+	for _, gs := range status.Grants {
+		if gs.Expired {
+			expired[gs.Namespace] = true
+		}
+	}
+	return expired
+}
+
+// warnExpiry records Events and sends notifications when u or one of its
+// grants expired or is about to, and marks the warnings sent in status.
+// revoked tells whether the credentials of an expired u are gone.
+func (duc *DispatchUserController) warnExpiry(u *netsys_v1.DispatchUser, status *netsys_v1.DispatchUserStatus, now time.Time, revoked bool, logger *logging.Logger) {
+	warnBefore := duc.config.Expiry.WarnBefore.Duration
+	if status.ExpiresAt != nil {
+		expiry := status.ExpiresAt.UTC().Format(time.RFC3339)
+		if status.Expired {
+			if !u.Status.Expired {
+				logger.Info("User expired", "expiresAt", expiry)
+				message := "Access expired at %s, credentials and grants were revoked"
+				if !revoked {
+					message = "Access expired at %s, grants were revoked, credentials not created by dispatch were left in place"
+				}
+				duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.UserExpired, message, expiry)
+				duc.notify(u, notify.Expired, "", "Your access expired at %s", expiry)
+			}
+			// grants went with the user
+			return
+		}
+		if !status.ExpiryWarned && !now.Before(status.ExpiresAt.Add(-warnBefore)) {
+			logger.Info("User expires soon", "expiresAt", expiry)
+			duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.UserExpiring, "Access expires at %s", expiry)
+			duc.notify(u, notify.Expiring, "", "Your access expires at %s, ask for an extension if you still need it", expiry)
+			status.ExpiryWarned = true
+		}
+	}
+
+	for i := range status.Grants {
+		gs := &status.Grants[i]
+		expiry := gs.ExpiresAt.UTC().Format(time.RFC3339)
+		if gs.Expired {
+			if prev := u.Status.GrantStatus(gs.Namespace); prev == nil || !prev.Expired {
+				logger.Info("Grant expired", "namespace", gs.Namespace, "expiresAt", expiry)
+				duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantExpired,
+					"Access to namespace %s expired at %s", gs.Namespace, expiry)
+				duc.notify(u, notify.Expired, gs.Namespace, "Your %s access to namespace %s expired at %s", gs.Role, gs.Namespace, expiry)
+			}
+			continue
+		}
+		if !gs.ExpiryWarned && !now.Before(gs.ExpiresAt.Add(-warnBefore)) {
+			logger.Info("Grant expires soon", "namespace", gs.Namespace, "expiresAt", expiry)
+			duc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantExpiring,
+				"Access to namespace %s expires at %s", gs.Namespace, expiry)
+			duc.notify(u, notify.Expiring, gs.Namespace,
+				"Your %s access to namespace %s expires at %s, ask for an extension if you still need it", gs.Role, gs.Namespace, expiry)
+			gs.ExpiryWarned = true
+		}
+	}
+}
+
+func (duc *DispatchUserController) notify(u *netsys_v1.DispatchUser, typ, namespace, format string, args ...interface{}) {
+	duc.notifier.Notify(notify.Notification{
+		Type:      typ,
+		User:      u.Spec.UserID,
+		Namespace: namespace,
+		Time:      time.Now(),
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// updateStatus writes status to u if it changed
+func (duc *DispatchUserController) updateStatus(u *netsys_v1.DispatchUser, status netsys_v1.DispatchUserStatus) error {
+	if equality.Semantic.DeepEqual(u.Status, status) {
+		return nil
+	}
+	u = u.DeepCopy()
+	u.Status = status
+	_, err := duc.clientsets.NetsysClient.NetsysV1().DispatchUsers(u.Namespace).Update(u)
+	return err
+}
+
+// scheduleExpiry syncs u again at its next warning or expiry, replacing
+// any earlier schedule
+func (duc *DispatchUserController) scheduleExpiry(u *netsys_v1.DispatchUser, status netsys_v1.DispatchUserStatus, now time.Time) {
+	warnBefore := duc.config.Expiry.WarnBefore.Duration
+	var next time.Time
+	consider := func(expiresAt *meta_v1.Time) {
+		for _, t := range []time.Time{expiresAt.Add(-warnBefore), expiresAt.Time} {
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	if status.ExpiresAt != nil && !status.Expired {
+		consider(status.ExpiresAt)
+	}
+	for _, gs := range status.Grants {
+		if !gs.Expired {
+			consider(gs.ExpiresAt)
+		}
+	}
+
+	namespace, name := u.Namespace, u.Name
+	duc.stopExpiryTimer(namespace + "/" + name)
+	if next.IsZero() {
+		return
+	}
+	duc.timersLock.Lock()
+	defer duc.timersLock.Unlock()
+	// a second late so the expiry has passed when the sync runs
+	duc.timers[namespace+"/"+name] = time.AfterFunc(next.Sub(now)+time.Second, func() {
+		latest, err := duc.duLister.DispatchUsers(namespace).Get(name)
+		if err != nil {
+			return
+		}
+		duc.enqueue(DispatchUserEvent{
+			action: "update",
+			old:    latest,
+			new:    latest,
+			queued: time.Now(),
+		})
+	})
+}
+
+func (duc *DispatchUserController) stopExpiryTimer(key string) {
+	duc.timersLock.Lock()
+	defer duc.timersLock.Unlock()
+	if t, ok := duc.timers[key]; ok {
+		t.Stop()
+		delete(duc.timers, key)
+	}
+}
@@ -3,29 +3,36 @@ package dispatchuser
 import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/client-go/kubernetes"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
 	lister_v1 "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
 	netsys_client "github.com/hantaowang/dispatch/pkg/client/clientset/versioned"
 	core_v1 "k8s.io/api/core/v1"
 
+	"github.com/hantaowang/dispatch/pkg/client"
 	"github.com/hantaowang/dispatch/pkg/controller"
 
 	"fmt"
 )
 
+// OwnedNamespaceControl manages the OwnedNamespaces of grants. Grants are
+// identified by their key, the namespace or cluster/namespace in member
+// clusters.
 type OwnedNamespaceControl interface {
+	EnsureNamespace(key string)		(created bool, err error)
 	ListForUser(owner string)				([]*netsys_v1.OwnedNamespace, error)
-	Get(owner, namespace string)			(*netsys_v1.OwnedNamespace, error)
-	Create(owner, namespace string)			(*netsys_v1.OwnedNamespace, error)
-	Delete(owner, namespace string) 		error
+	Get(owner, key string)			(*netsys_v1.OwnedNamespace, error)
+	Create(owner, key, role string, lease *meta_v1.Duration, renewedAt *meta_v1.Time, schedule *netsys_v1.AvailabilitySchedule)	(*netsys_v1.OwnedNamespace, error)
+	Update(owner, key, role string, lease *meta_v1.Duration, schedule *netsys_v1.AvailabilitySchedule)	(*netsys_v1.OwnedNamespace, error)
+	SetSuspended(owner, key string, suspended bool)	(*netsys_v1.OwnedNamespace, error)
+	Delete(owner, key string) 		error
 }
 
 type RealOwnedNamespaceControl struct {
 	onLister			lister_v1.OwnedNamespaceNamespaceLister
 	netsys_client		netsys_client.Interface
-	original_client		kubernetes.Interface
+	clientsets			client.ClientSets
+	namespace			string
 }
 
 func (ronc RealOwnedNamespaceControl) ListForUser(owner string) ([]*netsys_v1.OwnedNamespace, error) {
@@ -36,27 +43,43 @@ func (ronc RealOwnedNamespaceControl) ListForUser(owner string) ([]*netsys_v1.Ow
 	return ronc.onLister.List(s)
 }
 
-func (ronc RealOwnedNamespaceControl) Get(owner, namespace string) (*netsys_v1.OwnedNamespace, error) {
-	return ronc.onLister.Get(controller.NameFunc(owner, namespace))
+func (ronc RealOwnedNamespaceControl) Get(owner, key string) (*netsys_v1.OwnedNamespace, error) {
+	return ronc.onLister.Get(controller.OwnedNamespaceName(owner, key))
 }
 
-func (ronc RealOwnedNamespaceControl) Create(owner, namespace string) (*netsys_v1.OwnedNamespace, error) {
-
-	_, err := ronc.original_client.CoreV1().Namespaces().Get(namespace, meta_v1.GetOptions{})
-	if errors.IsNotFound(err) {
-		nSpec := &core_v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: namespace}}
-		_, err = ronc.original_client.CoreV1().Namespaces().Create(nSpec)
-	}
+// EnsureNamespace creates the namespace of key in its cluster unless it exists
+func (ronc RealOwnedNamespaceControl) EnsureNamespace(key string) (bool, error) {
+	cluster, namespace := netsys_v1.ParseGrantKey(key)
+	c, err := ronc.clientsets.ForCluster(cluster)
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	_, err = c.CoreV1().Namespaces().Get(namespace, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		nSpec := &core_v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{
+			Name: namespace,
+			// only namespaces created by dispatch are reclaimed
+			Labels: map[string]string{netsys_v1.CreatedByLabel: "dispatch"},
+		}}
+		_, err = c.CoreV1().Namespaces().Create(nSpec)
+		if errors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
 	}
+	return false, err
+}
 
-	if _, err := ronc.Get(owner, namespace); err != nil {
+// Create creates the OwnedNamespace of a grant. A lease renewed at
+// renewedAt is carried over from a transferred grant.
+func (ronc RealOwnedNamespaceControl) Create(owner, key, role string, lease *meta_v1.Duration, renewedAt *meta_v1.Time, schedule *netsys_v1.AvailabilitySchedule) (*netsys_v1.OwnedNamespace, error) {
+	if _, err := ronc.Get(owner, key); err != nil {
 		if errors.IsNotFound(err) {
+			cluster, namespace := netsys_v1.ParseGrantKey(key)
 			on := netsys_v1.OwnedNamespace{
 				ObjectMeta: meta_v1.ObjectMeta{
-					Name: controller.NameFunc(owner, namespace),
-					Namespace: dispatchNamespace,
+					Name: controller.OwnedNamespaceName(owner, key),
+					Namespace: ronc.namespace,
 					Labels: map[string]string{
 						"ownerID": owner,
 					},
@@ -64,9 +87,17 @@ func (ronc RealOwnedNamespaceControl) Create(owner, namespace string) (*netsys_v
 				Spec: netsys_v1.OwnedNamespaceSpec{
 					OwnerID: owner,
 					Namespace: namespace,
+					Cluster: cluster,
+					Role: role,
+					Lease: lease,
+					Schedule: schedule,
 				},
 			}
-			return ronc.netsys_client.NetsysV1().OwnedNamespaces(dispatchNamespace).Create(&on)
+			if lease != nil && renewedAt != nil {
+				// the lease controller works out when it expires
+				on.Status.Lease = &netsys_v1.NamespaceLease{RenewedAt: *renewedAt}
+			}
+			return ronc.netsys_client.NetsysV1().OwnedNamespaces(ronc.namespace).Create(&on)
 		} else {
 			return nil, err
 		}
@@ -75,11 +106,46 @@ func (ronc RealOwnedNamespaceControl) Create(owner, namespace string) (*netsys_v
 	}
 }
 
-func (ronc RealOwnedNamespaceControl) Delete(owner, namespace string) error {
-	if _, err := ronc.Get(owner, namespace); err != nil && errors.IsNotFound(err){
-		return ronc.netsys_client.NetsysV1().OwnedNamespaces(dispatchNamespace).Delete(controller.NameFunc(owner, namespace), nil)
-	} else {
+func (ronc RealOwnedNamespaceControl) Update(owner, key, role string, lease *meta_v1.Duration, schedule *netsys_v1.AvailabilitySchedule) (*netsys_v1.OwnedNamespace, error) {
+	on, err := ronc.Get(owner, key)
+	if err != nil {
+		return nil, err
+	}
+	on = on.DeepCopy()
+	on.Spec.Role = role
+	on.Spec.Lease = lease
+	if lease == nil {
+		// the grant is no longer leased
+		on.Status.Lease = nil
+	}
+	on.Spec.Schedule = schedule
+	if schedule == nil {
+		on.Status.Schedule = nil
+	}
+	// roles only change while the owner is active
+	on.Spec.Suspended = false
+	return ronc.netsys_client.NetsysV1().OwnedNamespaces(ronc.namespace).Update(on)
+}
+
+// SetSuspended marks the OwnedNamespace suspended, its RoleBinding is
+// removed until it is resumed
+func (ronc RealOwnedNamespaceControl) SetSuspended(owner, key string, suspended bool) (*netsys_v1.OwnedNamespace, error) {
+	on, err := ronc.Get(owner, key)
+	if err != nil {
+		return nil, err
+	}
+	on = on.DeepCopy()
+	on.Spec.Suspended = suspended
+	return ronc.netsys_client.NetsysV1().OwnedNamespaces(ronc.namespace).Update(on)
+}
+
+func (ronc RealOwnedNamespaceControl) Delete(owner, key string) error {
+	if _, err := ronc.Get(owner, key); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
 		return err
 	}
+	return ronc.netsys_client.NetsysV1().OwnedNamespaces(ronc.namespace).Delete(controller.OwnedNamespaceName(owner, key), nil)
 }
 
@@ -8,6 +8,8 @@ import (
 	"k8s.io/client-go/kubernetes"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"fmt"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
 )
 
 type ServiceAccountControl interface {
@@ -20,6 +22,7 @@ type ServiceAccountControl interface {
 type RealServiceAccountControl struct {
 	saLister		lister_v1.ServiceAccountNamespaceLister
 	client			kubernetes.Interface
+	namespace		string
 }
 
 func (rsac RealServiceAccountControl) List() ([]*v1.ServiceAccount, error) {
@@ -30,28 +33,66 @@ func (rsac RealServiceAccountControl) Get(name string) (*v1.ServiceAccount, erro
 	return rsac.saLister.Get(name)
 }
 
+// Create creates the ServiceAccount name labeled with the user it belongs
+// to. ServiceAccounts that exist without the label are never adopted.
 func (rsac RealServiceAccountControl) Create(name string) (*v1.ServiceAccount, error) {
-	if _, err := rsac.Get(name); err != nil {
+	if netsys_v1.ReservedUserID(name) {
+		return nil, fmt.Errorf("ServiceAccount %s is reserved for dispatch", name)
+	}
+	if sa, err := rsac.Get(name); err != nil {
 		if errors.IsNotFound(err) {
 			sa := &v1.ServiceAccount{
 				ObjectMeta: meta_v1.ObjectMeta{
 					Name:      name,
-					Namespace: dispatchNamespace,
+					Namespace: rsac.namespace,
+					Labels:    map[string]string{netsys_v1.UserLabel: name},
 				},
 			}
-			return rsac.client.CoreV1().ServiceAccounts(dispatchNamespace).Create(sa)
+			return rsac.client.CoreV1().ServiceAccounts(rsac.namespace).Create(sa)
 		} else {
 			return nil, err
 		}
+	} else if sa.Labels[netsys_v1.UserLabel] != name {
+		return nil, fmt.Errorf("ServiceAccount %s/%s was not created by dispatch, label it %s=%s to adopt it",
+			rsac.namespace, name, netsys_v1.UserLabel, name)
 	} else {
 		return nil, fmt.Errorf("already exists")
 	}
 }
 
+// Delete deletes the ServiceAccount name if it was created by dispatch. A
+// ServiceAccount that was not is left in place and a notOwnedError returned.
 func (rsac RealServiceAccountControl) Delete(name string) error {
-	if _, err := rsac.Get(name); err != nil && errors.IsNotFound(err){
-		return rsac.client.CoreV1().ServiceAccounts(dispatchNamespace).Delete(name, nil)
-	} else {
+	if sa, err := rsac.Get(name); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
 		return err
+	} else if sa.Labels[netsys_v1.UserLabel] != name {
+		return notOwnedError{namespace: rsac.namespace, name: name}
+	}
+	return rsac.client.CoreV1().ServiceAccounts(rsac.namespace).Delete(name, nil)
+}
+
+// notOwnedError is returned instead of deleting a ServiceAccount that is
+// not labeled with the user it is named after
+type notOwnedError struct {
+	namespace	string
+	name		string
+	cluster		string
+}
+
+func (e notOwnedError) Error() string {
+	where := ""
+	if e.cluster != "" {
+		where = " in cluster " + e.cluster
 	}
+	return fmt.Sprintf("ServiceAccount %s/%s%s was not created by dispatch and was not deleted, label it %s=%s or delete it by hand",
+		e.namespace, e.name, where, netsys_v1.UserLabel, e.name)
+}
+
+// isNotOwned returns true if err is a notOwnedError
+func isNotOwned(err error) bool {
+	_, ok := err.(notOwnedError)
+	return ok
 }
\ No newline at end of file
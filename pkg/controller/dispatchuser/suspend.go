@@ -0,0 +1,67 @@
+package dispatchuser
+
+import (
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/audit"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+)
+
+// suspend revokes the credentials of u and marks its OwnedNamespaces
+// suspended, which removes their RoleBindings. The OwnedNamespaces are kept
+// so resuming u restores its access. revoked is as returned by
+// revokeCredentials.
+func (duc *DispatchUserController) suspend(u *netsys_v1.DispatchUser, logger *logging.Logger) (revoked bool, err error) {
+	metrics.ProvisioningCancelled(u.Spec.UserID)
+	reason := u.Reason()
+	if reason == "" {
+		reason = "user suspended"
+	}
+	if revoked, err = duc.revokeCredentials(u, reason, logger); err != nil {
+		return false, err
+	}
+	owned, err := duc.onControl.ListForUser(u.Spec.UserID)
+	if err != nil {
+		return false, err
+	}
+	for _, on := range owned {
+		if on.Spec.Suspended {
+			continue
+		}
+		if _, err := duc.onControl.SetSuspended(u.Spec.UserID, on.Key(), true); err != nil {
+			return false, err
+		}
+		logger.Info("Suspended grant", "namespace", on.Key(), "role", netsys_v1.RoleOrDefault(on.Spec.Role))
+	}
+	return revoked, nil
+}
+
+// recordSuspension notes in status when u was suspended or resumed and
+// records the change. revoked tells whether the credentials of u are gone.
+func (duc *DispatchUserController) recordSuspension(u *netsys_v1.DispatchUser, status *netsys_v1.DispatchUserStatus, now time.Time, revoked bool, logger *logging.Logger) {
+	switch {
+	case u.Spec.Suspended && status.SuspendedAt == nil && !status.Expired:
+		// the API server stores times in seconds
+		suspendedAt := meta_v1.NewTime(now.Truncate(time.Second))
+		status.SuspendedAt = &suspendedAt
+		logger.Info("Suspended user")
+		duc.audit(u, audit.ActionSuspend, "", "", "")
+		message := "Suspended, credentials revoked and RoleBindings removed"
+		if !revoked {
+			message = "Suspended and RoleBindings removed, credentials not created by dispatch were left in place"
+		}
+		duc.recorder.Event(u, core_v1.EventTypeWarning, controller.UserSuspended, message)
+	case !u.Spec.Suspended && status.SuspendedAt != nil:
+		status.SuspendedAt = nil
+		logger.Info("Resumed user")
+		duc.audit(u, audit.ActionResume, "", "", "")
+		duc.recorder.Event(u, core_v1.EventTypeNormal, controller.UserResumed,
+			"Resumed, credentials and RoleBindings restored")
+	}
+}
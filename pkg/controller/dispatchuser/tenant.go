@@ -0,0 +1,119 @@
+package dispatchuser
+
+import (
+	"fmt"
+	"sort"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+// tenantBudget is what the tenant of a user has left while its grants are
+// synced
+type tenantBudget struct {
+	tenant *netsys_v1.DispatchTenant
+	// refusal applies to every grant of the user, e.g. its tenant does not
+	// exist or it is one user too many
+	refusal string
+	// namespaces held by the tenant's users, keyed like grants
+	held map[string]bool
+	// namespaces held by users outside the tenant
+	foreign map[string]bool
+}
+
+// tenantBudget loads the budget of the tenant u belongs to. It returns nil
+// if u belongs to no tenant or tenants are disabled.
+func (duc *DispatchUserController) tenantBudget(u *netsys_v1.DispatchUser) (*tenantBudget, error) {
+	name := u.Labels[netsys_v1.TenantLabel]
+	if name == "" || !duc.config.Tenants.Enabled() {
+		return nil, nil
+	}
+	netsys := duc.clientsets.NetsysClient.NetsysV1()
+	b := &tenantBudget{held: map[string]bool{}, foreign: map[string]bool{}}
+	tenants, err := netsys.DispatchTenants(duc.config.DispatchNamespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	all := make([]*netsys_v1.DispatchTenant, len(tenants.Items))
+	for i := range tenants.Items {
+		all[i] = &tenants.Items[i]
+		if all[i].Name == name {
+			b.tenant = all[i]
+		}
+	}
+	if b.tenant == nil {
+		b.refusal = fmt.Sprintf("tenant %s does not exist", name)
+		return b, nil
+	}
+	if conflict := b.tenant.Conflict(all); conflict != "" {
+		b.refusal = fmt.Sprintf("tenant %s is refused: %s", name, conflict)
+		return b, nil
+	}
+
+	users, err := netsys.DispatchUsers(duc.config.DispatchNamespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	tenantOf := map[string]string{}
+	var members []*netsys_v1.DispatchUser
+	for i := range users.Items {
+		du := &users.Items[i]
+		tenantOf[du.Spec.UserID] = du.Labels[netsys_v1.TenantLabel]
+		if du.Labels[netsys_v1.TenantLabel] == name {
+			members = append(members, du)
+		}
+	}
+	if max := b.tenant.Spec.MaxUsers; max > 0 && len(members) > max {
+		// the oldest users keep their grants
+		sort.Slice(members, func(i, j int) bool {
+			if !members[i].CreationTimestamp.Equal(&members[j].CreationTimestamp) {
+				return members[i].CreationTimestamp.Before(&members[j].CreationTimestamp)
+			}
+			return members[i].Name < members[j].Name
+		})
+		for _, du := range members[max:] {
+			if du.Name == u.Name {
+				b.refusal = fmt.Sprintf("tenant %s has more than its maximum of %d users", name, max)
+				return b, nil
+			}
+		}
+	}
+
+	owned, err := netsys.OwnedNamespaces(duc.config.DispatchNamespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, on := range owned.Items {
+		if tenantOf[on.Spec.OwnerID] == name {
+			b.held[on.Key()] = true
+		} else {
+			b.foreign[on.Key()] = true
+		}
+	}
+	return b, nil
+}
+
+// refuse returns why g cannot be held by a user of the tenant, or "" if
+// it can. Namespaces the tenant already holds stay within the budget as
+// long as the tenant allows them, new ones are counted against it.
+func (b *tenantBudget) refuse(g netsys_v1.NamespaceGrant) string {
+	if b.refusal != "" {
+		return b.refusal
+	}
+	if reason := b.tenant.Allows(g); reason != "" {
+		return reason
+	}
+	k := g.Key()
+	if b.held[k] {
+		return ""
+	}
+	if b.foreign[k] {
+		return fmt.Sprintf("namespace %s is held by users outside tenant %s", k, b.tenant.Name)
+	}
+	if max := b.tenant.Spec.MaxNamespaces; max > 0 && len(b.held) >= max {
+		return fmt.Sprintf("tenant %s holds its maximum of %d namespaces", b.tenant.Name, max)
+	}
+	b.held[k] = true
+	return ""
+}
@@ -0,0 +1,616 @@
+// Package elevation grants the temporary roles users ask for with
+// AccessElevations. Elevations are checked against the configured policy,
+// approved by it or by an approver, bound with a RoleBinding for their
+// duration and unbound when the time is up. Every step is audited.
+package elevation
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/audit"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+)
+
+const (
+	// label of this controller's metrics
+	controllerName = "elevation"
+
+	// failed events are retried this often, backing off linearly
+	maxRetries   = 5
+	retryBackoff = 2 * time.Second
+)
+
+// ElevationController grants and removes the roles of AccessElevations
+type ElevationController struct {
+	elevationLister netsys_lister.AccessElevationLister
+	duLister        netsys_lister.DispatchUserLister
+
+	elevationListerSynced cache.InformerSynced
+	duListerSynced        cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on AccessElevations
+	recorder record.EventRecorder
+
+	// records every step of an elevation
+	auditor audit.Auditor
+
+	// Buffered channel of events to be done
+	workqueue chan elevationEvent
+
+	// follows queued and in-flight events for health checks
+	tracker *health.Tracker
+
+	// sync active elevations again when they expire, by key
+	timersLock sync.Mutex
+	timers     map[string]*time.Timer
+}
+
+type elevationEvent struct {
+	// sync or delete
+	action string
+	key    string
+	// the deleted elevation
+	old *netsys_v1.AccessElevation
+
+	// how often processing the event failed
+	retries int
+}
+
+// NewElevationController creates a new ElevationController
+func NewElevationController(
+	elevationInformer netsys_informer.AccessElevationInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+	auditor audit.Auditor,
+) *ElevationController {
+	ec := &ElevationController{
+		elevationLister:       elevationInformer.Lister(),
+		duLister:              duInformer.Lister(),
+		elevationListerSynced: elevationInformer.Informer().HasSynced,
+		duListerSynced:        duInformer.Informer().HasSynced,
+		clientsets:            clientSets,
+		config:                cfg,
+		recorder:              recorder,
+		auditor:               auditor,
+		workqueue:             make(chan elevationEvent, 100),
+		tracker:               health.NewTracker(controllerName),
+		timers:                map[string]*time.Timer{},
+	}
+	dispatchNamespace := cfg.DispatchNamespace
+
+	sync := func(obj interface{}) {
+		e := obj.(*netsys_v1.AccessElevation)
+		if e.Namespace != dispatchNamespace {
+			return
+		}
+		ec.enqueue(elevationEvent{action: "sync", key: e.Namespace + "/" + e.Name})
+	}
+	elevationInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    sync,
+		UpdateFunc: func(oldObj, newObj interface{}) { sync(newObj) },
+		DeleteFunc: func(obj interface{}) {
+			e := obj.(*netsys_v1.AccessElevation)
+			if e.Namespace != dispatchNamespace {
+				return
+			}
+			ec.enqueue(elevationEvent{action: "delete", key: e.Namespace + "/" + e.Name, old: e})
+		},
+	})
+
+	// end the elevations of users as soon as they are suspended or expire
+	duInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, u := oldObj.(*netsys_v1.DispatchUser), newObj.(*netsys_v1.DispatchUser)
+			if (u.Spec.Suspended && !old.Spec.Suspended) || (u.Status.Expired && !old.Status.Expired) {
+				ec.enqueueUser(u.Spec.UserID)
+			}
+		},
+	})
+
+	metrics.RegisterWorkqueue(controllerName, func() int { return len(ec.workqueue) })
+
+	return ec
+}
+
+// Run begins watching and syncing with a single worker, elevations are
+// few and short to process
+func (ec *ElevationController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, ec.elevationListerSynced, ec.duListerSynced) {
+		return
+	}
+
+	// return only once the event in flight is done, a leader keeps its
+	// lease until then
+	wait.Until(func() { ec.worker(stopCh) }, time.Second, stopCh)
+
+	ec.timersLock.Lock()
+	defer ec.timersLock.Unlock()
+	for _, t := range ec.timers {
+		t.Stop()
+	}
+}
+
+// Tracker returns the tracker of the controller's work items
+func (ec *ElevationController) Tracker() *health.Tracker {
+	return ec.tracker
+}
+
+// worker processes events until stopCh is closed
+func (ec *ElevationController) worker(stopCh <-chan struct{}) {
+	logging.Debug("Starting worker", "controller", controllerName)
+	for ec.processNextWorkItem(stopCh) {
+	}
+}
+
+func (ec *ElevationController) processNextWorkItem(stopCh <-chan struct{}) bool {
+	var event elevationEvent
+	select {
+	case event = <-ec.workqueue:
+	case <-stopCh:
+		return false
+	}
+	start := time.Now()
+	id := ec.tracker.Started(event.action, event.key)
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID(), "action", event.action,
+		"elevation", event.key)
+
+	var err error
+	switch event.action {
+	case "sync":
+		err = ec.sync(event.key, logger)
+	case "delete":
+		err = ec.deleted(event.old, logger)
+	default:
+		err = fmt.Errorf("event action not recognized %s", event.action)
+	}
+
+	ec.tracker.Done(id, err, event.retries)
+	metrics.ReconcileTotal.Inc(controllerName, event.action)
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err, "retries", event.retries)
+		metrics.ReconcileErrors.Inc(controllerName, event.action)
+		ec.retry(event)
+	}
+	return true
+}
+
+// retry queues a failed event again after a backoff
+func (ec *ElevationController) retry(e elevationEvent) {
+	if e.retries >= maxRetries {
+		logging.Error("Giving up after retries", "controller", controllerName, "elevation", e.key, "retries", e.retries)
+		return
+	}
+	e.retries++
+	metrics.WorkqueueRetries.Inc(controllerName)
+
+	go func() {
+		time.Sleep(time.Duration(e.retries) * retryBackoff)
+		ec.enqueue(e)
+	}()
+}
+
+// enqueue puts an event on the work queue
+func (ec *ElevationController) enqueue(e elevationEvent) {
+	ec.tracker.Queued(e.action, e.key)
+	ec.workqueue <- e
+}
+
+// enqueueUser syncs the unfinished elevations of userID
+func (ec *ElevationController) enqueueUser(userID string) {
+	elevations, err := ec.elevationLister.AccessElevations(ec.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return
+	}
+	for _, e := range elevations {
+		if e.Spec.UserID == userID && !e.Finished() {
+			ec.enqueue(elevationEvent{action: "sync", key: e.Namespace + "/" + e.Name})
+		}
+	}
+}
+
+// sync moves an elevation on to its next phase
+func (ec *ElevationController) sync(key string, logger *logging.Logger) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	e, err := ec.elevationLister.AccessElevations(namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	e = e.DeepCopy()
+	logger = logger.With("user", e.Spec.UserID, "namespace", e.Spec.Namespace, "role", e.Spec.Role)
+	now := time.Now()
+
+	if e.Status.Phase != "" && !e.Finished() && e.Status.ObservedGeneration != e.Generation {
+		// only what was requested is approved and bound
+		return ec.revoke(e, "the elevation changed after it was requested", now, logger)
+	}
+	switch e.Status.Phase {
+	case "":
+		return ec.request(e, now, logger)
+	case netsys_v1.ElevationPending:
+		return ec.autoApprove(e, now, logger)
+	case netsys_v1.ElevationApproved:
+		return ec.start(e, now, logger)
+	case netsys_v1.ElevationActive:
+		if e.Status.StartedAt == nil {
+			return ec.revoke(e, "the elevation has no start time", now, logger)
+		}
+		// the expiry follows from the spec, status.expiresAt is only shown
+		expires := e.Status.StartedAt.Add(e.Spec.Duration.Duration)
+		if !now.Before(expires) {
+			e.Status.Phase = netsys_v1.ElevationExpired
+			return ec.end(e, "expired", now, logger)
+		}
+		if reason := ec.refusal(e, now); reason != "" {
+			return ec.revoke(e, reason, now, logger)
+		}
+		// recreate the binding if someone removed it
+		if err := ec.bind(e); err != nil {
+			return err
+		}
+		ec.schedule(key, expires, now)
+	case netsys_v1.ElevationRevoked:
+		if e.Status.EndedAt == nil {
+			return ec.end(e, decision("revoked", e.Status.DecidedBy, e.Status.Message), now, logger)
+		}
+	case netsys_v1.ElevationDenied:
+		if e.Status.EndedAt == nil {
+			return ec.end(e, decision("denied", e.Status.DecidedBy, e.Status.Message), now, logger)
+		}
+	}
+	return nil
+}
+
+// refusal returns why the policy does not allow e now, or "" if it does.
+// It is checked from the spec whenever a role is about to be bound, as the
+// status may have been written by someone else than the controller.
+func (ec *ElevationController) refusal(e *netsys_v1.AccessElevation, now time.Time) string {
+	policy := ec.config.Elevation
+	_, refusal := ec.user(e.Spec.UserID, now)
+	switch {
+	case !netsys_v1.ValidRole(e.Spec.Role):
+		refusal = fmt.Sprintf("invalid role %q", e.Spec.Role)
+	case ec.config.IsProtected(e.Spec.Namespace):
+		refusal = fmt.Sprintf("namespace %s is protected", e.Spec.Namespace)
+	case e.Spec.Duration.Duration <= 0 || e.Spec.Duration.Duration > policy.MaxDuration.Duration:
+		refusal = fmt.Sprintf("duration must be positive and at most %s", policy.MaxDuration.Duration)
+	}
+	return refusal
+}
+
+// unapproved returns why the decision on an approved elevation does not
+// hold, or "" if it does: the policy must auto-approve it, or the approver
+// must be allowed to decide on it by the current policy
+func (ec *ElevationController) unapproved(e *netsys_v1.AccessElevation, now time.Time) string {
+	requester, _ := ec.user(e.Spec.UserID, now)
+	if e.Status.DecidedBy == netsys_v1.ElevationPolicy {
+		if requester != nil && ec.config.Elevation.AutoApproves(e.Spec.Role, e.Spec.Namespace, e.Spec.Duration.Duration,
+			requester.Spec.Groups, requester.Spec.HasNamespace(e.Spec.Namespace)) {
+			return ""
+		}
+		return "the policy does not approve the elevation"
+	}
+	// the approvers in the status are only trusted as far as the policy
+	// agrees
+	checked := e.DeepCopy()
+	checked.Status.Approvers = ec.config.Elevation.Approvers
+	checked.Status.ApproverGroups = ec.config.Elevation.ApproverGroups
+	reviewer, _ := ec.user(e.Status.DecidedBy, now)
+	var groups []string
+	if reviewer != nil {
+		groups = reviewer.Spec.Groups
+	}
+	var owned *netsys_v1.OwnedNamespace
+	if reviewer != nil {
+		owned = ec.ownedNamespace(reviewer.Spec.UserID, e.Spec.Namespace)
+	}
+	if e.Status.DecidedBy == "" || !checked.MayDecide(e.Status.DecidedBy, groups, reviewer, owned, ec.config.DefaultRole, now) {
+		return fmt.Sprintf("%q may not approve the elevation", e.Status.DecidedBy)
+	}
+	return ""
+}
+
+// revoke ends an unfinished elevation by policy
+func (ec *ElevationController) revoke(e *netsys_v1.AccessElevation, reason string, now time.Time, logger *logging.Logger) error {
+	e.Status.Phase = netsys_v1.ElevationRevoked
+	e.Status.DecidedBy = netsys_v1.ElevationPolicy
+	e.Status.Message = reason
+	return ec.end(e, reason, now, logger)
+}
+
+// request checks a new elevation against the policy and either denies it
+// or leaves it pending
+func (ec *ElevationController) request(e *netsys_v1.AccessElevation, now time.Time, logger *logging.Logger) error {
+	policy := ec.config.Elevation
+	refusal := ec.refusal(e, now)
+
+	e.Status.Phase = netsys_v1.ElevationPending
+	e.Status.ObservedGeneration = e.Generation
+	e.Status.Approvers = policy.Approvers
+	e.Status.ApproverGroups = policy.ApproverGroups
+	if refusal != "" {
+		if err := e.Deny(netsys_v1.ElevationPolicy, refusal, meta_v1.NewTime(now)); err != nil {
+			return err
+		}
+		e.Status.EndedAt = e.Status.DecidedAt
+	}
+	if _, err := ec.update(e); err != nil {
+		return err
+	}
+
+	ec.audit(e, audit.ActionElevationRequested, e.Spec.Reason)
+	logger.Info("Elevation requested", "duration", e.Spec.Duration.Duration, "reason", e.Spec.Reason)
+	ec.recorder.Eventf(e, core_v1.EventTypeNormal, controller.ElevationRequested,
+		"%s asked for %s in namespace %s for %s: %s", e.Spec.UserID, e.Spec.Role, e.Spec.Namespace,
+		e.Spec.Duration.Duration, e.Spec.Reason)
+	if refusal != "" {
+		reason := decision("denied", netsys_v1.ElevationPolicy, refusal)
+		ec.audit(e, audit.ActionElevationDenied, reason)
+		logger.Info("Elevation denied", "reason", reason)
+		ec.recorder.Eventf(e, core_v1.EventTypeWarning, controller.ElevationDenied, "Elevation %s", reason)
+	}
+	return nil
+}
+
+// autoApprove starts a pending elevation that matches an auto-approval
+// rule, others wait for an approver
+func (ec *ElevationController) autoApprove(e *netsys_v1.AccessElevation, now time.Time, logger *logging.Logger) error {
+	u, _ := ec.user(e.Spec.UserID, now)
+	if u == nil || !ec.config.Elevation.AutoApproves(e.Spec.Role, e.Spec.Namespace, e.Spec.Duration.Duration,
+		u.Spec.Groups, u.Spec.HasNamespace(e.Spec.Namespace)) {
+		return nil
+	}
+	if err := e.Approve(netsys_v1.ElevationPolicy, meta_v1.NewTime(now)); err != nil {
+		return err
+	}
+	return ec.start(e, now, logger)
+}
+
+// start binds the role of an approved elevation for its duration once the
+// policy and the decision are checked again
+func (ec *ElevationController) start(e *netsys_v1.AccessElevation, now time.Time, logger *logging.Logger) error {
+	reason := ec.refusal(e, now)
+	if reason == "" {
+		reason = ec.unapproved(e, now)
+	}
+	if reason != "" {
+		return ec.revoke(e, reason, now, logger)
+	}
+	if err := ec.bind(e); err != nil {
+		ec.recorder.Eventf(e, core_v1.EventTypeWarning, controller.BindingFailed,
+			"Failed to create RoleBinding %s/%s: %v", e.Spec.Namespace, bindingName(e), err)
+		return err
+	}
+	// the API server stores times in seconds
+	started := meta_v1.NewTime(now.Truncate(time.Second))
+	expires := meta_v1.NewTime(started.Add(e.Spec.Duration.Duration))
+	e.Status.Phase = netsys_v1.ElevationActive
+	e.Status.StartedAt = &started
+	e.Status.ExpiresAt = &expires
+	if _, err := ec.update(e); err != nil {
+		return err
+	}
+
+	approval := decision("approved", e.Status.DecidedBy, "")
+	ec.audit(e, audit.ActionElevationApproved, approval)
+	ec.audit(e, audit.ActionElevationStarted, e.Spec.Reason)
+	logger.Info("Elevation started", "decidedBy", e.Status.DecidedBy, "expiresAt", expires.UTC().Format(time.RFC3339))
+	ec.recorder.Eventf(e, core_v1.EventTypeNormal, controller.ElevationApproved, "Elevation %s", approval)
+	ec.recorder.Eventf(e, core_v1.EventTypeNormal, controller.ElevationStarted,
+		"Bound %s in namespace %s until %s", e.Spec.Role, e.Spec.Namespace, expires.UTC().Format(time.RFC3339))
+	ec.schedule(e.Namespace+"/"+e.Name, expires.Time, now)
+	return nil
+}
+
+// end removes the binding of an elevation in its final phase and records
+// why it ended
+func (ec *ElevationController) end(e *netsys_v1.AccessElevation, reason string, now time.Time, logger *logging.Logger) error {
+	key := e.Namespace + "/" + e.Name
+	ec.stopTimer(key)
+	if err := ec.unbind(e); err != nil {
+		return err
+	}
+	ended := meta_v1.NewTime(now)
+	e.Status.EndedAt = &ended
+	if _, err := ec.update(e); err != nil {
+		return err
+	}
+
+	if e.Status.Phase == netsys_v1.ElevationDenied {
+		ec.audit(e, audit.ActionElevationDenied, reason)
+		logger.Info("Elevation denied", "reason", reason)
+		ec.recorder.Eventf(e, core_v1.EventTypeWarning, controller.ElevationDenied, "Elevation %s", reason)
+		return nil
+	}
+	ec.audit(e, audit.ActionElevationEnded, reason)
+	logger.Info("Elevation ended", "reason", reason)
+	ec.recorder.Eventf(e, core_v1.EventTypeNormal, controller.ElevationEnded,
+		"Removed %s in namespace %s: %s", e.Spec.Role, e.Spec.Namespace, reason)
+	return nil
+}
+
+// deleted removes the binding of an elevation deleted before it ended
+func (ec *ElevationController) deleted(e *netsys_v1.AccessElevation, logger *logging.Logger) error {
+	ec.stopTimer(e.Namespace + "/" + e.Name)
+	if e.Status.EndedAt != nil || (e.Status.Phase != netsys_v1.ElevationApproved && e.Status.Phase != netsys_v1.ElevationActive) {
+		return nil
+	}
+	if err := ec.unbind(e); err != nil {
+		return err
+	}
+	ec.audit(e, audit.ActionElevationEnded, "elevation deleted")
+	logger.Info("Elevation ended", "reason", "elevation deleted")
+	return nil
+}
+
+// user returns the DispatchUser with userID, or nil and why the user may
+// not hold an elevation
+func (ec *ElevationController) user(userID string, now time.Time) (*netsys_v1.DispatchUser, string) {
+	users, err := ec.duLister.DispatchUsers(ec.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return nil, err.Error()
+	}
+	for _, u := range users {
+		if u.Spec.UserID != userID {
+			continue
+		}
+		if u.Expired(now) {
+			return nil, fmt.Sprintf("access of %s expired", userID)
+		}
+		if u.Spec.Suspended {
+			return nil, fmt.Sprintf("%s is suspended", userID)
+		}
+		return u, ""
+	}
+	return nil, fmt.Sprintf("no DispatchUser with user ID %s", userID)
+}
+
+// ownedNamespace returns the OwnedNamespace of userID for the namespace
+// key, or nil if there is none or it cannot be read
+func (ec *ElevationController) ownedNamespace(userID, key string) *netsys_v1.OwnedNamespace {
+	on, err := ec.clientsets.NetsysClient.NetsysV1().OwnedNamespaces(ec.config.DispatchNamespace).
+		Get(controller.OwnedNamespaceName(userID, key), meta_v1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	return on
+}
+
+// bindingName is the name of the RoleBinding of an elevation in its namespace
+func bindingName(e *netsys_v1.AccessElevation) string {
+	return "elevation-" + e.Name
+}
+
+// the label on the RoleBindings of elevations, set to the elevation's name
+const elevationLabel = "netsys.io/elevation"
+
+// bind creates the RoleBinding of an elevation unless it exists
+func (ec *ElevationController) bind(e *netsys_v1.AccessElevation) error {
+	rb := rbac_v1.RoleBinding{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      bindingName(e),
+			Namespace: e.Spec.Namespace,
+			Labels:    map[string]string{elevationLabel: e.Name},
+		},
+		Subjects: []rbac_v1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      e.Spec.UserID,
+				Namespace: ec.config.DispatchNamespace,
+			},
+		},
+		RoleRef: rbac_v1.RoleRef{
+			Kind:     "ClusterRole",
+			Name:     e.Spec.Role,
+			APIGroup: "rbac.authorization.k8s.io",
+		},
+	}
+	_, err := ec.clientsets.OriginalClient.RbacV1().RoleBindings(e.Spec.Namespace).Create(&rb)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// unbind removes the RoleBindings of an elevation, including those in
+// namespaces its spec named before it was changed
+func (ec *ElevationController) unbind(e *netsys_v1.AccessElevation) error {
+	rbs := ec.clientsets.OriginalClient.RbacV1()
+	list, err := rbs.RoleBindings("").List(meta_v1.ListOptions{LabelSelector: elevationLabel + "=" + e.Name})
+	if err != nil {
+		return err
+	}
+	bindings := list.Items
+	// bindings created before they were labeled
+	bindings = append(bindings, rbac_v1.RoleBinding{ObjectMeta: meta_v1.ObjectMeta{Name: bindingName(e), Namespace: e.Spec.Namespace}})
+	for _, rb := range bindings {
+		if err := rbs.RoleBindings(rb.Namespace).Delete(rb.Name, nil); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// update writes the status of an elevation, which clients cannot change
+// without the status subresource
+func (ec *ElevationController) update(e *netsys_v1.AccessElevation) (*netsys_v1.AccessElevation, error) {
+	return ec.clientsets.NetsysClient.NetsysV1().AccessElevations(e.Namespace).UpdateStatus(e)
+}
+
+func (ec *ElevationController) audit(e *netsys_v1.AccessElevation, action, reason string) {
+	ec.auditor.Record(audit.Record{
+		Action:    action,
+		User:      e.Spec.UserID,
+		Namespace: e.Spec.Namespace,
+		Role:      e.Spec.Role,
+		Reason:    reason,
+		Source:    "AccessElevation/" + e.Namespace + "/" + e.Name,
+	})
+}
+
+// decision describes who decided on an elevation, e.g. "denied by policy:
+// namespace kube-system is protected"
+func decision(what, by, message string) string {
+	s := what + " by " + by
+	if message != "" {
+		s += ": " + message
+	}
+	return s
+}
+
+// schedule syncs the elevation with key again when it expires
+func (ec *ElevationController) schedule(key string, expires, now time.Time) {
+	ec.stopTimer(key)
+	ec.timersLock.Lock()
+	defer ec.timersLock.Unlock()
+	// a second late so the expiry has passed when the sync runs
+	ec.timers[key] = time.AfterFunc(expires.Sub(now)+time.Second, func() {
+		ec.enqueue(elevationEvent{action: "sync", key: key})
+	})
+}
+
+func (ec *ElevationController) stopTimer(key string) {
+	ec.timersLock.Lock()
+	defer ec.timersLock.Unlock()
+	if t, ok := ec.timers[key]; ok {
+		t.Stop()
+		delete(ec.timers, key)
+	}
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typed_core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	netsys_scheme "github.com/hantaowang/dispatch/pkg/client/clientset/versioned/scheme"
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// Reasons of the Events dispatch records on its objects
+const (
+	ServiceAccountCreated = "ServiceAccountCreated"
+	ServiceAccountDeleted = "ServiceAccountDeleted"
+	CredentialsNotRevoked = "CredentialsNotRevoked"
+	NamespaceCreated      = "NamespaceCreated"
+	GrantAdded            = "GrantAdded"
+	GrantRevoked          = "GrantRevoked"
+	RoleChanged           = "RoleChanged"
+	GrantRefused          = "GrantRefused"
+	GrantQueued           = "GrantQueued"
+	SyncFailed            = "SyncFailed"
+
+	UserExpiring  = "UserExpiring"
+	UserExpired   = "UserExpired"
+	GrantExpiring = "GrantExpiring"
+	GrantExpired  = "GrantExpired"
+
+	UserSuspended = "UserSuspended"
+	UserResumed   = "UserResumed"
+
+	LeaseExpiring      = "LeaseExpiring"
+	LeaseExpired       = "LeaseExpired"
+	LeaseRenewed       = "LeaseRenewed"
+	NamespaceReclaimed = "NamespaceReclaimed"
+
+	NamespaceIdle       = "NamespaceIdle"
+	NamespaceHibernated = "NamespaceHibernated"
+
+	ScheduleInvalid = "ScheduleInvalid"
+	WindowOpened    = "WindowOpened"
+	WindowClosed    = "WindowClosed"
+
+	RecertificationRequired = "RecertificationRequired"
+	GrantNotRecertified     = "GrantNotRecertified"
+
+	// recorded on AccessElevations
+	ElevationRequested = "ElevationRequested"
+	ElevationApproved  = "ElevationApproved"
+	ElevationDenied    = "ElevationDenied"
+	ElevationStarted   = "ElevationStarted"
+	ElevationEnded     = "ElevationEnded"
+
+	// recorded on SubNamespaces
+	SubNamespaceCreated  = "SubNamespaceCreated"
+	SubNamespaceRefused  = "SubNamespaceRefused"
+	SubNamespaceDeleted  = "SubNamespaceDeleted"
+	SubNamespaceOrphaned = "SubNamespaceOrphaned"
+
+	// recorded on DispatchTenants
+	TenantRefused    = "TenantRefused"
+	TenantOverBudget = "TenantOverBudget"
+
+	// recorded on NamespaceInvitations
+	InvitationWithdrawn = "InvitationWithdrawn"
+
+	// recorded on DispatchRobots
+	RobotDisabled     = "RobotDisabled"
+	RobotEnabled      = "RobotEnabled"
+	RobotGrantRefused = "RobotGrantRefused"
+	RobotTokenRotated = "RobotTokenRotated"
+
+	BindingCreated  = "BindingCreated"
+	BindingReplaced = "BindingReplaced"
+	BindingDeleted  = "BindingDeleted"
+	BindingFailed   = "BindingFailed"
+	QuotaCreated    = "QuotaCreated"
+)
+
+// NewEventRecorder returns a recorder that writes Events to the API server
+// and logs them
+func NewEventRecorder(client kubernetes.Interface) record.EventRecorder {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	netsys_scheme.AddToScheme(s)
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartEventWatcher(func(e *core_v1.Event) {
+		logging.Debug("Recorded event", "reason", e.Reason, "object", e.InvolvedObject.Kind+"/"+e.InvolvedObject.Name,
+			"message", e.Message)
+	})
+	broadcaster.StartRecordingToSink(&typed_core_v1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	return broadcaster.NewRecorder(s, core_v1.EventSource{Component: "dispatch"})
+}
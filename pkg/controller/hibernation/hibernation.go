@@ -0,0 +1,243 @@
+// Package hibernation watches granted namespaces for activity. Namespaces
+// idle for longer than configured are reported and, if enabled, hibernated.
+package hibernation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/hibernate"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+	"github.com/hantaowang/dispatch/pkg/notify"
+)
+
+const (
+	// label of this controller's metrics
+	controllerName = "hibernation"
+
+	// how often namespaces are checked, listing every pod is not cheap
+	syncPeriod = 5 * time.Minute
+)
+
+// HibernationController checks the granted namespaces for activity
+type HibernationController struct {
+	onLister netsys_lister.OwnedNamespaceLister
+	duLister netsys_lister.DispatchUserLister
+
+	onListerSynced cache.InformerSynced
+	duListerSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on the DispatchUsers holding a namespace
+	recorder record.EventRecorder
+
+	// tells owners their namespace was hibernated
+	notifier notify.Notifier
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+
+	// namespaces with an idle time metric, to drop those no longer granted
+	measured map[string]bool
+}
+
+// NewHibernationController creates a new HibernationController
+func NewHibernationController(
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+	notifier notify.Notifier,
+) *HibernationController {
+	return &HibernationController{
+		onLister:       onInformer.Lister(),
+		duLister:       duInformer.Lister(),
+		onListerSynced: onInformer.Informer().HasSynced,
+		duListerSynced: duInformer.Informer().HasSynced,
+		clientsets:     clientSets,
+		config:         cfg,
+		recorder:       recorder,
+		notifier:       notifier,
+		tracker:        health.NewTracker(controllerName),
+		measured:       map[string]bool{},
+	}
+}
+
+// Run checks the namespaces every five minutes until stopCh is closed
+func (hc *HibernationController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, hc.onListerSynced, hc.duListerSynced) {
+		return
+	}
+	wait.Until(hc.sync, syncPeriod, stopCh)
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (hc *HibernationController) Tracker() *health.Tracker {
+	return hc.tracker
+}
+
+func (hc *HibernationController) sync() {
+	start := time.Now()
+	id := hc.tracker.Started("sync", "namespaces")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := hc.syncNamespaces(time.Now(), logger)
+
+	hc.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// syncNamespaces checks every granted namespace once
+func (hc *HibernationController) syncNamespaces(now time.Time, logger *logging.Logger) error {
+	owned, err := hc.onLister.OwnedNamespaces(hc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	owners := map[string][]string{}
+	for _, on := range owned {
+		// workloads are only watched in the cluster dispatch runs in
+		if on.Spec.Cluster == "" && !hc.config.IsProtected(on.Spec.Namespace) {
+			owners[on.Spec.Namespace] = append(owners[on.Spec.Namespace], on.Spec.OwnerID)
+		}
+	}
+	namespaces := make([]string, 0, len(owners))
+	for ns := range owners {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	var errs []string
+	measured := map[string]bool{}
+	for _, ns := range namespaces {
+		l := logger.With("namespace", ns)
+		ok, err := hc.syncNamespace(ns, owners[ns], now, l)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", ns, err))
+		}
+		if ok {
+			measured[ns] = true
+		}
+	}
+	for ns := range hc.measured {
+		if !measured[ns] {
+			metrics.NamespaceIdleSeconds.Delete(ns)
+		}
+	}
+	hc.measured = measured
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// syncNamespace reports and hibernates namespace if it is idle. It returns
+// true if the idle time of the namespace was measured.
+func (hc *HibernationController) syncNamespace(namespace string, owners []string, now time.Time, logger *logging.Logger) (bool, error) {
+	ns, err := hc.clientsets.OriginalClient.CoreV1().Namespaces().Get(namespace, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if hibernate.Hibernated(ns) {
+		return false, nil
+	}
+	last, err := hibernate.LastActivity(hc.clientsets.OriginalClient, ns, hc.config.Hibernation.Signals)
+	if err != nil {
+		return false, err
+	}
+	idle := now.Sub(last)
+	metrics.NamespaceIdleSeconds.Set(idle.Seconds(), namespace)
+
+	_, marked := ns.Annotations[hibernate.IdleAnnotation]
+	if idle < hc.config.Hibernation.IdleAfter.Duration {
+		if marked {
+			logger.Info("Namespace is active again")
+			return true, hibernate.MarkIdle(hc.clientsets.OriginalClient, namespace, time.Time{})
+		}
+		return true, nil
+	}
+
+	since := last.UTC().Format(time.RFC3339)
+	if !hc.config.Hibernation.Hibernate {
+		if marked {
+			return true, nil
+		}
+		logger.Info("Namespace is idle", "lastActivity", since)
+		hc.event(owners, core_v1.EventTypeNormal, controller.NamespaceIdle,
+			"Namespace %s has been idle since %s", namespace, since)
+		return true, hibernate.MarkIdle(hc.clientsets.OriginalClient, namespace, last)
+	}
+
+	result, err := hibernate.Hibernate(hc.clientsets.OriginalClient, namespace, hibernate.ByIdle, now)
+	if err != nil {
+		return true, err
+	}
+	metrics.NamespaceIdleSeconds.Delete(namespace)
+	logger.Info("Hibernated idle namespace", "lastActivity", since, "deployments", result.Deployments,
+		"statefulsets", result.StatefulSets, "cronjobs", result.CronJobs)
+	hc.event(owners, core_v1.EventTypeWarning, controller.NamespaceHibernated,
+		"Namespace %s was idle since %s and was hibernated, scaling down %s", namespace, since, result)
+	for _, owner := range owners {
+		hc.notifier.Notify(notify.Notification{
+			Type:      notify.Hibernated,
+			User:      owner,
+			Namespace: namespace,
+			Time:      now,
+			Message: fmt.Sprintf("Namespace %s was idle since %s and was scaled down, wake it when you need it again",
+				namespace, since),
+		})
+	}
+	return false, nil
+}
+
+// event records an Event on the DispatchUser of every owner
+func (hc *HibernationController) event(owners []string, eventType, reason, format string, args ...interface{}) {
+	users, err := hc.duLister.DispatchUsers(hc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return
+	}
+	byID := make(map[string]*netsys_v1.DispatchUser, len(users))
+	for _, u := range users {
+		byID[u.Spec.UserID] = u
+	}
+	for _, owner := range owners {
+		if u, ok := byID[owner]; ok {
+			hc.recorder.Eventf(u, eventType, reason, format, args...)
+		}
+	}
+}
@@ -0,0 +1,485 @@
+// Package hierarchy keeps sub-namespaces in line with their parents. Every
+// SubNamespace gets its child namespace, which inherits the owners,
+// RoleBindings, NetworkPolicies and propagated Secrets of its parent and a
+// slice of the parent's quota. When a parent is deleted its children are
+// deleted or orphaned according to their cascade policy.
+package hierarchy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/audit"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+)
+
+// label of this controller's metrics
+const controllerName = "hierarchy"
+
+// HierarchyController creates the namespaces of SubNamespaces and keeps
+// what they inherit from their parents up to date
+type HierarchyController struct {
+	snLister netsys_lister.SubNamespaceLister
+	onLister netsys_lister.OwnedNamespaceLister
+
+	snListerSynced cache.InformerSynced
+	onListerSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on SubNamespaces
+	recorder record.EventRecorder
+
+	// records sub-namespaces created and deleted
+	auditor audit.Auditor
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+}
+
+// NewHierarchyController creates a new HierarchyController
+func NewHierarchyController(
+	snInformer netsys_informer.SubNamespaceInformer,
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+	auditor audit.Auditor,
+) *HierarchyController {
+	return &HierarchyController{
+		snLister:       snInformer.Lister(),
+		onLister:       onInformer.Lister(),
+		snListerSynced: snInformer.Informer().HasSynced,
+		onListerSynced: onInformer.Informer().HasSynced,
+		clientsets:     clientSets,
+		config:         cfg,
+		recorder:       recorder,
+		auditor:        auditor,
+		tracker:        health.NewTracker(controllerName),
+	}
+}
+
+// Run syncs the sub-namespaces every hierarchy.interval until stopCh is
+// closed
+func (hc *HierarchyController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, hc.snListerSynced, hc.onListerSynced) {
+		return
+	}
+	wait.Until(hc.sync, hc.config.Hierarchy.Interval.Duration, stopCh)
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (hc *HierarchyController) Tracker() *health.Tracker {
+	return hc.tracker
+}
+
+func (hc *HierarchyController) sync() {
+	start := time.Now()
+	id := hc.tracker.Started("sync", "subnamespaces")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := hc.syncHierarchy(time.Now(), logger)
+
+	hc.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// tree is the hierarchy as seen at the start of a sync
+type tree struct {
+	// SubNamespaces by the name of their namespace
+	subs map[string]*netsys_v1.SubNamespace
+	// user IDs holding a namespace through an OwnedNamespace
+	holders map[string][]string
+}
+
+// owners returns the users holding namespace, directly or through the
+// parents of the sub-namespaces it is under
+func (t tree) owners(namespace string) []string {
+	seen := map[string]bool{}
+	var owners []string
+	// a child's name is longer than its parent's, so this ends
+	for namespace != "" {
+		for _, o := range t.holders[namespace] {
+			if !seen[o] {
+				seen[o] = true
+				owners = append(owners, o)
+			}
+		}
+		sn, ok := t.subs[namespace]
+		if !ok || sn.Status.Phase == netsys_v1.SubNamespaceOrphaned {
+			break
+		}
+		namespace = sn.Spec.Parent
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+// syncHierarchy admits new sub-namespaces, creates their namespaces,
+// copies what they inherit, carves their quota out of their parents' and
+// cleans up after deleted parents and SubNamespaces
+func (hc *HierarchyController) syncHierarchy(now time.Time, logger *logging.Logger) error {
+	subs, err := hc.snLister.SubNamespaces(hc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	owned, err := hc.onLister.OwnedNamespaces(hc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	t := tree{subs: map[string]*netsys_v1.SubNamespace{}, holders: map[string][]string{}}
+	for _, on := range owned {
+		if on.Spec.Cluster == "" {
+			t.holders[on.Spec.Namespace] = append(t.holders[on.Spec.Namespace], on.Spec.OwnerID)
+		}
+	}
+	for _, sn := range subs {
+		t.subs[sn.Name] = sn
+	}
+	// parents are usually created first, so most children see their
+	// parent's namespace in the same sync
+	sort.Slice(subs, func(i, j int) bool {
+		if !subs[i].CreationTimestamp.Equal(&subs[j].CreationTimestamp) {
+			return subs[i].CreationTimestamp.Before(&subs[j].CreationTimestamp)
+		}
+		return subs[i].Name < subs[j].Name
+	})
+
+	var errs []string
+	refusals := map[string]string{}
+	// children whose parent is itself created in this sync
+	waiting := map[string]bool{}
+	var live []*netsys_v1.SubNamespace
+	isLive := map[string]bool{}
+	for _, sn := range subs {
+		if sn.Status.Phase == netsys_v1.SubNamespaceOrphaned {
+			continue
+		}
+		l := logger.With("namespace", sn.Name, "parent", sn.Spec.Parent)
+		var gone bool
+		if parent, ok := t.subs[sn.Spec.Parent]; ok && parent.Status.Phase != netsys_v1.SubNamespaceReady &&
+			isLive[parent.Name] && refusals[parent.Name] == "" {
+			waiting[sn.Name] = true
+		} else if gone, err = hc.parentGone(sn); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", sn.Name, err))
+			continue
+		}
+		if gone {
+			inherited, err := hc.inheritedFrom(sn.Name, sn.Spec.Parent)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", sn.Name, err))
+				continue
+			}
+			if inherited {
+				if err := hc.cascade(sn, now, l); err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", sn.Name, err))
+				}
+				continue
+			}
+			refusals[sn.Name] = fmt.Sprintf("parent namespace %s does not exist", sn.Spec.Parent)
+		} else if refusal, err := hc.check(sn, t); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", sn.Name, err))
+			continue
+		} else if refusal != "" {
+			refusals[sn.Name] = refusal
+		}
+		live = append(live, sn)
+		isLive[sn.Name] = true
+	}
+
+	quotas, err := hc.planQuotas(live, refusals, t)
+	if err != nil {
+		return err
+	}
+	// the parent of a waiting child may have been refused its quota
+	for _, sn := range live {
+		if waiting[sn.Name] && refusals[sn.Spec.Parent] != "" && refusals[sn.Name] == "" {
+			refusals[sn.Name] = fmt.Sprintf("parent namespace %s does not exist", sn.Spec.Parent)
+		}
+	}
+
+	for _, sn := range live {
+		l := logger.With("namespace", sn.Name, "parent", sn.Spec.Parent)
+		if err := hc.syncSubNamespace(sn, t, refusals[sn.Name], now, l); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", sn.Name, err))
+		}
+	}
+	for _, q := range quotas {
+		if err := hc.applyQuota(q, logger.With("namespace", q.namespace)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", q.namespace, err))
+		}
+	}
+	if err := hc.deleteAbandoned(t, logger); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// parentGone returns true if the parent namespace of sn does not exist or
+// is being deleted
+func (hc *HierarchyController) parentGone(sn *netsys_v1.SubNamespace) (bool, error) {
+	ns, err := hc.clientsets.OriginalClient.CoreV1().Namespaces().Get(sn.Spec.Parent, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ns.DeletionTimestamp != nil, nil
+}
+
+// inheritedFrom returns true if namespace exists and is a child of parent
+func (hc *HierarchyController) inheritedFrom(namespace, parent string) (bool, error) {
+	ns, err := hc.clientsets.OriginalClient.CoreV1().Namespaces().Get(namespace, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ns.Labels[netsys_v1.ParentLabel] == parent, nil
+}
+
+// check returns why sn cannot have its namespace, or "" if it can
+func (hc *HierarchyController) check(sn *netsys_v1.SubNamespace, t tree) (string, error) {
+	child, parent := sn.Name, sn.Spec.Parent
+	if !strings.HasPrefix(child, parent+"-") {
+		return fmt.Sprintf("name must start with %s-", parent), nil
+	}
+	if msgs := validation.IsDNS1123Label(child); len(msgs) > 0 {
+		return fmt.Sprintf("invalid namespace name: %s", strings.Join(msgs, ", ")), nil
+	}
+	if hc.config.IsProtected(parent) || hc.config.IsProtected(child) {
+		return fmt.Sprintf("namespace %s is protected", parent), nil
+	}
+	if sn.Spec.Cascade != "" && !netsys_v1.ValidCascade(sn.Spec.Cascade) {
+		return fmt.Sprintf("cascade %q must be Delete or Orphan", sn.Spec.Cascade), nil
+	}
+	owners := t.owners(parent)
+	if len(owners) == 0 {
+		return fmt.Sprintf("namespace %s has no owners", parent), nil
+	}
+	if by := sn.Spec.CreatedBy; by != "" && !contains(owners, by) {
+		return fmt.Sprintf("%s does not own namespace %s", by, parent), nil
+	}
+
+	ns, err := hc.clientsets.OriginalClient.CoreV1().Namespaces().Get(child, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if ns.Labels[netsys_v1.ParentLabel] != parent {
+		return fmt.Sprintf("namespace %s exists and is not labeled %s=%s", child, netsys_v1.ParentLabel, parent), nil
+	}
+	return "", nil
+}
+
+// syncSubNamespace refuses sn or creates its namespace and copies what it
+// inherits, then records the outcome in its status
+func (hc *HierarchyController) syncSubNamespace(sn *netsys_v1.SubNamespace, t tree, refusal string, now time.Time, logger *logging.Logger) error {
+	updated := sn.DeepCopy()
+	updated.Status.Owners = t.owners(sn.Spec.Parent)
+	var syncErr error
+	if refusal != "" {
+		updated.Status.Phase = netsys_v1.SubNamespaceRefused
+		updated.Status.Message = refusal
+	} else {
+		if err := hc.ensureNamespace(sn, logger); err != nil {
+			return err
+		}
+		syncErr = hc.inherit(sn.Spec.Parent, sn.Name)
+		updated.Status.Phase = netsys_v1.SubNamespaceReady
+		updated.Status.Message = ""
+		if syncErr != nil {
+			updated.Status.Message = fmt.Sprintf("inheriting from %s: %v", sn.Spec.Parent, syncErr)
+		}
+	}
+	if equality.Semantic.DeepEqual(sn.Status, updated.Status) {
+		return syncErr
+	}
+	updatedAt := meta_v1.NewTime(now)
+	updated.Status.UpdatedAt = &updatedAt
+	if _, err := hc.clientsets.NetsysClient.NetsysV1().SubNamespaces(sn.Namespace).Update(updated); err != nil {
+		return err
+	}
+	if refusal != "" && (sn.Status.Phase != netsys_v1.SubNamespaceRefused || sn.Status.Message != refusal) {
+		logger.Warn("Refused sub-namespace", "reason", refusal)
+		hc.recorder.Eventf(sn, core_v1.EventTypeWarning, controller.SubNamespaceRefused,
+			"Refused namespace %s under %s: %s", sn.Name, sn.Spec.Parent, refusal)
+	}
+	return syncErr
+}
+
+// ensureNamespace creates the namespace of sn unless it exists
+func (hc *HierarchyController) ensureNamespace(sn *netsys_v1.SubNamespace, logger *logging.Logger) error {
+	ns := &core_v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name: sn.Name,
+			Labels: map[string]string{
+				netsys_v1.CreatedByLabel: "dispatch",
+				netsys_v1.ParentLabel:    sn.Spec.Parent,
+			},
+		},
+	}
+	_, err := hc.clientsets.OriginalClient.CoreV1().Namespaces().Create(ns)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("Created sub-namespace", "createdBy", sn.Spec.CreatedBy)
+	hc.audit(sn, audit.ActionNamespaceCreated, "sub-namespace of "+sn.Spec.Parent)
+	hc.recorder.Eventf(sn, core_v1.EventTypeNormal, controller.SubNamespaceCreated,
+		"Created namespace %s under %s", sn.Name, sn.Spec.Parent)
+	return nil
+}
+
+// cascade applies the cascade policy of sn after its parent was deleted
+func (hc *HierarchyController) cascade(sn *netsys_v1.SubNamespace, now time.Time, logger *logging.Logger) error {
+	reason := fmt.Sprintf("parent namespace %s was deleted", sn.Spec.Parent)
+	policy := sn.Spec.Cascade
+	if policy == "" {
+		policy = hc.config.Hierarchy.Cascade
+	}
+	if policy == netsys_v1.CascadeOrphan {
+		updated := sn.DeepCopy()
+		updated.Status.Phase = netsys_v1.SubNamespaceOrphaned
+		updated.Status.Message = reason
+		updatedAt := meta_v1.NewTime(now)
+		updated.Status.UpdatedAt = &updatedAt
+		if _, err := hc.clientsets.NetsysClient.NetsysV1().SubNamespaces(sn.Namespace).Update(updated); err != nil {
+			return err
+		}
+		logger.Info("Orphaned sub-namespace", "reason", reason)
+		hc.recorder.Eventf(sn, core_v1.EventTypeNormal, controller.SubNamespaceOrphaned,
+			"Kept namespace %s: %s", sn.Name, reason)
+		return nil
+	}
+
+	if err := hc.deleteNamespace(sn.Name); err != nil {
+		return err
+	}
+	err := hc.clientsets.NetsysClient.NetsysV1().SubNamespaces(sn.Namespace).Delete(sn.Name, nil)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	logger.Info("Deleted sub-namespace", "reason", reason)
+	hc.audit(sn, audit.ActionNamespaceDeleted, reason)
+	hc.recorder.Eventf(sn, core_v1.EventTypeNormal, controller.SubNamespaceDeleted,
+		"Deleted namespace %s: %s", sn.Name, reason)
+	return nil
+}
+
+// deleteNamespace deletes a sub-namespace dispatch created, namespaces
+// that were adopted are left alone
+func (hc *HierarchyController) deleteNamespace(name string) error {
+	namespaces := hc.clientsets.OriginalClient.CoreV1().Namespaces()
+	ns, err := namespaces.Get(name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if ns.Labels[netsys_v1.CreatedByLabel] != "dispatch" || ns.DeletionTimestamp != nil {
+		return nil
+	}
+	err = namespaces.Delete(name, nil)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// deleteAbandoned deletes the sub-namespaces whose SubNamespace was
+// deleted. Namespaces an OwnedNamespace holds are kept.
+func (hc *HierarchyController) deleteAbandoned(t tree, logger *logging.Logger) error {
+	list, err := hc.clientsets.OriginalClient.CoreV1().Namespaces().List(meta_v1.ListOptions{
+		LabelSelector: netsys_v1.ParentLabel,
+	})
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, ns := range list.Items {
+		if _, ok := t.subs[ns.Name]; ok || len(t.holders[ns.Name]) > 0 {
+			continue
+		}
+		if ns.Labels[netsys_v1.CreatedByLabel] != "dispatch" || ns.DeletionTimestamp != nil {
+			continue
+		}
+		if err := hc.deleteNamespace(ns.Name); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", ns.Name, err))
+			continue
+		}
+		logger.Info("Deleted sub-namespace", "namespace", ns.Name, "reason", "SubNamespace deleted")
+		hc.auditor.Record(audit.Record{
+			Action:    audit.ActionNamespaceDeleted,
+			Namespace: ns.Name,
+			Reason:    "SubNamespace deleted",
+			Source:    "SubNamespace/" + hc.config.DispatchNamespace + "/" + ns.Name,
+		})
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (hc *HierarchyController) audit(sn *netsys_v1.SubNamespace, action, reason string) {
+	hc.auditor.Record(audit.Record{
+		Action:    action,
+		User:      sn.Spec.CreatedBy,
+		Namespace: sn.Name,
+		Reason:    reason,
+		Source:    "SubNamespace/" + sn.Namespace + "/" + sn.Name,
+	})
+}
+
+// contains returns true if s is in list
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package hierarchy
+
+import (
+	"fmt"
+	"strings"
+
+	core_v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+)
+
+// inherit copies the RoleBindings, NetworkPolicies and propagated Secrets
+// of parent into child and deletes the copies of those parent no longer
+// has. Objects of the child with the name of a parent's object that were
+// not copied are left alone.
+func (hc *HierarchyController) inherit(parent, child string) error {
+	var errs []string
+	if err := hc.inheritRoleBindings(parent, child); err != nil {
+		errs = append(errs, fmt.Sprintf("RoleBindings: %v", err))
+	}
+	if err := hc.inheritNetworkPolicies(parent, child); err != nil {
+		errs = append(errs, fmt.Sprintf("NetworkPolicies: %v", err))
+	}
+	if err := hc.inheritSecrets(parent, child); err != nil {
+		errs = append(errs, fmt.Sprintf("Secrets: %v", err))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// inheritedMeta is the metadata of the copy in child of an object of parent
+func inheritedMeta(from meta_v1.ObjectMeta, parent, child string) meta_v1.ObjectMeta {
+	labels := map[string]string{}
+	for k, v := range from.Labels {
+		labels[k] = v
+	}
+	labels[netsys_v1.InheritedFromLabel] = parent
+	return meta_v1.ObjectMeta{Name: from.Name, Namespace: child, Labels: labels}
+}
+
+// ownerBinding returns true if rb is the RoleBinding of an owner of
+// namespace, created for its OwnedNamespace or inherited from the
+// namespace's own parent. Temporary bindings, e.g. of elevations or
+// robots, stay in the namespace they were made for.
+func (hc *HierarchyController) ownerBinding(rb *rbac_v1.RoleBinding, namespace string) bool {
+	if rb.RoleRef.Kind != "ClusterRole" || !netsys_v1.ValidRole(rb.RoleRef.Name) || len(rb.Subjects) != 1 {
+		return false
+	}
+	s := rb.Subjects[0]
+	if s.Kind != rbac_v1.ServiceAccountKind || s.Namespace != hc.config.DispatchNamespace || rb.Labels[netsys_v1.RobotLabel] != "" {
+		return false
+	}
+	if rb.Labels[netsys_v1.InheritedFromLabel] != "" {
+		// copies keep the name of the owner's binding in the ancestor
+		return strings.HasPrefix(rb.Name, s.Name+"-")
+	}
+	return rb.Name == controller.NameFunc(s.Name, namespace)
+}
+
+// inheritRoleBindings copies the RoleBindings of the owners of parent.
+// Bindings to other roles would need permissions dispatch does not hold,
+// and Roles are not copied.
+func (hc *HierarchyController) inheritRoleBindings(parent, child string) error {
+	rbs := hc.clientsets.OriginalClient.RbacV1()
+	list, err := rbs.RoleBindings(parent).List(meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	current, err := rbs.RoleBindings(child).List(meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	existing := map[string]*rbac_v1.RoleBinding{}
+	for i := range current.Items {
+		existing[current.Items[i].Name] = &current.Items[i]
+	}
+
+	var errs []string
+	wanted := map[string]bool{}
+	for i := range list.Items {
+		rb := list.Items[i]
+		if !hc.ownerBinding(&rb, parent) {
+			continue
+		}
+		wanted[rb.Name] = true
+		inherited := &rbac_v1.RoleBinding{
+			ObjectMeta: inheritedMeta(rb.ObjectMeta, parent, child),
+			Subjects:   rb.Subjects,
+			RoleRef:    rb.RoleRef,
+		}
+		err = nil
+		old, ok := existing[rb.Name]
+		switch {
+		case !ok:
+			_, err = rbs.RoleBindings(child).Create(inherited)
+		case old.Labels[netsys_v1.InheritedFromLabel] != parent:
+			continue
+		case old.RoleRef != inherited.RoleRef:
+			// the role reference of a RoleBinding cannot be updated
+			if err = rbs.RoleBindings(child).Delete(inherited.Name, nil); err == nil {
+				_, err = rbs.RoleBindings(child).Create(inherited)
+			}
+		case !equality.Semantic.DeepEqual(old.Subjects, inherited.Subjects) || !equality.Semantic.DeepEqual(old.Labels, inherited.Labels):
+			updated := old.DeepCopy()
+			updated.Labels, updated.Subjects = inherited.Labels, inherited.Subjects
+			_, err = rbs.RoleBindings(child).Update(updated)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", rb.Name, err))
+		}
+	}
+	for name, rb := range existing {
+		if rb.Labels[netsys_v1.InheritedFromLabel] == parent && !wanted[name] {
+			if err := rbs.RoleBindings(child).Delete(name, nil); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// inheritNetworkPolicies copies every NetworkPolicy of parent
+func (hc *HierarchyController) inheritNetworkPolicies(parent, child string) error {
+	nps := hc.clientsets.OriginalClient.NetworkingV1()
+	list, err := nps.NetworkPolicies(parent).List(meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	current, err := nps.NetworkPolicies(child).List(meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	existing := map[string]*networking_v1.NetworkPolicy{}
+	for i := range current.Items {
+		existing[current.Items[i].Name] = &current.Items[i]
+	}
+
+	var errs []string
+	wanted := map[string]bool{}
+	for _, np := range list.Items {
+		wanted[np.Name] = true
+		inherited := &networking_v1.NetworkPolicy{
+			ObjectMeta: inheritedMeta(np.ObjectMeta, parent, child),
+			Spec:       np.Spec,
+		}
+		err = nil
+		old, ok := existing[np.Name]
+		switch {
+		case !ok:
+			_, err = nps.NetworkPolicies(child).Create(inherited)
+		case old.Labels[netsys_v1.InheritedFromLabel] != parent:
+			continue
+		case !equality.Semantic.DeepEqual(old.Spec, inherited.Spec) || !equality.Semantic.DeepEqual(old.Labels, inherited.Labels):
+			updated := old.DeepCopy()
+			updated.Labels, updated.Spec = inherited.Labels, inherited.Spec
+			_, err = nps.NetworkPolicies(child).Update(updated)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", np.Name, err))
+		}
+	}
+	for name, np := range existing {
+		if np.Labels[netsys_v1.InheritedFromLabel] == parent && !wanted[name] {
+			if err := nps.NetworkPolicies(child).Delete(name, nil); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// inheritSecrets copies the Secrets of parent annotated with
+// PropagateAnnotation. The copies keep the annotation, so they propagate
+// further down the hierarchy.
+func (hc *HierarchyController) inheritSecrets(parent, child string) error {
+	secrets := hc.clientsets.OriginalClient.CoreV1()
+	list, err := secrets.Secrets(parent).List(meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	current, err := secrets.Secrets(child).List(meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	existing := map[string]*core_v1.Secret{}
+	for i := range current.Items {
+		existing[current.Items[i].Name] = &current.Items[i]
+	}
+
+	var errs []string
+	wanted := map[string]bool{}
+	for _, s := range list.Items {
+		if s.Annotations[netsys_v1.PropagateAnnotation] != "true" || s.Type == core_v1.SecretTypeServiceAccountToken {
+			continue
+		}
+		wanted[s.Name] = true
+		inherited := &core_v1.Secret{
+			ObjectMeta: inheritedMeta(s.ObjectMeta, parent, child),
+			Type:       s.Type,
+			Data:       s.Data,
+		}
+		inherited.Annotations = map[string]string{netsys_v1.PropagateAnnotation: "true"}
+		err = nil
+		old, ok := existing[s.Name]
+		switch {
+		case !ok:
+			_, err = secrets.Secrets(child).Create(inherited)
+		case old.Labels[netsys_v1.InheritedFromLabel] != parent:
+			continue
+		case old.Type != inherited.Type:
+			// the type of a Secret cannot be updated
+			if err = secrets.Secrets(child).Delete(inherited.Name, nil); err == nil {
+				_, err = secrets.Secrets(child).Create(inherited)
+			}
+		case !equality.Semantic.DeepEqual(old.Data, inherited.Data) || !equality.Semantic.DeepEqual(old.Labels, inherited.Labels):
+			updated := old.DeepCopy()
+			updated.Labels, updated.Data = inherited.Labels, inherited.Data
+			_, err = secrets.Secrets(child).Update(updated)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", s.Name, err))
+		}
+	}
+	for name, s := range existing {
+		if s.Labels[netsys_v1.InheritedFromLabel] == parent && !wanted[name] {
+			if err := secrets.Secrets(child).Delete(name, nil); err != nil && !errors.IsNotFound(err) {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
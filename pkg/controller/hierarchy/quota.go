@@ -0,0 +1,253 @@
+package hierarchy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// TotalAnnotation on the dispatch quota of a namespace that is not a
+// sub-namespace keeps its hard limits from before the slices of its
+// children were carved out, as JSON
+const TotalAnnotation = "netsys.io/hierarchy-total"
+
+// quota is the dispatch quota a namespace of the hierarchy should have
+type quota struct {
+	namespace string
+	hard      core_v1.ResourceList
+	// root is true for namespaces that are not sub-namespaces, their
+	// total is kept in TotalAnnotation while they have children
+	root  bool
+	total core_v1.ResourceList
+}
+
+// planQuotas admits the quota slices of the children of every parent in
+// creation order and returns the quotas that leaves every namespace with.
+// Children that already have their namespace keep their slice, so a slice
+// that does not fit only refuses new children.
+func (hc *HierarchyController) planQuotas(live []*netsys_v1.SubNamespace, refusals map[string]string, t tree) ([]*quota, error) {
+	list, err := hc.clientsets.OriginalClient.CoreV1().ResourceQuotas("").List(meta_v1.ListOptions{
+		FieldSelector: "metadata.name=" + controller.QuotaName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]*core_v1.ResourceQuota{}
+	for i := range list.Items {
+		existing[list.Items[i].Namespace] = &list.Items[i]
+	}
+
+	children := map[string][]*netsys_v1.SubNamespace{}
+	var parents []string
+	for _, sn := range live {
+		if _, ok := children[sn.Spec.Parent]; !ok {
+			parents = append(parents, sn.Spec.Parent)
+		}
+		children[sn.Spec.Parent] = append(children[sn.Spec.Parent], sn)
+	}
+	// a parent's name is a prefix of its children's, so parents are
+	// planned before their children replace the slice they were given
+	sort.Strings(parents)
+
+	planned := map[string]*quota{}
+	for _, parent := range parents {
+		total, root, err := hc.total(parent, existing[parent], t)
+		if err != nil {
+			return nil, err
+		}
+		subs := children[parent]
+		sort.SliceStable(subs, func(i, j int) bool {
+			return subs[i].Status.Phase == netsys_v1.SubNamespaceReady && subs[j].Status.Phase != netsys_v1.SubNamespaceReady
+		})
+		used := core_v1.ResourceList{}
+		for _, sn := range subs {
+			if refusals[sn.Name] != "" {
+				continue
+			}
+			if total != nil && len(sn.Spec.Quota) == 0 {
+				refusals[sn.Name] = fmt.Sprintf("namespace %s has a quota, a slice of it is required", parent)
+				continue
+			}
+			if over := exceeds(total, used, sn.Spec.Quota); over != "" && sn.Status.Phase != netsys_v1.SubNamespaceReady {
+				refusals[sn.Name] = fmt.Sprintf("quota slice does not fit into what is left of %s: %s", parent, over)
+				continue
+			}
+			for name, q := range sn.Spec.Quota {
+				sum := used[name]
+				sum.Add(q)
+				used[name] = sum
+			}
+			if len(sn.Spec.Quota) > 0 {
+				planned[sn.Name] = &quota{namespace: sn.Name, hard: sn.Spec.Quota}
+			}
+		}
+		if total == nil {
+			continue
+		}
+		q := &quota{namespace: parent, hard: subtract(total, used), root: root}
+		if root && len(used) > 0 {
+			q.total = total
+		}
+		planned[parent] = q
+	}
+
+	// roots whose last child is gone get their whole quota back
+	for namespace, rq := range existing {
+		if _, ok := planned[namespace]; ok {
+			continue
+		}
+		if _, ok := rq.Annotations[TotalAnnotation]; !ok {
+			continue
+		}
+		total, _, err := hc.total(namespace, rq, tree{})
+		if err != nil {
+			return nil, err
+		}
+		planned[namespace] = &quota{namespace: namespace, hard: total, root: true}
+	}
+
+	quotas := make([]*quota, 0, len(planned))
+	for _, q := range planned {
+		quotas = append(quotas, q)
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].namespace < quotas[j].namespace })
+	return quotas, nil
+}
+
+// total returns the quota namespace has for itself and its children and
+// whether it is a root of the hierarchy. It is the slice of a
+// sub-namespace, otherwise the limits of its dispatch quota before
+// children were carved out. It is nil for namespaces without a quota.
+func (hc *HierarchyController) total(namespace string, rq *core_v1.ResourceQuota, t tree) (core_v1.ResourceList, bool, error) {
+	if sn, ok := t.subs[namespace]; ok && len(sn.Spec.Quota) > 0 {
+		return sn.Spec.Quota, false, nil
+	}
+	if rq == nil {
+		return nil, true, nil
+	}
+	if s, ok := rq.Annotations[TotalAnnotation]; ok {
+		var total core_v1.ResourceList
+		if err := json.Unmarshal([]byte(s), &total); err != nil {
+			return nil, true, fmt.Errorf("ResourceQuota %s/%s: %s: %v", namespace, rq.Name, TotalAnnotation, err)
+		}
+		return total, true, nil
+	}
+	return rq.Spec.Hard, true, nil
+}
+
+// applyQuota writes q to the dispatch quota of its namespace, creating it
+// unless the namespace does not exist
+func (hc *HierarchyController) applyQuota(q *quota, logger *logging.Logger) error {
+	quotas := hc.clientsets.OriginalClient.CoreV1().ResourceQuotas(q.namespace)
+	rq, err := quotas.Get(controller.QuotaName, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		rq = &core_v1.ResourceQuota{
+			ObjectMeta: meta_v1.ObjectMeta{Name: controller.QuotaName, Namespace: q.namespace},
+			Spec:       core_v1.ResourceQuotaSpec{Hard: q.hard},
+		}
+		_, err = quotas.Create(rq)
+		if errors.IsNotFound(err) {
+			// refused or not created yet
+			return nil
+		}
+		if err == nil {
+			logger.Info("Created ResourceQuota", "quota", controller.QuotaName, "hard", format(q.hard))
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	updated := rq.DeepCopy()
+	updated.Spec.Hard = q.hard
+	if q.root {
+		if q.total == nil {
+			delete(updated.Annotations, TotalAnnotation)
+		} else {
+			b, err := json.Marshal(q.total)
+			if err != nil {
+				return err
+			}
+			if updated.Annotations == nil {
+				updated.Annotations = map[string]string{}
+			}
+			updated.Annotations[TotalAnnotation] = string(b)
+		}
+	}
+	if equality.Semantic.DeepEqual(rq.Spec.Hard, updated.Spec.Hard) &&
+		rq.Annotations[TotalAnnotation] == updated.Annotations[TotalAnnotation] {
+		return nil
+	}
+	if _, err := quotas.Update(updated); err != nil {
+		return err
+	}
+	logger.Info("Updated ResourceQuota", "quota", controller.QuotaName, "hard", format(q.hard))
+	return nil
+}
+
+// exceeds describes the resources of slice that do not fit into total
+// once used is taken, or returns "" if slice fits
+func exceeds(total, used, slice core_v1.ResourceList) string {
+	var over []string
+	for _, name := range sortedNames(slice) {
+		t, ok := total[core_v1.ResourceName(name)]
+		if !ok {
+			continue
+		}
+		left := t.DeepCopy()
+		if u, ok := used[core_v1.ResourceName(name)]; ok {
+			left.Sub(u)
+		}
+		if q := slice[core_v1.ResourceName(name)]; q.Cmp(left) > 0 {
+			over = append(over, fmt.Sprintf("%s %s of %s left", name, q.String(), left.String()))
+		}
+	}
+	return strings.Join(over, ", ")
+}
+
+// subtract returns total less used, at least zero for every resource
+func subtract(total, used core_v1.ResourceList) core_v1.ResourceList {
+	left := core_v1.ResourceList{}
+	for name, t := range total {
+		l := t.DeepCopy()
+		if u, ok := used[name]; ok {
+			l.Sub(u)
+		}
+		if l.Sign() < 0 {
+			l = *resource.NewQuantity(0, t.Format)
+		}
+		left[name] = l
+	}
+	return left
+}
+
+// format writes list as name=quantity pairs, sorted by name
+func format(list core_v1.ResourceList) string {
+	var pairs []string
+	for _, name := range sortedNames(list) {
+		q := list[core_v1.ResourceName(name)]
+		pairs = append(pairs, name+"="+q.String())
+	}
+	return strings.Join(pairs, ",")
+}
+
+func sortedNames(list core_v1.ResourceList) []string {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,201 @@
+// Package invitation withdraws accepted NamespaceInvitations whose owner no
+// longer holds the namespace at the role shared, because the owner's grant
+// was revoked or downgraded, or the owner was suspended, expired or
+// deleted. The grant the invitation added is removed from the invitee.
+package invitation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/invitation"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+)
+
+// label of this controller's metrics
+const controllerName = "invitation"
+
+// InvitationController withdraws the accepted invitations of owners who
+// lost the namespace they shared
+type InvitationController struct {
+	niLister netsys_lister.NamespaceInvitationLister
+	duLister netsys_lister.DispatchUserLister
+
+	niListerSynced cache.InformerSynced
+	duListerSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on NamespaceInvitations
+	recorder record.EventRecorder
+
+	// asks for a sync before the next interval, e.g. when an owner is
+	// suspended
+	kick chan struct{}
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+}
+
+// NewInvitationController creates a new InvitationController
+func NewInvitationController(
+	niInformer netsys_informer.NamespaceInvitationInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+) *InvitationController {
+	ic := &InvitationController{
+		niLister:       niInformer.Lister(),
+		duLister:       duInformer.Lister(),
+		niListerSynced: niInformer.Informer().HasSynced,
+		duListerSynced: duInformer.Informer().HasSynced,
+		clientsets:     clientSets,
+		config:         cfg,
+		recorder:       recorder,
+		kick:           make(chan struct{}, 1),
+		tracker:        health.NewTracker(controllerName),
+	}
+
+	// withdraw as soon as an owner loses access, rather than at the next
+	// interval
+	duInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, u := oldObj.(*netsys_v1.DispatchUser), newObj.(*netsys_v1.DispatchUser)
+			if !equality.Semantic.DeepEqual(old.Spec, u.Spec) || u.Status.Expired != old.Status.Expired {
+				ic.syncSoon()
+			}
+		},
+		DeleteFunc: func(obj interface{}) { ic.syncSoon() },
+	})
+	return ic
+}
+
+// Run syncs the invitations every invitations.interval, and when kicked,
+// until stopCh is closed
+func (ic *InvitationController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, ic.niListerSynced, ic.duListerSynced) {
+		return
+	}
+	ticker := time.NewTicker(ic.config.Invitations.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		ic.sync()
+		select {
+		case <-ticker.C:
+		case <-ic.kick:
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (ic *InvitationController) Tracker() *health.Tracker {
+	return ic.tracker
+}
+
+// syncSoon asks for a sync unless one is already pending
+func (ic *InvitationController) syncSoon() {
+	select {
+	case ic.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (ic *InvitationController) sync() {
+	start := time.Now()
+	id := ic.tracker.Started("sync", "invitations")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := ic.syncInvitations(time.Now(), logger)
+
+	ic.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// syncInvitations withdraws every accepted invitation that its owner could
+// no longer make
+func (ic *InvitationController) syncInvitations(now time.Time, logger *logging.Logger) error {
+	namespace := ic.config.DispatchNamespace
+	invitations, err := ic.niLister.NamespaceInvitations(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	users, err := ic.duLister.DispatchUsers(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	byID := map[string]*netsys_v1.DispatchUser{}
+	for _, u := range users {
+		byID[u.Spec.UserID] = u
+	}
+
+	var errs []string
+	for _, inv := range invitations {
+		// expired invitations took their grant with them
+		if inv.PhaseAt(now) != netsys_v1.InvitationAccepted {
+			continue
+		}
+		why := fmt.Sprintf("%s no longer exists", inv.Spec.InvitedBy)
+		if inviter := byID[inv.Spec.InvitedBy]; inviter != nil {
+			err := invitation.Check(inviter, inv.Spec, now)
+			if err == nil {
+				continue
+			}
+			why = err.Error()
+		}
+		if err := ic.withdraw(inv, why, now, logger); err != nil {
+			errs = append(errs, fmt.Sprintf("invitation %s: %v", inv.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// withdraw revokes inv and the grant it added for why
+func (ic *InvitationController) withdraw(inv *netsys_v1.NamespaceInvitation, why string, now time.Time, logger *logging.Logger) error {
+	_, err := invitation.Withdraw(ic.clientsets.NetsysClient, inv.Namespace, inv.Name, why, now)
+	if invitation.IsRefused(err) || errors.IsNotFound(err) {
+		// revoked or deleted meanwhile
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("Withdrew invitation", "invitation", inv.Name, "namespace", inv.Spec.Namespace,
+		"invitee", inv.Spec.Invitee, "reason", why)
+	ic.recorder.Eventf(inv, core_v1.EventTypeWarning, controller.InvitationWithdrawn,
+		"Withdrew the share of namespace %s with %s: %s", inv.Spec.Namespace, inv.Spec.Invitee, why)
+	return nil
+}
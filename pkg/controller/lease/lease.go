@@ -0,0 +1,330 @@
+// Package lease expires leased namespaces. A grant with a lease must be
+// renewed by its owner; when the lease runs out the owner loses access,
+// and some time later the grant is removed and the namespace reclaimed.
+package lease
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/audit"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+	"github.com/hantaowang/dispatch/pkg/notify"
+)
+
+const (
+	// label of this controller's metrics
+	controllerName = "lease"
+
+	// how often leases are checked
+	syncPeriod = time.Minute
+)
+
+// LeaseController moves the leases of OwnedNamespaces through their states
+// and reclaims the namespaces of leases that were not renewed
+type LeaseController struct {
+	onLister netsys_lister.OwnedNamespaceLister
+	duLister netsys_lister.DispatchUserLister
+
+	onListerSynced cache.InformerSynced
+	duListerSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on DispatchUsers
+	recorder record.EventRecorder
+
+	// records expired, renewed and reclaimed leases
+	auditor audit.Auditor
+
+	// tells owners about leases about to expire
+	notifier notify.Notifier
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+}
+
+// NewLeaseController creates a new LeaseController
+func NewLeaseController(
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+	auditor audit.Auditor,
+	notifier notify.Notifier,
+) *LeaseController {
+	return &LeaseController{
+		onLister:       onInformer.Lister(),
+		duLister:       duInformer.Lister(),
+		onListerSynced: onInformer.Informer().HasSynced,
+		duListerSynced: duInformer.Informer().HasSynced,
+		clientsets:     clientSets,
+		config:         cfg,
+		recorder:       recorder,
+		auditor:        auditor,
+		notifier:       notifier,
+		tracker:        health.NewTracker(controllerName),
+	}
+}
+
+// Run syncs the leases every minute until stopCh is closed
+func (lc *LeaseController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, lc.onListerSynced, lc.duListerSynced) {
+		return
+	}
+	wait.Until(lc.sync, syncPeriod, stopCh)
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (lc *LeaseController) Tracker() *health.Tracker {
+	return lc.tracker
+}
+
+func (lc *LeaseController) sync() {
+	start := time.Now()
+	id := lc.tracker.Started("sync", "leases")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := lc.syncLeases(time.Now(), logger)
+
+	lc.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// syncLeases brings the lease of every leased OwnedNamespace up to date
+func (lc *LeaseController) syncLeases(now time.Time, logger *logging.Logger) error {
+	owned, err := lc.onLister.OwnedNamespaces(lc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, on := range owned {
+		if on.Spec.Lease == nil {
+			continue
+		}
+		l := logger.With("user", on.Spec.OwnerID, "namespace", on.Key())
+		if err := lc.syncLease(on, owned, now, l); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", on.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// state returns the state of a lease at now
+func (lc *LeaseController) state(l *netsys_v1.NamespaceLease, now time.Time) string {
+	switch {
+	case now.Before(l.ExpiresAt.Add(-lc.config.Leases.WarnBefore.Duration)):
+		return netsys_v1.LeaseActive
+	case now.Before(l.ExpiresAt.Time):
+		return netsys_v1.LeaseExpiring
+	case now.Before(l.ExpiresAt.Add(lc.config.Leases.ReclaimAfter.Duration)):
+		return netsys_v1.LeaseExpired
+	}
+	return netsys_v1.LeaseReclaimed
+}
+
+// syncLease moves the lease of on to its state at now. A lease starts when
+// the namespace is granted.
+func (lc *LeaseController) syncLease(on *netsys_v1.OwnedNamespace, owned []*netsys_v1.OwnedNamespace, now time.Time, logger *logging.Logger) error {
+	previous := ""
+	lease := netsys_v1.NamespaceLease{RenewedAt: on.CreationTimestamp}
+	if on.Status.Lease != nil {
+		previous = on.Status.Lease.State
+		lease = *on.Status.Lease.DeepCopy()
+	}
+	if previous == netsys_v1.LeaseReclaimed {
+		// only the grant may be left if removing it failed
+		return lc.removeGrant(on, logger)
+	}
+	// the lease may have changed since it was renewed
+	lease.ExpiresAt = meta_v1.NewTime(lease.RenewedAt.Add(on.Spec.Lease.Duration))
+	lease.State = lc.state(&lease, now)
+
+	updated := on.DeepCopy()
+	updated.Status.Lease = &lease
+	if !equality.Semantic.DeepEqual(on.Status, updated.Status) {
+		if _, err := lc.clientsets.NetsysClient.NetsysV1().OwnedNamespaces(on.Namespace).Update(updated); err != nil {
+			return err
+		}
+	}
+
+	expiry := lease.ExpiresAt.UTC().Format(time.RFC3339)
+	role := netsys_v1.RoleOrDefault(on.Spec.Role)
+	switch lease.State {
+	case previous:
+	case netsys_v1.LeaseActive, netsys_v1.LeaseExpiring:
+		if previous == netsys_v1.LeaseExpired {
+			logger.Info("Lease renewed", "expiresAt", expiry)
+			lc.audit(on, audit.ActionGrant, "lease renewed")
+			lc.event(on, core_v1.EventTypeNormal, controller.LeaseRenewed,
+				"Lease of namespace %s was renewed until %s", on.Key(), expiry)
+		}
+		if lease.State == netsys_v1.LeaseExpiring && previous != netsys_v1.LeaseExpiring {
+			logger.Info("Lease expires soon", "expiresAt", expiry)
+			lc.event(on, core_v1.EventTypeWarning, controller.LeaseExpiring,
+				"Lease of namespace %s expires at %s", on.Key(), expiry)
+			lc.notify(on, notify.Expiring, "The lease of your %s access to namespace %s expires at %s, renew it if you still need it",
+				role, on.Key(), expiry)
+		}
+	case netsys_v1.LeaseExpired:
+		reclaim := lease.ExpiresAt.Add(lc.config.Leases.ReclaimAfter.Duration).UTC().Format(time.RFC3339)
+		logger.Info("Lease expired", "expiresAt", expiry)
+		lc.audit(on, audit.ActionRevoke, "lease expired")
+		lc.event(on, core_v1.EventTypeWarning, controller.LeaseExpired,
+			"Lease of namespace %s expired at %s, it is reclaimed at %s unless renewed", on.Key(), expiry, reclaim)
+		lc.notify(on, notify.Expired, "The lease of your %s access to namespace %s expired at %s, renew it before %s to keep the namespace",
+			role, on.Key(), expiry, reclaim)
+	case netsys_v1.LeaseReclaimed:
+		return lc.reclaim(updated, owned, logger)
+	}
+	return nil
+}
+
+// reclaim deletes the namespace of on if dispatch created it and nobody
+// else holds it, then removes the grant
+func (lc *LeaseController) reclaim(on *netsys_v1.OwnedNamespace, owned []*netsys_v1.OwnedNamespace, logger *logging.Logger) error {
+	deleted, err := lc.deleteNamespace(on, owned)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Lease of namespace %s was not renewed, the grant was removed", on.Key())
+	if deleted {
+		logger.Info("Deleted namespace of expired lease")
+		lc.audit(on, audit.ActionNamespaceDeleted, "lease expired")
+		message = fmt.Sprintf("Lease of namespace %s was not renewed, the namespace was deleted", on.Key())
+	}
+	lc.event(on, core_v1.EventTypeWarning, controller.NamespaceReclaimed, "%s", message)
+	lc.notify(on, notify.Reclaimed, "%s", message)
+	return lc.removeGrant(on, logger)
+}
+
+// deleteNamespace deletes the namespace of on if that is allowed
+func (lc *LeaseController) deleteNamespace(on *netsys_v1.OwnedNamespace, owned []*netsys_v1.OwnedNamespace) (bool, error) {
+	if !lc.config.Leases.DeleteNamespaces || lc.config.IsProtected(on.Spec.Namespace) {
+		return false, nil
+	}
+	for _, other := range owned {
+		if other.Name != on.Name && other.Key() == on.Key() {
+			return false, nil
+		}
+	}
+	c, err := lc.clientsets.ForCluster(on.Spec.Cluster)
+	if err != nil {
+		return false, err
+	}
+	namespaces := c.CoreV1().Namespaces()
+	ns, err := namespaces.Get(on.Spec.Namespace, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if ns.Labels[netsys_v1.CreatedByLabel] != "dispatch" {
+		// created by someone else, who may still need it
+		return false, nil
+	}
+	err = namespaces.Delete(on.Spec.Namespace, nil)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// removeGrant removes the reclaimed namespace from the owner's grants. The
+// DispatchUser controller then deletes the OwnedNamespace.
+func (lc *LeaseController) removeGrant(on *netsys_v1.OwnedNamespace, logger *logging.Logger) error {
+	u := lc.owner(on)
+	if u == nil || !u.Spec.HasNamespace(on.Key()) {
+		return nil
+	}
+	u = u.DeepCopy()
+	u.Spec.RemoveNamespace(on.Key())
+	u.SetReason("lease of " + on.Key() + " was not renewed")
+	if _, err := lc.clientsets.NetsysClient.NetsysV1().DispatchUsers(u.Namespace).Update(u); err != nil {
+		return err
+	}
+	logger.Info("Removed grant of reclaimed namespace")
+	return nil
+}
+
+// owner returns the DispatchUser holding on, or nil
+func (lc *LeaseController) owner(on *netsys_v1.OwnedNamespace) *netsys_v1.DispatchUser {
+	users, err := lc.duLister.DispatchUsers(lc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+	for _, u := range users {
+		if u.Spec.UserID == on.Spec.OwnerID {
+			return u
+		}
+	}
+	return nil
+}
+
+// event records an Event on the owner of on, which is where owners look
+// for what happened to their access
+func (lc *LeaseController) event(on *netsys_v1.OwnedNamespace, eventType, reason, format string, args ...interface{}) {
+	var obj runtime.Object = on
+	if u := lc.owner(on); u != nil {
+		obj = u
+	}
+	lc.recorder.Eventf(obj, eventType, reason, format, args...)
+}
+
+func (lc *LeaseController) notify(on *netsys_v1.OwnedNamespace, typ, format string, args ...interface{}) {
+	lc.notifier.Notify(notify.Notification{
+		Type:      typ,
+		User:      on.Spec.OwnerID,
+		Namespace: on.Key(),
+		Time:      time.Now(),
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+func (lc *LeaseController) audit(on *netsys_v1.OwnedNamespace, action, reason string) {
+	lc.auditor.Record(audit.Record{
+		Action:    action,
+		User:      on.Spec.OwnerID,
+		Namespace: on.Spec.Namespace,
+		Cluster:   on.Spec.Cluster,
+		Role:      netsys_v1.RoleOrDefault(on.Spec.Role),
+		Reason:    reason,
+		Source:    "OwnedNamespace/" + on.Namespace + "/" + on.Name,
+	})
+}
@@ -3,6 +3,8 @@ package ownednamespace
 import (
 	"time"
 	"fmt"
+	"hash/fnv"
+	"sync"
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -12,16 +14,29 @@ import (
 	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
 	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 
 	rbac_v1 "k8s.io/api/rbac/v1"
 
+	core_v1 "k8s.io/api/core/v1"
+
 	"github.com/hantaowang/dispatch/pkg/client"
+	"github.com/hantaowang/dispatch/pkg/config"
 	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+	"k8s.io/client-go/tools/record"
 
 )
 
 const (
-	dispatchNamespace = "dispatch"
+	// label of this controller's metrics
+	controllerName = "ownednamespace"
+
+	// failed events are retried this often, backing off linearly
+	maxRetries = 5
+	retryBackoff = 2 * time.Second
 )
 
 // NamespaceController is responsible for performing actions dependent upon a namespace phase
@@ -40,139 +55,417 @@ type OwnedNamespaceController struct {
 	// clients to modify resources
 	clientsets	client.ClientSets
 
-	// Buffered channel of events to be done
-	workqueue 	chan OwnedNamespaceEvent
+	// controller configuration
+	config		*config.Config
+
+	// records Events on OwnedNamespaces
+	recorder	record.EventRecorder
+
+	// Buffered channels of events to be done, one per worker. The events of
+	// an OwnedNamespace always go to the same one.
+	workqueues 	[]chan OwnedNamespaceEvent
+
+	// follows queued and in-flight events for health checks
+	tracker		*health.Tracker
 }
 
 type OwnedNamespaceEvent struct {
 	action		string
 	old			*netsys_v1.OwnedNamespace
 	new			*netsys_v1.OwnedNamespace
+
+	// how often processing the event failed
+	retries		int
 }
 
 // NewOwnedNamespaceController creates a new OwnedNamespaceController
 func NewOwnedNamespaceController(
 	onInformer  netsys_informer.OwnedNamespaceInformer,
 	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
 	) *OwnedNamespaceController {
 
 	onc := &OwnedNamespaceController{
 		GroupVersionKind: netsys_v1.SchemeGroupVersion.WithKind("OwnedNamespace"),
 		clientsets: clientSets,
-		workqueue: make(chan OwnedNamespaceEvent, 100),
+		config: cfg,
+		recorder: recorder,
+		workqueues: make([]chan OwnedNamespaceEvent, cfg.Workers.OwnedNamespace),
+		tracker: health.NewTracker(controllerName),
 	}
+	for i := range onc.workqueues {
+		onc.workqueues[i] = make(chan OwnedNamespaceEvent, 100)
+	}
+	dispatchNamespace := cfg.DispatchNamespace
 
 	onInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) {
 			if obj.(*netsys_v1.OwnedNamespace).Namespace != dispatchNamespace {
 				return
 			}
-			onc.workqueue <- OwnedNamespaceEvent{
+			onc.enqueue(OwnedNamespaceEvent{
 				action: "add",
 				new: obj.(*netsys_v1.OwnedNamespace),
-			}
+			})
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			if newObj.(*netsys_v1.OwnedNamespace).Namespace != dispatchNamespace {
 				return
 			}
-			onc.workqueue <- OwnedNamespaceEvent{
+			onc.enqueue(OwnedNamespaceEvent{
 				action: "update",
 				old: oldObj.(*netsys_v1.OwnedNamespace),
 				new: newObj.(*netsys_v1.OwnedNamespace),
-			}
+			})
 		},
 		DeleteFunc:    func(obj interface{}) {
 			if obj.(*netsys_v1.OwnedNamespace).Namespace != dispatchNamespace {
 				return
 			}
-			onc.workqueue <- OwnedNamespaceEvent{
+			onc.enqueue(OwnedNamespaceEvent{
 				action: "delete",
 				old: obj.(*netsys_v1.OwnedNamespace),
-			}
+			})
 		},
 	})
 
 	onc.onLister = onInformer.Lister()
 	onc.onListerSynced = onInformer.Informer().HasSynced
 
+	metrics.RegisterWorkqueue(controllerName, func() int {
+		n := 0
+		for _, q := range onc.workqueues {
+			n += len(q)
+		}
+		return n
+	})
+
 	return onc
 }
 
-// Run begins watching and syncing.
-func (onc *OwnedNamespaceController) Run(workers int, stopCh <-chan struct{}) {
+// Run begins watching and syncing with one worker per work queue, as many
+// as workers.ownedNamespace.
+func (onc *OwnedNamespaceController) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 
-	fmt.Printf("Starting %s controller\n", onc.Kind)
-	defer fmt.Printf("Shutting down %v controller\n", onc.Kind)
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
 
-	for !onc.onListerSynced() {
-		time.Sleep(time.Second)
+	if !cache.WaitForCacheSync(stopCh, onc.onListerSynced) {
+		return
 	}
 
-	for i := 0; i < workers; i++ {
-		go wait.Until(onc.worker, time.Second, stopCh)
+	var workers sync.WaitGroup
+	for _, queue := range onc.workqueues {
+		queue := queue
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.Until(func() { onc.worker(queue, stopCh) }, time.Second, stopCh)
+		}()
 	}
 
 	<-stopCh
+	// return only once the events in flight are done, a leader keeps its
+	// lease until then
+	workers.Wait()
 }
 
-// worker runs a worker thread that just dequeues items, processes them, and marks them done.
-// It enforces that the syncHandler is never invoked concurrently with the same key.
-func (onc *OwnedNamespaceController) worker() {
-	fmt.Printf("Starting a %s worker\n", onc.Kind)
-	for onc.processNextWorkItem() {
+// worker runs a worker thread that just dequeues items of its queue and
+// processes them. As every key has a single queue, the handlers are never
+// invoked concurrently with the same key. It returns once stopCh is closed.
+func (onc *OwnedNamespaceController) worker(queue chan OwnedNamespaceEvent, stopCh <-chan struct{}) {
+	logging.Debug("Starting worker", "controller", controllerName)
+	for onc.processNextWorkItem(queue, stopCh) {
 	}
 }
 
-func (onc *OwnedNamespaceController) processNextWorkItem() bool {
-	event := <- onc.workqueue
+func (onc *OwnedNamespaceController) processNextWorkItem(queue chan OwnedNamespaceEvent, stopCh <-chan struct{}) bool {
+	var event OwnedNamespaceEvent
+	select {
+	case event = <- queue:
+	case <-stopCh:
+		return false
+	}
+	start := time.Now()
+	id := onc.tracker.Started(event.action, event.key())
+	logger := onc.logger(event)
 
 	var err error
 	if event.action == "add" {
-		err = onc.addHandler(event)
+		err = onc.addHandler(event, logger)
 	} else if event.action == "update" {
-		// drop
-		return true
+		err = onc.updateHandler(event, logger)
 	} else if event.action == "delete" {
-		err = onc.deleteHandler(event)
+		err = onc.deleteHandler(event, logger)
 	} else {
 		err = fmt.Errorf("event action not recoginized %s", event.action)
 	}
 
+	onc.tracker.Done(id, err, event.retries)
+	metrics.ReconcileTotal.Inc(controllerName, event.action)
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+
 	if err != nil {
-		fmt.Printf("Error processing OwnedNamespace %s: %s", event.action, err)
-		return false
+		logger.Error("Reconcile failed", "error", err, "retries", event.retries)
+		metrics.ReconcileErrors.Inc(controllerName, event.action)
+		onc.retry(event)
+	}
+
+	return true
+}
+
+// retry queues a failed event again after a backoff. Adds and updates are
+// retried with the latest OwnedNamespace from the cache.
+func (onc *OwnedNamespaceController) retry(e OwnedNamespaceEvent) {
+	if e.retries >= maxRetries {
+		onc.logger(e).Error("Giving up after retries", "retries", e.retries)
+		return
+	}
+	e.retries++
+	metrics.WorkqueueRetries.Inc(controllerName)
+
+	go func() {
+		time.Sleep(time.Duration(e.retries) * retryBackoff)
+		if e.new != nil {
+			latest, err := onc.onLister.OwnedNamespaces(e.new.Namespace).Get(e.new.Name)
+			if err != nil {
+				return
+			}
+			e.new = latest
+		}
+		onc.enqueue(e)
+	}()
+}
+
+// enqueue puts an event on the work queue of its key
+func (onc *OwnedNamespaceController) enqueue(e OwnedNamespaceEvent) {
+	onc.tracker.Queued(e.action, e.key())
+	onc.workqueues[shard(e.key(), len(onc.workqueues))] <- e
+}
+
+// shard returns the work queue of key out of n
+func shard(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// Tracker returns the tracker of the controller's work items
+func (onc *OwnedNamespaceController) Tracker() *health.Tracker {
+	return onc.tracker
+}
+
+// key identifies the object of an event in health checks
+func (e OwnedNamespaceEvent) key() string {
+	obj := e.new
+	if obj == nil {
+		obj = e.old
+	}
+	return obj.Namespace + "/" + obj.Name
+}
+
+// logger returns a logger with the user and namespace of an event and a
+// new reconcile ID
+func (onc *OwnedNamespaceController) logger(e OwnedNamespaceEvent) *logging.Logger {
+	obj := e.new
+	if obj == nil {
+		obj = e.old
+	}
+	return logging.With("controller", controllerName, "reconcile", logging.NewID(), "action", e.action,
+		"user", obj.Spec.OwnerID, "namespace", obj.Key())
+}
+
+func (onc *OwnedNamespaceController) addHandler(e OwnedNamespaceEvent, logger *logging.Logger) error {
+	created, err := onc.ensureQuota(e.new)
+	if err != nil {
+		return err
+	}
+	if created {
+		logger.Info("Created ResourceQuota", "quota", controller.QuotaName)
+		onc.recorder.Eventf(e.new, core_v1.EventTypeNormal, controller.QuotaCreated,
+			"Created ResourceQuota %s/%s", e.new.Spec.Namespace, controller.QuotaName)
+	}
+	if !e.new.Bound() {
+		// bound once the owner is resumed or the lease renewed
+		return nil
 	}
+	if onc.refuseRole(e.new, logger) {
+		return nil
+	}
+	if err := onc.createRoleBinding(e.new); err != nil {
+		onc.recorder.Eventf(e.new, core_v1.EventTypeWarning, controller.BindingFailed,
+			"Failed to create RoleBinding %s/%s: %v", e.new.Spec.Namespace, e.new.Name, err)
+		return err
+	}
+	logger.Info("Created RoleBinding", "rolebinding", e.new.Name, "role", e.new.EffectiveRole())
+	onc.recorder.Eventf(e.new, core_v1.EventTypeNormal, controller.BindingCreated,
+		"Bound ClusterRole %s in namespace %s", e.new.EffectiveRole(), e.new.Key())
+	return nil
+}
+
+// updateHandler replaces the RoleBinding when the role changes, also when
+// an availability window that revokes write access opens or closes, since
+// the role reference of a RoleBinding cannot be updated. The RoleBinding is
+// removed while the owner is suspended or its lease expired and restored
+// when resumed or renewed.
+func (onc *OwnedNamespaceController) updateHandler(e OwnedNamespaceEvent, logger *logging.Logger) error {
+	if !e.new.Bound() {
+		if !e.old.Bound() {
+			return nil
+		}
+		return onc.unbind(e.new, logger)
+	}
+	if !e.old.Bound() {
+		return onc.addHandler(e, logger)
+	}
+	oldRole, newRole := e.old.EffectiveRole(), e.new.EffectiveRole()
+	if oldRole == newRole {
+		return nil
+	}
+	err := onc.deleteRoleBinding(e.old)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if onc.refuseRole(e.new, logger) {
+		return nil
+	}
+	if err := onc.createRoleBinding(e.new); err != nil {
+		onc.recorder.Eventf(e.new, core_v1.EventTypeWarning, controller.BindingFailed,
+			"Failed to replace RoleBinding %s/%s: %v", e.new.Spec.Namespace, e.new.Name, err)
+		return err
+	}
+	logger.Info("Replaced RoleBinding", "rolebinding", e.new.Name, "from", oldRole, "role", newRole)
+	onc.recorder.Eventf(e.new, core_v1.EventTypeNormal, controller.BindingReplaced,
+		"Changed ClusterRole in namespace %s from %s to %s", e.new.Key(), oldRole, newRole)
+	return nil
+}
+
+func (onc *OwnedNamespaceController) deleteHandler(e OwnedNamespaceEvent, logger *logging.Logger) error {
+	err := onc.deleteRoleBinding(e.old)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("Deleted RoleBinding", "rolebinding", e.old.Name)
+	onc.recorder.Eventf(e.old, core_v1.EventTypeNormal, controller.BindingDeleted,
+		"Deleted RoleBinding %s/%s", e.old.Spec.Namespace, e.old.Name)
+	return nil
+}
+
+// unbind removes the RoleBinding of a suspended or expired OwnedNamespace
+func (onc *OwnedNamespaceController) unbind(on *netsys_v1.OwnedNamespace, logger *logging.Logger) error {
+	err := onc.deleteRoleBinding(on)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	why := "while " + on.Spec.OwnerID + " is suspended"
+	if !on.Spec.Suspended {
+		why = "after its lease expired"
+	}
+	logger.Info("Deleted RoleBinding of unbound owner", "rolebinding", on.Name, "suspended", on.Spec.Suspended)
+	onc.recorder.Eventf(on, core_v1.EventTypeNormal, controller.BindingDeleted,
+		"Deleted RoleBinding %s/%s %s", on.Spec.Namespace, on.Name, why)
+	return nil
+}
 
+// refuseRole returns true, and records why, if the role of on is not one
+// dispatch grants, so it is never bound
+func (onc *OwnedNamespaceController) refuseRole(on *netsys_v1.OwnedNamespace, logger *logging.Logger) bool {
+	role := on.EffectiveRole()
+	if netsys_v1.ValidRole(role) {
+		return false
+	}
+	logger.Warn("Refusing to bind an invalid role", "role", role)
+	onc.recorder.Eventf(on, core_v1.EventTypeWarning, controller.GrantRefused,
+		"Role %q of namespace %s must be view, edit or admin and is not bound", role, on.Key())
 	return true
 }
 
-func (onc *OwnedNamespaceController) addHandler(e OwnedNamespaceEvent) error {
+func (onc *OwnedNamespaceController) createRoleBinding(on *netsys_v1.OwnedNamespace) error {
 	rb := rbac_v1.RoleBinding{
 		ObjectMeta: meta_v1.ObjectMeta{
-			Name:      controller.NameFunc(e.new.Spec.OwnerID, e.new.Spec.Namespace),
-			Namespace: e.new.Spec.Namespace,
+			Name:      controller.NameFunc(on.Spec.OwnerID, on.Spec.Namespace),
+			Namespace: on.Spec.Namespace,
 		},
 		Subjects: []rbac_v1.Subject{
 			{
 				Kind: "ServiceAccount",
-				Name: e.new.Spec.OwnerID,
-				Namespace: dispatchNamespace,
+				Name: on.Spec.OwnerID,
+				Namespace: onc.config.DispatchNamespace,
 			},
 		},
 		RoleRef: rbac_v1.RoleRef{
 			Kind: "ClusterRole",
-			Name: "edit",
+			Name: on.EffectiveRole(),
 			APIGroup: "rbac.authorization.k8s.io",
 		},
 	}
 
-	_, err := onc.clientsets.OriginalClient.RbacV1().RoleBindings(e.new.Spec.Namespace).Create(&rb)
+	c, err := onc.clientsets.ForCluster(on.Spec.Cluster)
+	if err != nil {
+		return err
+	}
+	rbClient := c.RbacV1().RoleBindings(on.Spec.Namespace)
+	_, err = rbClient.Create(&rb)
+	if errors.IsAlreadyExists(err) {
+		// left over from an earlier run, e.g. before a leader change
+		existing, getErr := rbClient.Get(rb.Name, meta_v1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		err = nil
+		if existing.RoleRef != rb.RoleRef {
+			if err = rbClient.Delete(rb.Name, nil); err == nil {
+				_, err = rbClient.Create(&rb)
+			}
+		}
+	}
+	if err == nil {
+		metrics.RoleBindingReady(on.Spec.OwnerID, on.Key())
+	}
 
 	return err
 }
 
-func (onc *OwnedNamespaceController) deleteHandler(e OwnedNamespaceEvent) error {
-	return onc.clientsets.OriginalClient.RbacV1().RoleBindings(e.old.Spec.Namespace).Delete(e.old.Spec.OwnerID, nil)
-}
\ No newline at end of file
+func (onc *OwnedNamespaceController) deleteRoleBinding(on *netsys_v1.OwnedNamespace) error {
+	c, err := onc.clientsets.ForCluster(on.Spec.Cluster)
+	if err != nil {
+		return err
+	}
+	return c.RbacV1().RoleBindings(on.Spec.Namespace).Delete(
+		controller.NameFunc(on.Spec.OwnerID, on.Spec.Namespace), nil)
+}
+
+// ensureQuota creates the configured default ResourceQuota in the namespace
+// of on unless it already has one
+func (onc *OwnedNamespaceController) ensureQuota(on *netsys_v1.OwnedNamespace) (bool, error) {
+	if len(onc.config.DefaultQuota) == 0 {
+		return false, nil
+	}
+	c, err := onc.clientsets.ForCluster(on.Spec.Cluster)
+	if err != nil {
+		return false, err
+	}
+	namespace := on.Spec.Namespace
+	rq := core_v1.ResourceQuota{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      controller.QuotaName,
+			Namespace: namespace,
+		},
+		Spec: core_v1.ResourceQuotaSpec{
+			Hard: onc.config.DefaultQuota,
+		},
+	}
+	_, err = c.CoreV1().ResourceQuotas(namespace).Create(&rq)
+	if errors.IsAlreadyExists(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
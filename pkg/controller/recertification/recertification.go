@@ -0,0 +1,370 @@
+// Package recertification runs access recertification campaigns. Every
+// interval a campaign lists all grants; grants nobody confirms before the
+// deadline are revoked.
+package recertification
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+)
+
+const (
+	// label of this controller's metrics
+	controllerName = "recertification"
+
+	// how often campaigns are started and deadlines are checked
+	syncPeriod = time.Minute
+
+	day = 24 * time.Hour
+)
+
+// RecertificationController starts campaigns and revokes the grants left
+// unconfirmed when a campaign ends
+type RecertificationController struct {
+	duLister       netsys_lister.DispatchUserLister
+	onLister       netsys_lister.OwnedNamespaceLister
+	campaignLister netsys_lister.RecertificationCampaignLister
+
+	duListerSynced       cache.InformerSynced
+	onListerSynced       cache.InformerSynced
+	campaignListerSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on DispatchUsers
+	recorder record.EventRecorder
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+}
+
+// NewRecertificationController creates a new RecertificationController
+func NewRecertificationController(
+	duInformer netsys_informer.DispatchUserInformer,
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	campaignInformer netsys_informer.RecertificationCampaignInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+) *RecertificationController {
+	return &RecertificationController{
+		duLister:             duInformer.Lister(),
+		onLister:             onInformer.Lister(),
+		campaignLister:       campaignInformer.Lister(),
+		duListerSynced:       duInformer.Informer().HasSynced,
+		onListerSynced:       onInformer.Informer().HasSynced,
+		campaignListerSynced: campaignInformer.Informer().HasSynced,
+		clientsets:           clientSets,
+		config:               cfg,
+		recorder:             recorder,
+		tracker:              health.NewTracker(controllerName),
+	}
+}
+
+// Run syncs the campaigns every minute until stopCh is closed
+func (rc *RecertificationController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, rc.duListerSynced, rc.onListerSynced, rc.campaignListerSynced) {
+		return
+	}
+	wait.Until(rc.sync, syncPeriod, stopCh)
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (rc *RecertificationController) Tracker() *health.Tracker {
+	return rc.tracker
+}
+
+func (rc *RecertificationController) sync() {
+	start := time.Now()
+	id := rc.tracker.Started("sync", "campaigns")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := rc.syncCampaigns(logger)
+
+	rc.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// syncCampaigns carries grants changed since into running campaigns,
+// completes campaigns past their deadline and starts a new campaign once
+// the interval since the last one has passed
+func (rc *RecertificationController) syncCampaigns(logger *logging.Logger) error {
+	campaigns, err := rc.campaignLister.RecertificationCampaigns(rc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var errs []string
+	var latest *netsys_v1.RecertificationCampaign
+	for _, c := range campaigns {
+		if latest == nil || latest.CreationTimestamp.Before(&c.CreationTimestamp) {
+			latest = c
+		}
+		if c.Status.Completed {
+			continue
+		}
+		if now.Before(c.Spec.Deadline.Time) {
+			if err := rc.syncRunning(c, now, logger.With("campaign", c.Name)); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", c.Name, err))
+			}
+			continue
+		}
+		if err := rc.complete(c, logger.With("campaign", c.Name)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", c.Name, err))
+		}
+	}
+
+	interval := time.Duration(rc.config.Recertification.IntervalDays) * day
+	if latest == nil || !now.Before(latest.CreationTimestamp.Add(interval)) {
+		if err := rc.start(now, logger); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// start creates a campaign listing every grant of every user
+func (rc *RecertificationController) start(now time.Time, logger *logging.Logger) error {
+	users, err := rc.duLister.DispatchUsers(rc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Spec.UserID < users[j].Spec.UserID })
+
+	items := []netsys_v1.RecertificationItem{}
+	for _, u := range users {
+		if u.Status.Expired {
+			continue
+		}
+		for _, g := range u.Spec.EffectiveGrants() {
+			// expired grants are already revoked
+			if gs := u.Status.GrantStatus(g.Key()); rc.config.IsProtected(g.Namespace) || (gs != nil && gs.Expired) {
+				continue
+			}
+			items = append(items, netsys_v1.RecertificationItem{
+				UserID:    u.Spec.UserID,
+				Namespace: g.Key(),
+				Role:      rc.config.RoleOrDefault(g.Role),
+				State:     netsys_v1.RecertificationPending,
+			})
+		}
+	}
+
+	r := rc.config.Recertification
+	deadline := meta_v1.NewTime(now.Add(time.Duration(r.GracePeriodDays) * day))
+	campaign := &netsys_v1.RecertificationCampaign{
+		ObjectMeta: meta_v1.ObjectMeta{
+			// one campaign a day at most, so replicas racing after a
+			// leader change cannot start two
+			Name:      "recertification-" + now.UTC().Format("2006-01-02"),
+			Namespace: rc.config.DispatchNamespace,
+		},
+		Spec: netsys_v1.RecertificationCampaignSpec{
+			Deadline:       deadline,
+			Approvers:      r.Approvers,
+			ApproverGroups: r.ApproverGroups,
+		},
+		Status: netsys_v1.RecertificationCampaignStatus{Items: items},
+	}
+	_, err = rc.clientsets.NetsysClient.NetsysV1().RecertificationCampaigns(rc.config.DispatchNamespace).Create(campaign)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("Started recertification campaign", "campaign", campaign.Name, "grants", len(items),
+		"deadline", deadline.UTC().Format(time.RFC3339))
+
+	for _, u := range users {
+		var namespaces []string
+		for _, item := range items {
+			if item.UserID == u.Spec.UserID {
+				namespaces = append(namespaces, item.Namespace)
+			}
+		}
+		if len(namespaces) > 0 {
+			rc.recorder.Eventf(u, core_v1.EventTypeNormal, controller.RecertificationRequired,
+				"Access to %s must be confirmed by %s in campaign %s", strings.Join(namespaces, ", "),
+				deadline.UTC().Format(time.RFC3339), campaign.Name)
+		}
+	}
+	return nil
+}
+
+// syncRunning carries the grants changed since c started into c
+func (rc *RecertificationController) syncRunning(c *netsys_v1.RecertificationCampaign, now time.Time, logger *logging.Logger) error {
+	users, err := rc.duLister.DispatchUsers(rc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	c = c.DeepCopy()
+	if !rc.carry(c, users, meta_v1.NewTime(now), logger) {
+		return nil
+	}
+	_, err = rc.clientsets.NetsysClient.NetsysV1().RecertificationCampaigns(c.Namespace).Update(c)
+	return err
+}
+
+// carry adds a pending item for every grant of c that was given another
+// role or removed and granted again since its item was added, and drops
+// the pending item it replaces. It returns true if c changed.
+func (rc *RecertificationController) carry(c *netsys_v1.RecertificationCampaign, users []*netsys_v1.DispatchUser, now meta_v1.Time, logger *logging.Logger) bool {
+	carried := false
+	for _, u := range users {
+		if u.Status.Expired {
+			continue
+		}
+		for _, g := range u.Spec.EffectiveGrants() {
+			item := c.Item(u.Spec.UserID, g.Key())
+			if item == nil || (item.State != netsys_v1.RecertificationPending && item.State != netsys_v1.RecertificationConfirmed) {
+				// granted after the campaign started, left to the next one
+				continue
+			}
+			role := rc.config.RoleOrDefault(g.Role)
+			if role == item.Role && !rc.grantedSince(u.Spec.UserID, g.Key(), item, c) {
+				continue
+			}
+			if item.State == netsys_v1.RecertificationPending {
+				item.State = netsys_v1.RecertificationDropped
+				item.ReviewedAt = &now
+			}
+			logger.Info("Carried changed grant into campaign", "user", u.Spec.UserID, "namespace", g.Key(),
+				"from", item.Role, "role", role)
+			c.Status.Items = append(c.Status.Items, netsys_v1.RecertificationItem{
+				UserID:    u.Spec.UserID,
+				Namespace: g.Key(),
+				Role:      role,
+				State:     netsys_v1.RecertificationPending,
+				AddedAt:   &now,
+			})
+			carried = true
+		}
+	}
+	return carried
+}
+
+// grantedSince returns true if the grant of userID in key was provisioned
+// again after item was added to c, i.e. it was removed and granted again
+func (rc *RecertificationController) grantedSince(userID, key string, item *netsys_v1.RecertificationItem, c *netsys_v1.RecertificationCampaign) bool {
+	on, err := rc.onLister.OwnedNamespaces(rc.config.DispatchNamespace).Get(controller.OwnedNamespaceName(userID, key))
+	if err != nil {
+		// not provisioned yet, its role decides
+		return false
+	}
+	added := c.CreationTimestamp
+	if item.AddedAt != nil {
+		added = *item.AddedAt
+	}
+	return added.Before(&on.CreationTimestamp)
+}
+
+// complete revokes the grants still pending after the deadline, including
+// those carried into the campaign since they changed. Grants removed since
+// the campaign started are dropped.
+func (rc *RecertificationController) complete(c *netsys_v1.RecertificationCampaign, logger *logging.Logger) error {
+	c = c.DeepCopy()
+	users, err := rc.duLister.DispatchUsers(rc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*netsys_v1.DispatchUser, len(users))
+	for _, u := range users {
+		byID[u.Spec.UserID] = u
+	}
+
+	now := meta_v1.NewTime(time.Now())
+	rc.carry(c, users, now, logger)
+	reason := fmt.Sprintf("not recertified in campaign %s", c.Name)
+	revoke := map[string][]string{}
+	for i := range c.Status.Items {
+		item := &c.Status.Items[i]
+		if item.State != netsys_v1.RecertificationPending {
+			continue
+		}
+		item.ReviewedAt = &now
+		u, ok := byID[item.UserID]
+		if !ok || !rc.hasGrant(u, item.Namespace, item.Role) {
+			item.State = netsys_v1.RecertificationDropped
+			if ok && u.Reason() == reason {
+				// revoked by an earlier attempt that failed to update
+				// the campaign
+				item.State = netsys_v1.RecertificationRevoked
+			}
+			continue
+		}
+		item.State = netsys_v1.RecertificationRevoked
+		revoke[item.UserID] = append(revoke[item.UserID], item.Namespace)
+	}
+
+	for userID, namespaces := range revoke {
+		u := byID[userID].DeepCopy()
+		for _, ns := range namespaces {
+			u.Spec.RemoveNamespace(ns)
+		}
+		u.SetReason(reason)
+		// the DispatchUser controller revokes the grants and audits them
+		// with the reason
+		if _, err := rc.clientsets.NetsysClient.NetsysV1().DispatchUsers(u.Namespace).Update(u); err != nil {
+			return err
+		}
+		logger.Info("Revoked unconfirmed grants", "user", userID, "namespaces", strings.Join(namespaces, ","))
+		rc.recorder.Eventf(u, core_v1.EventTypeWarning, controller.GrantNotRecertified,
+			"Revoked access to %s, not confirmed in campaign %s", strings.Join(namespaces, ", "), c.Name)
+	}
+
+	c.Status.Completed = true
+	if _, err := rc.clientsets.NetsysClient.NetsysV1().RecertificationCampaigns(c.Namespace).Update(c); err != nil {
+		return err
+	}
+	progress := c.Progress()
+	logger.Info("Completed recertification campaign", "confirmed", progress[netsys_v1.RecertificationConfirmed],
+		"revoked", progress[netsys_v1.RecertificationRevoked], "dropped", progress[netsys_v1.RecertificationDropped])
+	return nil
+}
+
+// hasGrant returns true if u still has role in namespace
+func (rc *RecertificationController) hasGrant(u *netsys_v1.DispatchUser, namespace, role string) bool {
+	for _, g := range u.Spec.EffectiveGrants() {
+		if g.Key() == namespace {
+			return rc.config.RoleOrDefault(g.Role) == role
+		}
+	}
+	return false
+}
@@ -0,0 +1,646 @@
+// Package robot gives DispatchRobots a ServiceAccount with rotating tokens
+// and binds it in the namespaces of the robot's grants that its owners
+// hold, at most with their role. Robots whose owners are all deleted,
+// expired or suspended lose their ServiceAccount, and so their tokens, and
+// their bindings until an owner is active again.
+package robot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/audit"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+)
+
+// label of this controller's metrics
+const controllerName = "robot"
+
+// RobotController keeps the ServiceAccounts, tokens and RoleBindings of
+// DispatchRobots in line with their grants and owners
+type RobotController struct {
+	drLister netsys_lister.DispatchRobotLister
+	duLister netsys_lister.DispatchUserLister
+	onLister netsys_lister.OwnedNamespaceLister
+
+	drListerSynced cache.InformerSynced
+	duListerSynced cache.InformerSynced
+	onListerSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on DispatchRobots
+	recorder record.EventRecorder
+	auditor  audit.Auditor
+
+	// asks for a sync before the next interval, e.g. when an owner is
+	// suspended
+	kick chan struct{}
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+}
+
+// NewRobotController creates a new RobotController
+func NewRobotController(
+	drInformer netsys_informer.DispatchRobotInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+	auditor audit.Auditor,
+) *RobotController {
+	rc := &RobotController{
+		drLister:       drInformer.Lister(),
+		duLister:       duInformer.Lister(),
+		onLister:       onInformer.Lister(),
+		drListerSynced: drInformer.Informer().HasSynced,
+		duListerSynced: duInformer.Informer().HasSynced,
+		onListerSynced: onInformer.Informer().HasSynced,
+		clientsets:     clientSets,
+		config:         cfg,
+		recorder:       recorder,
+		auditor:        auditor,
+		kick:           make(chan struct{}, 1),
+		tracker:        health.NewTracker(controllerName),
+	}
+
+	// disable the robots of users as soon as they are suspended, expire or
+	// are deleted
+	duInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, u := oldObj.(*netsys_v1.DispatchUser), newObj.(*netsys_v1.DispatchUser)
+			if (u.Spec.Suspended && !old.Spec.Suspended) || (u.Status.Expired && !old.Status.Expired) {
+				rc.syncSoon()
+			}
+		},
+		DeleteFunc: func(obj interface{}) { rc.syncSoon() },
+	})
+	return rc
+}
+
+// Run syncs the robots every robots.interval, and when kicked, until
+// stopCh is closed
+func (rc *RobotController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, rc.drListerSynced, rc.duListerSynced, rc.onListerSynced) {
+		return
+	}
+	ticker := time.NewTicker(rc.config.Robots.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		rc.sync()
+		select {
+		case <-ticker.C:
+		case <-rc.kick:
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (rc *RobotController) Tracker() *health.Tracker {
+	return rc.tracker
+}
+
+// syncSoon asks for a sync unless one is already pending
+func (rc *RobotController) syncSoon() {
+	select {
+	case rc.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (rc *RobotController) sync() {
+	start := time.Now()
+	id := rc.tracker.Started("sync", "robots")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := rc.syncRobots(time.Now(), logger)
+
+	rc.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// syncRobots syncs every robot and removes what is left of deleted ones
+func (rc *RobotController) syncRobots(now time.Time, logger *logging.Logger) error {
+	namespace := rc.config.DispatchNamespace
+	robots, err := rc.drLister.DispatchRobots(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	users, err := rc.duLister.DispatchUsers(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	owned, err := rc.onLister.OwnedNamespaces(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	bindings, err := rc.clientsets.OriginalClient.RbacV1().RoleBindings("").List(
+		meta_v1.ListOptions{LabelSelector: netsys_v1.RobotLabel})
+	if err != nil {
+		return err
+	}
+	secrets, err := rc.clientsets.OriginalClient.CoreV1().Secrets(namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	bindingsOf := map[string][]rbac_v1.RoleBinding{}
+	var robotBindings []rbac_v1.RoleBinding
+	for _, rb := range bindings.Items {
+		// copies of the hierarchy controller keep the labels but are not the
+		// robot's to manage
+		if rb.Labels[netsys_v1.InheritedFromLabel] != "" {
+			continue
+		}
+		bindingsOf[rb.Labels[netsys_v1.RobotLabel]] = append(bindingsOf[rb.Labels[netsys_v1.RobotLabel]], rb)
+		robotBindings = append(robotBindings, rb)
+	}
+	// tokens by ServiceAccount, including those the token controller
+	// created on its own
+	tokensOf := map[string][]core_v1.Secret{}
+	for _, s := range secrets.Items {
+		if s.Type == core_v1.SecretTypeServiceAccountToken {
+			sa := s.Annotations[core_v1.ServiceAccountNameKey]
+			tokensOf[sa] = append(tokensOf[sa], s)
+		}
+	}
+
+	var errs []string
+	exists := map[string]bool{}
+	for _, r := range robots {
+		exists[r.Name] = true
+		l := logger.With("robot", r.Name)
+		tokens := tokensOf[netsys_v1.RobotServiceAccount(r.Name)]
+		if err := rc.syncRobot(r, users, owned, bindingsOf[r.Name], tokens, now, l); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", r.Name, err))
+		}
+	}
+	if err := rc.removeDeleted(exists, robotBindings, logger); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// syncRobot gives r its ServiceAccount, a current token and the bindings
+// of its grants, or takes them away while it has no active owner
+func (rc *RobotController) syncRobot(r *netsys_v1.DispatchRobot, users []*netsys_v1.DispatchUser, owned []*netsys_v1.OwnedNamespace,
+	bindings []rbac_v1.RoleBinding, tokens []core_v1.Secret, now time.Time, logger *logging.Logger) error {
+	status := r.Status.DeepCopy()
+	owners, reason := rc.owners(r, users)
+	var sa *core_v1.ServiceAccount
+	if len(owners) > 0 {
+		var err error
+		if sa, reason, err = rc.ensureServiceAccount(r, users, logger); err != nil {
+			return err
+		}
+	}
+	if sa == nil {
+		if err := rc.disable(r, bindings, reason, logger); err != nil {
+			return err
+		}
+		*status = netsys_v1.DispatchRobotStatus{Phase: netsys_v1.RobotDisabled, Message: reason, UpdatedAt: status.UpdatedAt}
+		return rc.updateStatus(r, status, now, logger)
+	}
+
+	granted, refusals := rc.grants(r, owners, owned)
+	if err := rc.rotateToken(r, sa, status, tokens, now, logger); err != nil {
+		return err
+	}
+	if err := rc.bind(r, owners, granted, bindings, logger); err != nil {
+		return err
+	}
+	status.Phase = netsys_v1.RobotActive
+	status.Message = strings.Join(refusals, "; ")
+	status.ServiceAccount = sa.Name
+	status.Grants = granted
+	return rc.updateStatus(r, status, now, logger)
+}
+
+// owners returns the active users whose roles r may hold, or why there are
+// none: its owner, or the member of its group who created it
+func (rc *RobotController) owners(r *netsys_v1.DispatchRobot, users []*netsys_v1.DispatchUser) ([]*netsys_v1.DispatchUser, string) {
+	if (r.Spec.Owner == "") == (r.Spec.OwnerGroup == "") {
+		return nil, "set either owner or ownerGroup"
+	}
+	var owners []*netsys_v1.DispatchUser
+	for _, u := range users {
+		if r.IsOwner(u.Spec.UserID, u.Spec.Groups) && !u.Status.Expired && !u.Spec.Suspended {
+			owners = append(owners, u)
+		}
+	}
+	if r.Spec.Owner != "" {
+		if len(owners) == 0 {
+			return nil, fmt.Sprintf("owner %s does not exist or is expired or suspended", r.Spec.Owner)
+		}
+		return owners, ""
+	}
+	// any member holding a role would otherwise pass it to robots other
+	// members create
+	for _, u := range owners {
+		if u.Spec.UserID == r.Spec.CreatedBy {
+			return []*netsys_v1.DispatchUser{u}, ""
+		}
+	}
+	if r.Spec.CreatedBy == "" {
+		return nil, "robots of a group need createdBy, the member whose roles they hold"
+	}
+	return nil, fmt.Sprintf("%s, who created the robot, is no longer an active member of group %s", r.Spec.CreatedBy, r.Spec.OwnerGroup)
+}
+
+// grants returns the grants of r its owners hold with at least the role
+// asked for, and why the others were refused
+func (rc *RobotController) grants(r *netsys_v1.DispatchRobot, owners []*netsys_v1.DispatchUser, owned []*netsys_v1.OwnedNamespace) ([]netsys_v1.RobotGrant, []string) {
+	isOwner := map[string]bool{}
+	for _, u := range owners {
+		isOwner[u.Spec.UserID] = true
+	}
+	// the highest role any owner holds right now
+	held := map[string]string{}
+	for _, on := range owned {
+		if !isOwner[on.Spec.OwnerID] || !on.Bound() {
+			continue
+		}
+		if role := on.EffectiveRole(); held[on.Key()] == "" || netsys_v1.RoleIncludes(role, held[on.Key()]) {
+			held[on.Key()] = role
+		}
+	}
+
+	var granted []netsys_v1.RobotGrant
+	var refusals []string
+	seen := map[string]bool{}
+	for _, g := range r.Spec.Grants {
+		role, ns := g.GrantedRole(), g.Namespace
+		switch {
+		case seen[ns]:
+			refusals = append(refusals, fmt.Sprintf("%s: granted more than once", ns))
+		case !netsys_v1.ValidRole(role):
+			refusals = append(refusals, fmt.Sprintf("%s: role %q must be view, edit or admin", ns, role))
+		case strings.Contains(ns, "/"):
+			refusals = append(refusals, fmt.Sprintf("%s: robots only hold namespaces of the cluster dispatch runs in", ns))
+		case rc.config.IsProtected(ns):
+			refusals = append(refusals, fmt.Sprintf("%s: the namespace is protected", ns))
+		case held[ns] == "":
+			refusals = append(refusals, fmt.Sprintf("%s: no owner holds the namespace", ns))
+		case !netsys_v1.RoleIncludes(held[ns], role):
+			refusals = append(refusals, fmt.Sprintf("%s: owners hold %s, not %s", ns, held[ns], role))
+		default:
+			granted = append(granted, netsys_v1.RobotGrant{Namespace: ns, Role: role})
+		}
+		seen[ns] = true
+	}
+	return granted, refusals
+}
+
+// ensureServiceAccount returns the ServiceAccount of r, created if
+// missing, or why r cannot have it
+func (rc *RobotController) ensureServiceAccount(r *netsys_v1.DispatchRobot, users []*netsys_v1.DispatchUser, logger *logging.Logger) (*core_v1.ServiceAccount, string, error) {
+	name := netsys_v1.RobotServiceAccount(r.Name)
+	for _, u := range users {
+		if u.Spec.UserID == name {
+			return nil, fmt.Sprintf("ServiceAccount %s belongs to the user %s", name, u.Spec.UserID), nil
+		}
+	}
+	sas := rc.clientsets.OriginalClient.CoreV1().ServiceAccounts(rc.config.DispatchNamespace)
+	sa, err := sas.Get(name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		sa, err = sas.Create(&core_v1.ServiceAccount{ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: rc.config.DispatchNamespace,
+			Labels:    map[string]string{netsys_v1.RobotLabel: r.Name},
+		}})
+		if err != nil {
+			return nil, "", err
+		}
+		logger.Info("Created ServiceAccount", "serviceaccount", name)
+		return sa, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if sa.Labels[netsys_v1.RobotLabel] != r.Name {
+		return nil, fmt.Sprintf("ServiceAccount %s exists and does not belong to the robot", name), nil
+	}
+	return sa, "", nil
+}
+
+// rotateToken issues a new token once the current one is older than the
+// TTL of r and deletes the tokens that are no longer valid: all but the
+// current one and, within robots.tokenOverlap of the rotation, the
+// previous one
+func (rc *RobotController) rotateToken(r *netsys_v1.DispatchRobot, sa *core_v1.ServiceAccount, status *netsys_v1.DispatchRobotStatus,
+	tokens []core_v1.Secret, now time.Time, logger *logging.Logger) error {
+	ttl := rc.config.Robots.TokenTTL.Duration
+	if r.Spec.TokenTTL != nil && r.Spec.TokenTTL.Duration > 0 {
+		ttl = r.Spec.TokenTTL.Duration
+	}
+	secrets := rc.clientsets.OriginalClient.CoreV1().Secrets(sa.Namespace)
+	current := false
+	for _, t := range tokens {
+		current = current || t.Name == status.TokenSecret
+	}
+	if !current || status.TokenIssuedAt == nil || !now.Before(status.TokenIssuedAt.Add(ttl)) {
+		token, err := secrets.Create(&core_v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{
+				GenerateName: sa.Name + "-token-",
+				Namespace:    sa.Namespace,
+				Labels:       map[string]string{netsys_v1.RobotLabel: r.Name},
+				Annotations:  map[string]string{core_v1.ServiceAccountNameKey: sa.Name},
+			},
+			Type: core_v1.SecretTypeServiceAccountToken,
+		})
+		if err != nil {
+			return err
+		}
+		status.PreviousTokenSecret = ""
+		if current {
+			status.PreviousTokenSecret = status.TokenSecret
+		}
+		issuedAt := meta_v1.NewTime(now)
+		status.TokenSecret, status.TokenIssuedAt = token.Name, &issuedAt
+		tokens = append(tokens, *token)
+		logger.Info("Issued token", "secret", token.Name, "previous", status.PreviousTokenSecret)
+		rc.audit(r, audit.ActionCredentialIssued, "", "", "", "token rotated")
+		rc.recorder.Eventf(r, core_v1.EventTypeNormal, controller.RobotTokenRotated,
+			"Issued token %s, the previous token stays valid for %s", token.Name, rc.config.Robots.TokenOverlap.Duration)
+	}
+
+	overlap := now.Before(status.TokenIssuedAt.Add(rc.config.Robots.TokenOverlap.Duration))
+	var refs []core_v1.ObjectReference
+	for _, t := range tokens {
+		if t.Name == status.TokenSecret || (t.Name == status.PreviousTokenSecret && overlap) {
+			refs = append(refs, core_v1.ObjectReference{Name: t.Name})
+			continue
+		}
+		// tokens are valid as long as their Secret exists
+		if err := secrets.Delete(t.Name, nil); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if t.Name == status.PreviousTokenSecret {
+			status.PreviousTokenSecret = ""
+		}
+		logger.Info("Deleted token", "secret", t.Name)
+		rc.audit(r, audit.ActionCredentialRevoked, "", "", "", "token rotated")
+	}
+	// the token controller creates a token for ServiceAccounts that
+	// reference none
+	if equality.Semantic.DeepEqual(sa.Secrets, refs) {
+		return nil
+	}
+	updated := sa.DeepCopy()
+	updated.Secrets = refs
+	_, err := rc.clientsets.OriginalClient.CoreV1().ServiceAccounts(sa.Namespace).Update(updated)
+	return err
+}
+
+// bindingName is the name of the RoleBindings of the robot name
+func bindingName(name string) string {
+	return netsys_v1.RobotServiceAccount(name)
+}
+
+// bind binds the ServiceAccount of r with granted and removes the other
+// bindings of r
+func (rc *RobotController) bind(r *netsys_v1.DispatchRobot, owners []*netsys_v1.DispatchUser, granted []netsys_v1.RobotGrant,
+	bindings []rbac_v1.RoleBinding, logger *logging.Logger) error {
+	var ids []string
+	for _, u := range owners {
+		ids = append(ids, u.Spec.UserID)
+	}
+	reason := "robot of " + strings.Join(ids, ", ")
+	current := map[string]*rbac_v1.RoleBinding{}
+	for i := range bindings {
+		if bindings[i].Name == bindingName(r.Name) {
+			current[bindings[i].Namespace] = &bindings[i]
+		}
+	}
+
+	var errs []string
+	for _, g := range granted {
+		rb := &rbac_v1.RoleBinding{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      bindingName(r.Name),
+				Namespace: g.Namespace,
+				Labels:    map[string]string{netsys_v1.RobotLabel: r.Name},
+			},
+			Subjects: []rbac_v1.Subject{{
+				Kind:      rbac_v1.ServiceAccountKind,
+				Name:      netsys_v1.RobotServiceAccount(r.Name),
+				Namespace: rc.config.DispatchNamespace,
+			}},
+			RoleRef: rbac_v1.RoleRef{APIGroup: rbac_v1.GroupName, Kind: "ClusterRole", Name: g.Role},
+		}
+		existing := current[g.Namespace]
+		delete(current, g.Namespace)
+		if existing != nil && existing.RoleRef == rb.RoleRef {
+			continue
+		}
+		if err := rc.replaceBinding(existing, rb); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", g.Namespace, err))
+			continue
+		}
+		if existing == nil {
+			logger.Info("Added grant", "namespace", g.Namespace, "role", g.Role)
+			rc.audit(r, audit.ActionGrant, g.Namespace, g.Role, "", reason)
+			continue
+		}
+		logger.Info("Changed role", "namespace", g.Namespace, "from", existing.RoleRef.Name, "role", g.Role)
+		rc.audit(r, audit.ActionRoleChange, g.Namespace, g.Role, existing.RoleRef.Name, reason)
+	}
+	for _, rb := range current {
+		if err := rc.unbind(rb, "no longer granted or held by an owner", logger); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", rb.Namespace, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// replaceBinding creates rb, replacing existing if any. The role of a
+// RoleBinding cannot be changed in place.
+func (rc *RobotController) replaceBinding(existing, rb *rbac_v1.RoleBinding) error {
+	rbs := rc.clientsets.OriginalClient.RbacV1().RoleBindings(rb.Namespace)
+	if existing != nil {
+		if err := rbs.Delete(existing.Name, nil); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	_, err := rbs.Create(rb)
+	if errors.IsAlreadyExists(err) {
+		return fmt.Errorf("RoleBinding %s exists and does not belong to the robot", rb.Name)
+	}
+	return err
+}
+
+// unbind deletes a RoleBinding of a robot
+func (rc *RobotController) unbind(rb *rbac_v1.RoleBinding, reason string, logger *logging.Logger) error {
+	err := rc.clientsets.OriginalClient.RbacV1().RoleBindings(rb.Namespace).Delete(rb.Name, nil)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("Revoked grant", "namespace", rb.Namespace, "role", rb.RoleRef.Name, "reason", reason)
+	rc.auditor.Record(audit.Record{
+		Action:    audit.ActionRevoke,
+		User:      rb.Subjects[0].Name,
+		Namespace: rb.Namespace,
+		Role:      rb.RoleRef.Name,
+		Reason:    reason,
+		Source:    "DispatchRobot/" + rc.config.DispatchNamespace + "/" + rb.Labels[netsys_v1.RobotLabel],
+	})
+	return nil
+}
+
+// disable removes the bindings and ServiceAccount of r, which invalidates
+// its tokens
+func (rc *RobotController) disable(r *netsys_v1.DispatchRobot, bindings []rbac_v1.RoleBinding, reason string, logger *logging.Logger) error {
+	for i := range bindings {
+		if err := rc.unbind(&bindings[i], reason, logger); err != nil {
+			return err
+		}
+	}
+	return rc.deleteServiceAccount(r.Name, reason, logger)
+}
+
+// deleteServiceAccount deletes the ServiceAccount of the robot name unless
+// it belongs to someone else
+func (rc *RobotController) deleteServiceAccount(name, reason string, logger *logging.Logger) error {
+	sas := rc.clientsets.OriginalClient.CoreV1().ServiceAccounts(rc.config.DispatchNamespace)
+	sa, err := sas.Get(netsys_v1.RobotServiceAccount(name), meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if sa.Labels[netsys_v1.RobotLabel] != name {
+		return nil
+	}
+	if err := sas.Delete(sa.Name, nil); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	logger.Info("Deleted ServiceAccount", "serviceaccount", sa.Name, "reason", reason)
+	rc.auditor.Record(audit.Record{
+		Action: audit.ActionCredentialRevoked,
+		User:   sa.Name,
+		Reason: reason,
+		Source: "DispatchRobot/" + rc.config.DispatchNamespace + "/" + name,
+	})
+	return nil
+}
+
+// removeDeleted deletes the bindings and ServiceAccounts of robots that no
+// longer exist
+func (rc *RobotController) removeDeleted(exists map[string]bool, bindings []rbac_v1.RoleBinding, logger *logging.Logger) error {
+	var errs []string
+	for i := range bindings {
+		name := bindings[i].Labels[netsys_v1.RobotLabel]
+		if exists[name] || bindings[i].Name != bindingName(name) {
+			continue
+		}
+		if err := rc.unbind(&bindings[i], "DispatchRobot deleted", logger.With("robot", name)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s/%s: %v", bindings[i].Namespace, bindings[i].Name, err))
+		}
+	}
+	sas, err := rc.clientsets.OriginalClient.CoreV1().ServiceAccounts(rc.config.DispatchNamespace).List(
+		meta_v1.ListOptions{LabelSelector: netsys_v1.RobotLabel})
+	if err != nil {
+		return err
+	}
+	for _, sa := range sas.Items {
+		name := sa.Labels[netsys_v1.RobotLabel]
+		if exists[name] {
+			continue
+		}
+		if err := rc.deleteServiceAccount(name, "DispatchRobot deleted", logger.With("robot", name)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", sa.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// updateStatus records status on r, with an Event when r is disabled,
+// enabled again or grants are refused
+func (rc *RobotController) updateStatus(r *netsys_v1.DispatchRobot, status *netsys_v1.DispatchRobotStatus, now time.Time, logger *logging.Logger) error {
+	if equality.Semantic.DeepEqual(&r.Status, status) {
+		return nil
+	}
+	updated := r.DeepCopy()
+	updated.Status = *status
+	updatedAt := meta_v1.NewTime(now)
+	updated.Status.UpdatedAt = &updatedAt
+	if _, err := rc.clientsets.NetsysClient.NetsysV1().DispatchRobots(r.Namespace).Update(updated); err != nil {
+		return err
+	}
+
+	switch {
+	case status.Phase == netsys_v1.RobotDisabled && r.Status.Phase != netsys_v1.RobotDisabled:
+		logger.Warn("Disabled robot", "reason", status.Message)
+		rc.recorder.Eventf(r, core_v1.EventTypeWarning, controller.RobotDisabled,
+			"Disabled robot %s, its tokens are revoked: %s", r.Name, status.Message)
+	case status.Phase == netsys_v1.RobotActive && r.Status.Phase == netsys_v1.RobotDisabled:
+		logger.Info("Enabled robot")
+		rc.recorder.Eventf(r, core_v1.EventTypeNormal, controller.RobotEnabled,
+			"Enabled robot %s, an owner is active again", r.Name)
+	}
+	if status.Phase == netsys_v1.RobotActive && status.Message != "" && status.Message != r.Status.Message {
+		logger.Warn("Refused robot grants", "reason", status.Message)
+		rc.recorder.Eventf(r, core_v1.EventTypeWarning, controller.RobotGrantRefused,
+			"Refused grants of robot %s: %s", r.Name, status.Message)
+	}
+	return nil
+}
+
+// audit records an access change of r
+func (rc *RobotController) audit(r *netsys_v1.DispatchRobot, action, namespace, role, previousRole, reason string) {
+	rc.auditor.Record(audit.Record{
+		Action:       action,
+		User:         netsys_v1.RobotServiceAccount(r.Name),
+		Namespace:    namespace,
+		Role:         role,
+		PreviousRole: previousRole,
+		Reason:       reason,
+		Source:       "DispatchRobot/" + r.Namespace + "/" + r.Name,
+	})
+}
@@ -0,0 +1,167 @@
+package robot
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+)
+
+func user(userID string, groups ...string) *netsys_v1.DispatchUser {
+	return &netsys_v1.DispatchUser{
+		ObjectMeta: meta_v1.ObjectMeta{Name: userID},
+		Spec:       netsys_v1.DispatchUserSpec{UserID: userID, Groups: groups},
+	}
+}
+
+func owned(owner, namespace, role string) *netsys_v1.OwnedNamespace {
+	return &netsys_v1.OwnedNamespace{
+		Spec: netsys_v1.OwnedNamespaceSpec{OwnerID: owner, Namespace: namespace, Role: role},
+	}
+}
+
+// testUsers are members of the group ci, carol is suspended and dave
+// expired
+func testUsers() []*netsys_v1.DispatchUser {
+	carol, dave := user("carol", "ci"), user("dave", "ci")
+	carol.Spec.Suspended = true
+	dave.Status.Expired = true
+	return []*netsys_v1.DispatchUser{user("alice", "ci"), user("bob", "ci"), carol, dave, user("eve")}
+}
+
+// testOwned has alice hold web as admin and db as view, and bob db as
+// admin and api as edit. alice lost the lease of old and is outside the
+// window of sleepy, which revokes write access.
+func testOwned() []*netsys_v1.OwnedNamespace {
+	old := owned("alice", "old", netsys_v1.RoleAdmin)
+	old.Status.Lease = &netsys_v1.NamespaceLease{State: netsys_v1.LeaseExpired}
+	sleepy := owned("alice", "sleepy", netsys_v1.RoleEdit)
+	sleepy.Spec.Schedule = &netsys_v1.AvailabilitySchedule{RevokeWrite: true}
+	sleepy.Status.Schedule = &netsys_v1.ScheduleStatus{Awake: false}
+	return []*netsys_v1.OwnedNamespace{
+		owned("alice", "web", netsys_v1.RoleAdmin),
+		owned("alice", "db", netsys_v1.RoleView),
+		owned("bob", "db", netsys_v1.RoleAdmin),
+		owned("bob", "api", netsys_v1.RoleEdit),
+		old,
+		sleepy,
+	}
+}
+
+func robot(owner, ownerGroup, createdBy string, grants ...netsys_v1.RobotGrant) *netsys_v1.DispatchRobot {
+	return &netsys_v1.DispatchRobot{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "deploy"},
+		Spec: netsys_v1.DispatchRobotSpec{Owner: owner, OwnerGroup: ownerGroup, CreatedBy: createdBy,
+			Grants: grants},
+	}
+}
+
+func TestOwners(t *testing.T) {
+	rc := &RobotController{config: config.Default()}
+
+	tests := []struct {
+		name   string
+		robot  *netsys_v1.DispatchRobot
+		owners []string
+		reason string
+	}{
+		{name: "owner", robot: robot("alice", "", ""), owners: []string{"alice"}},
+		{name: "suspended owner", robot: robot("carol", "", ""), reason: "owner carol does not exist or is expired or suspended"},
+		{name: "expired owner", robot: robot("dave", "", ""), reason: "owner dave does not exist"},
+		{name: "unknown owner", robot: robot("zed", "", ""), reason: "owner zed does not exist"},
+		{name: "no owner", robot: robot("", "", ""), reason: "set either owner or ownerGroup"},
+		{name: "owner and group", robot: robot("alice", "ci", ""), reason: "set either owner or ownerGroup"},
+		// a group robot holds the roles of its creator only
+		{name: "group", robot: robot("", "ci", "bob"), owners: []string{"bob"}},
+		{name: "group without creator", robot: robot("", "ci", ""), reason: "robots of a group need createdBy"},
+		{name: "suspended creator", robot: robot("", "ci", "carol"), reason: "carol, who created the robot, is no longer an active member of group ci"},
+		{name: "creator outside the group", robot: robot("", "ci", "eve"), reason: "eve, who created the robot, is no longer an active member"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			owners, reason := rc.owners(test.robot, testUsers())
+			var ids []string
+			for _, u := range owners {
+				ids = append(ids, u.Spec.UserID)
+			}
+			if !reflect.DeepEqual(ids, test.owners) {
+				t.Errorf("expected owners %v, got %v", test.owners, ids)
+			}
+			if (test.reason == "") != (reason == "") || !strings.Contains(reason, test.reason) {
+				t.Errorf("expected reason %q, got %q", test.reason, reason)
+			}
+		})
+	}
+}
+
+func TestGrants(t *testing.T) {
+	rc := &RobotController{config: config.Default()}
+
+	tests := []struct {
+		name    string
+		creator string
+		grant   netsys_v1.RobotGrant
+		// role granted, or why the grant is refused
+		role, refusal string
+	}{
+		{name: "creator's role", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "web", Role: "admin"}, role: "admin"},
+		{name: "lower role", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "web", Role: "edit"}, role: "edit"},
+		{name: "view by default", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "web"}, role: "view"},
+		{name: "other member's role", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "db", Role: "admin"},
+			refusal: "db: owners hold view, not admin"},
+		{name: "other member's namespace", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "api"},
+			refusal: "api: no owner holds the namespace"},
+		{name: "other creator", creator: "bob", grant: netsys_v1.RobotGrant{Namespace: "db", Role: "admin"}, role: "admin"},
+		{name: "expired lease", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "old"},
+			refusal: "old: no owner holds the namespace"},
+		{name: "outside the window", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "sleepy", Role: "edit"},
+			refusal: "sleepy: owners hold view, not edit"},
+		{name: "invalid role", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "web", Role: "cluster-admin"},
+			refusal: `web: role "cluster-admin" must be view, edit or admin`},
+		{name: "member cluster", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "east/web"},
+			refusal: "robots only hold namespaces of the cluster dispatch runs in"},
+		{name: "protected", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "kube-system"},
+			refusal: "kube-system: the namespace is protected"},
+		{name: "dispatch namespace", creator: "alice", grant: netsys_v1.RobotGrant{Namespace: "dispatch"},
+			refusal: "dispatch: the namespace is protected"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := robot("", "ci", test.creator, test.grant)
+			owners, reason := rc.owners(r, testUsers())
+			if reason != "" {
+				t.Fatal(reason)
+			}
+			granted, refusals := rc.grants(r, owners, testOwned())
+			if test.role != "" {
+				expected := []netsys_v1.RobotGrant{{Namespace: test.grant.Namespace, Role: test.role}}
+				if !reflect.DeepEqual(granted, expected) || len(refusals) > 0 {
+					t.Errorf("expected %v granted, got %v and refusals %v", expected, granted, refusals)
+				}
+				return
+			}
+			if len(granted) > 0 || len(refusals) != 1 || !strings.Contains(refusals[0], test.refusal) {
+				t.Errorf("expected the refusal %q, got %v granted and refusals %v", test.refusal, granted, refusals)
+			}
+		})
+	}
+}
+
+func TestGrantsOnce(t *testing.T) {
+	rc := &RobotController{config: config.Default()}
+	r := robot("alice", "", "", netsys_v1.RobotGrant{Namespace: "web"}, netsys_v1.RobotGrant{Namespace: "web", Role: "admin"})
+	owners, _ := rc.owners(r, testUsers())
+	granted, refusals := rc.grants(r, owners, testOwned())
+	if len(granted) != 1 || granted[0].Role != netsys_v1.RoleView {
+		t.Errorf("expected the first grant only, got %v", granted)
+	}
+	if len(refusals) != 1 || !strings.Contains(refusals[0], "web: granted more than once") {
+		t.Errorf("expected the second grant refused, got %v", refusals)
+	}
+}
@@ -0,0 +1,318 @@
+// Package tenant keeps DispatchTenants in line with their users. The
+// admins of every tenant are bound to the dispatch-tenant-admin ClusterRole
+// in the dispatch namespace, which lets them manage DispatchUsers; the
+// admission webhook keeps them to the users of their tenant and the
+// DispatchUser controller to its budget.
+package tenant
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+)
+
+const (
+	// label of this controller's metrics
+	controllerName = "tenant"
+
+	// AdminClusterRole is bound to the admins of every active tenant
+	AdminClusterRole = "dispatch-tenant-admin"
+)
+
+// TenantController binds the admins of DispatchTenants and records what
+// the tenants use of their budget
+type TenantController struct {
+	dtLister netsys_lister.DispatchTenantLister
+	duLister netsys_lister.DispatchUserLister
+	onLister netsys_lister.OwnedNamespaceLister
+
+	dtListerSynced cache.InformerSynced
+	duListerSynced cache.InformerSynced
+	onListerSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// records Events on DispatchTenants
+	recorder record.EventRecorder
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+}
+
+// NewTenantController creates a new TenantController
+func NewTenantController(
+	dtInformer netsys_informer.DispatchTenantInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+	recorder record.EventRecorder,
+) *TenantController {
+	return &TenantController{
+		dtLister:       dtInformer.Lister(),
+		duLister:       duInformer.Lister(),
+		onLister:       onInformer.Lister(),
+		dtListerSynced: dtInformer.Informer().HasSynced,
+		duListerSynced: duInformer.Informer().HasSynced,
+		onListerSynced: onInformer.Informer().HasSynced,
+		clientsets:     clientSets,
+		config:         cfg,
+		recorder:       recorder,
+		tracker:        health.NewTracker(controllerName),
+	}
+}
+
+// Run syncs the tenants every tenants.interval until stopCh is closed
+func (tc *TenantController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, tc.dtListerSynced, tc.duListerSynced, tc.onListerSynced) {
+		return
+	}
+	wait.Until(tc.sync, tc.config.Tenants.Interval.Duration, stopCh)
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (tc *TenantController) Tracker() *health.Tracker {
+	return tc.tracker
+}
+
+func (tc *TenantController) sync() {
+	start := time.Now()
+	id := tc.tracker.Started("sync", "tenants")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := tc.syncTenants(time.Now(), logger)
+
+	tc.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// syncTenants counts the users and namespaces of every tenant, binds the
+// admins of active tenants and unbinds those of refused and deleted ones
+func (tc *TenantController) syncTenants(now time.Time, logger *logging.Logger) error {
+	tenants, err := tc.dtLister.DispatchTenants(tc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	users, err := tc.duLister.DispatchUsers(tc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	owned, err := tc.onLister.OwnedNamespaces(tc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	tenantOf := map[string]string{}
+	count := map[string]int{}
+	for _, du := range users {
+		if name := du.Labels[netsys_v1.TenantLabel]; name != "" {
+			tenantOf[du.Spec.UserID] = name
+			count[name]++
+		}
+	}
+	namespaces := map[string]map[string]bool{}
+	for _, on := range owned {
+		name := tenantOf[on.Spec.OwnerID]
+		if name == "" {
+			continue
+		}
+		if namespaces[name] == nil {
+			namespaces[name] = map[string]bool{}
+		}
+		namespaces[name][on.Key()] = true
+	}
+
+	var errs []string
+	active := map[string]bool{}
+	for _, t := range tenants {
+		l := logger.With("tenant", t.Name)
+		conflict := t.Conflict(tenants)
+		if conflict == "" {
+			active[t.Name] = true
+			if err := tc.bindAdmins(t, l); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", t.Name, err))
+			}
+		}
+		if err := tc.updateStatus(t, conflict, count[t.Name], len(namespaces[t.Name]), now, l); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", t.Name, err))
+		}
+	}
+	if err := tc.unbindInactive(active, logger); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// updateStatus records the phase and usage of t, warning once when it is
+// refused or over its budget
+func (tc *TenantController) updateStatus(t *netsys_v1.DispatchTenant, conflict string, users, namespaces int, now time.Time, logger *logging.Logger) error {
+	updated := t.DeepCopy()
+	updated.Status.Users = users
+	updated.Status.Namespaces = namespaces
+	updated.Status.Phase = netsys_v1.TenantActive
+	updated.Status.Message = ""
+	if conflict != "" {
+		updated.Status.Phase = netsys_v1.TenantRefused
+		updated.Status.Message = conflict
+	} else if over := overBudget(t, users, namespaces); over != "" {
+		updated.Status.Message = over
+	}
+	if equality.Semantic.DeepEqual(t.Status, updated.Status) {
+		return nil
+	}
+	updatedAt := meta_v1.NewTime(now)
+	updated.Status.UpdatedAt = &updatedAt
+	if _, err := tc.clientsets.NetsysClient.NetsysV1().DispatchTenants(t.Namespace).Update(updated); err != nil {
+		return err
+	}
+	if updated.Status.Message == t.Status.Message || updated.Status.Message == "" {
+		return nil
+	}
+	if conflict != "" {
+		logger.Warn("Refused tenant", "reason", conflict)
+		tc.recorder.Eventf(t, core_v1.EventTypeWarning, controller.TenantRefused,
+			"Refused tenant %s, its users get no grants: %s", t.Name, conflict)
+	} else {
+		logger.Warn("Tenant over budget", "reason", updated.Status.Message)
+		tc.recorder.Eventf(t, core_v1.EventTypeWarning, controller.TenantOverBudget,
+			"Tenant %s is over its budget: %s", t.Name, updated.Status.Message)
+	}
+	return nil
+}
+
+// overBudget describes what t holds beyond its limits, e.g. after they
+// were lowered, or returns "" if it is within them
+func overBudget(t *netsys_v1.DispatchTenant, users, namespaces int) string {
+	var over []string
+	if max := t.Spec.MaxUsers; max > 0 && users > max {
+		over = append(over, fmt.Sprintf("%d users of %d", users, max))
+	}
+	if max := t.Spec.MaxNamespaces; max > 0 && namespaces > max {
+		over = append(over, fmt.Sprintf("%d namespaces of %d", namespaces, max))
+	}
+	return strings.Join(over, ", ")
+}
+
+// bindingName is the name of the RoleBinding of the admins of a tenant
+func bindingName(tenant string) string {
+	return AdminClusterRole + "-" + tenant
+}
+
+// bindAdmins binds the admins and admin groups of t to AdminClusterRole in
+// the dispatch namespace. Admins are bound as their ServiceAccounts, which
+// the proxy and their tokens authenticate them as.
+func (tc *TenantController) bindAdmins(t *netsys_v1.DispatchTenant, logger *logging.Logger) error {
+	var subjects []rbac_v1.Subject
+	for _, id := range t.Spec.Admins {
+		subjects = append(subjects, rbac_v1.Subject{
+			Kind:      rbac_v1.ServiceAccountKind,
+			Name:      id,
+			Namespace: tc.config.DispatchNamespace,
+		})
+	}
+	for _, g := range t.Spec.AdminGroups {
+		subjects = append(subjects, rbac_v1.Subject{Kind: rbac_v1.GroupKind, APIGroup: rbac_v1.GroupName, Name: g})
+	}
+	rbs := tc.clientsets.OriginalClient.RbacV1().RoleBindings(tc.config.DispatchNamespace)
+	binding := &rbac_v1.RoleBinding{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      bindingName(t.Name),
+			Namespace: tc.config.DispatchNamespace,
+			Labels:    map[string]string{netsys_v1.TenantLabel: t.Name},
+		},
+		Subjects: subjects,
+		RoleRef:  rbac_v1.RoleRef{APIGroup: rbac_v1.GroupName, Kind: "ClusterRole", Name: AdminClusterRole},
+	}
+
+	existing, err := rbs.Get(binding.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if len(subjects) == 0 {
+			return nil
+		}
+		if _, err := rbs.Create(binding); err != nil {
+			return err
+		}
+		logger.Info("Bound tenant admins", "rolebinding", binding.Name, "admins", len(subjects))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(subjects) == 0 {
+		if err := rbs.Delete(binding.Name, nil); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("Unbound tenant admins", "rolebinding", binding.Name)
+		return nil
+	}
+	if equality.Semantic.DeepEqual(existing.Subjects, binding.Subjects) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Subjects = binding.Subjects
+	if _, err := rbs.Update(updated); err != nil {
+		return err
+	}
+	logger.Info("Updated tenant admins", "rolebinding", binding.Name, "admins", len(subjects))
+	return nil
+}
+
+// unbindInactive deletes the admin RoleBindings of tenants that were
+// deleted or refused
+func (tc *TenantController) unbindInactive(active map[string]bool, logger *logging.Logger) error {
+	rbs := tc.clientsets.OriginalClient.RbacV1().RoleBindings(tc.config.DispatchNamespace)
+	list, err := rbs.List(meta_v1.ListOptions{LabelSelector: netsys_v1.TenantLabel})
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, rb := range list.Items {
+		name := rb.Labels[netsys_v1.TenantLabel]
+		if active[name] || rb.Name != bindingName(name) {
+			continue
+		}
+		if err := rbs.Delete(rb.Name, nil); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("%s: %v", rb.Name, err))
+			continue
+		}
+		logger.Info("Unbound tenant admins", "tenant", name, "rolebinding", rb.Name)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
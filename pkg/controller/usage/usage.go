@@ -0,0 +1,244 @@
+// Package usage keeps the resource usage of every DispatchUser in its
+// status. Usage is added up from informer caches of the granted namespaces,
+// and namespaces held by several users are split among them. With
+// chargeback enabled every user's share is also sampled into daily records.
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/chargeback"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/health"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/metrics"
+	"github.com/hantaowang/dispatch/pkg/usage"
+)
+
+// label of this controller's metrics
+const controllerName = "usage"
+
+// UsageController writes the usage of DispatchUsers
+type UsageController struct {
+	onLister netsys_lister.OwnedNamespaceLister
+	duLister netsys_lister.DispatchUserLister
+
+	onListerSynced cache.InformerSynced
+	duListerSynced cache.InformerSynced
+
+	// objects counted in the granted namespaces
+	usage       usage.Listers
+	usageSynced cache.InformerSynced
+
+	clientsets client.ClientSets
+	config     *config.Config
+
+	// keeps the chargeback records
+	store chargeback.Store
+	// when usage was last sampled, and the day old records were last pruned
+	lastSample time.Time
+	lastPrune  string
+
+	// follows the periodic syncs for health checks
+	tracker *health.Tracker
+}
+
+// NewUsageController creates a new UsageController
+func NewUsageController(
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	usageInformers usage.Informers,
+	clientSets client.ClientSets,
+	cfg *config.Config,
+) *UsageController {
+	return &UsageController{
+		onLister:       onInformer.Lister(),
+		duLister:       duInformer.Lister(),
+		onListerSynced: onInformer.Informer().HasSynced,
+		duListerSynced: duInformer.Informer().HasSynced,
+		usage:          usageInformers.Listers(),
+		usageSynced:    usageInformers.HasSynced,
+		clientsets:     clientSets,
+		config:         cfg,
+		store:          chargeback.ConfigMapStore{Client: clientSets.OriginalClient, Namespace: cfg.DispatchNamespace},
+		tracker:        health.NewTracker(controllerName),
+	}
+}
+
+// Run adds up usage every usage.interval until stopCh is closed
+func (uc *UsageController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+
+	logging.Info("Starting controller", "controller", controllerName)
+	defer logging.Info("Shutting down controller", "controller", controllerName)
+
+	if !cache.WaitForCacheSync(stopCh, uc.onListerSynced, uc.duListerSynced, uc.usageSynced) {
+		return
+	}
+	wait.Until(uc.sync, uc.config.Usage.Interval.Duration, stopCh)
+}
+
+// Tracker returns the tracker of the controller's syncs
+func (uc *UsageController) Tracker() *health.Tracker {
+	return uc.tracker
+}
+
+func (uc *UsageController) sync() {
+	start := time.Now()
+	id := uc.tracker.Started("sync", "usage")
+	logger := logging.With("controller", controllerName, "reconcile", logging.NewID())
+
+	err := uc.syncUsage(time.Now(), logger)
+
+	uc.tracker.Done(id, err, 0)
+	metrics.ReconcileTotal.Inc(controllerName, "sync")
+	metrics.ReconcileDuration.Observe(time.Since(start).Seconds(), controllerName)
+	if err != nil {
+		logger.Error("Reconcile failed", "error", err)
+		metrics.ReconcileErrors.Inc(controllerName, "sync")
+	}
+}
+
+// syncUsage adds up every granted namespace once and writes the share of
+// every holder to its DispatchUser. Namespaces of suspended users and
+// expired leases still count, since their objects still run.
+func (uc *UsageController) syncUsage(now time.Time, logger *logging.Logger) error {
+	owned, err := uc.onLister.OwnedNamespaces(uc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	holders := map[string][]string{}
+	for _, on := range owned {
+		if on.Spec.Cluster != "" {
+			// only the cluster dispatch runs in is watched
+			continue
+		}
+		holders[on.Spec.Namespace] = append(holders[on.Spec.Namespace], on.Spec.OwnerID)
+	}
+
+	var errs []string
+	byUser := map[string]map[string]core_v1.ResourceList{}
+	for ns, users := range holders {
+		u, err := uc.usage.Namespace(ns)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", ns, err))
+			continue
+		}
+		share := usage.Share(u, len(users), uc.config.Usage.Split)
+		for _, user := range users {
+			if byUser[user] == nil {
+				byUser[user] = map[string]core_v1.ResourceList{}
+			}
+			byUser[user][ns] = share
+		}
+	}
+
+	users, err := uc.duLister.DispatchUsers(uc.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	for _, u := range users {
+		if err := uc.updateUsage(u, byUser[u.Spec.UserID], now); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", u.Name, err))
+		}
+	}
+	logger.Debug("Updated usage", "users", len(users), "namespaces", len(holders))
+
+	if uc.config.Chargeback.Enabled {
+		if err := uc.sample(byUser, users, now); err != nil {
+			errs = append(errs, fmt.Sprintf("chargeback: %v", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// updateUsage writes the usage of u if it changed
+func (uc *UsageController) updateUsage(u *netsys_v1.DispatchUser, namespaces map[string]core_v1.ResourceList, now time.Time) error {
+	var status *netsys_v1.UsageStatus
+	if len(namespaces) > 0 {
+		status = &netsys_v1.UsageStatus{
+			Total:      core_v1.ResourceList{},
+			Namespaces: namespaces,
+			UpdatedAt:  meta_v1.NewTime(now.Truncate(time.Second)),
+		}
+		for _, share := range namespaces {
+			usage.Add(status.Total, share)
+		}
+	}
+	if old := u.Status.Usage; (old == nil) == (status == nil) &&
+		(old == nil || equality.Semantic.DeepEqual(old.Namespaces, status.Namespaces)) {
+		return nil
+	}
+
+	dus := uc.clientsets.NetsysClient.NetsysV1().DispatchUsers(u.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := dus.Get(u.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		latest.Status.Usage = status
+		_, err = dus.Update(latest)
+		return err
+	})
+}
+
+// sample charges every user for their shares over the time since the last
+// sample, at most twice the interval so that downtime is not billed
+func (uc *UsageController) sample(byUser map[string]map[string]core_v1.ResourceList, users []*netsys_v1.DispatchUser, now time.Time) error {
+	d := uc.config.Usage.Interval.Duration
+	if since := now.Sub(uc.lastSample); since < 2*d {
+		d = since
+	}
+	uc.lastSample = now
+
+	groups := make(map[string][]string, len(users))
+	for _, u := range users {
+		groups[u.Spec.UserID] = u.Spec.Groups
+	}
+	prices := uc.config.Chargeback.Prices
+	var records []chargeback.Record
+	for user, namespaces := range byUser {
+		for ns, share := range namespaces {
+			records = append(records, chargeback.Sample(ns, user, groups[user], share, d, prices))
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Namespace != records[j].Namespace {
+			return records[i].Namespace < records[j].Namespace
+		}
+		return records[i].User < records[j].User
+	})
+	if err := uc.store.Add(now, prices.Currency, records); err != nil {
+		return err
+	}
+
+	if today := now.UTC().Format(chargeback.DateLayout); today != uc.lastPrune {
+		if err := uc.store.Prune(now.AddDate(0, 0, -uc.config.Chargeback.RetentionDays)); err != nil {
+			return err
+		}
+		uc.lastPrune = today
+	}
+	return nil
+}
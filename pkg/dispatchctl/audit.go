@@ -0,0 +1,127 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/hantaowang/dispatch/pkg/audit"
+)
+
+func newAuditCommand() *command {
+	var file, user, namespace, action, keyFile, anchor, chainConfigMap string
+	var verify bool
+	return &command{
+		name:  "audit",
+		args:  "-f FILE",
+		short: "Query and verify the audit log of access changes",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&file, "file", "f", "", "audit log to read as JSON lines, - for stdin")
+			fs.StringVar(&user, "user", "", "only show records of this user ID")
+			fs.StringVar(&namespace, "namespace", "", "only show records of this namespace")
+			fs.StringVar(&action, "action", "", "only show records of this action, e.g. grant or revoke")
+			fs.BoolVar(&verify, "verify", false, "check the hash chain of the whole log before filtering")
+			fs.StringVar(&keyFile, "key-file", "", "with --verify, the audit.keyFile the records were hashed with")
+			fs.StringVar(&anchor, "anchor", "", "with --verify, SEQUENCE:HASH of the record before the first one read, if the log does not start the chain")
+			fs.StringVar(&chainConfigMap, "chain-configmap", "dispatch-audit", "with --verify, ConfigMap holding the chain head the last record must match, empty to skip")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			if file == "" {
+				return fmt.Errorf("--file is required, e.g. kubectl logs deploy/dispatch-controller | dispatchctl audit -f -")
+			}
+			var in io.Reader = os.Stdin
+			if file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+			records, err := audit.ReadRecords(in)
+			if err != nil {
+				return err
+			}
+			if verify {
+				v, err := c.verification(keyFile, anchor, chainConfigMap)
+				if err != nil {
+					return err
+				}
+				if err := audit.Verify(records, v); err != nil {
+					return fmt.Errorf("audit log verification failed: %v", err)
+				}
+				fmt.Fprintf(c.errOut, "verified %d records\n", len(records))
+			}
+
+			matched := []audit.Record{}
+			for _, r := range records {
+				if (user == "" || r.User == user) && (namespace == "" || r.Namespace == namespace) &&
+					(action == "" || r.Action == action) {
+					matched = append(matched, r)
+				}
+			}
+			return c.print(matched, func(w io.Writer) {
+				row(w, "SEQ", "TIME", "ACTION", "USER", "NAMESPACE", "ROLE", "REASON")
+				for _, r := range matched {
+					role := r.Role
+					if r.PreviousRole != "" {
+						role = r.PreviousRole + "->" + r.Role
+					}
+					row(w, r.Sequence, r.Time.Format(time.RFC3339), r.Action, orNone(r.User),
+						orNone(r.Namespace), orNone(role), orNone(r.Reason))
+				}
+			})
+		},
+	}
+}
+
+// verification reads what audit records are verified against: the key,
+// the anchor given as SEQUENCE:HASH, the start of the chain if empty, and
+// the chain head stored in the ConfigMap chainConfigMap unless empty
+func (c *ctl) verification(keyFile, anchor, chainConfigMap string) (audit.Verification, error) {
+	var v audit.Verification
+	if keyFile != "" {
+		key, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return v, err
+		}
+		v.Key = key
+	}
+	v.Anchor = &audit.ChainHead{}
+	if anchor != "" {
+		parts := strings.SplitN(anchor, ":", 2)
+		seq, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil || len(parts) != 2 {
+			return v, fmt.Errorf("--anchor must be SEQUENCE:HASH")
+		}
+		v.Anchor = &audit.ChainHead{Sequence: seq, Hash: parts[1]}
+	}
+	if chainConfigMap != "" {
+		cs, err := c.clients()
+		if err != nil {
+			return v, err
+		}
+		head, err := audit.ConfigMapChainStore{Client: cs.OriginalClient, Namespace: c.namespace, Name: chainConfigMap}.Load()
+		if err != nil {
+			return v, fmt.Errorf("loading the chain head: %v", err)
+		}
+		v.Head = &head
+	}
+	return v, nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
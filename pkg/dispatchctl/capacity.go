@@ -0,0 +1,46 @@
+package dispatchctl
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/spf13/pflag"
+
+	"github.com/hantaowang/dispatch/pkg/capacity"
+	"github.com/hantaowang/dispatch/pkg/controller"
+)
+
+func newCapacityCommand() *command {
+	var configMap string
+	return &command{
+		name:  "capacity",
+		short: "Show how much of the cluster the namespace quotas commit",
+		flags: func(fs *pflag.FlagSet) {
+			configMapFlag(fs, &configMap, "overcommit ratios")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			cfg, err := c.controllerConfig(configMap)
+			if err != nil {
+				return err
+			}
+			report, err := capacity.Load(cs.OriginalClient, controller.QuotaName, cfg.Capacity.Overcommit)
+			if err != nil {
+				return err
+			}
+			return c.print(report, func(w io.Writer) {
+				row(w, "RESOURCE", "ALLOCATABLE", "OVERCOMMIT", "CAPACITY", "COMMITTED", "AVAILABLE")
+				for _, r := range report.Resources {
+					row(w, r.Name, r.Allocatable.String(), strconv.FormatFloat(r.Overcommit, 'g', -1, 64),
+						r.Capacity.String(), r.Committed.String(), r.Available.String())
+				}
+			})
+		},
+	}
+}
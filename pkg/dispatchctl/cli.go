@@ -0,0 +1,352 @@
+// Package dispatchctl implements the dispatchctl command line tool, which
+// manages DispatchUsers and their namespace grants.
+package dispatchctl
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/pflag"
+	auth_v1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/clientcmd"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_client "github.com/hantaowang/dispatch/pkg/client/clientset/versioned"
+	"github.com/hantaowang/dispatch/pkg/config"
+)
+
+// key of the controller configuration in its ConfigMap
+const configKey = "config.yaml"
+
+// command is a node in the command tree. Leaf commands have run set,
+// intermediate ones only group subcommands.
+type command struct {
+	name  string
+	args  string
+	short string
+	flags func(fs *pflag.FlagSet)
+	run   func(c *ctl, args []string) error
+	subs  []*command
+}
+
+func (cmd *command) find(name string) *command {
+	for _, sub := range cmd.subs {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// ctl holds the global options and lazily created clients of one invocation
+type ctl struct {
+	out    io.Writer
+	errOut io.Writer
+
+	kubeconfig string
+	context    string
+	output     string
+	namespace  string
+
+	clientsets *client.ClientSets
+}
+
+func (c *ctl) addGlobalFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&c.kubeconfig, "kubeconfig", "", "path to the kubeconfig file to use")
+	fs.StringVar(&c.context, "context", "", "kubeconfig context to use")
+	fs.StringVarP(&c.output, "output", "o", "table", "output format: table, json or yaml")
+	fs.StringVar(&c.namespace, "dispatch-namespace", "dispatch", "namespace holding DispatchUsers")
+}
+
+// globalValueFlags take a value as the next argument
+var globalValueFlags = map[string]bool{
+	"--kubeconfig":         true,
+	"--context":            true,
+	"--output":             true,
+	"-o":                   true,
+	"--dispatch-namespace": true,
+}
+
+func (c *ctl) clientConfig() clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = c.kubeconfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: c.context})
+}
+
+func (c *ctl) clients() (client.ClientSets, error) {
+	if c.clientsets != nil {
+		return *c.clientsets, nil
+	}
+	config, err := c.clientConfig().ClientConfig()
+	if err != nil {
+		return client.ClientSets{}, err
+	}
+	original, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return client.ClientSets{}, err
+	}
+	netsys, err := netsys_client.NewForConfig(config)
+	if err != nil {
+		return client.ClientSets{}, err
+	}
+	c.clientsets = &client.ClientSets{
+		OriginalClient: original,
+		NetsysClient:   netsys,
+		Members:        client.NewClusters(original, c.namespace),
+	}
+	return *c.clientsets, nil
+}
+
+// findUser returns the DispatchUser with the given name or user ID
+func (c *ctl) findUser(nameOrID string) (*netsys_v1.DispatchUser, error) {
+	cs, err := c.clients()
+	if err != nil {
+		return nil, err
+	}
+	users := cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace)
+	du, err := users.Get(nameOrID, meta_v1.GetOptions{})
+	if err == nil {
+		return du, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	list, err := users.List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if list.Items[i].Spec.UserID == nameOrID {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no DispatchUser named %s or with user ID %s", nameOrID, nameOrID)
+}
+
+// caller returns the user ID and groups the credentials of the kubeconfig
+// authenticate as. Tokens are checked with a TokenReview, client
+// certificates name the user and groups in their subject. ServiceAccounts
+// of the dispatch namespace are the users with their name as user ID.
+func (c *ctl) caller() (string, []string, error) {
+	config, err := c.clientConfig().ClientConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	if config.BearerToken != "" {
+		cs, err := c.clients()
+		if err != nil {
+			return "", nil, err
+		}
+		review, err := cs.OriginalClient.AuthenticationV1().TokenReviews().Create(&auth_v1.TokenReview{
+			Spec: auth_v1.TokenReviewSpec{Token: config.BearerToken},
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("checking who the kubeconfig authenticates as: %v", err)
+		}
+		if !review.Status.Authenticated {
+			return "", nil, fmt.Errorf("the token of the kubeconfig is not valid")
+		}
+		prefix := "system:serviceaccount:" + c.namespace + ":"
+		return strings.TrimPrefix(review.Status.User.Username, prefix), review.Status.User.Groups, nil
+	}
+	data := config.CertData
+	if len(data) == 0 && config.CertFile != "" {
+		if data, err = ioutil.ReadFile(config.CertFile); err != nil {
+			return "", nil, err
+		}
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "", nil, err
+		}
+		return cert.Subject.CommonName, cert.Subject.Organization, nil
+	}
+	return "", nil, fmt.Errorf("cannot tell who the kubeconfig authenticates as, use a token or client certificate")
+}
+
+// updateUser applies mutate to a copy of the user and writes it back.
+// reason is recorded on the user for the audit log of the change.
+func (c *ctl) updateUser(nameOrID, reason string, mutate func(spec *netsys_v1.DispatchUserSpec) error) (*netsys_v1.DispatchUser, error) {
+	du, err := c.findUser(nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	du = du.DeepCopy()
+	if err := mutate(&du.Spec); err != nil {
+		return nil, err
+	}
+	du.SetReason(reason)
+	cs, err := c.clients()
+	if err != nil {
+		return nil, err
+	}
+	return cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace).Update(du)
+}
+
+// configMapFlag adds --config-map to a command that reads what from the
+// controller configuration
+func configMapFlag(fs *pflag.FlagSet, configMap *string, what string) {
+	fs.StringVar(configMap, "config-map", "dispatch-config", "ConfigMap of the controller configuration, for its "+what)
+}
+
+// controllerConfig returns the controller configuration in the ConfigMap
+// configMap, or the defaults if there is none
+func (c *ctl) controllerConfig(configMap string) (*config.Config, error) {
+	cs, err := c.clients()
+	if err != nil {
+		return nil, err
+	}
+	cm, err := cs.OriginalClient.CoreV1().ConfigMaps(c.namespace).Get(configMap, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return config.Default(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Parse([]byte(cm.Data[configKey]))
+	if err != nil {
+		return nil, fmt.Errorf("ConfigMap %s: %v", configMap, err)
+	}
+	return cfg, nil
+}
+
+// reasonFlag adds --reason to a command that changes access
+func reasonFlag(fs *pflag.FlagSet, reason *string) {
+	fs.StringVar(reason, "reason", "", "why the change is made, recorded in the audit log")
+}
+
+func newRootCommand() *command {
+	return &command{
+		name:  "dispatchctl",
+		short: "Manage dispatch users and the namespaces they own",
+		subs: []*command{
+			newUserCommand(),
+			newNamespaceCommand(),
+			newGrantCommand(),
+			newRevokeCommand(),
+			newTransferCommand(),
+			newKubeconfigCommand(),
+			newStatusCommand(),
+			newAuditCommand(),
+			newRecertificationCommand(),
+			newExpiryCommand(),
+			newElevationCommand(),
+			newLeaseCommand(),
+			newIdleCommand(),
+			newHibernateCommand(),
+			newWakeCommand(),
+			newScheduleCommand(),
+			newUsageCommand(),
+			newReportCommand(),
+			newCapacityCommand(),
+			newSubNamespaceCommand(),
+			newTreeCommand(),
+			newTenantCommand(),
+			newInvitationCommand(),
+			newRobotCommand(),
+		},
+	}
+}
+
+// Run executes the command line args and returns the exit code
+func Run(name string, args []string, out, errOut io.Writer) int {
+	root := newRootCommand()
+	root.name = name
+	c := &ctl{out: out, errOut: errOut}
+
+	// walk down the command tree on the leading words, leaving flags and
+	// positional arguments for the leaf
+	cmd := root
+	path := []string{name}
+	var rest []string
+	positional := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			if globalValueFlags[arg] && i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		}
+		if !positional {
+			if sub := cmd.find(arg); sub != nil {
+				cmd = sub
+				path = append(path, arg)
+				continue
+			}
+		}
+		positional = true
+		rest = append(rest, arg)
+	}
+
+	fs := pflag.NewFlagSet(strings.Join(path, " "), pflag.ContinueOnError)
+	fs.SetOutput(errOut)
+	c.addGlobalFlags(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() { usage(errOut, cmd, path, fs) }
+	if err := fs.Parse(rest); err != nil {
+		if err == pflag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	if cmd.run == nil {
+		usage(errOut, cmd, path, fs)
+		if len(fs.Args()) > 0 {
+			fmt.Fprintf(errOut, "unknown command %q\n", fs.Args()[0])
+		}
+		return 2
+	}
+
+	if err := cmd.run(c, fs.Args()); err != nil {
+		fmt.Fprintf(errOut, "error: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer, cmd *command, path []string, fs *pflag.FlagSet) {
+	fmt.Fprintf(w, "%s\n\nUsage:\n  %s", cmd.short, strings.Join(path, " "))
+	if len(cmd.subs) > 0 {
+		fmt.Fprintf(w, " COMMAND\n\nCommands:\n")
+		for _, sub := range cmd.subs {
+			fmt.Fprintf(w, "  %-12s %s\n", sub.name, sub.short)
+		}
+	} else if cmd.args != "" {
+		fmt.Fprintf(w, " %s\n", cmd.args)
+	} else {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "\nFlags:\n%s", fs.FlagUsages())
+}
+
+// exactArgs checks the number of positional arguments of a command
+func exactArgs(args []string, n int, names string) error {
+	if len(args) != n {
+		return fmt.Errorf("expected %s", names)
+	}
+	return nil
+}
+
+// minArgs checks that at least n positional arguments were given
+func minArgs(args []string, n int, names string) error {
+	if len(args) < n {
+		return fmt.Errorf("expected %s", names)
+	}
+	return nil
+}
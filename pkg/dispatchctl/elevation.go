@@ -0,0 +1,197 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/spf13/pflag"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+)
+
+func newElevationCommand() *command {
+	return &command{
+		name:  "elevation",
+		short: "Ask for, approve and revoke temporary elevated roles",
+		subs: []*command{
+			newElevationRequestCommand(),
+			newElevationListCommand(),
+			newElevationDecideCommand("approve", "approved", "Approve a pending elevation", func(e *netsys_v1.AccessElevation, by, message string, now meta_v1.Time) error {
+				return e.Approve(by, now)
+			}),
+			newElevationDecideCommand("deny", "denied", "Deny a pending elevation", (*netsys_v1.AccessElevation).Deny),
+			newElevationDecideCommand("revoke", "revoked", "End an elevation before its time is up", (*netsys_v1.AccessElevation).Revoke),
+		},
+	}
+}
+
+func newElevationRequestCommand() *command {
+	var role, duration, reason string
+	return &command{
+		name:  "request",
+		args:  "USER NAMESPACE --duration DURATION --reason REASON",
+		short: "Ask for a role in a namespace for a limited time",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&role, "role", netsys_v1.RoleAdmin, "role to hold: view, edit or admin")
+			fs.StringVar(&duration, "duration", "1h", "how long to hold the role, e.g. 30m or 2h")
+			fs.StringVar(&reason, "reason", "", "why the role is needed, e.g. the incident")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 2, "USER NAMESPACE"); err != nil {
+				return err
+			}
+			if !netsys_v1.ValidRole(role) {
+				return fmt.Errorf("invalid role %q, use view, edit or admin", role)
+			}
+			if reason == "" {
+				return fmt.Errorf("--reason is required")
+			}
+			d, err := parseTTL(duration)
+			if err != nil {
+				return err
+			}
+			du, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			e := &netsys_v1.AccessElevation{
+				ObjectMeta: meta_v1.ObjectMeta{
+					GenerateName: du.Spec.UserID + "-",
+					Namespace:    c.namespace,
+				},
+				Spec: netsys_v1.AccessElevationSpec{
+					UserID:    du.Spec.UserID,
+					Namespace: args[1],
+					Role:      role,
+					Duration:  meta_v1.Duration{Duration: d},
+					Reason:    reason,
+				},
+			}
+			e, err = cs.NetsysClient.NetsysV1().AccessElevations(c.namespace).Create(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "accesselevation %s created\n", e.Name)
+			return nil
+		},
+	}
+}
+
+func newElevationListCommand() *command {
+	var phase string
+	return &command{
+		name:  "list",
+		short: "List elevations, newest first",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&phase, "phase", "", "only list elevations in this phase, e.g. Pending or Active")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().AccessElevations(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			sort.Slice(list.Items, func(i, j int) bool {
+				return list.Items[j].CreationTimestamp.Before(&list.Items[i].CreationTimestamp)
+			})
+			elevations := []netsys_v1.AccessElevation{}
+			for _, e := range list.Items {
+				if phase == "" || e.Status.Phase == phase {
+					elevations = append(elevations, e)
+				}
+			}
+			return c.print(elevations, func(w io.Writer) {
+				row(w, "NAME", "USER", "NAMESPACE", "ROLE", "DURATION", "PHASE", "DECIDED-BY", "EXPIRES", "REASON")
+				for _, e := range elevations {
+					expires := "<none>"
+					if e.Status.ExpiresAt != nil {
+						expires = e.Status.ExpiresAt.UTC().Format(time.RFC3339)
+					}
+					row(w, e.Name, e.Spec.UserID, e.Spec.Namespace, e.Spec.Role, e.Spec.Duration.Duration,
+						orNone(e.Status.Phase), orNone(e.Status.DecidedBy), expires, e.Spec.Reason)
+				}
+			})
+		},
+	}
+}
+
+// newElevationDecideCommand returns a command that applies decide to an
+// elevation on behalf of the user the kubeconfig authenticates as
+func newElevationDecideCommand(name, done, short string, decide func(e *netsys_v1.AccessElevation, by, message string, now meta_v1.Time) error) *command {
+	var message, configMap string
+	return &command{
+		name:  name,
+		args:  "ELEVATION",
+		short: short,
+		flags: func(fs *pflag.FlagSet) {
+			configMapFlag(fs, &configMap, "default role")
+			if name != "approve" {
+				fs.StringVar(&message, "message", "", "why, shown to the user and recorded in the audit log")
+			}
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "ELEVATION"); err != nil {
+				return err
+			}
+			reviewer, groups, err := c.caller()
+			if err != nil {
+				return err
+			}
+			// the grants of the reviewer, if they are a user, make them an
+			// admin of namespaces
+			du, _ := c.findUser(reviewer)
+			cfg, err := c.controllerConfig(configMap)
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			elevations := cs.NetsysClient.NetsysV1().AccessElevations(c.namespace)
+			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				e, err := elevations.Get(args[0], meta_v1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				var owned *netsys_v1.OwnedNamespace
+				if du != nil {
+					on, err := cs.NetsysClient.NetsysV1().OwnedNamespaces(c.namespace).
+						Get(controller.OwnedNamespaceName(du.Spec.UserID, e.Spec.Namespace), meta_v1.GetOptions{})
+					if err == nil {
+						owned = on
+					}
+				}
+				// users may end their own elevations early
+				if !e.MayDecide(reviewer, groups, du, owned, cfg.DefaultRole, time.Now()) && !(name == "revoke" && e.Spec.UserID == reviewer) {
+					return fmt.Errorf("%s may not %s elevation %s", reviewer, name, e.Name)
+				}
+				if err := decide(e, reviewer, message, meta_v1.Now()); err != nil {
+					return err
+				}
+				_, err = elevations.UpdateStatus(e)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "accesselevation %s %s by %s\n", args[0], done, reviewer)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,279 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+// expiryFlags are --expires-at and --ttl, at most one may be given
+type expiryFlags struct {
+	expiresAt string
+	ttl       string
+}
+
+func (f *expiryFlags) add(fs *pflag.FlagSet, what string) {
+	fs.StringVar(&f.expiresAt, "expires-at", "", what+" ends at this time, RFC 3339 or YYYY-MM-DD")
+	fs.StringVar(&f.ttl, "ttl", "", what+" ends after this long, e.g. 12h or 30d")
+}
+
+// time returns the expiry given on the command line, or nil
+func (f *expiryFlags) time() (*meta_v1.Time, error) {
+	switch {
+	case f.expiresAt != "" && f.ttl != "":
+		return nil, fmt.Errorf("use either --expires-at or --ttl")
+	case f.expiresAt != "":
+		t, err := parseTime(f.expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		return &t, nil
+	case f.ttl != "":
+		d, err := parseTTL(f.ttl)
+		if err != nil {
+			return nil, err
+		}
+		t := meta_v1.NewTime(time.Now().Add(d).Truncate(time.Second))
+		return &t, nil
+	}
+	return nil, nil
+}
+
+func parseTime(s string) (meta_v1.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return meta_v1.NewTime(t), nil
+		}
+	}
+	return meta_v1.Time{}, fmt.Errorf("invalid time %q, use RFC 3339 or YYYY-MM-DD", s)
+}
+
+// parseTTL parses a Go duration, plus days as in 30d
+func parseTTL(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err == nil && days > 0 {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid ttl %q, use e.g. 12h or 30d", s)
+	}
+	return d, nil
+}
+
+func formatExpiry(t *meta_v1.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func newExpiryCommand() *command {
+	return &command{
+		name:  "expiry",
+		short: "Show and extend expiring users and grants",
+		subs: []*command{
+			newExpiryListCommand(),
+			newExpiryExtendCommand(),
+			newExpiryDenyCommand(),
+		},
+	}
+}
+
+// expiryEntry is a user or grant that expires
+type expiryEntry struct {
+	User      string                      `json:"user"`
+	Namespace string                      `json:"namespace,omitempty"`
+	ExpiresAt *meta_v1.Time               `json:"expiresAt"`
+	Expired   bool                        `json:"expired"`
+	Request   *netsys_v1.ExtensionRequest `json:"extensionRequest,omitempty"`
+}
+
+func newExpiryListCommand() *command {
+	return &command{
+		name:  "list",
+		short: "List users and grants that expire, with pending extension requests",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+
+			entries := []expiryEntry{}
+			for _, du := range list.Items {
+				requests := map[string]*netsys_v1.ExtensionRequest{}
+				pending := du.ExtensionRequests()
+				for i := range pending {
+					requests[pending[i].Namespace] = &pending[i]
+				}
+				if expiresAt := du.ExpiryTime(); expiresAt != nil || requests[""] != nil {
+					entries = append(entries, expiryEntry{User: du.Name, ExpiresAt: expiresAt,
+						Expired: du.Status.Expired, Request: requests[""]})
+				}
+				for _, gs := range du.Status.Grants {
+					entries = append(entries, expiryEntry{User: du.Name, Namespace: gs.Namespace,
+						ExpiresAt: gs.ExpiresAt, Expired: gs.Expired, Request: requests[gs.Namespace]})
+				}
+			}
+			return c.print(entries, func(w io.Writer) {
+				row(w, "USER", "NAMESPACE", "EXPIRES", "EXPIRED", "REQUESTED-UNTIL", "REASON")
+				for _, e := range entries {
+					until, reason := "<none>", "<none>"
+					if e.Request != nil {
+						until, reason = formatExpiry(&e.Request.Until), e.Request.Reason
+					}
+					row(w, e.User, orNone(e.Namespace), formatExpiry(e.ExpiresAt), e.Expired, until, reason)
+				}
+			})
+		},
+	}
+}
+
+func newExpiryExtendCommand() *command {
+	var expiry expiryFlags
+	var approve, never bool
+	var reason string
+	return &command{
+		name:  "extend",
+		args:  "USER [NAMESPACE...]",
+		short: "Move the expiry of a user, or of its grants in namespaces",
+		flags: func(fs *pflag.FlagSet) {
+			expiry.add(fs, "access")
+			fs.BoolVar(&approve, "approve", false, "extend until the time of the pending extension request")
+			fs.BoolVar(&never, "never", false, "remove the expiry")
+			reasonFlag(fs, &reason)
+		},
+		run: func(c *ctl, args []string) error {
+			if err := minArgs(args, 1, "USER [NAMESPACE...]"); err != nil {
+				return err
+			}
+			expiresAt, err := expiry.time()
+			if err != nil {
+				return err
+			}
+			given := 0
+			for _, b := range []bool{expiresAt != nil, approve, never} {
+				if b {
+					given++
+				}
+			}
+			if given != 1 {
+				return fmt.Errorf("use one of --expires-at, --ttl, --approve or --never")
+			}
+			targets := args[1:]
+			if len(targets) == 0 {
+				// the user itself
+				targets = []string{""}
+			}
+
+			err = c.modifyUser(args[0], reason, func(du *netsys_v1.DispatchUser) error {
+				for _, ns := range targets {
+					request := du.RemoveExtensionRequest(ns)
+					until := expiresAt
+					if approve {
+						if request == nil {
+							return fmt.Errorf("no extension request for %s", describeTarget(du, ns))
+						}
+						until = &request.Until
+					}
+					if ns == "" {
+						du.Spec.ExpiresAt, du.Spec.TTL = until, nil
+						continue
+					}
+					g := du.Spec.Grant(ns)
+					if g == nil {
+						if !du.Spec.HasNamespace(ns) {
+							return fmt.Errorf("%s has no grant in namespace %s", du.Name, ns)
+						}
+						// listed in namespaces with the default role
+						du.Spec.SetGrant(ns, "")
+						g = du.Spec.Grant(ns)
+					}
+					g.ExpiresAt, g.TTL = until, nil
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "extended %s\n", strings.Join(args, " "))
+			return nil
+		},
+	}
+}
+
+func newExpiryDenyCommand() *command {
+	return &command{
+		name:  "deny",
+		args:  "USER [NAMESPACE...]",
+		short: "Drop pending extension requests",
+		run: func(c *ctl, args []string) error {
+			if err := minArgs(args, 1, "USER [NAMESPACE...]"); err != nil {
+				return err
+			}
+			targets := args[1:]
+			if len(targets) == 0 {
+				targets = []string{""}
+			}
+			err := c.modifyUser(args[0], "", func(du *netsys_v1.DispatchUser) error {
+				for _, ns := range targets {
+					if du.RemoveExtensionRequest(ns) == nil {
+						return fmt.Errorf("no extension request for %s", describeTarget(du, ns))
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "denied extension of %s\n", strings.Join(args, " "))
+			return nil
+		},
+	}
+}
+
+func describeTarget(du *netsys_v1.DispatchUser, namespace string) string {
+	if namespace == "" {
+		return du.Name
+	}
+	return du.Name + " in namespace " + namespace
+}
+
+// modifyUser applies mutate to the latest copy of a user, retrying on
+// conflicts since the controller writes the status of users
+func (c *ctl) modifyUser(nameOrID, reason string, mutate func(du *netsys_v1.DispatchUser) error) error {
+	cs, err := c.clients()
+	if err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		du, err := c.findUser(nameOrID)
+		if err != nil {
+			return err
+		}
+		du = du.DeepCopy()
+		if err := mutate(du); err != nil {
+			return err
+		}
+		du.SetReason(reason)
+		_, err = cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace).Update(du)
+		return err
+	})
+}
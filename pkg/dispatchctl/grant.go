@@ -0,0 +1,155 @@
+package dispatchctl
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+func newNamespaceCommand() *command {
+	var reason string
+	return &command{
+		name:  "ns",
+		short: "Add or remove namespaces owned with the default role",
+		subs: []*command{
+			{
+				name:  "add",
+				args:  "USER NAMESPACE...",
+				short: "Give a user the default role in namespaces",
+				flags: func(fs *pflag.FlagSet) { reasonFlag(fs, &reason) },
+				run: func(c *ctl, args []string) error {
+					if err := minArgs(args, 2, "USER NAMESPACE..."); err != nil {
+						return err
+					}
+					_, err := c.updateUser(args[0], reason, func(spec *netsys_v1.DispatchUserSpec) error {
+						for _, ns := range args[1:] {
+							if !spec.HasNamespace(ns) {
+								spec.Namespaces = append(spec.Namespaces, ns)
+							}
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(c.out, "added %v to %s\n", args[1:], args[0])
+					return nil
+				},
+			},
+			{
+				name:  "remove",
+				args:  "USER NAMESPACE...",
+				short: "Remove namespaces from a user",
+				flags: func(fs *pflag.FlagSet) { reasonFlag(fs, &reason) },
+				run: func(c *ctl, args []string) error {
+					if err := minArgs(args, 2, "USER NAMESPACE..."); err != nil {
+						return err
+					}
+					return removeNamespaces(c, args[0], reason, args[1:])
+				},
+			},
+		},
+	}
+}
+
+func newGrantCommand() *command {
+	var role, reason, lease string
+	var expiry expiryFlags
+	// tells which flags were given, an existing grant keeps the rest
+	var flags *pflag.FlagSet
+	return &command{
+		name:  "grant",
+		args:  "USER NAMESPACE... [--role ROLE]",
+		short: "Grant a user a role in namespaces",
+		flags: func(fs *pflag.FlagSet) {
+			flags = fs
+			fs.StringVar(&role, "role", "", "role to grant: view, edit or admin, the controller's default role if empty")
+			expiry.add(fs, "the grant")
+			fs.StringVar(&lease, "lease", "", "the user has to renew the grant within this long, e.g. 30d")
+			reasonFlag(fs, &reason)
+		},
+		run: func(c *ctl, args []string) error {
+			if err := minArgs(args, 2, "USER NAMESPACE..."); err != nil {
+				return err
+			}
+			if role != "" && !netsys_v1.ValidRole(role) {
+				return fmt.Errorf("invalid role %q, use view, edit or admin", role)
+			}
+			expiresAt, err := expiry.time()
+			if err != nil {
+				return err
+			}
+			var leaseDuration *meta_v1.Duration
+			if lease != "" {
+				d, err := parseTTL(lease)
+				if err != nil {
+					return err
+				}
+				leaseDuration = &meta_v1.Duration{Duration: d}
+			}
+			_, err = c.updateUser(args[0], reason, func(spec *netsys_v1.DispatchUserSpec) error {
+				for _, ns := range args[1:] {
+					g := spec.Grant(ns)
+					if g == nil {
+						spec.SetGrant(ns, role)
+						g = spec.Grant(ns)
+					} else if flags.Changed("role") {
+						g.Role = role
+					}
+					if flags.Changed("expires-at") || flags.Changed("ttl") {
+						g.ExpiresAt, g.TTL = expiresAt, nil
+					}
+					if flags.Changed("lease") {
+						// --lease "" drops the lease
+						g.Lease = leaseDuration
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if role == "" {
+				fmt.Fprintf(c.out, "granted %v to %s\n", args[1:], args[0])
+				return nil
+			}
+			fmt.Fprintf(c.out, "granted %s on %v to %s\n", role, args[1:], args[0])
+			return nil
+		},
+	}
+}
+
+func newRevokeCommand() *command {
+	var reason string
+	return &command{
+		name:  "revoke",
+		args:  "USER NAMESPACE...",
+		short: "Revoke all access of a user to namespaces",
+		flags: func(fs *pflag.FlagSet) { reasonFlag(fs, &reason) },
+		run: func(c *ctl, args []string) error {
+			if err := minArgs(args, 2, "USER NAMESPACE..."); err != nil {
+				return err
+			}
+			return removeNamespaces(c, args[0], reason, args[1:])
+		},
+	}
+}
+
+func removeNamespaces(c *ctl, user, reason string, namespaces []string) error {
+	_, err := c.updateUser(user, reason, func(spec *netsys_v1.DispatchUserSpec) error {
+		for _, ns := range namespaces {
+			if !spec.RemoveNamespace(ns) {
+				return fmt.Errorf("%s has no access to namespace %s", user, ns)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "revoked %v from %s\n", namespaces, user)
+	return nil
+}
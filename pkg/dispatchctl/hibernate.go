@@ -0,0 +1,144 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hantaowang/dispatch/pkg/hibernate"
+)
+
+// idleNamespace is a granted namespace with its last activity
+type idleNamespace struct {
+	Namespace    string    `json:"namespace"`
+	Owners       []string  `json:"owners"`
+	LastActivity time.Time `json:"lastActivity"`
+	Hibernated   string    `json:"hibernatedAt,omitempty"`
+}
+
+func newIdleCommand() *command {
+	var signals []string
+	var idleFor string
+	return &command{
+		name:  "idle",
+		short: "List granted namespaces by how long they were idle",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringSliceVar(&signals, "signals", hibernate.Signals, "what counts as activity: pods, deployments and events")
+			fs.StringVar(&idleFor, "for", "", "only list namespaces idle for at least this long, e.g. 7d")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			for _, s := range signals {
+				if !hibernate.ValidSignal(s) {
+					return fmt.Errorf("invalid signal %q, use pods, deployments or events", s)
+				}
+			}
+			var minIdle time.Duration
+			if idleFor != "" {
+				d, err := parseTTL(idleFor)
+				if err != nil {
+					return err
+				}
+				minIdle = d
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().OwnedNamespaces(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			owners := map[string][]string{}
+			for _, on := range list.Items {
+				if on.Spec.Cluster == "" {
+					owners[on.Spec.Namespace] = append(owners[on.Spec.Namespace], on.Spec.OwnerID)
+				}
+			}
+
+			now := time.Now()
+			idle := []idleNamespace{}
+			for name, ids := range owners {
+				ns, err := cs.OriginalClient.CoreV1().Namespaces().Get(name, meta_v1.GetOptions{})
+				if errors.IsNotFound(err) {
+					// granted but not created yet
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				last, err := hibernate.LastActivity(cs.OriginalClient, ns, signals)
+				if err != nil {
+					return err
+				}
+				if now.Sub(last) < minIdle {
+					continue
+				}
+				sort.Strings(ids)
+				idle = append(idle, idleNamespace{Namespace: name, Owners: ids, LastActivity: last.UTC(),
+					Hibernated: ns.Annotations[hibernate.HibernatedAnnotation]})
+			}
+			sort.Slice(idle, func(i, j int) bool { return idle[i].LastActivity.Before(idle[j].LastActivity) })
+			return c.print(idle, func(w io.Writer) {
+				row(w, "NAMESPACE", "OWNERS", "LAST-ACTIVITY", "IDLE", "HIBERNATED")
+				for _, n := range idle {
+					row(w, n.Namespace, joinOrNone(n.Owners), n.LastActivity.Format(time.RFC3339),
+						now.Sub(n.LastActivity).Truncate(time.Minute), orNone(n.Hibernated))
+				}
+			})
+		},
+	}
+}
+
+func newHibernateCommand() *command {
+	return &command{
+		name:  "hibernate",
+		args:  "NAMESPACE",
+		short: "Scale a namespace's Deployments and StatefulSets to zero and suspend its CronJobs",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "NAMESPACE"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			r, err := hibernate.Hibernate(cs.OriginalClient, args[0], c.currentUser(), time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "namespace %s hibernated, scaled down %s\n", args[0], r)
+			return nil
+		},
+	}
+}
+
+func newWakeCommand() *command {
+	return &command{
+		name:  "wake",
+		args:  "NAMESPACE",
+		short: "Restore the replicas and CronJobs of a hibernated namespace",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "NAMESPACE"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			r, err := hibernate.Wake(cs.OriginalClient, args[0], time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "namespace %s woken, restored %s\n", args[0], r)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,191 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/spf13/pflag"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/invitation"
+)
+
+func newInvitationCommand() *command {
+	return &command{
+		name:  "invitation",
+		short: "Share owned namespaces with other users through NamespaceInvitations",
+		subs: []*command{
+			newInvitationCreateCommand(),
+			newInvitationListCommand(),
+			newInvitationAcceptCommand(),
+			newInvitationRevokeCommand(),
+		},
+	}
+}
+
+func newInvitationCreateCommand() *command {
+	var role string
+	var expiry expiryFlags
+	return &command{
+		name:  "create",
+		args:  "OWNER NAMESPACE INVITEE --ttl TTL",
+		short: "Invite a user to a namespace of its owner, up to the owner's own role",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&role, "role", netsys_v1.RoleView, "role to share: view, edit or admin")
+			expiry.add(fs, "the share")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 3, "OWNER NAMESPACE INVITEE"); err != nil {
+				return err
+			}
+			expiresAt, err := expiry.time()
+			if err != nil {
+				return err
+			}
+			if expiresAt == nil {
+				return fmt.Errorf("--expires-at or --ttl is required")
+			}
+			owner, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			invitee, err := c.findUser(args[2])
+			if err != nil {
+				return err
+			}
+			spec := netsys_v1.NamespaceInvitationSpec{
+				Namespace: args[1],
+				Invitee:   invitee.Spec.UserID,
+				Role:      role,
+				ExpiresAt: *expiresAt,
+				InvitedBy: owner.Spec.UserID,
+			}
+			if err := invitation.Check(owner, spec, time.Now()); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			inv, err := cs.NetsysClient.NetsysV1().NamespaceInvitations(c.namespace).Create(&netsys_v1.NamespaceInvitation{
+				ObjectMeta: meta_v1.ObjectMeta{GenerateName: owner.Spec.UserID + "-", Namespace: c.namespace},
+				Spec:       spec,
+				Status:     netsys_v1.NamespaceInvitationStatus{Phase: netsys_v1.InvitationPending},
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "namespaceinvitation %s created\n", inv.Name)
+			return nil
+		},
+	}
+}
+
+func newInvitationListCommand() *command {
+	var user string
+	return &command{
+		name:  "list",
+		short: "List invitations, newest first",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&user, "user", "", "only list invitations this user sent or received")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			if user != "" {
+				du, err := c.findUser(user)
+				if err != nil {
+					return err
+				}
+				user = du.Spec.UserID
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().NamespaceInvitations(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			sort.Slice(list.Items, func(i, j int) bool {
+				return list.Items[j].CreationTimestamp.Before(&list.Items[i].CreationTimestamp)
+			})
+			invitations := []netsys_v1.NamespaceInvitation{}
+			now := time.Now()
+			for _, inv := range list.Items {
+				if user == "" || inv.Spec.InvitedBy == user || inv.Spec.Invitee == user {
+					inv.Status.Phase = inv.PhaseAt(now)
+					invitations = append(invitations, inv)
+				}
+			}
+			return c.print(invitations, func(w io.Writer) {
+				row(w, "NAME", "NAMESPACE", "OWNER", "INVITEE", "ROLE", "PHASE", "EXPIRES", "MESSAGE")
+				for _, inv := range invitations {
+					row(w, inv.Name, inv.Spec.Namespace, inv.Spec.InvitedBy, inv.Spec.Invitee, inv.GrantedRole(),
+						orNone(inv.Status.Phase), inv.Spec.ExpiresAt.UTC().Format(time.RFC3339), inv.Status.Message)
+				}
+			})
+		},
+	}
+}
+
+func newInvitationAcceptCommand() *command {
+	return &command{
+		name:  "accept",
+		args:  "INVITATION",
+		short: "Accept an invitation, granting its invitee the namespace",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "INVITATION"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			inv, err := invitation.Accept(cs.NetsysClient, c.namespace, args[0], time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "namespaceinvitation %s accepted, %s has %s in namespace %s\n",
+				inv.Name, inv.Spec.Invitee, inv.GrantedRole(), inv.Spec.Namespace)
+			return nil
+		},
+	}
+}
+
+func newInvitationRevokeCommand() *command {
+	var by string
+	return &command{
+		name:  "revoke",
+		args:  "INVITATION",
+		short: "Revoke an invitation, taking the namespace from the invitee if it was accepted",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&by, "by", "", "who revokes, defaults to the user of the kubeconfig context")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "INVITATION"); err != nil {
+				return err
+			}
+			if by == "" {
+				by = c.currentUser()
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			inv, err := invitation.Revoke(cs.NetsysClient, c.namespace, args[0], by, time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "namespaceinvitation %s revoked\n", inv.Name)
+			if inv.Status.Message != "" {
+				fmt.Fprintln(c.out, inv.Status.Message)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,232 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/pflag"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+func newKubeconfigCommand() *command {
+	var file, server, caFile, clusterName string
+	return &command{
+		name:  "kubeconfig",
+		args:  "USER",
+		short: "Write a kubeconfig with one context per cluster and namespace of a user",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVarP(&file, "file", "f", "", "merge into this kubeconfig file instead of printing")
+			fs.StringVar(&server, "server", "", "API server URL, defaults to the server of the current context")
+			fs.StringVar(&caFile, "certificate-authority", "", "CA file for --server, defaults to the cluster CA")
+			fs.StringVar(&clusterName, "cluster-name", "dispatch", "name of the cluster entry, member clusters are named as registered")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "USER"); err != nil {
+				return err
+			}
+			du, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			local, err := c.serviceAccountCredentials(cs.OriginalClient, du.Spec.UserID)
+			if err != nil {
+				return err
+			}
+			if caFile != "" {
+				if local.ca, err = ioutil.ReadFile(caFile); err != nil {
+					return err
+				}
+			}
+			local.server = server
+			if local.server == "" {
+				if local.server, err = c.currentServer(); err != nil {
+					return err
+				}
+			}
+			clusters := map[string]clusterCredentials{"": local}
+			for _, g := range du.Spec.EffectiveGrants() {
+				if _, ok := clusters[g.Cluster]; ok {
+					continue
+				}
+				if clusters[g.Cluster], err = c.memberCredentials(g.Cluster, du.Spec.UserID); err != nil {
+					return fmt.Errorf("cluster %s: %v", g.Cluster, err)
+				}
+			}
+
+			config := userKubeconfig(du, clusterName, clusters)
+			if file == "" {
+				b, err := clientcmd.Write(*config)
+				if err != nil {
+					return err
+				}
+				_, err = c.out.Write(b)
+				return err
+			}
+
+			merged, err := mergeKubeconfig(file, config)
+			if err != nil {
+				return err
+			}
+			if err := clientcmd.WriteToFile(*merged, file); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "wrote %d contexts for %s to %s\n", len(config.Contexts), du.Name, file)
+			return nil
+		},
+	}
+}
+
+// clusterCredentials is how a user's kubeconfig reaches one cluster
+type clusterCredentials struct {
+	server string
+	token  []byte
+	ca     []byte
+}
+
+// serviceAccountCredentials returns the token and CA of the user's
+// ServiceAccount token secret in the cluster of client
+func (c *ctl) serviceAccountCredentials(client kubernetes.Interface, userID string) (clusterCredentials, error) {
+	sa, err := client.CoreV1().ServiceAccounts(c.namespace).Get(userID, meta_v1.GetOptions{})
+	if err != nil {
+		return clusterCredentials{}, err
+	}
+	for _, ref := range sa.Secrets {
+		secret, err := client.CoreV1().Secrets(c.namespace).Get(ref.Name, meta_v1.GetOptions{})
+		if err != nil {
+			return clusterCredentials{}, err
+		}
+		if secret.Type == core_v1.SecretTypeServiceAccountToken {
+			return clusterCredentials{
+				token: secret.Data[core_v1.ServiceAccountTokenKey],
+				ca:    secret.Data[core_v1.ServiceAccountRootCAKey],
+			}, nil
+		}
+	}
+	return clusterCredentials{}, fmt.Errorf("ServiceAccount %s/%s has no token yet", c.namespace, userID)
+}
+
+// memberCredentials returns the credentials of the user in a member
+// cluster, reached at the server of the cluster's kubeconfig Secret
+func (c *ctl) memberCredentials(cluster, userID string) (clusterCredentials, error) {
+	cs, err := c.clients()
+	if err != nil {
+		return clusterCredentials{}, err
+	}
+	member, err := cs.ForCluster(cluster)
+	if err != nil {
+		return clusterCredentials{}, err
+	}
+	creds, err := c.serviceAccountCredentials(member, userID)
+	if err != nil {
+		return clusterCredentials{}, err
+	}
+	config, err := cs.Members.Config(cluster)
+	if err != nil {
+		return clusterCredentials{}, err
+	}
+	creds.server = config.Host
+	if len(config.CAData) > 0 {
+		creds.ca = config.CAData
+	} else if config.CAFile != "" {
+		if creds.ca, err = ioutil.ReadFile(config.CAFile); err != nil {
+			return clusterCredentials{}, err
+		}
+	}
+	return creds, nil
+}
+
+// currentServer returns the API server URL of the current context
+func (c *ctl) currentServer() (string, error) {
+	raw, err := c.clientConfig().RawConfig()
+	if err != nil {
+		return "", err
+	}
+	current := raw.CurrentContext
+	if c.context != "" {
+		current = c.context
+	}
+	ctx, ok := raw.Contexts[current]
+	if !ok {
+		return "", fmt.Errorf("context %q not found, use --server", current)
+	}
+	cluster, ok := raw.Clusters[ctx.Cluster]
+	if !ok {
+		return "", fmt.Errorf("cluster %q not found, use --server", ctx.Cluster)
+	}
+	return cluster.Server, nil
+}
+
+// userKubeconfig builds a kubeconfig with a context per granted namespace.
+// clusters holds the credentials by member cluster, the cluster dispatch
+// runs in under "" which is named clusterName.
+func userKubeconfig(du *netsys_v1.DispatchUser, clusterName string, clusters map[string]clusterCredentials) *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	entryName := func(member string) string {
+		if member == "" {
+			return clusterName
+		}
+		return member
+	}
+
+	for member, creds := range clusters {
+		name := entryName(member)
+		cluster := clientcmdapi.NewCluster()
+		cluster.Server = creds.server
+		cluster.CertificateAuthorityData = creds.ca
+		config.Clusters[name] = cluster
+
+		authInfo := clientcmdapi.NewAuthInfo()
+		authInfo.Token = string(creds.token)
+		config.AuthInfos[name+"-"+du.Spec.UserID] = authInfo
+	}
+
+	for _, g := range du.Spec.EffectiveGrants() {
+		entry := entryName(g.Cluster)
+		ctx := clientcmdapi.NewContext()
+		ctx.Cluster = entry
+		ctx.AuthInfo = entry + "-" + du.Spec.UserID
+		ctx.Namespace = g.Namespace
+		name := entry + "-" + g.Namespace
+		config.Contexts[name] = ctx
+		if config.CurrentContext == "" {
+			config.CurrentContext = name
+		}
+	}
+	return config
+}
+
+// mergeKubeconfig adds the entries of config to the kubeconfig in file,
+// replacing entries with the same name
+func mergeKubeconfig(file string, config *clientcmdapi.Config) (*clientcmdapi.Config, error) {
+	existing, err := clientcmd.LoadFromFile(file)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for name, cluster := range config.Clusters {
+		existing.Clusters[name] = cluster
+	}
+	for name, authInfo := range config.AuthInfos {
+		existing.AuthInfos[name] = authInfo
+	}
+	for name, ctx := range config.Contexts {
+		existing.Contexts[name] = ctx
+	}
+	if existing.CurrentContext == "" {
+		existing.CurrentContext = config.CurrentContext
+	}
+	return existing, nil
+}
@@ -0,0 +1,112 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+)
+
+func newLeaseCommand() *command {
+	return &command{
+		name:  "lease",
+		short: "Show and renew leased namespaces",
+		subs: []*command{
+			newLeaseListCommand(),
+			newLeaseRenewCommand(),
+		},
+	}
+}
+
+func newLeaseListCommand() *command {
+	return &command{
+		name:  "list",
+		short: "List leased namespaces, soonest to expire first",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().OwnedNamespaces(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			leased := []netsys_v1.OwnedNamespace{}
+			for _, on := range list.Items {
+				if on.Spec.Lease != nil {
+					leased = append(leased, on)
+				}
+			}
+			sort.Slice(leased, func(i, j int) bool {
+				return expiresAt(&leased[i]).Before(expiresAt(&leased[j]))
+			})
+			return c.print(leased, func(w io.Writer) {
+				row(w, "USER", "NAMESPACE", "ROLE", "LEASE", "STATE", "RENEWED", "EXPIRES")
+				for _, on := range leased {
+					state, renewed, expires := "<none>", "<none>", "<none>"
+					if l := on.Status.Lease; l != nil {
+						state, renewed, expires = l.State, formatExpiry(&l.RenewedAt), formatExpiry(&l.ExpiresAt)
+					}
+					row(w, on.Spec.OwnerID, on.Key(), netsys_v1.RoleOrDefault(on.Spec.Role),
+						on.Spec.Lease.Duration, state, renewed, expires)
+				}
+			})
+		},
+	}
+}
+
+// expiresAt returns when the lease of on expires, leases the controller
+// has not seen yet sort first
+func expiresAt(on *netsys_v1.OwnedNamespace) *meta_v1.Time {
+	if on.Status.Lease == nil {
+		return &meta_v1.Time{}
+	}
+	return &on.Status.Lease.ExpiresAt
+}
+
+func newLeaseRenewCommand() *command {
+	return &command{
+		name:  "renew",
+		args:  "USER NAMESPACE",
+		short: "Renew the lease of a namespace for another lease period",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 2, "USER NAMESPACE"); err != nil {
+				return err
+			}
+			du, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			owned := cs.NetsysClient.NetsysV1().OwnedNamespaces(c.namespace)
+			var on *netsys_v1.OwnedNamespace
+			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				on, err = owned.Get(controller.OwnedNamespaceName(du.Spec.UserID, args[1]), meta_v1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				if err := on.RenewLease(meta_v1.Now().Time); err != nil {
+					return err
+				}
+				on, err = owned.Update(on)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "lease of %s renewed for %s until %s\n", args[1], args[0], formatExpiry(&on.Status.Lease.ExpiresAt))
+			return nil
+		},
+	}
+}
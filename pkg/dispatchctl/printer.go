@@ -0,0 +1,61 @@
+package dispatchctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+)
+
+// print writes obj in the selected output format. table writes the
+// human readable form and is only called for the table format.
+func (c *ctl) print(obj interface{}, table func(w io.Writer)) error {
+	switch c.output {
+	case "json":
+		b, err := json.MarshalIndent(obj, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, string(b))
+	case "yaml":
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(c.out, string(b))
+	case "table", "":
+		w := tabwriter.NewWriter(c.out, 0, 4, 3, ' ', 0)
+		table(w)
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, use table, json or yaml", c.output)
+	}
+	return nil
+}
+
+// row writes one tab separated table row
+func row(w io.Writer, cells ...interface{}) {
+	s := make([]string, len(cells))
+	for i, cell := range cells {
+		s[i] = fmt.Sprint(cell)
+	}
+	fmt.Fprintln(w, strings.Join(s, "\t"))
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "<none>"
+	}
+	return strings.Join(items, ",")
+}
+
+// displayRole shows grants without an explicit role as the controller default
+func displayRole(role string) string {
+	if role == "" {
+		return "default"
+	}
+	return role
+}
@@ -0,0 +1,222 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/pflag"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+func newRecertificationCommand() *command {
+	return &command{
+		name:  "recert",
+		short: "Report on and confirm grants in access recertification campaigns",
+		subs: []*command{
+			newRecertReportCommand(),
+			newRecertListCommand(),
+			newRecertConfirmCommand(),
+		},
+	}
+}
+
+// campaignReport is the progress of one campaign
+type campaignReport struct {
+	Name      string    `json:"name"`
+	Started   time.Time `json:"started"`
+	Deadline  time.Time `json:"deadline"`
+	Completed bool      `json:"completed"`
+	Pending   int       `json:"pending"`
+	Confirmed int       `json:"confirmed"`
+	Revoked   int       `json:"revoked"`
+	Dropped   int       `json:"dropped"`
+}
+
+func newRecertReportCommand() *command {
+	return &command{
+		name:  "report",
+		short: "Show the progress of every campaign",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			campaigns, err := c.campaigns()
+			if err != nil {
+				return err
+			}
+			reports := []campaignReport{}
+			for _, campaign := range campaigns {
+				progress := campaign.Progress()
+				reports = append(reports, campaignReport{
+					Name:      campaign.Name,
+					Started:   campaign.CreationTimestamp.Time,
+					Deadline:  campaign.Spec.Deadline.Time,
+					Completed: campaign.Status.Completed,
+					Pending:   progress[netsys_v1.RecertificationPending],
+					Confirmed: progress[netsys_v1.RecertificationConfirmed],
+					Revoked:   progress[netsys_v1.RecertificationRevoked],
+					Dropped:   progress[netsys_v1.RecertificationDropped],
+				})
+			}
+			return c.print(reports, func(w io.Writer) {
+				row(w, "CAMPAIGN", "STARTED", "DEADLINE", "STATE", "PENDING", "CONFIRMED", "REVOKED", "DROPPED")
+				for _, r := range reports {
+					state := "Active"
+					if r.Completed {
+						state = "Completed"
+					}
+					row(w, r.Name, r.Started.Format(time.RFC3339), r.Deadline.Format(time.RFC3339), state,
+						r.Pending, r.Confirmed, r.Revoked, r.Dropped)
+				}
+			})
+		},
+	}
+}
+
+func newRecertListCommand() *command {
+	var state string
+	return &command{
+		name:  "list",
+		args:  "[CAMPAIGN]",
+		short: "List the grants of a campaign, by default the latest",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&state, "state", "", "only list grants in this state: Pending, Confirmed, Revoked or Dropped")
+		},
+		run: func(c *ctl, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("expected [CAMPAIGN]")
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			campaign, err := c.findCampaign(name, false)
+			if err != nil {
+				return err
+			}
+			items := []netsys_v1.RecertificationItem{}
+			for _, item := range campaign.Status.Items {
+				if state == "" || item.State == state {
+					items = append(items, item)
+				}
+			}
+			return c.print(items, func(w io.Writer) {
+				row(w, "USER", "NAMESPACE", "ROLE", "STATE", "REVIEWED-BY")
+				for _, item := range items {
+					row(w, item.UserID, item.Namespace, item.Role, item.State, orNone(item.ReviewedBy))
+				}
+			})
+		},
+	}
+}
+
+func newRecertConfirmCommand() *command {
+	var campaignName, reviewer string
+	return &command{
+		name:  "confirm",
+		args:  "USER NAMESPACE...",
+		short: "Confirm grants of a user so they are not revoked",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&campaignName, "campaign", "", "campaign to confirm in, defaults to the latest active one")
+			fs.StringVar(&reviewer, "reviewer", "", "who confirms, defaults to the user of the kubeconfig context")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := minArgs(args, 2, "USER NAMESPACE..."); err != nil {
+				return err
+			}
+			du, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			if reviewer == "" {
+				reviewer = c.currentUser()
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+
+			var name string
+			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				campaign, err := c.findCampaign(campaignName, true)
+				if err != nil {
+					return err
+				}
+				campaign = campaign.DeepCopy()
+				name = campaign.Name
+				now := meta_v1.Now()
+				for _, ns := range args[1:] {
+					if err := campaign.Confirm(du.Spec.UserID, ns, reviewer, now); err != nil {
+						return err
+					}
+				}
+				_, err = cs.NetsysClient.NetsysV1().RecertificationCampaigns(c.namespace).Update(campaign)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "confirmed %d grants of %s in %s\n", len(args)-1, du.Name, name)
+			return nil
+		},
+	}
+}
+
+// campaigns returns all campaigns, oldest first
+func (c *ctl) campaigns() ([]netsys_v1.RecertificationCampaign, error) {
+	cs, err := c.clients()
+	if err != nil {
+		return nil, err
+	}
+	list, err := cs.NetsysClient.NetsysV1().RecertificationCampaigns(c.namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].CreationTimestamp.Before(&list.Items[j].CreationTimestamp)
+	})
+	return list.Items, nil
+}
+
+// findCampaign returns the named campaign, or the latest one if name is
+// empty. active only considers campaigns that are not completed.
+func (c *ctl) findCampaign(name string, active bool) (*netsys_v1.RecertificationCampaign, error) {
+	campaigns, err := c.campaigns()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(campaigns) - 1; i >= 0; i-- {
+		campaign := &campaigns[i]
+		if (name == "" || campaign.Name == name) && !(active && campaign.Status.Completed) {
+			return campaign, nil
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("no campaign %s", name)
+	}
+	if active {
+		return nil, fmt.Errorf("no active campaign")
+	}
+	return nil, fmt.Errorf("no campaigns yet, is recertification enabled?")
+}
+
+// currentUser names the user of the kubeconfig context, or the local user
+func (c *ctl) currentUser() string {
+	raw, err := c.clientConfig().RawConfig()
+	if err == nil {
+		current := raw.CurrentContext
+		if c.context != "" {
+			current = c.context
+		}
+		if ctx, ok := raw.Contexts[current]; ok && ctx.AuthInfo != "" {
+			return ctx.AuthInfo
+		}
+	}
+	return os.Getenv("USER")
+}
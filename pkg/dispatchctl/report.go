@@ -0,0 +1,64 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/hantaowang/dispatch/pkg/chargeback"
+)
+
+func newReportCommand() *command {
+	var from, to, by string
+	return &command{
+		name:  "report",
+		short: "Report the cost of users, groups or namespaces, -o csv for spreadsheets",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&from, "from", "", "first day of the report as YYYY-MM-DD, defaults to the start of last month")
+			fs.StringVar(&to, "to", "", "last day of the report as YYYY-MM-DD, defaults to the end of the month of --from")
+			fs.StringVar(&by, "by", chargeback.ByUser, "break the report down by user, group or namespace")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			start, end, err := chargeback.ParseRange(from, to, time.Now())
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			store := chargeback.ConfigMapStore{Client: cs.OriginalClient, Namespace: c.namespace}
+			days, err := store.Load(start, end)
+			if err != nil {
+				return err
+			}
+			report, err := chargeback.Generate(days, start, end, by, nil)
+			if err != nil {
+				return err
+			}
+			if c.output == "csv" {
+				return report.WriteCSV(c.out)
+			}
+			return c.print(report, func(w io.Writer) {
+				row(w, strings.ToUpper(by), "CPU-HOURS", "MEMORY-GB-HOURS", "STORAGE-GB-HOURS", "COST")
+				for _, r := range append(report.Rows, report.Total) {
+					row(w, r.Key, fmt.Sprintf("%.2f", r.CPUHours), fmt.Sprintf("%.2f", r.MemoryGBHours),
+						fmt.Sprintf("%.2f", r.StorageGBHours), formatCost(r.Cost, report.Currency))
+				}
+			})
+		},
+	}
+}
+
+func formatCost(cost float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f", cost)
+	}
+	return fmt.Sprintf("%.2f %s", cost, currency)
+}
@@ -0,0 +1,203 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+func newRobotCommand() *command {
+	return &command{
+		name:  "robot",
+		short: "Manage DispatchRobots, identities for CI with rotating tokens",
+		subs: []*command{
+			newRobotCreateCommand(),
+			newRobotListCommand(),
+			newRobotTokenCommand(),
+			newRobotDeleteCommand(),
+		},
+	}
+}
+
+func newRobotCreateCommand() *command {
+	var owner, ownerGroup, ttl string
+	var grants []string
+	return &command{
+		name:  "create",
+		args:  "NAME --owner USER --grant NAMESPACE[=ROLE]",
+		short: "Create a DispatchRobot holding namespaces of its owner, e.g. ci --owner alice --grant team-a=edit",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&owner, "owner", "", "user that owns the robot")
+			fs.StringVar(&ownerGroup, "owner-group", "", "group whose members own the robot, instead of --owner; it holds at most your roles")
+			fs.StringSliceVar(&grants, "grant", nil, "namespace and role, view if omitted, e.g. team-a=edit; may be repeated")
+			fs.StringVar(&ttl, "token-ttl", "", "rotate the token after this long, e.g. 12h, instead of the configured default")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "NAME"); err != nil {
+				return err
+			}
+			if (owner == "") == (ownerGroup == "") {
+				return fmt.Errorf("set either --owner or --owner-group")
+			}
+			if len(grants) == 0 {
+				return fmt.Errorf("--grant is required")
+			}
+			spec := netsys_v1.DispatchRobotSpec{OwnerGroup: ownerGroup}
+			if owner != "" {
+				du, err := c.findUser(owner)
+				if err != nil {
+					return err
+				}
+				spec.Owner = du.Spec.UserID
+			} else {
+				// robots of a group hold at most what their creator holds
+				creator, groups, err := c.caller()
+				if err != nil {
+					return err
+				}
+				du, err := c.findUser(creator)
+				if err != nil {
+					return err
+				}
+				member := &netsys_v1.DispatchRobot{Spec: spec}
+				if !member.IsOwner(du.Spec.UserID, append(groups, du.Spec.Groups...)) {
+					return fmt.Errorf("%s is not a member of group %s", creator, ownerGroup)
+				}
+				spec.CreatedBy = du.Spec.UserID
+			}
+			for _, g := range grants {
+				ns, role := g, netsys_v1.RoleView
+				if i := strings.Index(g, "="); i >= 0 {
+					ns, role = g[:i], g[i+1:]
+				}
+				if !netsys_v1.ValidRole(role) {
+					return fmt.Errorf("--grant %s: role must be view, edit or admin", g)
+				}
+				spec.Grants = append(spec.Grants, netsys_v1.RobotGrant{Namespace: ns, Role: role})
+			}
+			if ttl != "" {
+				d, err := parseTTL(ttl)
+				if err != nil {
+					return fmt.Errorf("--token-ttl: %v", err)
+				}
+				spec.TokenTTL = &meta_v1.Duration{Duration: d}
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			_, err = cs.NetsysClient.NetsysV1().DispatchRobots(c.namespace).Create(&netsys_v1.DispatchRobot{
+				ObjectMeta: meta_v1.ObjectMeta{Name: args[0], Namespace: c.namespace},
+				Spec:       spec,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "dispatchrobot %s created\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newRobotListCommand() *command {
+	return &command{
+		name:  "list",
+		short: "List DispatchRobots with the grants they hold",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().DispatchRobots(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+			return c.print(list.Items, func(w io.Writer) {
+				row(w, "NAME", "OWNER", "PHASE", "GRANTS", "TOKEN ISSUED", "MESSAGE")
+				for _, r := range list.Items {
+					owner := r.Spec.Owner
+					if r.Spec.OwnerGroup != "" {
+						owner = "group " + r.Spec.OwnerGroup
+					}
+					var held []string
+					for _, g := range r.Status.Grants {
+						held = append(held, g.Namespace+"="+g.GrantedRole())
+					}
+					issued := "<none>"
+					if r.Status.TokenIssuedAt != nil {
+						issued = r.Status.TokenIssuedAt.UTC().Format(time.RFC3339)
+					}
+					row(w, r.Name, owner, orNone(r.Status.Phase), joinOrNone(held), issued, r.Status.Message)
+				}
+			})
+		},
+	}
+}
+
+func newRobotTokenCommand() *command {
+	return &command{
+		name:  "token",
+		args:  "NAME",
+		short: "Print the current token of a DispatchRobot",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "NAME"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			r, err := cs.NetsysClient.NetsysV1().DispatchRobots(c.namespace).Get(args[0], meta_v1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if r.Status.Phase != netsys_v1.RobotActive || r.Status.TokenSecret == "" {
+				return fmt.Errorf("dispatchrobot %s has no token: %s", r.Name, orNone(r.Status.Message))
+			}
+			secret, err := cs.OriginalClient.CoreV1().Secrets(c.namespace).Get(r.Status.TokenSecret, meta_v1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			token := secret.Data[core_v1.ServiceAccountTokenKey]
+			if len(token) == 0 {
+				return fmt.Errorf("the token of dispatchrobot %s is not issued yet", r.Name)
+			}
+			fmt.Fprintln(c.out, string(token))
+			return nil
+		},
+	}
+}
+
+func newRobotDeleteCommand() *command {
+	return &command{
+		name:  "delete",
+		args:  "NAME",
+		short: "Delete a DispatchRobot, revoking its tokens and grants",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "NAME"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			if err := cs.NetsysClient.NetsysV1().DispatchRobots(c.namespace).Delete(args[0], nil); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "dispatchrobot %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
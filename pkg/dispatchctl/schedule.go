@@ -0,0 +1,195 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/pflag"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/schedule"
+)
+
+func newScheduleCommand() *command {
+	return &command{
+		name:  "schedule",
+		short: "Run namespaces only within availability windows",
+		subs: []*command{
+			newScheduleSetCommand(),
+			newScheduleClearCommand(),
+			newScheduleListCommand(),
+			newKeepAwakeCommand(),
+		},
+	}
+}
+
+func newScheduleSetCommand() *command {
+	var s netsys_v1.AvailabilitySchedule
+	var class, reason string
+	return &command{
+		name:  "set",
+		args:  "USER NAMESPACE... (--start CRON --stop CRON | --class CLASS)",
+		short: "Give grants an availability window",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&s.Start, "start", "", `cron expression of when the window opens, e.g. "0 8 * * 1-5"`)
+			fs.StringVar(&s.Stop, "stop", "", `cron expression of when the window closes, e.g. "0 19 * * 1-5"`)
+			fs.StringVar(&s.TimeZone, "time-zone", "", "time zone of the cron expressions, e.g. Europe/Berlin, UTC by default")
+			fs.BoolVar(&s.RevokeWrite, "revoke-write", false, "lower the role to view outside the window")
+			fs.StringVar(&class, "class", "", "use a schedule class of the controller configuration instead")
+			reasonFlag(fs, &reason)
+		},
+		run: func(c *ctl, args []string) error {
+			if err := minArgs(args, 2, "USER NAMESPACE..."); err != nil {
+				return err
+			}
+			switch {
+			case class != "" && (s.Start != "" || s.Stop != ""):
+				return fmt.Errorf("use either --class or --start and --stop")
+			case class == "":
+				if _, err := schedule.NewWindow(s); err != nil {
+					return err
+				}
+			}
+			_, err := c.updateUser(args[0], reason, func(spec *netsys_v1.DispatchUserSpec) error {
+				for _, ns := range args[1:] {
+					g := spec.Grant(ns)
+					if g == nil {
+						return fmt.Errorf("%s has no grant in namespace %s", args[0], ns)
+					}
+					g.Schedule, g.ScheduleClass = nil, class
+					if class == "" {
+						sched := s
+						g.Schedule = &sched
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "schedule of %v set for %s\n", args[1:], args[0])
+			return nil
+		},
+	}
+}
+
+func newScheduleClearCommand() *command {
+	var reason string
+	return &command{
+		name:  "clear",
+		args:  "USER NAMESPACE...",
+		short: "Keep namespaces available at all times again",
+		flags: func(fs *pflag.FlagSet) { reasonFlag(fs, &reason) },
+		run: func(c *ctl, args []string) error {
+			if err := minArgs(args, 2, "USER NAMESPACE..."); err != nil {
+				return err
+			}
+			_, err := c.updateUser(args[0], reason, func(spec *netsys_v1.DispatchUserSpec) error {
+				for _, ns := range args[1:] {
+					if g := spec.Grant(ns); g != nil {
+						g.Schedule, g.ScheduleClass = nil, ""
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "schedule of %v cleared for %s\n", args[1:], args[0])
+			return nil
+		},
+	}
+}
+
+func newScheduleListCommand() *command {
+	return &command{
+		name:  "list",
+		short: "List scheduled namespaces with their next transition",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().OwnedNamespaces(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			scheduled := []netsys_v1.OwnedNamespace{}
+			for _, on := range list.Items {
+				if on.Spec.Schedule != nil {
+					scheduled = append(scheduled, on)
+				}
+			}
+			return c.print(scheduled, func(w io.Writer) {
+				row(w, "USER", "NAMESPACE", "START", "STOP", "TIME-ZONE", "AWAKE", "NEXT-TRANSITION", "KEPT-AWAKE-UNTIL")
+				for _, on := range scheduled {
+					s := on.Spec.Schedule
+					awake, next, keep := "<unknown>", "<none>", "<none>"
+					if st := on.Status.Schedule; st != nil {
+						awake = fmt.Sprint(st.Awake)
+						if st.NextTransition != nil {
+							next = formatExpiry(st.NextTransition)
+						}
+						if st.KeepAwakeUntil != nil {
+							keep = formatExpiry(st.KeepAwakeUntil)
+						}
+					}
+					row(w, on.Spec.OwnerID, on.Spec.Namespace, s.Start, s.Stop, orNone(s.TimeZone), awake, next, keep)
+				}
+			})
+		},
+	}
+}
+
+func newKeepAwakeCommand() *command {
+	var until string
+	return &command{
+		name:  "keep-awake",
+		args:  "USER NAMESPACE --until TIME",
+		short: "Keep a scheduled namespace available past the end of its window",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&until, "until", "", "time of day in the schedule's time zone, e.g. 22:00, or RFC 3339")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 2, "USER NAMESPACE"); err != nil {
+				return err
+			}
+			if until == "" {
+				return fmt.Errorf("--until is required")
+			}
+			du, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			owned := cs.NetsysClient.NetsysV1().OwnedNamespaces(c.namespace)
+			var t time.Time
+			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				on, err := owned.Get(controller.OwnedNamespaceName(du.Spec.UserID, args[1]), meta_v1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				if t, err = schedule.KeepAwake(on, until, time.Now()); err != nil {
+					return err
+				}
+				_, err = owned.Update(on)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "namespace %s kept awake until %s\n", args[1], t.Format(time.RFC3339))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package dispatchctl
+
+import (
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	"github.com/hantaowang/dispatch/pkg/controller"
+)
+
+// grantStatus is the provisioning state of one grant
+type grantStatus struct {
+	User           string `json:"user"`
+	UserID         string `json:"userID"`
+	Namespace      string `json:"namespace"`
+	Role           string `json:"role"`
+	OwnedNamespace bool   `json:"ownedNamespace"`
+	RoleBinding    bool   `json:"roleBinding"`
+}
+
+func newStatusCommand() *command {
+	return &command{
+		name:  "status",
+		short: "Show whether every grant has been provisioned",
+		run: func(c *ctl, args []string) error {
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			users, err := cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			ons, err := cs.NetsysClient.NetsysV1().OwnedNamespaces(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			owned := map[string]netsys_v1.OwnedNamespace{}
+			for _, on := range ons.Items {
+				owned[on.Name] = on
+			}
+
+			statuses := []grantStatus{}
+			for _, du := range users.Items {
+				for _, g := range du.Spec.EffectiveGrants() {
+					name := controller.OwnedNamespaceName(du.Spec.UserID, g.Key())
+					s := grantStatus{
+						User:      du.Name,
+						UserID:    du.Spec.UserID,
+						Namespace: g.Key(),
+						Role:      g.Role,
+					}
+					on, ok := owned[name]
+					if ok {
+						// grants without a role get whatever default the controller applied
+						if s.Role == "" {
+							s.Role = netsys_v1.RoleOrDefault(on.Spec.Role)
+						}
+						s.OwnedNamespace = netsys_v1.RoleOrDefault(on.Spec.Role) == s.Role
+					}
+					kc, err := cs.ForCluster(g.Cluster)
+					if client.IsUnknownCluster(err) {
+						statuses = append(statuses, s)
+						continue
+					}
+					if err != nil {
+						return err
+					}
+					rb, err := kc.RbacV1().RoleBindings(g.Namespace).Get(controller.NameFunc(du.Spec.UserID, g.Namespace), meta_v1.GetOptions{})
+					if err != nil && !errors.IsNotFound(err) {
+						return err
+					}
+					s.RoleBinding = err == nil && rb.RoleRef.Name == s.Role
+					statuses = append(statuses, s)
+				}
+			}
+
+			return c.print(statuses, func(w io.Writer) {
+				row(w, "USER", "NAMESPACE", "ROLE", "OWNEDNAMESPACE", "ROLEBINDING")
+				for _, s := range statuses {
+					row(w, s.User, s.Namespace, displayRole(s.Role), readiness(s.OwnedNamespace), readiness(s.RoleBinding))
+				}
+			})
+		},
+	}
+}
+
+func readiness(ok bool) string {
+	if ok {
+		return "ready"
+	}
+	return "pending"
+}
@@ -0,0 +1,265 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+func newSubNamespaceCommand() *command {
+	return &command{
+		name:  "subns",
+		short: "Create and delete sub-namespaces that inherit from their parent",
+		subs: []*command{
+			newSubNamespaceCreateCommand(),
+			newSubNamespaceListCommand(),
+			newSubNamespaceDeleteCommand(),
+		},
+	}
+}
+
+func newSubNamespaceCreateCommand() *command {
+	var quota []string
+	var cascade, user string
+	return &command{
+		name:  "create",
+		args:  "PARENT CHILD",
+		short: "Create CHILD under PARENT, e.g. team-a team-a-feature-x",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringSliceVar(&quota, "quota", nil, "slice of the parent's quota, e.g. requests.cpu=2,limits.memory=4Gi")
+			fs.StringVar(&cascade, "cascade", "", "Delete or Orphan the child when the parent is deleted, the controller's default if empty")
+			fs.StringVar(&user, "user", "", "owner of PARENT the child is created for")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 2, "PARENT CHILD"); err != nil {
+				return err
+			}
+			if cascade != "" && !netsys_v1.ValidCascade(cascade) {
+				return fmt.Errorf("invalid cascade %q, use Delete or Orphan", cascade)
+			}
+			parent, child := args[0], args[1]
+			if !strings.HasPrefix(child, parent+"-") {
+				return fmt.Errorf("%s must start with %s-", child, parent)
+			}
+			slice, err := parseQuota(quota)
+			if err != nil {
+				return err
+			}
+			var createdBy string
+			if user != "" {
+				du, err := c.findUser(user)
+				if err != nil {
+					return err
+				}
+				createdBy = du.Spec.UserID
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			_, err = cs.NetsysClient.NetsysV1().SubNamespaces(c.namespace).Create(&netsys_v1.SubNamespace{
+				ObjectMeta: meta_v1.ObjectMeta{Name: child, Namespace: c.namespace},
+				Spec: netsys_v1.SubNamespaceSpec{
+					Parent:    parent,
+					Quota:     slice,
+					Cascade:   cascade,
+					CreatedBy: createdBy,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "subnamespace %s created under %s\n", child, parent)
+			return nil
+		},
+	}
+}
+
+func newSubNamespaceListCommand() *command {
+	return &command{
+		name:  "list",
+		short: "List sub-namespaces",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			subs, err := c.subNamespaces()
+			if err != nil {
+				return err
+			}
+			return c.print(subs, func(w io.Writer) {
+				row(w, "NAME", "PARENT", "PHASE", "QUOTA", "CASCADE", "OWNERS", "MESSAGE")
+				for _, sn := range subs {
+					row(w, sn.Name, sn.Spec.Parent, orNone(sn.Status.Phase), orNone(formatQuota(sn.Spec.Quota)),
+						orNone(sn.Spec.Cascade), joinOrNone(sn.Status.Owners), sn.Status.Message)
+				}
+			})
+		},
+	}
+}
+
+func newSubNamespaceDeleteCommand() *command {
+	return &command{
+		name:  "delete",
+		args:  "CHILD",
+		short: "Delete a sub-namespace, its own children follow their cascade policy",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "CHILD"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			if err := cs.NetsysClient.NetsysV1().SubNamespaces(c.namespace).Delete(args[0], nil); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "subnamespace %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
+
+// treeNode is a namespace of the hierarchy with its sub-namespaces
+type treeNode struct {
+	Namespace string               `json:"namespace"`
+	Phase     string               `json:"phase,omitempty"`
+	Quota     core_v1.ResourceList `json:"quota,omitempty"`
+	Message   string               `json:"message,omitempty"`
+	Children  []*treeNode          `json:"children,omitempty"`
+}
+
+func newTreeCommand() *command {
+	return &command{
+		name:  "tree",
+		args:  "[NAMESPACE]",
+		short: "Show namespaces and their sub-namespaces as a tree",
+		run: func(c *ctl, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("expected at most one NAMESPACE")
+			}
+			subs, err := c.subNamespaces()
+			if err != nil {
+				return err
+			}
+			nodes := map[string]*treeNode{}
+			node := func(namespace string) *treeNode {
+				if n, ok := nodes[namespace]; ok {
+					return n
+				}
+				n := &treeNode{Namespace: namespace}
+				nodes[namespace] = n
+				return n
+			}
+			isChild := map[string]bool{}
+			for _, sn := range subs {
+				n := node(sn.Name)
+				n.Phase, n.Quota, n.Message = sn.Status.Phase, sn.Spec.Quota, sn.Status.Message
+				// orphans are roots of their own
+				if sn.Status.Phase != netsys_v1.SubNamespaceOrphaned {
+					p := node(sn.Spec.Parent)
+					p.Children = append(p.Children, n)
+					isChild[sn.Name] = true
+				}
+			}
+			var roots []*treeNode
+			if len(args) == 1 {
+				n, ok := nodes[args[0]]
+				if !ok {
+					return fmt.Errorf("namespace %s has no sub-namespaces", args[0])
+				}
+				roots = append(roots, n)
+			} else {
+				for namespace, n := range nodes {
+					if !isChild[namespace] {
+						roots = append(roots, n)
+					}
+				}
+			}
+			sortTree(roots)
+			return c.print(roots, func(w io.Writer) {
+				row(w, "NAMESPACE", "PHASE", "QUOTA", "MESSAGE")
+				for _, n := range roots {
+					printTree(w, n, "", "")
+				}
+			})
+		},
+	}
+}
+
+// printTree writes n and its children, indented under the branches of
+// their parents
+func printTree(w io.Writer, n *treeNode, branch, indent string) {
+	row(w, branch+n.Namespace, orNone(n.Phase), orNone(formatQuota(n.Quota)), n.Message)
+	for i, child := range n.Children {
+		if i == len(n.Children)-1 {
+			printTree(w, child, indent+"└── ", indent+"    ")
+		} else {
+			printTree(w, child, indent+"├── ", indent+"│   ")
+		}
+	}
+}
+
+func sortTree(nodes []*treeNode) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Namespace < nodes[j].Namespace })
+	for _, n := range nodes {
+		sortTree(n.Children)
+	}
+}
+
+// subNamespaces returns the SubNamespaces sorted by name
+func (c *ctl) subNamespaces() ([]netsys_v1.SubNamespace, error) {
+	cs, err := c.clients()
+	if err != nil {
+		return nil, err
+	}
+	list, err := cs.NetsysClient.NetsysV1().SubNamespaces(c.namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list.Items, nil
+}
+
+// parseQuota reads name=quantity pairs into a ResourceList
+func parseQuota(pairs []string) (core_v1.ResourceList, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	list := core_v1.ResourceList{}
+	for _, pair := range pairs {
+		i := strings.Index(pair, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid quota %q, use name=quantity", pair)
+		}
+		q, err := resource.ParseQuantity(pair[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quota %q: %v", pair, err)
+		}
+		list[core_v1.ResourceName(pair[:i])] = q
+	}
+	return list, nil
+}
+
+// formatQuota writes list as name=quantity pairs sorted by name
+func formatQuota(list core_v1.ResourceList) string {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		q := list[core_v1.ResourceName(name)]
+		pairs[i] = name + "=" + q.String()
+	}
+	return strings.Join(pairs, ",")
+}
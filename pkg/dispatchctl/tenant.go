@@ -0,0 +1,134 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/pflag"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+func newTenantCommand() *command {
+	return &command{
+		name:  "tenant",
+		short: "Create and list DispatchTenants, groups of users their admins manage",
+		subs: []*command{
+			newTenantCreateCommand(),
+			newTenantListCommand(),
+			newTenantDeleteCommand(),
+		},
+	}
+}
+
+func newTenantCreateCommand() *command {
+	var prefix string
+	var admins, adminGroups, classes []string
+	var maxUsers, maxNamespaces int
+	return &command{
+		name:  "create",
+		args:  "NAME",
+		short: "Create a DispatchTenant, e.g. team-a --prefix team-a- --admin alice",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&prefix, "prefix", "", "prefix every namespace of the tenant starts with, required")
+			fs.StringSliceVar(&admins, "admin", nil, "user ID of a tenant admin, may be repeated")
+			fs.StringSliceVar(&adminGroups, "admin-group", nil, "group whose members are tenant admins, may be repeated")
+			fs.IntVar(&maxUsers, "max-users", 0, "most users the tenant can have, 0 for no limit")
+			fs.IntVar(&maxNamespaces, "max-namespaces", 0, "most namespaces the tenant can hold, 0 for no limit")
+			fs.StringSliceVar(&classes, "class", nil, "schedule class grants of the tenant can use, may be repeated; any if not set")
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "NAME"); err != nil {
+				return err
+			}
+			if prefix == "" {
+				return fmt.Errorf("--prefix is required")
+			}
+			if maxUsers < 0 || maxNamespaces < 0 {
+				return fmt.Errorf("--max-users and --max-namespaces must not be negative")
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			_, err = cs.NetsysClient.NetsysV1().DispatchTenants(c.namespace).Create(&netsys_v1.DispatchTenant{
+				ObjectMeta: meta_v1.ObjectMeta{Name: args[0], Namespace: c.namespace},
+				Spec: netsys_v1.DispatchTenantSpec{
+					Admins:         admins,
+					AdminGroups:    adminGroups,
+					Prefix:         prefix,
+					MaxUsers:       maxUsers,
+					MaxNamespaces:  maxNamespaces,
+					AllowedClasses: classes,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "dispatchtenant %s created\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newTenantListCommand() *command {
+	return &command{
+		name:  "list",
+		short: "List DispatchTenants with what they use of their budget",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 0, "no arguments"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().DispatchTenants(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+			return c.print(list.Items, func(w io.Writer) {
+				row(w, "NAME", "PREFIX", "PHASE", "USERS", "NAMESPACES", "CLASSES", "ADMINS", "MESSAGE")
+				for _, t := range list.Items {
+					admins := append(append([]string(nil), t.Spec.Admins...), t.Spec.AdminGroups...)
+					row(w, t.Name, t.Spec.Prefix, orNone(t.Status.Phase), ofMax(t.Status.Users, t.Spec.MaxUsers),
+						ofMax(t.Status.Namespaces, t.Spec.MaxNamespaces), joinOrNone(t.Spec.AllowedClasses),
+						joinOrNone(admins), t.Status.Message)
+				}
+			})
+		},
+	}
+}
+
+func newTenantDeleteCommand() *command {
+	return &command{
+		name:  "delete",
+		args:  "NAME",
+		short: "Delete a DispatchTenant, its users lose their grants until they are moved",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "NAME"); err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			if err := cs.NetsysClient.NetsysV1().DispatchTenants(c.namespace).Delete(args[0], nil); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "dispatchtenant %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
+
+// ofMax writes n as n/max, or just n without a limit
+func ofMax(n, max int) string {
+	if max == 0 {
+		return fmt.Sprint(n)
+	}
+	return fmt.Sprintf("%d/%d", n, max)
+}
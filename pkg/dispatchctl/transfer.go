@@ -0,0 +1,66 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/hantaowang/dispatch/pkg/transfer"
+)
+
+func newTransferCommand() *command {
+	var toUser, toGroup, by, reason string
+	return &command{
+		name:  "transfer",
+		args:  "USER [NAMESPACE...] --to USER | --to-group GROUP",
+		short: "Move a user's grants in namespaces, all if none are given, to another user or a group",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&toUser, "to", "", "user receiving the grants")
+			fs.StringVar(&toGroup, "to-group", "", "group whose active members receive the grants")
+			fs.StringVar(&by, "by", "", "who transfers, defaults to the user of the kubeconfig context")
+			reasonFlag(fs, &reason)
+		},
+		run: func(c *ctl, args []string) error {
+			if err := minArgs(args, 1, "USER [NAMESPACE...]"); err != nil {
+				return err
+			}
+			if (toUser == "") == (toGroup == "") {
+				return fmt.Errorf("use either --to or --to-group")
+			}
+			from, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			if toUser != "" {
+				to, err := c.findUser(toUser)
+				if err != nil {
+					return err
+				}
+				toUser = to.Spec.UserID
+			}
+			if by == "" {
+				by = c.currentUser()
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			result, err := transfer.Transfer(cs.NetsysClient, c.namespace, transfer.Request{
+				From:       from.Spec.UserID,
+				Namespaces: args[1:],
+				ToUser:     toUser,
+				ToGroup:    toGroup,
+				By:         by,
+				Reason:     reason,
+			}, time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "transferred %v from %s to %s\n", result.Namespaces, from.Spec.UserID,
+				strings.Join(result.To, ", "))
+			return nil
+		},
+	}
+}
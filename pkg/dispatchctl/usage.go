@@ -0,0 +1,113 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+// usageColumns are the resources shown in usage tables
+var usageColumns = []core_v1.ResourceName{
+	core_v1.ResourceRequestsCPU,
+	core_v1.ResourceLimitsCPU,
+	core_v1.ResourceRequestsMemory,
+	core_v1.ResourceLimitsMemory,
+	core_v1.ResourceRequestsStorage,
+	core_v1.ResourcePods,
+}
+
+func newUsageCommand() *command {
+	return &command{
+		name:  "usage",
+		args:  "[USER]",
+		short: "Show what users consume, or one user by namespace",
+		run: func(c *ctl, args []string) error {
+			switch len(args) {
+			case 0:
+			case 1:
+				du, err := c.findUser(args[0])
+				if err != nil {
+					return err
+				}
+				return c.printUserUsage(du)
+			default:
+				return fmt.Errorf("expected at most one USER")
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Spec.UserID < list.Items[j].Spec.UserID })
+			usages := map[string]*netsys_v1.UsageStatus{}
+			for _, du := range list.Items {
+				usages[du.Spec.UserID] = du.Status.Usage
+			}
+			return c.print(usages, func(w io.Writer) {
+				row(w, usageHeader("USER", "NAMESPACES")...)
+				for _, du := range list.Items {
+					u := du.Status.Usage
+					if u == nil {
+						row(w, usageRow(nil, du.Spec.UserID, 0)...)
+						continue
+					}
+					row(w, usageRow(u.Total, du.Spec.UserID, len(u.Namespaces))...)
+				}
+			})
+		},
+	}
+}
+
+// printUserUsage shows the share of every namespace of du
+func (c *ctl) printUserUsage(du *netsys_v1.DispatchUser) error {
+	u := du.Status.Usage
+	if u == nil {
+		u = &netsys_v1.UsageStatus{}
+	}
+	return c.print(u, func(w io.Writer) {
+		namespaces := make([]string, 0, len(u.Namespaces))
+		for ns := range u.Namespaces {
+			namespaces = append(namespaces, ns)
+		}
+		sort.Strings(namespaces)
+		row(w, usageHeader("NAMESPACE")...)
+		for _, ns := range namespaces {
+			row(w, usageRow(u.Namespaces[ns], ns)...)
+		}
+		row(w, usageRow(u.Total, "TOTAL")...)
+	})
+}
+
+func usageHeader(first ...string) []interface{} {
+	cells := make([]interface{}, 0, len(first)+len(usageColumns))
+	for _, s := range first {
+		cells = append(cells, s)
+	}
+	for _, name := range usageColumns {
+		cells = append(cells, strings.ToUpper(string(name)))
+	}
+	return cells
+}
+
+// usageRow returns the cells of first followed by the usage columns of list
+func usageRow(list core_v1.ResourceList, first ...interface{}) []interface{} {
+	cells := append([]interface{}{}, first...)
+	for _, name := range usageColumns {
+		q, ok := list[name]
+		if !ok {
+			cells = append(cells, "-")
+			continue
+		}
+		cells = append(cells, q.String())
+	}
+	return cells
+}
@@ -0,0 +1,245 @@
+package dispatchctl
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/pflag"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+func newUserCommand() *command {
+	return &command{
+		name:  "user",
+		short: "Create, delete and inspect DispatchUsers",
+		subs: []*command{
+			newUserCreateCommand(),
+			newUserDeleteCommand(),
+			newUserListCommand(),
+			newUserDescribeCommand(),
+			newUserSuspendCommand(true),
+			newUserSuspendCommand(false),
+		},
+	}
+}
+
+func newUserCreateCommand() *command {
+	var userID, reason, tenant string
+	var namespaces, groups []string
+	var expiry expiryFlags
+	return &command{
+		name:  "create",
+		args:  "NAME",
+		short: "Create a DispatchUser",
+		flags: func(fs *pflag.FlagSet) {
+			fs.StringVar(&userID, "user-id", "", "user ID, also the ServiceAccount name; defaults to NAME")
+			fs.StringSliceVar(&namespaces, "namespace", nil, "namespace to own with the default role, may be repeated")
+			fs.StringSliceVar(&groups, "group", nil, "group the user belongs to, may be repeated")
+			fs.StringVar(&tenant, "tenant", "", "DispatchTenant the user belongs to")
+			expiry.add(fs, "the user")
+			reasonFlag(fs, &reason)
+		},
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "NAME"); err != nil {
+				return err
+			}
+			if userID == "" {
+				userID = args[0]
+			}
+			expiresAt, err := expiry.time()
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			du := &netsys_v1.DispatchUser{
+				ObjectMeta: meta_v1.ObjectMeta{
+					Name:      args[0],
+					Namespace: c.namespace,
+				},
+				Spec: netsys_v1.DispatchUserSpec{
+					UserID:     userID,
+					Namespaces: namespaces,
+					Groups:     groups,
+					ExpiresAt:  expiresAt,
+				},
+			}
+			if du.Spec.Namespaces == nil {
+				du.Spec.Namespaces = []string{}
+			}
+			if tenant != "" {
+				du.Labels = map[string]string{netsys_v1.TenantLabel: tenant}
+			}
+			du.SetReason(reason)
+			if _, err := cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace).Create(du); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "dispatchuser %s created\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newUserDeleteCommand() *command {
+	return &command{
+		name:  "delete",
+		args:  "USER",
+		short: "Delete a DispatchUser and revoke all of its grants",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "USER"); err != nil {
+				return err
+			}
+			du, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			if err := cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace).Delete(du.Name, nil); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "dispatchuser %s deleted\n", du.Name)
+			return nil
+		},
+	}
+}
+
+func newUserListCommand() *command {
+	return &command{
+		name:  "list",
+		short: "List DispatchUsers",
+		run: func(c *ctl, args []string) error {
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			list, err := cs.NetsysClient.NetsysV1().DispatchUsers(c.namespace).List(meta_v1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			return c.print(list, func(w io.Writer) {
+				row(w, "NAME", "USERID", "TENANT", "NAMESPACES", "GROUPS")
+				for _, du := range list.Items {
+					var namespaces []string
+					for _, g := range du.Spec.EffectiveGrants() {
+						namespaces = append(namespaces, g.Key()+"("+displayRole(g.Role)+")")
+					}
+					row(w, du.Name, du.Spec.UserID, orNone(du.Labels[netsys_v1.TenantLabel]), joinOrNone(namespaces),
+						joinOrNone(du.Spec.Groups))
+				}
+			})
+		},
+	}
+}
+
+// userDescription is the describe output of a DispatchUser
+type userDescription struct {
+	User            *netsys_v1.DispatchUser    `json:"user"`
+	OwnedNamespaces []netsys_v1.OwnedNamespace `json:"ownedNamespaces"`
+	ServiceAccount  bool                       `json:"serviceAccount"`
+}
+
+func newUserDescribeCommand() *command {
+	return &command{
+		name:  "describe",
+		args:  "USER",
+		short: "Show a DispatchUser with its grants and provisioning state",
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "USER"); err != nil {
+				return err
+			}
+			du, err := c.findUser(args[0])
+			if err != nil {
+				return err
+			}
+			cs, err := c.clients()
+			if err != nil {
+				return err
+			}
+			selector := labels.Set{"ownerID": du.Spec.UserID}.AsSelector().String()
+			ons, err := cs.NetsysClient.NetsysV1().OwnedNamespaces(c.namespace).List(meta_v1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return err
+			}
+			_, saErr := cs.OriginalClient.CoreV1().ServiceAccounts(c.namespace).Get(du.Spec.UserID, meta_v1.GetOptions{})
+
+			d := userDescription{User: du, OwnedNamespaces: ons.Items, ServiceAccount: saErr == nil}
+			return c.print(d, func(w io.Writer) {
+				provisioned := map[string]string{}
+				for _, on := range ons.Items {
+					provisioned[on.Key()] = netsys_v1.RoleOrDefault(on.Spec.Role)
+				}
+				row(w, "Name:", du.Name)
+				row(w, "User ID:", du.Spec.UserID)
+				row(w, "Groups:", joinOrNone(du.Spec.Groups))
+				row(w, "ServiceAccount:", fmt.Sprintf("%s/%s (exists: %t)", c.namespace, du.Spec.UserID, d.ServiceAccount))
+				row(w, "Member clusters:", joinOrNone(du.Status.Clusters))
+				suspended := "no"
+				if du.Spec.Suspended {
+					suspended = "yes"
+					if du.Status.SuspendedAt != nil {
+						suspended += ", since " + du.Status.SuspendedAt.UTC().Format(time.RFC3339)
+					}
+				}
+				row(w, "Suspended:", suspended)
+				row(w, "Expires:", formatExpiry(du.ExpiryTime()), fmt.Sprintf("(expired: %t)", du.Status.Expired))
+				row(w, "Grants:")
+				row(w, "  NAMESPACE", "ROLE", "PROVISIONED", "EXPIRES")
+				for _, g := range du.Spec.EffectiveGrants() {
+					state := "pending"
+					if role, ok := provisioned[g.Key()]; ok && (g.Role == "" || role == g.Role) {
+						state = "yes"
+					}
+					expires := formatExpiry(g.ExpiresAt)
+					if gs := du.Status.GrantStatus(g.Key()); gs != nil {
+						expires = formatExpiry(gs.ExpiresAt)
+						if gs.Expired {
+							state, expires = "expired", expires+" (expired)"
+						}
+					}
+					row(w, "  "+g.Key(), displayRole(g.Role), state, expires)
+				}
+			})
+		},
+	}
+}
+
+// newUserSuspendCommand returns user suspend, or user resume if suspend is false
+func newUserSuspendCommand(suspend bool) *command {
+	name, short, done := "suspend", "Cut all access of a user, keeping its grants", "suspended"
+	if !suspend {
+		name, short, done = "resume", "Restore the access of a suspended user", "resumed"
+	}
+	var reason string
+	return &command{
+		name:  name,
+		args:  "USER",
+		short: short,
+		flags: func(fs *pflag.FlagSet) { reasonFlag(fs, &reason) },
+		run: func(c *ctl, args []string) error {
+			if err := exactArgs(args, 1, "USER"); err != nil {
+				return err
+			}
+			du, err := c.updateUser(args[0], reason, func(spec *netsys_v1.DispatchUserSpec) error {
+				if spec.Suspended == suspend {
+					return fmt.Errorf("%s is already %s", args[0], done)
+				}
+				spec.Suspended = suspend
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.out, "dispatchuser %s %s\n", du.Name, done)
+			return nil
+		},
+	}
+}
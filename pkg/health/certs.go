@@ -0,0 +1,29 @@
+package health
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/util/cert"
+)
+
+// CertificateCheck fails when the PEM certificate in path cannot be read or
+// is not valid right now, e.g. an expired webhook serving certificate
+func CertificateCheck(path string) Check {
+	return func() error {
+		certs, err := cert.CertsFromFile(path)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, c := range certs {
+			if now.Before(c.NotBefore) {
+				return fmt.Errorf("%s is not valid before %s", path, c.NotBefore.Format(time.RFC3339))
+			}
+			if now.After(c.NotAfter) {
+				return fmt.Errorf("%s expired at %s", path, c.NotAfter.Format(time.RFC3339))
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,105 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	auth_v1 "k8s.io/api/authentication/v1"
+	authz_v1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// Authorizer decides whether a request may read /debug
+type Authorizer interface {
+	Authorize(r *http.Request) (allowed bool, reason string, err error)
+}
+
+// KubernetesAuthorizer checks the bearer token of a request with a
+// TokenReview and asks the API server with a SubjectAccessReview whether
+// its user may get the non-resource URL of the request, so access to
+// /debug is granted with ordinary RBAC.
+type KubernetesAuthorizer struct {
+	Client kubernetes.Interface
+}
+
+func (a KubernetesAuthorizer) Authorize(r *http.Request) (bool, string, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return false, "missing bearer token", nil
+	}
+
+	review, err := a.Client.AuthenticationV1().TokenReviews().Create(&auth_v1.TokenReview{
+		Spec: auth_v1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("reviewing token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return false, "invalid token", nil
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authz_v1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authz_v1.ExtraValue(v)
+	}
+	sar, err := a.Client.AuthorizationV1().SubjectAccessReviews().Create(&authz_v1.SubjectAccessReview{
+		Spec: authz_v1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authz_v1.NonResourceAttributes{
+				Path: r.URL.Path,
+				Verb: "get",
+			},
+		},
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("reviewing access: %v", err)
+	}
+	if !sar.Status.Allowed {
+		return false, fmt.Sprintf("%s may not get %s", user.Username, r.URL.Path), nil
+	}
+	return true, "", nil
+}
+
+// DebugHandler dumps the work items and last errors of every tracked
+// controller as JSON
+func (c *Checker) DebugHandler(authorizer Authorizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, reason, err := authorizer.Authorize(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, reason, http.StatusForbidden)
+			return
+		}
+
+		c.lock.Lock()
+		trackers := append([]*Tracker(nil), c.trackers...)
+		leader := c.leader
+		c.lock.Unlock()
+
+		statuses := make([]TrackerStatus, 0, len(trackers))
+		for _, t := range trackers {
+			statuses = append(statuses, t.Status())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		out := map[string]interface{}{"controllers": statuses}
+		if leader != nil {
+			out["leader"] = leader()
+		}
+		if err := enc.Encode(out); err != nil {
+			logging.Error("Encoding debug response failed", "error", err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+// Package health serves the liveness, readiness and debug endpoints of the
+// dispatch controllers.
+package health
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Check returns an error when the component it checks is unhealthy
+type Check func() error
+
+// Checker holds the liveness and readiness checks and the trackers of the
+// running controllers
+type Checker struct {
+	lock      sync.Mutex
+	liveness  map[string]Check
+	readiness map[string]Check
+	trackers  []*Tracker
+	leader    func() bool
+}
+
+// NewChecker creates a Checker without checks
+func NewChecker() *Checker {
+	return &Checker{
+		liveness:  map[string]Check{},
+		readiness: map[string]Check{},
+	}
+}
+
+// AddLivenessCheck adds a check to /healthz, a failing liveness check gets
+// the process restarted
+func (c *Checker) AddLivenessCheck(name string, check Check) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.liveness[name] = check
+}
+
+// AddReadinessCheck adds a check to /readyz
+func (c *Checker) AddReadinessCheck(name string, check Check) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.readiness[name] = check
+}
+
+// AddTracker includes the work items of a controller in /debug
+func (c *Checker) AddTracker(t *Tracker) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.trackers = append(c.trackers, t)
+}
+
+// SetLeaderStatus reports whether this replica leads in /debug
+func (c *Checker) SetLeaderStatus(leader func() bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.leader = leader
+}
+
+// Install registers /healthz and /readyz on mux. /debug is registered
+// separately, since it needs an authorizer.
+func (c *Checker) Install(mux *http.ServeMux) {
+	mux.Handle("/healthz", c.handler(func() map[string]Check { return c.liveness }))
+	mux.Handle("/readyz", c.handler(func() map[string]Check { return c.readiness }))
+}
+
+// handler runs the checks and answers 200 "ok" or 500 listing the failed
+// checks. With ?verbose every check is listed.
+func (c *Checker) handler(checks func() map[string]Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.lock.Lock()
+		names := make([]string, 0, len(checks()))
+		funcs := map[string]Check{}
+		for name, check := range checks() {
+			names = append(names, name)
+			funcs[name] = check
+		}
+		c.lock.Unlock()
+		sort.Strings(names)
+
+		var out bytes.Buffer
+		failed := false
+		for _, name := range names {
+			if err := funcs[name](); err != nil {
+				failed = true
+				fmt.Fprintf(&out, "[-]%s failed: %s\n", name, err)
+			} else {
+				fmt.Fprintf(&out, "[+]%s ok\n", name)
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if failed {
+			w.WriteHeader(http.StatusInternalServerError)
+			out.WriteTo(w)
+			return
+		}
+		if _, verbose := r.URL.Query()["verbose"]; verbose {
+			out.WriteTo(w)
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
@@ -0,0 +1,151 @@
+package health
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tracker follows the work items of a controller: what is queued, what
+// workers are processing and the last error per object. Controllers call
+// Queued when they put an event on their queue, Started when a worker takes
+// it and Done when it is processed.
+type Tracker struct {
+	name string
+
+	lock       sync.Mutex
+	queued     map[itemKey]int
+	inFlight   map[int64]InFlightItem
+	nextID     int64
+	lastErrors map[string]ItemError
+}
+
+type itemKey struct {
+	action string
+	key    string
+}
+
+// QueuedItem is an event waiting in the queue
+type QueuedItem struct {
+	Action string `json:"action"`
+	Key    string `json:"key"`
+	Count  int    `json:"count"`
+}
+
+// InFlightItem is an event a worker is processing
+type InFlightItem struct {
+	Action string    `json:"action"`
+	Key    string    `json:"key"`
+	Since  time.Time `json:"since"`
+}
+
+// ItemError is the last failure processing an object
+type ItemError struct {
+	Action  string    `json:"action"`
+	Error   string    `json:"error"`
+	Time    time.Time `json:"time"`
+	Retries int       `json:"retries"`
+}
+
+// TrackerStatus is what /debug shows of a controller
+type TrackerStatus struct {
+	Controller string               `json:"controller"`
+	Queued     []QueuedItem         `json:"queued"`
+	InFlight   []InFlightItem       `json:"inFlight"`
+	LastErrors map[string]ItemError `json:"lastErrors"`
+}
+
+// NewTracker creates a Tracker for the named controller
+func NewTracker(name string) *Tracker {
+	return &Tracker{
+		name:       name,
+		queued:     map[itemKey]int{},
+		inFlight:   map[int64]InFlightItem{},
+		lastErrors: map[string]ItemError{},
+	}
+}
+
+// Queued records an event for the object key put on the queue
+func (t *Tracker) Queued(action, key string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.queued[itemKey{action, key}]++
+}
+
+// Started records that a worker took an event off the queue and returns an
+// ID to pass to Done
+func (t *Tracker) Started(action, key string) int64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	k := itemKey{action, key}
+	if t.queued[k] <= 1 {
+		delete(t.queued, k)
+	} else {
+		t.queued[k]--
+	}
+	t.nextID++
+	t.inFlight[t.nextID] = InFlightItem{Action: action, Key: key, Since: time.Now()}
+	return t.nextID
+}
+
+// Done records the result of processing an event. A success clears the
+// last error of the object.
+func (t *Tracker) Done(id int64, err error, retries int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	item, ok := t.inFlight[id]
+	if !ok {
+		return
+	}
+	delete(t.inFlight, id)
+	if err == nil {
+		delete(t.lastErrors, item.Key)
+		return
+	}
+	t.lastErrors[item.Key] = ItemError{
+		Action:  item.Action,
+		Error:   err.Error(),
+		Time:    time.Now(),
+		Retries: retries,
+	}
+}
+
+// StuckCheck fails when a worker has been processing one event for longer
+// than timeout
+func (t *Tracker) StuckCheck(timeout time.Duration) Check {
+	return func() error {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		for _, item := range t.inFlight {
+			if d := time.Since(item.Since); d > timeout {
+				return fmt.Errorf("%s worker stuck on %s %s for %s", t.name, item.Action, item.Key, d.Round(time.Second))
+			}
+		}
+		return nil
+	}
+}
+
+// Status returns a snapshot of the tracked work items
+func (t *Tracker) Status() TrackerStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	status := TrackerStatus{
+		Controller: t.name,
+		Queued:     make([]QueuedItem, 0, len(t.queued)),
+		InFlight:   make([]InFlightItem, 0, len(t.inFlight)),
+		LastErrors: make(map[string]ItemError, len(t.lastErrors)),
+	}
+	for k, count := range t.queued {
+		status.Queued = append(status.Queued, QueuedItem{Action: k.action, Key: k.key, Count: count})
+	}
+	sort.Slice(status.Queued, func(i, j int) bool { return status.Queued[i].Key < status.Queued[j].Key })
+	for _, item := range t.inFlight {
+		status.InFlight = append(status.InFlight, item)
+	}
+	sort.Slice(status.InFlight, func(i, j int) bool { return status.InFlight[i].Since.Before(status.InFlight[j].Since) })
+	for k, e := range t.lastErrors {
+		status.LastErrors[k] = e
+	}
+	return status
+}
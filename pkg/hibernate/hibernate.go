@@ -0,0 +1,347 @@
+// Package hibernate detects idle namespaces and scales them down. A
+// hibernated namespace keeps its objects; the replica counts and CronJob
+// schedules it had are recorded in annotations, so waking it restores them.
+package hibernate
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Signals of activity in a namespace
+const (
+	// pods started, stopped or changed
+	SignalPods = "pods"
+	// Deployments created, rolled out or scaled
+	SignalDeployments = "deployments"
+	// Events recorded in the namespace, e.g. by kubectl or controllers
+	SignalEvents = "events"
+)
+
+// Signals are all known signals
+var Signals = []string{SignalPods, SignalDeployments, SignalEvents}
+
+const (
+	// ReplicasAnnotation on a Deployment or StatefulSet holds its replicas
+	// before it was hibernated
+	ReplicasAnnotation = "netsys.io/hibernated-replicas"
+	// SuspendAnnotation on a CronJob holds its spec.suspend before it was
+	// hibernated
+	SuspendAnnotation = "netsys.io/hibernated-suspend"
+
+	// HibernatedAnnotation on a Namespace is when it was hibernated
+	HibernatedAnnotation = "netsys.io/hibernated-at"
+	// HibernatedByAnnotation on a Namespace is who hibernated it: a user,
+	// ByIdle or BySchedule
+	HibernatedByAnnotation = "netsys.io/hibernated-by"
+	// WokenAnnotation on a Namespace is when it was last woken, which
+	// counts as activity
+	WokenAnnotation = "netsys.io/woken-at"
+	// IdleAnnotation on a Namespace is since when it is idle
+	IdleAnnotation = "netsys.io/idle-since"
+)
+
+// hibernated by the controllers
+const (
+	ByIdle     = "idle"
+	BySchedule = "schedule"
+)
+
+// ValidSignal returns true if s is a known signal
+func ValidSignal(s string) bool {
+	for _, known := range Signals {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Hibernated returns true if ns is hibernated
+func Hibernated(ns *core_v1.Namespace) bool {
+	_, ok := ns.Annotations[HibernatedAnnotation]
+	return ok
+}
+
+// HibernatedBy returns who hibernated ns
+func HibernatedBy(ns *core_v1.Namespace) string {
+	return ns.Annotations[HibernatedByAnnotation]
+}
+
+// LastActivity returns the time of the latest activity in ns seen by
+// signals. The creation and the last wake of the namespace count as
+// activity, so a new namespace is not idle.
+func LastActivity(client kubernetes.Interface, ns *core_v1.Namespace, signals []string) (time.Time, error) {
+	last := ns.CreationTimestamp.Time
+	see := func(t meta_v1.Time) {
+		if t.After(last) {
+			last = t.Time
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, ns.Annotations[WokenAnnotation]); err == nil && t.After(last) {
+		last = t
+	}
+
+	for _, signal := range signals {
+		switch signal {
+		case SignalPods:
+			pods, err := client.CoreV1().Pods(ns.Name).List(meta_v1.ListOptions{})
+			if err != nil {
+				return last, err
+			}
+			for _, p := range pods.Items {
+				see(p.CreationTimestamp)
+				for _, c := range p.Status.Conditions {
+					see(c.LastTransitionTime)
+				}
+				for _, cs := range p.Status.ContainerStatuses {
+					if cs.State.Running != nil {
+						see(cs.State.Running.StartedAt)
+					}
+					if cs.State.Terminated != nil {
+						see(cs.State.Terminated.FinishedAt)
+					}
+				}
+			}
+		case SignalDeployments:
+			deployments, err := client.AppsV1().Deployments(ns.Name).List(meta_v1.ListOptions{})
+			if err != nil {
+				return last, err
+			}
+			for _, d := range deployments.Items {
+				see(d.CreationTimestamp)
+				for _, c := range d.Status.Conditions {
+					see(c.LastUpdateTime)
+				}
+			}
+		case SignalEvents:
+			events, err := client.CoreV1().Events(ns.Name).List(meta_v1.ListOptions{})
+			if err != nil {
+				return last, err
+			}
+			for _, e := range events.Items {
+				see(e.LastTimestamp)
+				if e.EventTime.After(last) {
+					last = e.EventTime.Time
+				}
+			}
+		default:
+			return last, fmt.Errorf("unknown signal %q", signal)
+		}
+	}
+	return last, nil
+}
+
+// Result counts the objects hibernated or woken
+type Result struct {
+	Deployments  int `json:"deployments"`
+	StatefulSets int `json:"statefulSets"`
+	CronJobs     int `json:"cronJobs"`
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d Deployments, %d StatefulSets and %d CronJobs", r.Deployments, r.StatefulSets, r.CronJobs)
+}
+
+// Hibernate scales the Deployments and StatefulSets of namespace to zero
+// and suspends its CronJobs. Objects hibernated before are left alone, so
+// hibernating again after a failure picks up where it stopped. by is
+// recorded as who hibernated the namespace.
+func Hibernate(client kubernetes.Interface, namespace, by string, now time.Time) (Result, error) {
+	var r Result
+	deployments := client.AppsV1().Deployments(namespace)
+	dl, err := deployments.List(meta_v1.ListOptions{})
+	if err != nil {
+		return r, err
+	}
+	for i := range dl.Items {
+		d := &dl.Items[i]
+		if _, ok := d.Annotations[ReplicasAnnotation]; ok || replicas(d.Spec.Replicas) == 0 {
+			continue
+		}
+		setAnnotation(&d.ObjectMeta, ReplicasAnnotation, strconv.Itoa(int(replicas(d.Spec.Replicas))))
+		d.Spec.Replicas = new(int32)
+		if _, err := deployments.Update(d); err != nil {
+			return r, err
+		}
+		r.Deployments++
+	}
+
+	statefulSets := client.AppsV1().StatefulSets(namespace)
+	sl, err := statefulSets.List(meta_v1.ListOptions{})
+	if err != nil {
+		return r, err
+	}
+	for i := range sl.Items {
+		s := &sl.Items[i]
+		if _, ok := s.Annotations[ReplicasAnnotation]; ok || replicas(s.Spec.Replicas) == 0 {
+			continue
+		}
+		setAnnotation(&s.ObjectMeta, ReplicasAnnotation, strconv.Itoa(int(replicas(s.Spec.Replicas))))
+		s.Spec.Replicas = new(int32)
+		if _, err := statefulSets.Update(s); err != nil {
+			return r, err
+		}
+		r.StatefulSets++
+	}
+
+	cronJobs := client.BatchV1beta1().CronJobs(namespace)
+	cl, err := cronJobs.List(meta_v1.ListOptions{})
+	if err != nil {
+		return r, err
+	}
+	for i := range cl.Items {
+		c := &cl.Items[i]
+		suspended := c.Spec.Suspend != nil && *c.Spec.Suspend
+		if _, ok := c.Annotations[SuspendAnnotation]; ok || suspended {
+			continue
+		}
+		setAnnotation(&c.ObjectMeta, SuspendAnnotation, "false")
+		suspend := true
+		c.Spec.Suspend = &suspend
+		if _, err := cronJobs.Update(c); err != nil {
+			return r, err
+		}
+		r.CronJobs++
+	}
+
+	return r, annotateNamespace(client, namespace, func(ns *core_v1.Namespace) {
+		setAnnotation(&ns.ObjectMeta, HibernatedAnnotation, now.UTC().Format(time.RFC3339))
+		setAnnotation(&ns.ObjectMeta, HibernatedByAnnotation, by)
+		delete(ns.Annotations, IdleAnnotation)
+	})
+}
+
+// Wake restores the replicas and CronJobs recorded by Hibernate
+func Wake(client kubernetes.Interface, namespace string, now time.Time) (Result, error) {
+	var r Result
+	deployments := client.AppsV1().Deployments(namespace)
+	dl, err := deployments.List(meta_v1.ListOptions{})
+	if err != nil {
+		return r, err
+	}
+	for i := range dl.Items {
+		d := &dl.Items[i]
+		n, ok, err := recorded(d.Annotations)
+		if err != nil {
+			return r, fmt.Errorf("Deployment %s: %v", d.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		d.Spec.Replicas = &n
+		delete(d.Annotations, ReplicasAnnotation)
+		if _, err := deployments.Update(d); err != nil {
+			return r, err
+		}
+		r.Deployments++
+	}
+
+	statefulSets := client.AppsV1().StatefulSets(namespace)
+	sl, err := statefulSets.List(meta_v1.ListOptions{})
+	if err != nil {
+		return r, err
+	}
+	for i := range sl.Items {
+		s := &sl.Items[i]
+		n, ok, err := recorded(s.Annotations)
+		if err != nil {
+			return r, fmt.Errorf("StatefulSet %s: %v", s.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		s.Spec.Replicas = &n
+		delete(s.Annotations, ReplicasAnnotation)
+		if _, err := statefulSets.Update(s); err != nil {
+			return r, err
+		}
+		r.StatefulSets++
+	}
+
+	cronJobs := client.BatchV1beta1().CronJobs(namespace)
+	cl, err := cronJobs.List(meta_v1.ListOptions{})
+	if err != nil {
+		return r, err
+	}
+	for i := range cl.Items {
+		c := &cl.Items[i]
+		v, ok := c.Annotations[SuspendAnnotation]
+		if !ok {
+			continue
+		}
+		suspend := v == "true"
+		c.Spec.Suspend = &suspend
+		delete(c.Annotations, SuspendAnnotation)
+		if _, err := cronJobs.Update(c); err != nil {
+			return r, err
+		}
+		r.CronJobs++
+	}
+
+	return r, annotateNamespace(client, namespace, func(ns *core_v1.Namespace) {
+		delete(ns.Annotations, HibernatedAnnotation)
+		delete(ns.Annotations, HibernatedByAnnotation)
+		delete(ns.Annotations, IdleAnnotation)
+		setAnnotation(&ns.ObjectMeta, WokenAnnotation, now.UTC().Format(time.RFC3339))
+	})
+}
+
+// MarkIdle records since when namespace is idle, or clears it when since
+// is zero
+func MarkIdle(client kubernetes.Interface, namespace string, since time.Time) error {
+	return annotateNamespace(client, namespace, func(ns *core_v1.Namespace) {
+		if since.IsZero() {
+			delete(ns.Annotations, IdleAnnotation)
+			return
+		}
+		setAnnotation(&ns.ObjectMeta, IdleAnnotation, since.UTC().Format(time.RFC3339))
+	})
+}
+
+// replicas returns the replicas of a spec, which default to 1
+func replicas(n *int32) int32 {
+	if n == nil {
+		return 1
+	}
+	return *n
+}
+
+// recorded returns the replicas recorded by Hibernate
+func recorded(annotations map[string]string) (int32, bool, error) {
+	v, ok := annotations[ReplicasAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || n < 0 {
+		return 0, false, fmt.Errorf("invalid %s %q", ReplicasAnnotation, v)
+	}
+	return int32(n), true, nil
+}
+
+func setAnnotation(meta *meta_v1.ObjectMeta, key, value string) {
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[key] = value
+}
+
+func annotateNamespace(client kubernetes.Interface, namespace string, mutate func(ns *core_v1.Namespace)) error {
+	namespaces := client.CoreV1().Namespaces()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ns, err := namespaces.Get(namespace, meta_v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		mutate(ns)
+		_, err = namespaces.Update(ns)
+		return err
+	})
+}
@@ -0,0 +1,216 @@
+// Package invitation shares owned namespaces with NamespaceInvitations.
+// Accepting an invitation adds a grant that expires with the invitation to
+// the invitee's DispatchUser, which the DispatchUser controller provisions
+// like any other grant. Revoking it removes that grant again, and so does
+// withdrawing it once its owner lost the namespace. The self-service
+// server, dispatchctl and the invitation controller go through this
+// package.
+package invitation
+
+import (
+	"fmt"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client/clientset/versioned"
+)
+
+// Refused is returned when an invitation cannot be created, accepted or
+// revoked as asked, e.g. for a role above the owner's own
+type Refused struct {
+	msg string
+}
+
+func (r Refused) Error() string {
+	return r.msg
+}
+
+func refused(format string, args ...interface{}) error {
+	return Refused{msg: fmt.Sprintf(format, args...)}
+}
+
+// IsRefused returns true if err is a Refused
+func IsRefused(err error) bool {
+	_, ok := err.(Refused)
+	return ok
+}
+
+// Check returns why inviter cannot share the namespace of spec with the
+// invitee, or nil if they can. Owners share up to their own role and not
+// beyond the expiry of their own access.
+func Check(inviter *netsys_v1.DispatchUser, spec netsys_v1.NamespaceInvitationSpec, now time.Time) error {
+	inv := &netsys_v1.NamespaceInvitation{Spec: spec}
+	role, key := inv.GrantedRole(), spec.Namespace
+	if !netsys_v1.ValidRole(role) {
+		return refused("role %q must be view, edit or admin", role)
+	}
+	if spec.Invitee == "" || spec.Invitee == inviter.Spec.UserID {
+		return refused("invite a user other than yourself")
+	}
+	if !spec.ExpiresAt.After(now) {
+		return refused("the expiry must be in the future")
+	}
+	if inviter.Status.Expired || inviter.Spec.Suspended {
+		return refused("the access of %s is expired or suspended", inviter.Spec.UserID)
+	}
+	var held string
+	for _, g := range inviter.Spec.EffectiveGrants() {
+		if g.Key() == key {
+			held = netsys_v1.RoleOrDefault(g.Role)
+		}
+	}
+	if held == "" {
+		return refused("%s does not hold namespace %s", inviter.Spec.UserID, key)
+	}
+	if !netsys_v1.RoleIncludes(held, role) {
+		return refused("%s holds %s in namespace %s and cannot share %s", inviter.Spec.UserID, held, key, role)
+	}
+	ends := inviter.Status.ExpiresAt
+	for _, g := range inviter.Status.Grants {
+		if g.Namespace == key && g.ExpiresAt != nil && (ends == nil || g.ExpiresAt.Before(ends)) {
+			ends = g.ExpiresAt
+		}
+	}
+	if ends != nil && ends.Before(&spec.ExpiresAt) {
+		return refused("the access of %s to namespace %s ends at %s, the share cannot outlive it",
+			inviter.Spec.UserID, key, ends.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Accept grants the invitee of the pending invitation name its namespace
+// until the invitation expires. The owner's access is checked again, so an
+// invitation does not outlive the owner losing the namespace before it
+// was accepted.
+func Accept(client versioned.Interface, namespace, name string, now time.Time) (*netsys_v1.NamespaceInvitation, error) {
+	inv, err := client.NetsysV1().NamespaceInvitations(namespace).Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if phase := inv.PhaseAt(now); phase != netsys_v1.InvitationPending {
+		return nil, refused("invitation %s is %s", name, phase)
+	}
+	inviter, err := findUser(client, namespace, inv.Spec.InvitedBy)
+	if err != nil {
+		return nil, err
+	}
+	if inviter == nil {
+		return nil, refused("%s no longer exists", inv.Spec.InvitedBy)
+	}
+	if err := Check(inviter, inv.Spec, now); err != nil {
+		return nil, err
+	}
+
+	key := inv.Spec.Namespace
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		invitee, err := findUser(client, namespace, inv.Spec.Invitee)
+		if err != nil {
+			return err
+		}
+		if invitee == nil {
+			return refused("%s has no DispatchUser", inv.Spec.Invitee)
+		}
+		if granted(invitee, inv) {
+			// accepted before, but the invitation was not updated
+			return nil
+		}
+		if invitee.Spec.HasNamespace(key) {
+			return refused("%s already has access to namespace %s", inv.Spec.Invitee, key)
+		}
+		invitee.Spec.SetGrant(key, inv.GrantedRole())
+		expiresAt := inv.Spec.ExpiresAt
+		invitee.Spec.Grant(key).ExpiresAt = &expiresAt
+		invitee.SetReason(fmt.Sprintf("accepted invitation %s from %s", inv.Name, inv.Spec.InvitedBy))
+		_, err = client.NetsysV1().DispatchUsers(namespace).Update(invitee)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	acceptedAt := meta_v1.NewTime(now)
+	inv.Status.Phase = netsys_v1.InvitationAccepted
+	inv.Status.AcceptedAt = &acceptedAt
+	inv.Status.Message = ""
+	return client.NetsysV1().NamespaceInvitations(namespace).Update(inv)
+}
+
+// Revoke revokes the invitation name. If it was accepted, the grant it
+// added is removed from the invitee, unless it was changed since.
+func Revoke(client versioned.Interface, namespace, name, by string, now time.Time) (*netsys_v1.NamespaceInvitation, error) {
+	return revoke(client, namespace, name, by, fmt.Sprintf("invitation %s revoked by %s", name, by), "", now)
+}
+
+// Withdraw revokes the accepted invitation name on behalf of dispatch
+// because its owner no longer holds the namespace at the role shared, for
+// why, e.g. what Check returned
+func Withdraw(client versioned.Interface, namespace, name, why string, now time.Time) (*netsys_v1.NamespaceInvitation, error) {
+	return revoke(client, namespace, name, "dispatch", fmt.Sprintf("invitation %s withdrawn: %s", name, why), why, now)
+}
+
+// revoke revokes the invitation name, recording reason on the invitee and
+// message in the status of the invitation
+func revoke(client versioned.Interface, namespace, name, by, reason, message string, now time.Time) (*netsys_v1.NamespaceInvitation, error) {
+	inv, err := client.NetsysV1().NamespaceInvitations(namespace).Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status.Phase == netsys_v1.InvitationRevoked {
+		return nil, refused("invitation %s is already revoked", name)
+	}
+
+	if inv.Status.Phase == netsys_v1.InvitationAccepted {
+		key := inv.Spec.Namespace
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			invitee, err := findUser(client, namespace, inv.Spec.Invitee)
+			if err != nil || invitee == nil {
+				return err
+			}
+			if invitee.Spec.Grant(key) == nil {
+				return nil
+			}
+			if !granted(invitee, inv) {
+				message = fmt.Sprintf("the grant of %s in namespace %s was changed since and is kept", inv.Spec.Invitee, key)
+				return nil
+			}
+			invitee.Spec.RemoveNamespace(key)
+			invitee.SetReason(reason)
+			_, err = client.NetsysV1().DispatchUsers(namespace).Update(invitee)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	revokedAt := meta_v1.NewTime(now)
+	inv.Status.Phase = netsys_v1.InvitationRevoked
+	inv.Status.RevokedAt = &revokedAt
+	inv.Status.RevokedBy = by
+	inv.Status.Message = message
+	return client.NetsysV1().NamespaceInvitations(namespace).Update(inv)
+}
+
+// granted returns true if du holds the grant inv added when it was accepted
+func granted(du *netsys_v1.DispatchUser, inv *netsys_v1.NamespaceInvitation) bool {
+	g := du.Spec.Grant(inv.Spec.Namespace)
+	return g != nil && netsys_v1.RoleOrDefault(g.Role) == inv.GrantedRole() &&
+		g.ExpiresAt != nil && g.ExpiresAt.Equal(&inv.Spec.ExpiresAt)
+}
+
+// findUser returns the DispatchUser with userID, or nil if there is none
+func findUser(client versioned.Interface, namespace, userID string) (*netsys_v1.DispatchUser, error) {
+	list, err := client.NetsysV1().DispatchUsers(namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if list.Items[i].Spec.UserID == userID {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,305 @@
+// Package leaderelection makes sure only one replica of the dispatch
+// controllers reconciles at a time.
+//
+// The lease is a record kept in an annotation of a ConfigMap in the dispatch
+// namespace, the same scheme client-go used before the coordination API
+// existed. A candidate takes the lease once the current holder has not
+// renewed it for a lease duration, measured with the candidate's own clock,
+// so clocks of different replicas do not need to agree. client-go's own
+// leaderelection package is not part of the client-go version dispatch is
+// built against.
+package leaderelection
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// LeaderAnnotation holds the JSON encoded Record on the lock ConfigMap
+const LeaderAnnotation = "netsys.io/leader"
+
+// Record is the state of the lease
+type Record struct {
+	HolderIdentity       string       `json:"holderIdentity"`
+	LeaseDurationSeconds int          `json:"leaseDurationSeconds"`
+	AcquireTime          meta_v1.Time `json:"acquireTime"`
+	RenewTime            meta_v1.Time `json:"renewTime"`
+	LeaderTransitions    int          `json:"leaderTransitions"`
+}
+
+// Config configures a LeaderElector
+type Config struct {
+	Client kubernetes.Interface
+
+	// namespace and name of the lock ConfigMap
+	Namespace string
+	Name      string
+
+	// identity of this replica, usually the pod name
+	Identity string
+
+	// how long other replicas wait before taking over a lease that is not
+	// renewed
+	LeaseDuration time.Duration
+	// how long the leader keeps trying to renew before giving up
+	RenewDeadline time.Duration
+	// how often candidates try to acquire and the leader tries to renew
+	RetryPeriod time.Duration
+}
+
+// LeaderElector acquires and renews the lease
+type LeaderElector struct {
+	config Config
+
+	// last record seen on the lock and when it was first seen locally
+	observedRaw  string
+	observedTime time.Time
+
+	lock   sync.Mutex
+	leader bool
+	// last time the lock could be read or written
+	lastContact time.Time
+}
+
+// NewLeaderElector validates cfg and creates a LeaderElector
+func NewLeaderElector(cfg Config) (*LeaderElector, error) {
+	if cfg.Identity == "" {
+		return nil, fmt.Errorf("leader election identity must not be empty")
+	}
+	if cfg.Name == "" || cfg.Namespace == "" {
+		return nil, fmt.Errorf("leader election lock needs a namespace and name")
+	}
+	if cfg.RetryPeriod <= 0 {
+		return nil, fmt.Errorf("retry period must be positive")
+	}
+	if cfg.RenewDeadline <= cfg.RetryPeriod {
+		return nil, fmt.Errorf("renew deadline must be longer than the retry period")
+	}
+	if cfg.LeaseDuration <= cfg.RenewDeadline {
+		return nil, fmt.Errorf("lease duration must be longer than the renew deadline")
+	}
+	return &LeaderElector{config: cfg, lastContact: time.Now()}, nil
+}
+
+// IsLeader returns true while this replica holds the lease
+func (le *LeaderElector) IsLeader() bool {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	return le.leader
+}
+
+// Check fails when the lock has not been reachable for a lease duration, so
+// this replica can neither renew nor take over the lease
+func (le *LeaderElector) Check() error {
+	le.lock.Lock()
+	defer le.lock.Unlock()
+	if time.Since(le.lastContact) > le.config.LeaseDuration {
+		return fmt.Errorf("leader lease %s/%s unreachable since %s", le.config.Namespace, le.config.Name,
+			le.lastContact.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (le *LeaderElector) contact() {
+	le.lock.Lock()
+	le.lastContact = time.Now()
+	le.lock.Unlock()
+}
+
+func (le *LeaderElector) setLeader(leader bool) {
+	le.lock.Lock()
+	le.leader = leader
+	le.lock.Unlock()
+}
+
+// Run blocks until the lease is acquired, then calls run and keeps renewing
+// the lease. The channel passed to run is closed when leadership ends, and
+// run has to return once it is.
+//
+// When stopCh is closed the leader keeps renewing the lease until run
+// returned, then releases it so a standby can take over right away; Run
+// then returns nil. If the lease cannot be renewed within the renew
+// deadline Run waits for run to return and returns an error, since another
+// replica may already be reconciling. The lease is never released while run
+// is still active.
+func (le *LeaderElector) Run(stopCh <-chan struct{}, run func(stop <-chan struct{})) error {
+	if !le.acquire(stopCh) {
+		return nil
+	}
+	logging.Info("Acquired leader lease", "lock", le.config.Namespace+"/"+le.config.Name, "identity", le.config.Identity)
+	le.setLeader(true)
+	defer le.setLeader(false)
+
+	leaderStop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(leaderStop)
+	}()
+
+	ticker := time.NewTicker(le.config.RetryPeriod)
+	defer ticker.Stop()
+	lastRenew := time.Now()
+	stopping := stopCh
+	for {
+		select {
+		case <-stopping:
+			// stop the controllers, renewing until they returned
+			close(leaderStop)
+			stopping = nil
+		case <-done:
+			// run returned, on its own or once stopped
+			le.release()
+			return nil
+		case <-ticker.C:
+			if le.tryAcquireOrRenew() {
+				lastRenew = time.Now()
+				continue
+			}
+			if time.Since(lastRenew) > le.config.RenewDeadline {
+				if stopping != nil {
+					close(leaderStop)
+				}
+				le.setLeader(false)
+				logging.Error("Lost leader lease, waiting for controllers to stop", "lock", le.config.Namespace+"/"+le.config.Name)
+				<-done
+				return fmt.Errorf("failed to renew leader lease %s/%s", le.config.Namespace, le.config.Name)
+			}
+		}
+	}
+}
+
+// acquire retries until the lease is acquired or stopCh is closed
+func (le *LeaderElector) acquire(stopCh <-chan struct{}) bool {
+	logging.Info("Waiting for leader lease", "lock", le.config.Namespace+"/"+le.config.Name, "identity", le.config.Identity)
+	for {
+		if le.tryAcquireOrRenew() {
+			return true
+		}
+		select {
+		case <-stopCh:
+			return false
+		case <-time.After(le.config.RetryPeriod):
+		}
+	}
+}
+
+// tryAcquireOrRenew takes the lease if it is free or expired, or renews it
+// if this replica holds it
+func (le *LeaderElector) tryAcquireOrRenew() bool {
+	now := meta_v1.Now()
+	desired := Record{
+		HolderIdentity:       le.config.Identity,
+		LeaseDurationSeconds: int(le.config.LeaseDuration / time.Second),
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+
+	configMaps := le.config.Client.CoreV1().ConfigMaps(le.config.Namespace)
+	cm, err := configMaps.Get(le.config.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		raw, _ := json.Marshal(desired)
+		cm = &core_v1.ConfigMap{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:        le.config.Name,
+				Namespace:   le.config.Namespace,
+				Annotations: map[string]string{LeaderAnnotation: string(raw)},
+			},
+		}
+		if _, err := configMaps.Create(cm); err != nil {
+			logging.Error("Creating leader lease failed", "lock", le.config.Namespace+"/"+le.config.Name, "error", err)
+			return false
+		}
+		le.observe(string(raw))
+		le.contact()
+		return true
+	}
+	if err != nil {
+		logging.Error("Getting leader lease failed", "lock", le.config.Namespace+"/"+le.config.Name, "error", err)
+		return false
+	}
+	le.contact()
+
+	var current Record
+	raw := cm.Annotations[LeaderAnnotation]
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &current); err != nil {
+			logging.Warn("Ignoring unreadable leader lease", "lock", le.config.Namespace+"/"+le.config.Name, "error", err)
+			current = Record{}
+		}
+	}
+	if raw != le.observedRaw {
+		le.observe(raw)
+	}
+
+	leaseDuration := time.Duration(current.LeaseDurationSeconds) * time.Second
+	if current.HolderIdentity != "" && current.HolderIdentity != le.config.Identity &&
+		time.Now().Before(le.observedTime.Add(leaseDuration)) {
+		return false
+	}
+
+	if current.HolderIdentity == le.config.Identity {
+		desired.AcquireTime = current.AcquireTime
+		desired.LeaderTransitions = current.LeaderTransitions
+	} else {
+		desired.LeaderTransitions = current.LeaderTransitions + 1
+	}
+
+	b, _ := json.Marshal(desired)
+	cm = cm.DeepCopy()
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[LeaderAnnotation] = string(b)
+	// the update fails on a conflict if another replica got there first
+	if _, err := configMaps.Update(cm); err != nil {
+		logging.Debug("Updating leader lease failed", "lock", le.config.Namespace+"/"+le.config.Name, "error", err)
+		return false
+	}
+	le.observe(string(b))
+	return true
+}
+
+// release clears the holder so standbys do not wait for the lease to expire
+func (le *LeaderElector) release() {
+	configMaps := le.config.Client.CoreV1().ConfigMaps(le.config.Namespace)
+	cm, err := configMaps.Get(le.config.Name, meta_v1.GetOptions{})
+	if err != nil {
+		logging.Error("Releasing leader lease failed", "lock", le.config.Namespace+"/"+le.config.Name, "error", err)
+		return
+	}
+	var current Record
+	if err := json.Unmarshal([]byte(cm.Annotations[LeaderAnnotation]), &current); err != nil || current.HolderIdentity != le.config.Identity {
+		return
+	}
+
+	now := meta_v1.Now()
+	released := Record{
+		LeaseDurationSeconds: 1,
+		AcquireTime:          now,
+		RenewTime:            now,
+		LeaderTransitions:    current.LeaderTransitions,
+	}
+	b, _ := json.Marshal(released)
+	cm = cm.DeepCopy()
+	cm.Annotations[LeaderAnnotation] = string(b)
+	if _, err := configMaps.Update(cm); err != nil {
+		logging.Error("Releasing leader lease failed", "lock", le.config.Namespace+"/"+le.config.Name, "error", err)
+		return
+	}
+	logging.Info("Released leader lease", "lock", le.config.Namespace+"/"+le.config.Name)
+}
+
+func (le *LeaderElector) observe(raw string) {
+	le.observedRaw = raw
+	le.observedTime = time.Now()
+}
@@ -0,0 +1,188 @@
+// Package logging writes leveled, structured log lines. Every line carries
+// a message and key/value fields, either as logfmt text or as JSON:
+//
+//	time=2018-07-01T12:00:00Z level=info msg="Created RoleBinding" user=123456 namespace=dev reconcile=9f2c61aa
+package logging
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level orders log lines by severity
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+// output is shared by a logger and the loggers derived from it
+type output struct {
+	lock  sync.Mutex
+	w     io.Writer
+	level Level
+	json  bool
+}
+
+// Logger writes lines with a fixed set of fields
+type Logger struct {
+	out    *output
+	fields []interface{}
+}
+
+var std = &Logger{out: &output{w: os.Stderr, level: LevelInfo}}
+
+// Configure sets where and how the package level logger, and every logger
+// derived from it, writes. format is "text" or "json".
+func Configure(w io.Writer, level Level, format string) error {
+	var useJSON bool
+	switch format {
+	case "", "text":
+	case "json":
+		useJSON = true
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+	std.out.lock.Lock()
+	defer std.out.lock.Unlock()
+	std.out.w = w
+	std.out.level = level
+	std.out.json = useJSON
+	return nil
+}
+
+// With returns a logger that adds the key/value pairs kv to every line
+func With(kv ...interface{}) *Logger {
+	return std.With(kv...)
+}
+
+// With returns a logger that adds kv to the fields of l
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	return &Logger{out: l.out, fields: fields}
+}
+
+func (l *Logger) Debug(msg string, kv ...interface{}) { l.log(LevelDebug, msg, kv) }
+func (l *Logger) Info(msg string, kv ...interface{})  { l.log(LevelInfo, msg, kv) }
+func (l *Logger) Warn(msg string, kv ...interface{})  { l.log(LevelWarn, msg, kv) }
+func (l *Logger) Error(msg string, kv ...interface{}) { l.log(LevelError, msg, kv) }
+
+func Debug(msg string, kv ...interface{}) { std.log(LevelDebug, msg, kv) }
+func Info(msg string, kv ...interface{})  { std.log(LevelInfo, msg, kv) }
+func Warn(msg string, kv ...interface{})  { std.log(LevelWarn, msg, kv) }
+func Error(msg string, kv ...interface{}) { std.log(LevelError, msg, kv) }
+
+// NewID returns a short random ID to correlate the lines of one reconcile
+func NewID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "00000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+func (l *Logger) log(level Level, msg string, kv []interface{}) {
+	l.out.lock.Lock()
+	defer l.out.lock.Unlock()
+	if level < l.out.level {
+		return
+	}
+
+	fields := make([]interface{}, 0, 6+len(l.fields)+len(kv))
+	fields = append(fields, "time", time.Now().UTC().Format(time.RFC3339), "level", level.String(), "msg", msg)
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	if len(fields)%2 == 1 {
+		fields = append(fields, "(missing)")
+	}
+
+	var buf bytes.Buffer
+	if l.out.json {
+		writeJSON(&buf, fields)
+	} else {
+		writeText(&buf, fields)
+	}
+	buf.WriteByte('\n')
+	l.out.w.Write(buf.Bytes())
+}
+
+func writeText(buf *bytes.Buffer, fields []interface{}) {
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(fmt.Sprint(fields[i]))
+		buf.WriteByte('=')
+		v := valueString(fields[i+1])
+		if v == "" || strings.ContainsAny(v, " =\"\t\n") {
+			v = fmt.Sprintf("%q", v)
+		}
+		buf.WriteString(v)
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, fields []interface{}) {
+	buf.WriteByte('{')
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(fmt.Sprint(fields[i]))
+		buf.Write(k)
+		buf.WriteByte(':')
+		v := fields[i+1]
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			b, _ = json.Marshal(fmt.Sprint(v))
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte('}')
+}
+
+func valueString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
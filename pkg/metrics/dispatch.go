@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ReconcileTotal counts processed work items by controller and action
+	ReconcileTotal = NewCounterVec("dispatch_reconcile_total",
+		"Work items processed by a controller.", "controller", "action")
+
+	// ReconcileErrors counts work items that failed
+	ReconcileErrors = NewCounterVec("dispatch_reconcile_errors_total",
+		"Work items a controller failed to process.", "controller", "action")
+
+	// ReconcileDuration observes how long processing a work item took
+	ReconcileDuration = NewHistogramVec("dispatch_reconcile_duration_seconds",
+		"Time a controller spent processing a work item.", DefaultBuckets, "controller")
+
+	// WorkqueueRetries counts failed work items put back on the queue
+	WorkqueueRetries = NewCounterVec("dispatch_workqueue_retries_total",
+		"Failed work items queued again.", "controller")
+
+	// ProvisioningDuration observes the time from a DispatchUser change until
+	// the RoleBindings it asks for exist
+	ProvisioningDuration = NewHistogramVec("dispatch_provisioning_duration_seconds",
+		"Time from a DispatchUser change until its RoleBindings exist.", DefaultBuckets)
+
+	// NamespaceIdleSeconds is how long each granted namespace saw no
+	// activity, hibernated namespaces are left out
+	NamespaceIdleSeconds = NewGaugeVec("dispatch_namespace_idle_seconds",
+		"Time since the last activity in a granted namespace.", "namespace")
+)
+
+func init() {
+	Register(ReconcileTotal)
+	Register(ReconcileErrors)
+	Register(ReconcileDuration)
+	Register(WorkqueueRetries)
+	Register(ProvisioningDuration)
+	Register(NamespaceIdleSeconds)
+	Register(NewGaugeFunc("dispatch_workqueue_depth",
+		"Work items waiting in a controller queue.", []string{"controller"}, workqueueDepths))
+}
+
+var (
+	workqueueLock sync.Mutex
+	workqueues    = map[string]func() int{}
+)
+
+// RegisterWorkqueue reports the depth of a controller's queue
+func RegisterWorkqueue(controller string, depth func() int) {
+	workqueueLock.Lock()
+	workqueues[controller] = depth
+	workqueueLock.Unlock()
+}
+
+func workqueueDepths() []Sample {
+	workqueueLock.Lock()
+	defer workqueueLock.Unlock()
+	samples := make([]Sample, 0, len(workqueues))
+	for name, depth := range workqueues {
+		samples = append(samples, Sample{Labels: []string{name}, Value: float64(depth())})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Labels[0] < samples[j].Labels[0] })
+	return samples
+}
+
+// provisioning tracks DispatchUser changes whose RoleBindings are not
+// created yet, by user ID
+var provisioning = struct {
+	sync.Mutex
+	pending map[string]*pendingGrants
+}{pending: map[string]*pendingGrants{}}
+
+type pendingGrants struct {
+	since      time.Time
+	namespaces map[string]bool
+}
+
+// ProvisioningStarted records that a change of a DispatchUser seen at
+// since needs RoleBindings in namespaces
+func ProvisioningStarted(userID string, namespaces []string, since time.Time) {
+	if len(namespaces) == 0 {
+		return
+	}
+	provisioning.Lock()
+	defer provisioning.Unlock()
+	p, ok := provisioning.pending[userID]
+	if !ok {
+		// an earlier change still in flight keeps its start time
+		p = &pendingGrants{since: since, namespaces: map[string]bool{}}
+		provisioning.pending[userID] = p
+	}
+	for _, n := range namespaces {
+		p.namespaces[n] = true
+	}
+}
+
+// RoleBindingReady records that the RoleBinding of a user in a namespace
+// exists, and observes ProvisioningDuration once all are there
+func RoleBindingReady(userID, namespace string) {
+	provisioning.Lock()
+	defer provisioning.Unlock()
+	p, ok := provisioning.pending[userID]
+	if !ok {
+		return
+	}
+	delete(p.namespaces, namespace)
+	if len(p.namespaces) == 0 {
+		ProvisioningDuration.Observe(time.Since(p.since).Seconds())
+		delete(provisioning.pending, userID)
+	}
+}
+
+// ProvisioningCancelled forgets a user whose grants no longer need
+// RoleBindings, e.g. because the DispatchUser was deleted
+func ProvisioningCancelled(userID string, namespaces ...string) {
+	provisioning.Lock()
+	defer provisioning.Unlock()
+	p, ok := provisioning.pending[userID]
+	if !ok {
+		return
+	}
+	if len(namespaces) == 0 {
+		delete(provisioning.pending, userID)
+		return
+	}
+	for _, n := range namespaces {
+		delete(p.namespaces, n)
+	}
+	if len(p.namespaces) == 0 {
+		delete(provisioning.pending, userID)
+	}
+}
@@ -0,0 +1,305 @@
+// Package metrics exposes dispatch metrics in the Prometheus text format.
+//
+// It implements the few metric types dispatch needs rather than pulling in
+// the Prometheus client library: counters, gauges and histograms with
+// labels, plus collectors that compute their samples when scraped.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric is anything that can write itself in the text exposition format
+type Metric interface {
+	Name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds the metrics served by Handler
+type Registry struct {
+	lock    sync.Mutex
+	metrics map[string]Metric
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]Metric{}}
+}
+
+// DefaultRegistry holds the dispatch metrics
+var DefaultRegistry = NewRegistry()
+
+// Register adds m to the registry, replacing a metric of the same name
+func (r *Registry) Register(m Metric) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.metrics[m.Name()] = m
+}
+
+// Handler serves the registered metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.lock.Lock()
+		names := make([]string, 0, len(r.metrics))
+		for name := range r.metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		metrics := make([]Metric, len(names))
+		for i, name := range names {
+			metrics[i] = r.metrics[name]
+		}
+		r.lock.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		bw := bufio.NewWriter(w)
+		for _, m := range metrics {
+			m.write(bw)
+		}
+		bw.Flush()
+	})
+}
+
+// Register adds m to the DefaultRegistry
+func Register(m Metric) {
+	DefaultRegistry.Register(m)
+}
+
+// Handler serves the DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+// desc is shared by all metric types
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) Name() string { return d.name }
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, strings.Replace(d.help, "\n", " ", -1))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.typ)
+}
+
+// key joins label values so series can live in a map
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s has labels %v, got values %v", d.name, d.labels, values))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func (d *desc) labelValues(key string) []string {
+	if len(d.labels) == 0 {
+		return nil
+	}
+	return strings.Split(key, "\xff")
+}
+
+// formatLabels renders {a="1",b="2"}, extra is appended as is
+func formatLabels(names, values []string, extra string) string {
+	if len(names) == 0 && extra == "" {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, n := range names {
+		parts = append(parts, n+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extra != "" {
+		parts = append(parts, extra)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func escapeLabel(v string) string {
+	v = strings.Replace(v, `\`, `\\`, -1)
+	v = strings.Replace(v, `"`, `\"`, -1)
+	return strings.Replace(v, "\n", `\n`, -1)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// series is a set of labelled values, shared by counters and gauges
+type series struct {
+	desc
+	lock   sync.Mutex
+	values map[string]float64
+}
+
+func (s *series) add(v float64, labels []string) {
+	k := s.key(labels)
+	s.lock.Lock()
+	s.values[k] += v
+	s.lock.Unlock()
+}
+
+func (s *series) set(v float64, labels []string) {
+	k := s.key(labels)
+	s.lock.Lock()
+	s.values[k] = v
+	s.lock.Unlock()
+}
+
+func (s *series) write(w *bufio.Writer) {
+	s.writeHeader(w)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, k := range sortedKeys(s.values) {
+		fmt.Fprintf(w, "%s%s %s\n", s.name, formatLabels(s.labels, s.labelValues(k), ""), formatValue(s.values[k]))
+	}
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct{ series }
+
+// NewCounterVec creates a counter, label values are passed in the order of
+// labels
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{series{desc: desc{name, help, "counter", labels}, values: map[string]float64{}}}
+}
+
+// Inc adds one to the counter with the given label values
+func (c *CounterVec) Inc(labels ...string) {
+	c.add(1, labels)
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct{ series }
+
+// NewGaugeVec creates a gauge, label values are passed in the order of
+// labels
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{series{desc: desc{name, help, "gauge", labels}, values: map[string]float64{}}}
+}
+
+// Set sets the gauge with the given label values
+func (g *GaugeVec) Set(v float64, labels ...string) {
+	g.set(v, labels)
+}
+
+// Delete drops the gauge with the given label values
+func (g *GaugeVec) Delete(labels ...string) {
+	k := g.key(labels)
+	g.lock.Lock()
+	delete(g.series.values, k)
+	g.lock.Unlock()
+}
+
+// HistogramVec counts observations in buckets, partitioned by labels
+type HistogramVec struct {
+	desc
+	buckets []float64
+
+	lock   sync.Mutex
+	counts map[string][]uint64
+	sums   map[string]float64
+}
+
+// DefaultBuckets suit latencies from milliseconds to a minute
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// NewHistogramVec creates a histogram with the given upper bounds
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &HistogramVec{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: b,
+		counts:  map[string][]uint64{},
+		sums:    map[string]float64{},
+	}
+}
+
+// Observe records v for the given label values
+func (h *HistogramVec) Observe(v float64, labels ...string) {
+	k := h.key(labels)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	counts, ok := h.counts[k]
+	if !ok {
+		// one more for +Inf
+		counts = make([]uint64, len(h.buckets)+1)
+		h.counts[k] = counts
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			counts[i]++
+		}
+	}
+	counts[len(h.buckets)]++
+	h.sums[k] += v
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, k := range sortedKeys(h.sums) {
+		values := h.labelValues(k)
+		counts := h.counts[k]
+		for i, upper := range h.buckets {
+			le := `le="` + formatValue(upper) + `"`
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, le), counts[i])
+		}
+		total := counts[len(h.buckets)]
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, `le="+Inf"`), total)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, values, ""), formatValue(h.sums[k]))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, values, ""), total)
+	}
+}
+
+// Sample is one value of a collector, with label values in the order of the
+// collector's labels
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// Collector computes its samples when scraped, for values that are cheaper
+// to read from a cache than to keep up to date
+type Collector struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc creates a gauge whose samples are returned by collect
+func NewGaugeFunc(name, help string, labels []string, collect func() []Sample) *Collector {
+	return &Collector{desc: desc{name, help, "gauge", labels}, collect: collect}
+}
+
+func (c *Collector) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	for _, s := range c.collect() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, s.Labels, ""), formatValue(s.Value))
+	}
+}
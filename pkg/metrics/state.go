@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	core_lister "k8s.io/client-go/listers/core/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+)
+
+// RegisterStateMetrics registers gauges computed from the informer caches
+// of the dispatch namespace on every scrape
+func RegisterStateMetrics(
+	namespace string,
+	duLister netsys_lister.DispatchUserLister,
+	onLister netsys_lister.OwnedNamespaceLister,
+	saLister core_lister.ServiceAccountLister,
+) {
+	listUsers := func() []*netsys_v1.DispatchUser {
+		users, _ := duLister.DispatchUsers(namespace).List(labels.Everything())
+		return users
+	}
+	listOwned := func() []*netsys_v1.OwnedNamespace {
+		owned, _ := onLister.OwnedNamespaces(namespace).List(labels.Everything())
+		return owned
+	}
+
+	Register(NewGaugeFunc("dispatch_dispatchusers",
+		"DispatchUsers in the dispatch namespace.", nil,
+		func() []Sample {
+			return []Sample{{Value: float64(len(listUsers()))}}
+		}))
+
+	Register(NewGaugeFunc("dispatch_ownednamespaces",
+		"OwnedNamespaces in the dispatch namespace.", nil,
+		func() []Sample {
+			return []Sample{{Value: float64(len(listOwned()))}}
+		}))
+
+	Register(NewGaugeFunc("dispatch_managed_namespaces",
+		"Namespaces granted to at least one user.", nil,
+		func() []Sample {
+			namespaces := map[string]bool{}
+			for _, on := range listOwned() {
+				namespaces[on.Key()] = true
+			}
+			return []Sample{{Value: float64(len(namespaces))}}
+		}))
+
+	Register(NewGaugeFunc("dispatch_grants",
+		"Namespace grants by role.", []string{"role"},
+		func() []Sample {
+			counts := map[string]int{}
+			for _, role := range []string{netsys_v1.RoleView, netsys_v1.RoleEdit, netsys_v1.RoleAdmin} {
+				counts[role] = 0
+			}
+			for _, on := range listOwned() {
+				counts[netsys_v1.RoleOrDefault(on.Spec.Role)]++
+			}
+			samples := make([]Sample, 0, len(counts))
+			for _, role := range sortedRoles(counts) {
+				samples = append(samples, Sample{Labels: []string{role}, Value: float64(counts[role])})
+			}
+			return samples
+		}))
+
+	Register(NewGaugeFunc("dispatch_credential_age_seconds",
+		"Age of each user's ServiceAccount, whose token is the user's credential.", []string{"user"},
+		func() []Sample {
+			var samples []Sample
+			for _, du := range listUsers() {
+				sa, err := saLister.ServiceAccounts(namespace).Get(du.Spec.UserID)
+				if err != nil {
+					continue
+				}
+				age := time.Since(sa.CreationTimestamp.Time).Seconds()
+				samples = append(samples, Sample{Labels: []string{du.Spec.UserID}, Value: age})
+			}
+			sort.Slice(samples, func(i, j int) bool { return samples[i].Labels[0] < samples[j].Labels[0] })
+			return samples
+		}))
+}
+
+func sortedRoles(counts map[string]int) []string {
+	roles := make([]string, 0, len(counts))
+	for role := range counts {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,85 @@
+// Package notify tells users about changes to their access that they did
+// not make themselves, such as grants about to expire.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// Notification types
+const (
+	Expiring = "expiring"
+	Expired  = "expired"
+	// a leased namespace was taken back
+	Reclaimed = "reclaimed"
+	// an idle namespace was scaled down
+	Hibernated = "hibernated"
+)
+
+// Notification is sent to a user, Namespace is empty when it is about the
+// user's account itself
+type Notification struct {
+	Type      string    `json:"type"`
+	User      string    `json:"user"`
+	Namespace string    `json:"namespace,omitempty"`
+	Time      time.Time `json:"time"`
+	Message   string    `json:"message"`
+}
+
+// Notifier delivers notifications. Delivery is best effort, failures are
+// logged.
+type Notifier interface {
+	Notify(n Notification)
+}
+
+type discard struct{}
+
+func (discard) Notify(Notification) {}
+
+// Discard drops all notifications
+var Discard Notifier = discard{}
+
+// WebhookNotifier POSTs every notification as JSON, e.g. to a chat or mail
+// gateway
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Notify sends n in the background
+func (w *WebhookNotifier) Notify(n Notification) {
+	go func() {
+		if err := w.send(n); err != nil {
+			logging.Error("Sending notification failed", "user", n.User, "type", n.Type, "error", err)
+		}
+	}()
+}
+
+func (w *WebhookNotifier) send(n Notification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
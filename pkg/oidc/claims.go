@@ -0,0 +1,72 @@
+package oidc
+
+import (
+	"fmt"
+)
+
+// ClaimMapping selects which ID token claims identify a dispatch user
+type ClaimMapping struct {
+	// claim holding the stable user identifier, defaults to "sub"
+	UserIDClaim string
+	// claim holding the email address, defaults to "email"
+	EmailClaim string
+	// claim holding the list of groups, defaults to "groups"
+	GroupsClaim string
+	// prefix prepended to every group name, e.g. "oidc:"
+	GroupsPrefix string
+}
+
+// Identity is the dispatch view of an authenticated person
+type Identity struct {
+	UserID string   `json:"userID"`
+	Email  string   `json:"email,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// DefaultClaimMapping maps the standard OpenID Connect claims
+func DefaultClaimMapping() ClaimMapping {
+	return ClaimMapping{
+		UserIDClaim: "sub",
+		EmailClaim:  "email",
+		GroupsClaim: "groups",
+	}
+}
+
+// Map extracts an Identity from the claims of a verified token
+func (m ClaimMapping) Map(claims map[string]interface{}) (Identity, error) {
+	userIDClaim := m.UserIDClaim
+	if userIDClaim == "" {
+		userIDClaim = "sub"
+	}
+	emailClaim := m.EmailClaim
+	if emailClaim == "" {
+		emailClaim = "email"
+	}
+	groupsClaim := m.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
+	userID := stringClaim(claims, userIDClaim)
+	if userID == "" {
+		return Identity{}, fmt.Errorf("token has no %q claim", userIDClaim)
+	}
+
+	// refuse unverified addresses when the email itself is the user ID
+	if userIDClaim == "email" {
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return Identity{}, fmt.Errorf("email %q is not verified", userID)
+		}
+	}
+
+	var groups []string
+	for _, g := range stringsClaim(claims, groupsClaim) {
+		groups = append(groups, m.GroupsPrefix+g)
+	}
+
+	return Identity{
+		UserID: userID,
+		Email:  stringClaim(claims, emailClaim),
+		Groups: groups,
+	}, nil
+}
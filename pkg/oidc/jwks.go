@@ -0,0 +1,178 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval is the least time between two fetches of the key set.
+// Without it every token with a made-up key ID would cost a request to the
+// provider.
+const minRefreshInterval = 30 * time.Second
+
+// jsonWebKey is a single key of a JSON Web Key Set (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet caches the signing keys of a provider by key ID. Keys are
+// refetched when a token references a key ID that is not cached, which
+// is how providers announce key rotation, but at most every
+// minRefreshInterval.
+type keySet struct {
+	client *http.Client
+	url    string
+
+	mu   sync.RWMutex
+	keys map[string]crypto.PublicKey
+	// fetched is when the key set was last requested
+	fetched time.Time
+}
+
+func newKeySet(client *http.Client, url string) *keySet {
+	return &keySet{
+		client: client,
+		url:    url,
+		keys:   map[string]crypto.PublicKey{},
+	}
+}
+
+// get returns the key with the given ID. An empty kid matches the only
+// key in the set, if there is exactly one.
+func (ks *keySet) get(kid string) (crypto.PublicKey, error) {
+	if key, ok := ks.lookup(kid); ok {
+		return key, nil
+	}
+	if ks.mayRefresh(time.Now()) {
+		if err := ks.refresh(); err != nil {
+			return nil, err
+		}
+		if key, ok := ks.lookup(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("no signing key with id %q", kid)
+}
+
+// mayRefresh reports whether the key set may be fetched at now and, if so,
+// records the fetch. Failed fetches count too so that an unreachable
+// provider is not retried on every token.
+func (ks *keySet) mayRefresh(now time.Time) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if !ks.fetched.IsZero() && now.Sub(ks.fetched) < minRefreshInterval {
+		return false
+	}
+	ks.fetched = now
+	return true
+}
+
+func (ks *keySet) lookup(kid string) (crypto.PublicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *keySet) refresh() error {
+	resp, err := ks.client.Get(ks.url)
+	if err != nil {
+		return fmt.Errorf("fetching signing keys: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching signing keys: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding signing keys: %v", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// skip keys we do not understand instead of rejecting the whole set
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
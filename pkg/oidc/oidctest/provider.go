@@ -0,0 +1,230 @@
+// Package oidctest runs a local stand-in OpenID Connect identity provider.
+// It implements just enough of discovery, the authorization code flow and
+// JWKS publishing to exercise dispatch's login flow without a real provider.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const keyID = "oidctest"
+
+// Provider is a stand-in identity provider. Every authorization request is
+// approved immediately for the identity in Claims.
+type Provider struct {
+	Server   *httptest.Server
+	ClientID string
+	Key      *rsa.PrivateKey
+
+	mu sync.Mutex
+	// Claims are added to every ID token issued after they are set
+	claims map[string]interface{}
+	// codes maps issued authorization codes to the nonce of their request
+	codes map[string]string
+	// keyFetches counts the requests for the key set
+	keyFetches int
+}
+
+// NewProvider starts a provider that issues tokens for clientID
+func NewProvider(clientID string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
+		ClientID: clientID,
+		Key:      key,
+		claims:   map[string]interface{}{"sub": "oidctest-user"},
+		codes:    map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/keys", p.keys)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	return p, nil
+}
+
+// Issuer returns the issuer URL of the provider
+func (p *Provider) Issuer() string {
+	return p.Server.URL
+}
+
+// SetClaims replaces the claims of the identity that logs in
+func (p *Provider) SetClaims(claims map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claims = claims
+}
+
+// Close shuts the provider down
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// IDToken signs an ID token for the current claims
+func (p *Provider) IDToken(nonce string) (string, error) {
+	p.mu.Lock()
+	claims := map[string]interface{}{}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+	p.mu.Unlock()
+
+	now := time.Now()
+	claims["iss"] = p.Issuer()
+	claims["aud"] = p.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Hour).Unix()
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	return p.Sign(nil, claims)
+}
+
+// Sign signs claims as they are with the provider's key. Entries of header
+// replace the default "alg", "kid" and "typ" of the token header, which
+// lets tests forge tokens the provider would never issue.
+func (p *Provider) Sign(header map[string]string, claims map[string]interface{}) (string, error) {
+	h := map[string]string{"alg": "RS256", "kid": keyID, "typ": "JWT"}
+	for k, v := range header {
+		h[k] = v
+	}
+	encodedHeader, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.Key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// KeyFetches returns how often the key set was requested
+func (p *Provider) KeyFetches() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.keyFetches
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.Issuer(),
+		"authorization_endpoint":                p.Issuer() + "/authorize",
+		"token_endpoint":                        p.Issuer() + "/token",
+		"jwks_uri":                              p.Issuer() + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *Provider) keys(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.keyFetches++
+	p.mu.Unlock()
+
+	pub := p.Key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// authorize approves the request and redirects back with a fresh code
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != p.ClientID {
+		http.Error(w, "unknown client", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.String() == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	p.mu.Lock()
+	p.codes[code] = q.Get("nonce")
+	p.mu.Unlock()
+
+	rq := redirect.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirect.RawQuery = rq.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	code := r.PostForm.Get("code")
+
+	p.mu.Lock()
+	nonce, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := p.IDToken(nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// writeJSON writes v with the given status. The Content-Type is set first as
+// headers written after the status are dropped.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Printf("oidctest: error encoding response: %s\n", err)
+	}
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
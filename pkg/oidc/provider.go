@@ -0,0 +1,75 @@
+// Package oidc authenticates dispatch users against an OpenID Connect
+// identity provider using the authorization code flow.
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+// Provider holds the endpoints of an OpenID Connect identity provider
+// as published in its discovery document
+type Provider struct {
+	Issuer      string   `json:"issuer"`
+	AuthURL     string   `json:"authorization_endpoint"`
+	TokenURL    string   `json:"token_endpoint"`
+	JWKSURL     string   `json:"jwks_uri"`
+	UserInfoURL string   `json:"userinfo_endpoint"`
+	SigningAlgs []string `json:"id_token_signing_alg_values_supported"`
+
+	keys *keySet
+}
+
+// Discover fetches the discovery document of the issuer and returns a Provider
+// for it. The issuer in the document must match the one requested.
+func Discover(client *http.Client, issuer string) (*Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	wellKnown := strings.TrimSuffix(issuer, "/") + discoveryPath
+	resp, err := client.Get(wellKnown)
+	if err != nil {
+		return nil, fmt.Errorf("fetching discovery document: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching discovery document: %s", resp.Status)
+	}
+
+	p := &Provider{}
+	if err := json.NewDecoder(resp.Body).Decode(p); err != nil {
+		return nil, fmt.Errorf("decoding discovery document: %v", err)
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("issuer mismatch: expected %q got %q", issuer, p.Issuer)
+	}
+	if p.AuthURL == "" || p.TokenURL == "" || p.JWKSURL == "" {
+		return nil, fmt.Errorf("discovery document for %s is missing required endpoints", issuer)
+	}
+
+	p.keys = newKeySet(client, p.JWKSURL)
+	return p, nil
+}
+
+// Endpoint returns the OAuth2 endpoint of the provider
+func (p *Provider) Endpoint() oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:  p.AuthURL,
+		TokenURL: p.TokenURL,
+	}
+}
+
+// Verifier returns a Verifier that checks ID tokens issued for clientID
+func (p *Provider) Verifier(clientID string) *Verifier {
+	return &Verifier{
+		provider: p,
+		clientID: clientID,
+	}
+}
@@ -0,0 +1,202 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// allowed clock skew between dispatch and the identity provider
+const clockSkew = time.Minute
+
+// Verifier checks the signature and standard claims of ID tokens
+type Verifier struct {
+	provider *Provider
+	clientID string
+
+	// Now returns the current time, defaults to time.Now
+	Now func() time.Time
+}
+
+// IDToken is a verified ID token
+type IDToken struct {
+	Issuer   string
+	Subject  string
+	Audience []string
+	Expiry   time.Time
+	IssuedAt time.Time
+	Nonce    string
+
+	// Claims holds every claim of the token, including the standard ones
+	Claims map[string]interface{}
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify parses a raw ID token, checks its signature against the provider's
+// key set, and validates the issuer, audience and lifetime claims
+func (v *Verifier) Verify(rawIDToken string) (*IDToken, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token: expected 3 parts got %d", len(parts))
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %v", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %v", err)
+	}
+
+	claims := map[string]interface{}{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %v", err)
+	}
+
+	// check the issuer before looking up keys so that tokens of other
+	// issuers do not trigger a key set refresh
+	if iss := stringClaim(claims, "iss"); iss != v.provider.Issuer {
+		return nil, fmt.Errorf("token issued by %q, expected %q", iss, v.provider.Issuer)
+	}
+
+	key, err := v.provider.keys.get(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	token := &IDToken{
+		Issuer:   stringClaim(claims, "iss"),
+		Subject:  stringClaim(claims, "sub"),
+		Audience: stringsClaim(claims, "aud"),
+		Expiry:   timeClaim(claims, "exp"),
+		IssuedAt: timeClaim(claims, "iat"),
+		Nonce:    stringClaim(claims, "nonce"),
+		Claims:   claims,
+	}
+
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+
+	if !contains(token.Audience, v.clientID) {
+		return nil, fmt.Errorf("token audience %v does not contain %q", token.Audience, v.clientID)
+	}
+	if token.Expiry.IsZero() || now().Add(-clockSkew).After(token.Expiry) {
+		return nil, fmt.Errorf("token expired at %s", token.Expiry)
+	}
+	if nbf := timeClaim(claims, "nbf"); !nbf.IsZero() && now().Add(clockSkew).Before(nbf) {
+		return nil, fmt.Errorf("token not valid before %s", nbf)
+	}
+
+	return token, nil
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("signing algorithm %q does not match RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return fmt.Errorf("invalid token signature: %v", err)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("signing algorithm %q does not match EC key", alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid token signature length %d", len(signature))
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("invalid token signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+// stringsClaim reads a claim that may either be a single string or a list
+// of strings, as is the case for "aud" and most group claims
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func timeClaim(claims map[string]interface{}, name string) time.Time {
+	if f, ok := claims[name].(float64); ok {
+		return time.Unix(int64(f), 0)
+	}
+	return time.Time{}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
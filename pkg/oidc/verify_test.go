@@ -0,0 +1,178 @@
+package oidc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hantaowang/dispatch/pkg/oidc/oidctest"
+)
+
+const testClientID = "dispatch"
+
+// newTestVerifier starts an oidctest provider and discovers it
+func newTestVerifier(t *testing.T) (*oidctest.Provider, *Verifier) {
+	op, err := oidctest.NewProvider(testClientID)
+	if err != nil {
+		t.Fatalf("starting provider: %v", err)
+	}
+	t.Cleanup(op.Close)
+
+	provider, err := Discover(nil, op.Issuer())
+	if err != nil {
+		t.Fatalf("discovering provider: %v", err)
+	}
+	return op, provider.Verifier(testClientID)
+}
+
+// claims returns valid claims of a token issued by op
+func claims(op *oidctest.Provider) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":   op.Issuer(),
+		"aud":   testClientID,
+		"sub":   "alice",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": "n-0S6_WzA2Mj",
+	}
+}
+
+func TestVerify(t *testing.T) {
+	op, v := newTestVerifier(t)
+
+	raw, err := op.Sign(nil, claims(op))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := v.Verify(raw)
+	if err != nil {
+		t.Fatalf("verifying a valid token: %v", err)
+	}
+	if token.Subject != "alice" || token.Nonce != "n-0S6_WzA2Mj" || token.Issuer != op.Issuer() {
+		t.Errorf("unexpected token %+v", token)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	op, v := newTestVerifier(t)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		claims func(map[string]interface{})
+		err    string
+	}{{
+		name:   "wrong issuer",
+		claims: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		err:    "token issued by",
+	}, {
+		name:   "wrong audience",
+		claims: func(c map[string]interface{}) { c["aud"] = []string{"someone-else"} },
+		err:    "token audience",
+	}, {
+		name:   "missing audience",
+		claims: func(c map[string]interface{}) { delete(c, "aud") },
+		err:    "token audience",
+	}, {
+		name: "expired",
+		claims: func(c map[string]interface{}) {
+			c["exp"] = time.Now().Add(-clockSkew - time.Minute).Unix()
+		},
+		err: "token expired",
+	}, {
+		name:   "not yet valid",
+		claims: func(c map[string]interface{}) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		err:    "token not valid before",
+	}, {
+		name:   "algorithm of another key type",
+		header: map[string]string{"alg": "ES256"},
+		err:    "does not match RSA key",
+	}, {
+		name:   "unsupported algorithm",
+		header: map[string]string{"alg": "HS256"},
+		err:    "unsupported signing algorithm",
+	}, {
+		name:   "no algorithm",
+		header: map[string]string{"alg": "none"},
+		err:    "unsupported signing algorithm",
+	}, {
+		name:   "unknown key",
+		header: map[string]string{"kid": "rotated-away"},
+		err:    "no signing key",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := claims(op)
+			if test.claims != nil {
+				test.claims(c)
+			}
+			raw, err := op.Sign(test.header, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = v.Verify(raw)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyTamperedSignature(t *testing.T) {
+	op, v := newTestVerifier(t)
+
+	raw, err := op.Sign(nil, claims(op))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := op.Sign(nil, map[string]interface{}{"iss": op.Issuer(), "aud": testClientID, "sub": "mallory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, otherParts := strings.Split(raw, "."), strings.Split(other, ".")
+	forged := otherParts[0] + "." + otherParts[1] + "." + parts[2]
+
+	if _, err := v.Verify(forged); err == nil || !strings.Contains(err.Error(), "invalid token signature") {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+}
+
+func TestUnknownKeyRefetchInterval(t *testing.T) {
+	op, v := newTestVerifier(t)
+
+	raw, err := op.Sign(map[string]string{"kid": "unknown"}, claims(op))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := v.Verify(raw); err == nil {
+			t.Fatal("expected a token signed with an unknown key to be rejected")
+		}
+	}
+	if n := op.KeyFetches(); n != 1 {
+		t.Errorf("expected the key set to be fetched once, got %d", n)
+	}
+
+	// known keys are served from the cache meanwhile
+	valid, err := op.Sign(nil, claims(op))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(valid); err != nil {
+		t.Errorf("verifying a valid token: %v", err)
+	}
+
+	// once the interval passed the set is fetched again
+	ks := v.provider.keys
+	ks.mu.Lock()
+	ks.fetched = time.Now().Add(-minRefreshInterval)
+	ks.mu.Unlock()
+	if _, err := v.Verify(raw); err == nil {
+		t.Fatal("expected a token signed with an unknown key to be rejected")
+	}
+	if n := op.KeyFetches(); n != 2 {
+		t.Errorf("expected the key set to be fetched twice, got %d", n)
+	}
+}
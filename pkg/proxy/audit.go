@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// RequestRecord is one line of a user's request audit trail
+type RequestRecord struct {
+	Time      time.Time `json:"time"`
+	UserID    string    `json:"userID"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Query     string    `json:"query,omitempty"`
+	Status    int       `json:"status"`
+	Duration  string    `json:"duration"`
+	RemoteIP  string    `json:"remoteIP"`
+	UserAgent string    `json:"userAgent,omitempty"`
+}
+
+// AuditTrail records the requests each user sends through the proxy
+type AuditTrail interface {
+	Record(r RequestRecord)
+}
+
+// FileAuditTrail appends records as JSON lines to one file per user
+type FileAuditTrail struct {
+	dir string
+
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+// NewFileAuditTrail writes audit files into dir, creating it if needed
+func NewFileAuditTrail(dir string) (*FileAuditTrail, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileAuditTrail{
+		dir:   dir,
+		files: map[string]*os.File{},
+	}, nil
+}
+
+func (t *FileAuditTrail) Record(r RequestRecord) {
+	line, err := json.Marshal(r)
+	if err != nil {
+		logging.Error("Encoding audit record failed", "user", r.UserID, "error", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, ok := t.files[r.UserID]
+	if !ok {
+		// user IDs are valid ServiceAccount names, so they are safe file names
+		f, err = os.OpenFile(filepath.Join(t.dir, r.UserID+".log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			logging.Error("Opening audit trail failed", "user", r.UserID, "error", err)
+			return
+		}
+		t.files[r.UserID] = f
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		logging.Error("Writing audit trail failed", "user", r.UserID, "error", err)
+	}
+}
+
+// Close closes all open audit files
+func (t *FileAuditTrail) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for user, f := range t.files {
+		f.Close()
+		delete(t.files, user)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	auth_v1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// TokenAuthenticator resolves a bearer token to a dispatch user ID. It
+// returns ok == false if the token is not one it understands, so that the
+// next authenticator can be tried.
+type TokenAuthenticator interface {
+	AuthenticateToken(token string) (userID string, ok bool, err error)
+}
+
+// ServiceAccountTokenAuthenticator accepts the ServiceAccount tokens dispatch
+// issues to its users. Tokens are checked with a TokenReview and the result
+// is cached for a short time.
+type ServiceAccountTokenAuthenticator struct {
+	client    kubernetes.Interface
+	namespace string
+	cache     *cache.LRUExpireCache
+	ttl       time.Duration
+}
+
+// NewServiceAccountTokenAuthenticator accepts tokens of ServiceAccounts in
+// the given namespace
+func NewServiceAccountTokenAuthenticator(client kubernetes.Interface, namespace string) *ServiceAccountTokenAuthenticator {
+	return &ServiceAccountTokenAuthenticator{
+		client:    client,
+		namespace: namespace,
+		cache:     cache.NewLRUExpireCache(1024),
+		ttl:       30 * time.Second,
+	}
+}
+
+type reviewResult struct {
+	userID string
+	ok     bool
+}
+
+func (a *ServiceAccountTokenAuthenticator) AuthenticateToken(token string) (string, bool, error) {
+	if cached, found := a.cache.Get(token); found {
+		r := cached.(reviewResult)
+		return r.userID, r.ok, nil
+	}
+
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(&auth_v1.TokenReview{
+		Spec: auth_v1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("reviewing token: %v", err)
+	}
+
+	var r reviewResult
+	if review.Status.Authenticated {
+		prefix := serviceAccountPrefix + a.namespace + ":"
+		if name := review.Status.User.Username; strings.HasPrefix(name, prefix) {
+			r = reviewResult{userID: strings.TrimPrefix(name, prefix), ok: true}
+		}
+	}
+	a.cache.Add(token, r, a.ttl)
+	return r.userID, r.ok, nil
+}
+
+// OIDCTokenAuthenticator accepts ID tokens of the identity provider used by
+// the self-service server
+type OIDCTokenAuthenticator struct {
+	Verifier *oidc.Verifier
+	Mapping  oidc.ClaimMapping
+}
+
+func (a OIDCTokenAuthenticator) AuthenticateToken(token string) (string, bool, error) {
+	// ID tokens are JWTs, ServiceAccount tokens may be too, so a failed
+	// verification just means the token is not ours
+	if strings.Count(token, ".") != 2 {
+		return "", false, nil
+	}
+	idToken, err := a.Verifier.Verify(token)
+	if err != nil {
+		return "", false, nil
+	}
+	id, err := a.Mapping.Map(idToken.Claims)
+	if err != nil {
+		return "", false, err
+	}
+	return id.UserID, true, nil
+}
@@ -0,0 +1,255 @@
+// Package proxy implements an authenticating reverse proxy in front of the
+// Kubernetes API server. Requests are authenticated with dispatch-issued
+// tokens or ID tokens and forwarded with impersonation headers for the
+// user's ServiceAccount, so users never hold cluster credentials.
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// Proxy forwards authenticated requests to the API server
+type Proxy struct {
+	proxy          *httputil.ReverseProxy
+	authenticators []TokenAuthenticator
+	duLister       netsys_lister.DispatchUserNamespaceLister
+	namespace      string
+	audit          AuditTrail
+}
+
+// NewProxy creates a Proxy that talks to the API server described by config
+// using dispatch's own credentials. Users are looked up in the dispatch
+// namespace. Tokens are tried against authenticators in order. audit may
+// be nil.
+func NewProxy(
+	config *rest.Config,
+	namespace string,
+	duLister netsys_lister.DispatchUserLister,
+	audit AuditTrail,
+	authenticators ...TokenAuthenticator,
+) (*Proxy, error) {
+	host := config.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	target, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("parsing API server address: %v", err)
+	}
+
+	transport, err := upgradeCapableTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := &httputil.ReverseProxy{
+		Director: func(r *http.Request) {
+			r.URL.Scheme = target.Scheme
+			r.URL.Host = target.Host
+			r.Host = target.Host
+		},
+		Transport: transport,
+		// flush immediately so watches stream instead of being buffered
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logging.Error("Proxying request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	return &Proxy{
+		proxy:          rp,
+		authenticators: authenticators,
+		duLister:       duLister.DispatchUsers(namespace),
+		namespace:      namespace,
+		audit:          audit,
+	}, nil
+}
+
+// upgradeCapableTransport builds a transport limited to HTTP/1.1. The
+// connection upgrades used by exec, attach and port-forward are not possible
+// over HTTP/2.
+func upgradeCapableTransport(config *rest.Config) (http.RoundTripper, error) {
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		tlsConfig.NextProtos = []string{"http/1.1"}
+	}
+	base := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+	}
+	return rest.HTTPWrappersForConfig(config, base)
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	token := bearerToken(r)
+	if token == "" {
+		http.Error(w, "missing bearer token", http.StatusUnauthorized)
+		return
+	}
+	userID, err := p.authenticate(token)
+	if err != nil {
+		logging.Warn("Authenticating proxy request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		http.Error(w, "authentication failed", http.StatusUnauthorized)
+		return
+	}
+	du, err := p.dispatchUser(userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if du.Expired(start) {
+		http.Error(w, fmt.Sprintf("access of %s expired", userID), http.StatusForbidden)
+		return
+	}
+	if du.Spec.Suspended {
+		http.Error(w, fmt.Sprintf("access of %s is suspended", userID), http.StatusForbidden)
+		return
+	}
+
+	// never pass client supplied credentials or impersonation through
+	r.Header.Del("Authorization")
+	for h := range r.Header {
+		if strings.HasPrefix(h, "Impersonate-") {
+			r.Header.Del(h)
+		}
+	}
+	r.Header.Set("Impersonate-User", serviceAccountPrefix+p.namespace+":"+du.Spec.UserID)
+	for _, g := range impersonatedGroups(p.namespace, du) {
+		r.Header.Add("Impersonate-Group", g)
+	}
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	p.proxy.ServeHTTP(sw, r)
+
+	if p.audit != nil {
+		p.audit.Record(RequestRecord{
+			Time:      start,
+			UserID:    du.Spec.UserID,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Query:     r.URL.RawQuery,
+			Status:    sw.status,
+			Duration:  time.Since(start).String(),
+			RemoteIP:  remoteIP(r),
+			UserAgent: r.UserAgent(),
+		})
+	}
+}
+
+func (p *Proxy) authenticate(token string) (string, error) {
+	for _, a := range p.authenticators {
+		userID, ok, err := a.AuthenticateToken(token)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return userID, nil
+		}
+	}
+	return "", fmt.Errorf("token not recognized")
+}
+
+func (p *Proxy) dispatchUser(userID string) (*netsys_v1.DispatchUser, error) {
+	users, err := p.duLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, du := range users {
+		if du.Spec.UserID == userID {
+			return du, nil
+		}
+	}
+	return nil, fmt.Errorf("no DispatchUser for user %s", userID)
+}
+
+// impersonatedGroups are the groups of the user's ServiceAccount plus the
+// groups the user has at the identity provider. Groups starting with
+// system: are left out, an identity provider must not grant
+// system:masters.
+func impersonatedGroups(namespace string, du *netsys_v1.DispatchUser) []string {
+	groups := []string{
+		"system:serviceaccounts",
+		"system:serviceaccounts:" + namespace,
+		"system:authenticated",
+	}
+	for _, g := range du.Spec.Groups {
+		if !strings.HasPrefix(g, "system:") {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func bearerToken(r *http.Request) string {
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	parts := strings.SplitN(auth, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// statusWriter remembers the response status for the audit trail while
+// still supporting the flushing and hijacking that streaming needs
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	// the connection is only hijacked to complete a protocol upgrade
+	w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// a Monday
+	monday := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	friday := time.Date(2024, 1, 5, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		from time.Time
+		next time.Time
+	}{
+		{"0 9 * * *", monday, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", monday, time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", monday, time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"5/15 * * * *", monday, time.Date(2024, 1, 1, 10, 35, 0, 0, time.UTC)},
+		{"0 8-18/4 * * *", monday, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 9,17 * * *", monday, time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", friday, time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", friday, time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", monday, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", monday, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * *", monday, time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC)},
+		// a restricted day of month and day of week match either
+		{"0 0 13 * FRI", monday, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * FRI", friday, time.Date(2024, 1, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jun *", monday, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", monday, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", monday, time.Time{}},
+	}
+
+	for _, test := range tests {
+		c, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("parsing %q: %v", test.expr, err)
+			continue
+		}
+		if next := c.Next(test.from); !next.Equal(test.next) {
+			t.Errorf("%q after %s: expected %s, got %s", test.expr, test.from, test.next, next)
+		}
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	c, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := c.Next(time.Date(2024, 1, 1, 12, 0, 0, 0, loc))
+	if want := time.Date(2024, 1, 2, 9, 0, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("expected %s, got %s", want, next)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"* * * *", "must have 5 fields"},
+		{"* * * * * *", "must have 5 fields"},
+		{"60 * * * *", "not between 0 and 59"},
+		{"* 24 * * *", "not between 0 and 23"},
+		{"* * 0 * *", "not between 1 and 31"},
+		{"* * * 13 *", "not between 1 and 12"},
+		{"* * * * 8", "not between 0 and 7"},
+		{"* * * foo *", "not between 1 and 12"},
+		{"5-1 * * * *", "invalid range"},
+		{"*/0 * * * *", "invalid step"},
+		{"*/x * * * *", "invalid step"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.expr)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: expected error containing %q, got %v", test.expr, test.err, err)
+		}
+	}
+}
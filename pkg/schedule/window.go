@@ -0,0 +1,98 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+// MaxKeepAwake is the longest a namespace can be kept awake past its window
+const MaxKeepAwake = 24 * time.Hour
+
+// Window is a parsed AvailabilitySchedule
+type Window struct {
+	start, stop *Cron
+	loc         *time.Location
+}
+
+// NewWindow parses s
+func NewWindow(s netsys_v1.AvailabilitySchedule) (*Window, error) {
+	start, err := Parse(s.Start)
+	if err != nil {
+		return nil, fmt.Errorf("start: %v", err)
+	}
+	stop, err := Parse(s.Stop)
+	if err != nil {
+		return nil, fmt.Errorf("stop: %v", err)
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %v", s.TimeZone, err)
+	}
+	w := &Window{start: start, stop: stop, loc: loc}
+	if w.start.Next(time.Now().In(loc)).IsZero() || w.stop.Next(time.Now().In(loc)).IsZero() {
+		return nil, fmt.Errorf("the window never opens or never closes")
+	}
+	return w, nil
+}
+
+// Location returns the time zone of the window
+func (w *Window) Location() *time.Location {
+	return w.loc
+}
+
+// At returns whether the window is open at t and when it opens or closes
+// next. The window is open when it closes before it opens again.
+func (w *Window) At(t time.Time) (bool, time.Time) {
+	t = t.In(w.loc)
+	start, stop := w.start.Next(t), w.stop.Next(t)
+	if stop.Before(start) {
+		return true, stop
+	}
+	return false, start
+}
+
+// ParseUntil parses the end of a keep-awake override, either a time of day
+// like 22:00 in the window's time zone, meaning its next occurrence, or an
+// RFC 3339 time
+func (w *Window) ParseUntil(s string, now time.Time) (time.Time, error) {
+	var until time.Time
+	if clock, err := time.ParseInLocation("15:04", s, w.loc); err == nil {
+		local := now.In(w.loc)
+		until = time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, w.loc)
+		if !until.After(now) {
+			until = until.AddDate(0, 0, 1)
+		}
+	} else if until, err = time.Parse(time.RFC3339, s); err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use e.g. 22:00 or RFC 3339", s)
+	}
+	if !until.After(now) || until.After(now.Add(MaxKeepAwake)) {
+		return time.Time{}, fmt.Errorf("keep awake until must be within the next %s", MaxKeepAwake)
+	}
+	return until, nil
+}
+
+// KeepAwake keeps on available until the time given as for ParseUntil. The
+// availability controller wakes the namespace if needed.
+func KeepAwake(on *netsys_v1.OwnedNamespace, until string, now time.Time) (time.Time, error) {
+	if on.Spec.Schedule == nil {
+		return time.Time{}, fmt.Errorf("namespace %s has no schedule", on.Spec.Namespace)
+	}
+	w, err := NewWindow(*on.Spec.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := w.ParseUntil(until, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if on.Status.Schedule == nil {
+		on.Status.Schedule = &netsys_v1.ScheduleStatus{}
+	}
+	keep := meta_v1.NewTime(t.Truncate(time.Second))
+	on.Status.Schedule.KeepAwakeUntil = &keep
+	return t, nil
+}
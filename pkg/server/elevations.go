@@ -0,0 +1,197 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// elevationBody is the body of POST /api/v1/me/elevations
+type elevationBody struct {
+	Namespace string `json:"namespace"`
+	Role      string `json:"role"`
+	// Go duration, e.g. 1h
+	Duration string `json:"duration"`
+	Reason   string `json:"reason"`
+}
+
+// decisionBody is the body of POST /api/v1/elevations/approve and /deny
+type decisionBody struct {
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+}
+
+// myElevations lists the elevations of the logged in user on GET and asks
+// for a new one on POST
+func (s *Server) myElevations(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	elevations := s.clientsets.NetsysClient.NetsysV1().AccessElevations(s.namespace)
+	switch r.Method {
+	case http.MethodGet:
+		list, err := elevations.List(meta_v1.ListOptions{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		mine := []netsys_v1.AccessElevation{}
+		for _, e := range list.Items {
+			if e.Spec.UserID == id.UserID {
+				mine = append(mine, e)
+			}
+		}
+		writeJSON(w, http.StatusOK, mine)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "use GET or POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body elevationBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	d, err := time.ParseDuration(body.Duration)
+	if err != nil || d <= 0 {
+		http.Error(w, fmt.Sprintf("invalid duration %q", body.Duration), http.StatusBadRequest)
+		return
+	}
+	if body.Namespace == "" || !netsys_v1.ValidRole(body.Role) || body.Reason == "" {
+		http.Error(w, "namespace, a role of view, edit or admin and a reason are required", http.StatusBadRequest)
+		return
+	}
+	du, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if du == nil {
+		http.Error(w, "no DispatchUser for this session, log in again", http.StatusNotFound)
+		return
+	}
+
+	// the controller checks the elevation against the policy
+	e, err := elevations.Create(&netsys_v1.AccessElevation{
+		ObjectMeta: meta_v1.ObjectMeta{
+			GenerateName: id.UserID + "-",
+			Namespace:    s.namespace,
+		},
+		Spec: netsys_v1.AccessElevationSpec{
+			UserID:    id.UserID,
+			Namespace: body.Namespace,
+			Role:      body.Role,
+			Duration:  meta_v1.Duration{Duration: d},
+			Reason:    body.Reason,
+		},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logging.Info("Requested elevation", "user", id.UserID, "elevation", e.Name, "namespace", body.Namespace, "role", body.Role, "duration", d)
+	writeJSON(w, http.StatusCreated, e)
+}
+
+// elevations lists the pending elevations the logged in user may approve
+func (s *Server) elevations(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	list, err := s.clientsets.NetsysClient.NetsysV1().AccessElevations(s.namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	reviewer, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pending := []netsys_v1.AccessElevation{}
+	for i := range list.Items {
+		e := &list.Items[i]
+		if e.Status.Phase == netsys_v1.ElevationPending && s.mayApprove(e, id, reviewer) {
+			pending = append(pending, *e)
+		}
+	}
+	writeJSON(w, http.StatusOK, pending)
+}
+
+// decideElevation approves or denies a pending elevation, depending on
+// the path it was posted to
+func (s *Server) decideElevation(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var body decisionBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	reviewer, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	action := path.Base(r.URL.Path)
+
+	elevations := s.clientsets.NetsysClient.NetsysV1().AccessElevations(s.namespace)
+	status := http.StatusOK
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		e, err := elevations.Get(body.Name, meta_v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			status = http.StatusNotFound
+			return err
+		}
+		if err != nil {
+			status = http.StatusInternalServerError
+			return err
+		}
+		if !s.mayApprove(e, id, reviewer) {
+			status = http.StatusForbidden
+			return fmt.Errorf("you may not decide on elevation %s", body.Name)
+		}
+		if action == "approve" {
+			err = e.Approve(id.UserID, meta_v1.Now())
+		} else {
+			err = e.Deny(id.UserID, body.Message, meta_v1.Now())
+		}
+		if err != nil {
+			status = http.StatusConflict
+			return err
+		}
+		status = http.StatusInternalServerError
+		_, err = elevations.UpdateStatus(e)
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	logging.Info("Decided elevation", "user", id.UserID, "elevation", body.Name, "decision", action)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mayApprove returns true if id, whose DispatchUser is reviewer, may
+// approve or deny e
+func (s *Server) mayApprove(e *netsys_v1.AccessElevation, id oidc.Identity, reviewer *netsys_v1.DispatchUser) bool {
+	var owned *netsys_v1.OwnedNamespace
+	if reviewer != nil {
+		// without it a grant with a lease makes nobody an admin
+		on, err := s.clientsets.NetsysClient.NetsysV1().OwnedNamespaces(s.namespace).
+			Get(controller.OwnedNamespaceName(reviewer.Spec.UserID, e.Spec.Namespace), meta_v1.GetOptions{})
+		if err == nil {
+			owned = on
+		}
+	}
+	return e.MayDecide(id.UserID, id.Groups, reviewer, owned, s.defaultRole, time.Now())
+}
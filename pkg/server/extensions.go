@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// extensionBody is the body of POST /api/v1/me/extensions
+type extensionBody struct {
+	// grant to extend, empty for the account itself
+	Namespace string    `json:"namespace,omitempty"`
+	Until     time.Time `json:"until"`
+	Reason    string    `json:"reason"`
+}
+
+// requestExtension asks an administrator to move the expiry of the logged
+// in user or of one of its grants. A new request replaces an older one for
+// the same grant.
+func (s *Server) requestExtension(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var body extensionBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !body.Until.After(time.Now()) {
+		http.Error(w, "until must be in the future", http.StatusBadRequest)
+		return
+	}
+	if body.Reason == "" {
+		http.Error(w, "a reason is required", http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var request netsys_v1.ExtensionRequest
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		du, err := s.getDispatchUser(id.UserID)
+		if err != nil {
+			return err
+		}
+		if du == nil {
+			status = http.StatusNotFound
+			return fmt.Errorf("no DispatchUser for this session, log in again")
+		}
+		if body.Namespace != "" && !du.Spec.HasNamespace(body.Namespace) {
+			status = http.StatusBadRequest
+			return fmt.Errorf("you have no grant in namespace %s", body.Namespace)
+		}
+		du = du.DeepCopy()
+		request = netsys_v1.ExtensionRequest{
+			Namespace:   body.Namespace,
+			Until:       meta_v1.NewTime(body.Until),
+			Reason:      body.Reason,
+			RequestedAt: meta_v1.Now(),
+		}
+		// kept in an annotation, status is written by the controller only
+		du.AddExtensionRequest(request)
+		_, err = s.clientsets.NetsysClient.NetsysV1().DispatchUsers(s.namespace).Update(du)
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	logging.Info("Requested extension", "user", id.UserID, "until", body.Until.Format(time.RFC3339))
+	writeJSON(w, http.StatusCreated, request)
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hantaowang/dispatch/pkg/hibernate"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// wakeBody is the body of POST /api/v1/me/wake
+type wakeBody struct {
+	Namespace string `json:"namespace"`
+}
+
+// wake restores a hibernated namespace the logged in user holds
+func (s *Server) wake(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var body wakeBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Namespace == "" {
+		http.Error(w, "invalid request: a namespace is required", http.StatusBadRequest)
+		return
+	}
+	du, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if du == nil || !du.Spec.HasNamespace(body.Namespace) {
+		http.Error(w, fmt.Sprintf("you have no grant in namespace %s", body.Namespace), http.StatusForbidden)
+		return
+	}
+	result, err := hibernate.Wake(s.clientsets.OriginalClient, body.Namespace, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logging.Info("Woke namespace", "user", id.UserID, "namespace", body.Namespace, "restored", result)
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/invitation"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// invitationBody is the body of POST /api/v1/me/invitations
+type invitationBody struct {
+	Namespace string    `json:"namespace"`
+	Invitee   string    `json:"invitee"`
+	Role      string    `json:"role,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// invitationNameBody is the body of POST /api/v1/me/invitations/accept and
+// /api/v1/me/invitations/revoke
+type invitationNameBody struct {
+	Name string `json:"name"`
+}
+
+// myInvitations lists the invitations the logged in user sent or received
+// on GET and shares one of its namespaces on POST
+func (s *Server) myInvitations(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	invitations := s.clientsets.NetsysClient.NetsysV1().NamespaceInvitations(s.namespace)
+	switch r.Method {
+	case http.MethodGet:
+		list, err := invitations.List(meta_v1.ListOptions{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		mine := []netsys_v1.NamespaceInvitation{}
+		now := time.Now()
+		for _, inv := range list.Items {
+			if inv.Spec.InvitedBy == id.UserID || inv.Spec.Invitee == id.UserID {
+				inv.Status.Phase = inv.PhaseAt(now)
+				mine = append(mine, inv)
+			}
+		}
+		writeJSON(w, http.StatusOK, mine)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "use GET or POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body invitationBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if body.Namespace == "" || body.Invitee == "" {
+		http.Error(w, "namespace and invitee are required", http.StatusBadRequest)
+		return
+	}
+	du, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if du == nil {
+		http.Error(w, "no DispatchUser for this session, log in again", http.StatusNotFound)
+		return
+	}
+	invitee, err := s.findDispatchUser(body.Invitee)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if invitee == nil {
+		http.Error(w, fmt.Sprintf("no DispatchUser for %s", body.Invitee), http.StatusNotFound)
+		return
+	}
+	spec := netsys_v1.NamespaceInvitationSpec{
+		Namespace: body.Namespace,
+		Invitee:   body.Invitee,
+		Role:      body.Role,
+		ExpiresAt: meta_v1.NewTime(body.ExpiresAt),
+		InvitedBy: id.UserID,
+	}
+	if err := invitation.Check(du, spec, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	inv, err := invitations.Create(&netsys_v1.NamespaceInvitation{
+		ObjectMeta: meta_v1.ObjectMeta{GenerateName: id.UserID + "-", Namespace: s.namespace},
+		Spec:       spec,
+		Status:     netsys_v1.NamespaceInvitationStatus{Phase: netsys_v1.InvitationPending},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logging.Info("Invited user", "user", id.UserID, "invitation", inv.Name, "invitee", body.Invitee,
+		"namespace", body.Namespace, "role", inv.GrantedRole(), "expiresAt", body.ExpiresAt.Format(time.RFC3339))
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+// acceptInvitation accepts an invitation sent to the logged in user, which
+// grants it the namespace shared
+func (s *Server) acceptInvitation(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	inv, ok := s.invitationOf(w, r, func(inv *netsys_v1.NamespaceInvitation) bool {
+		return inv.Spec.Invitee == id.UserID
+	})
+	if !ok {
+		return
+	}
+	inv, err := invitation.Accept(s.clientsets.NetsysClient, s.namespace, inv.Name, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), invitationStatus(err))
+		return
+	}
+	logging.Info("Accepted invitation", "user", id.UserID, "invitation", inv.Name, "namespace", inv.Spec.Namespace)
+	writeJSON(w, http.StatusOK, inv)
+}
+
+// revokeInvitation revokes an invitation the logged in user sent, which
+// takes the namespace from the invitee if it was accepted
+func (s *Server) revokeInvitation(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	inv, ok := s.invitationOf(w, r, func(inv *netsys_v1.NamespaceInvitation) bool {
+		return inv.Spec.InvitedBy == id.UserID
+	})
+	if !ok {
+		return
+	}
+	inv, err := invitation.Revoke(s.clientsets.NetsysClient, s.namespace, inv.Name, id.UserID, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), invitationStatus(err))
+		return
+	}
+	logging.Info("Revoked invitation", "user", id.UserID, "invitation", inv.Name, "namespace", inv.Spec.Namespace)
+	writeJSON(w, http.StatusOK, inv)
+}
+
+// invitationOf returns the invitation named in the body of r if mayAct
+// allows the logged in user to act on it, or writes why not
+func (s *Server) invitationOf(w http.ResponseWriter, r *http.Request,
+	mayAct func(*netsys_v1.NamespaceInvitation) bool) (*netsys_v1.NamespaceInvitation, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	var body invitationNameBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		http.Error(w, "invalid request: a name is required", http.StatusBadRequest)
+		return nil, false
+	}
+	inv, err := s.clientsets.NetsysClient.NetsysV1().NamespaceInvitations(s.namespace).Get(body.Name, meta_v1.GetOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), invitationStatus(err))
+		return nil, false
+	}
+	if !mayAct(inv) {
+		// do not tell others which invitations exist
+		http.Error(w, fmt.Sprintf("no invitation %s", body.Name), http.StatusNotFound)
+		return nil, false
+	}
+	return inv, true
+}
+
+// invitationStatus returns the HTTP status for an error of the invitation
+// package
+func invitationStatus(err error) int {
+	switch {
+	case invitation.IsRefused(err):
+		return http.StatusConflict
+	case errors.IsNotFound(err):
+		return http.StatusNotFound
+	case errors.IsConflict(err):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// renewBody is the body of POST /api/v1/me/leases/renew
+type renewBody struct {
+	Namespace string `json:"namespace"`
+}
+
+// myLeases lists the leased namespaces of the logged in user
+func (s *Server) myLeases(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	list, err := s.clientsets.NetsysClient.NetsysV1().OwnedNamespaces(s.namespace).List(meta_v1.ListOptions{
+		LabelSelector: "ownerID=" + id.UserID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	leased := []netsys_v1.OwnedNamespace{}
+	for _, on := range list.Items {
+		if on.Spec.Lease != nil {
+			leased = append(leased, on)
+		}
+	}
+	writeJSON(w, http.StatusOK, leased)
+}
+
+// renewLease renews the lease of one of the logged in user's namespaces,
+// which restores access if the lease expired but was not reclaimed yet
+func (s *Server) renewLease(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var body renewBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Namespace == "" {
+		http.Error(w, "invalid request: a namespace is required", http.StatusBadRequest)
+		return
+	}
+
+	owned := s.clientsets.NetsysClient.NetsysV1().OwnedNamespaces(s.namespace)
+	status := http.StatusInternalServerError
+	var on *netsys_v1.OwnedNamespace
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		on, err = owned.Get(controller.OwnedNamespaceName(id.UserID, body.Namespace), meta_v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			status = http.StatusNotFound
+			return fmt.Errorf("you have no grant in namespace %s", body.Namespace)
+		}
+		if err != nil {
+			return err
+		}
+		if err := on.RenewLease(time.Now()); err != nil {
+			status = http.StatusConflict
+			return err
+		}
+		on, err = owned.Update(on)
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	logging.Info("Renewed lease", "user", id.UserID, "namespace", body.Namespace,
+		"expiresAt", on.Status.Lease.ExpiresAt.UTC().Format(time.RFC3339))
+	writeJSON(w, http.StatusOK, on)
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// pendingGrant is a grant the logged in user may confirm
+type pendingGrant struct {
+	Campaign  string    `json:"campaign"`
+	Deadline  time.Time `json:"deadline"`
+	UserID    string    `json:"userID"`
+	Namespace string    `json:"namespace"`
+	Role      string    `json:"role"`
+}
+
+// confirmRequest is the body of POST /api/v1/recertifications/confirm
+type confirmRequest struct {
+	Campaign  string `json:"campaign"`
+	UserID    string `json:"userID"`
+	Namespace string `json:"namespace"`
+}
+
+// recertifications lists the pending grants of active campaigns the logged
+// in user may confirm
+func (s *Server) recertifications(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	list, err := s.clientsets.NetsysClient.NetsysV1().RecertificationCampaigns(s.namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	reviewer, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	pending := []pendingGrant{}
+	for i := range list.Items {
+		campaign := &list.Items[i]
+		if campaign.Status.Completed {
+			continue
+		}
+		for _, item := range campaign.Status.Items {
+			if item.State == netsys_v1.RecertificationPending && mayConfirm(campaign, item, id, reviewer) {
+				pending = append(pending, pendingGrant{
+					Campaign:  campaign.Name,
+					Deadline:  campaign.Spec.Deadline.Time,
+					UserID:    item.UserID,
+					Namespace: item.Namespace,
+					Role:      item.Role,
+				})
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, pending)
+}
+
+// confirmRecertification confirms a grant in a campaign
+func (s *Server) confirmRecertification(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var req confirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	reviewer, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	campaigns := s.clientsets.NetsysClient.NetsysV1().RecertificationCampaigns(s.namespace)
+	status := http.StatusOK
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		campaign, err := campaigns.Get(req.Campaign, meta_v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			status = http.StatusNotFound
+			return err
+		}
+		if err != nil {
+			status = http.StatusInternalServerError
+			return err
+		}
+		item := campaign.Item(req.UserID, req.Namespace)
+		if item == nil || !mayConfirm(campaign, *item, id, reviewer) {
+			status = http.StatusForbidden
+			return fmt.Errorf("you may not confirm the grant of %s in %s", req.UserID, req.Namespace)
+		}
+		if err := campaign.Confirm(req.UserID, req.Namespace, id.UserID, meta_v1.Now()); err != nil {
+			status = http.StatusConflict
+			return err
+		}
+		status = http.StatusInternalServerError
+		_, err = campaigns.Update(campaign)
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	logging.Info("Confirmed grant", "user", id.UserID, "grantee", req.UserID, "namespace", req.Namespace, "campaign", req.Campaign)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mayConfirm returns true if id may confirm item. Approvers may confirm any
+// grant, owners with the admin role in a namespace the grants of others in
+// that namespace. Nobody confirms their own grants.
+func mayConfirm(campaign *netsys_v1.RecertificationCampaign, item netsys_v1.RecertificationItem, id oidc.Identity, reviewer *netsys_v1.DispatchUser) bool {
+	if item.UserID == id.UserID {
+		return false
+	}
+	if campaign.IsApprover(id.UserID, id.Groups) {
+		return true
+	}
+	return isNamespaceAdmin(reviewer, item.Namespace)
+}
+
+// isNamespaceAdmin returns true if u holds the admin role in namespace
+func isNamespaceAdmin(u *netsys_v1.DispatchUser, namespace string) bool {
+	if u == nil {
+		return false
+	}
+	for _, g := range u.Spec.EffectiveGrants() {
+		if g.Key() == namespace && netsys_v1.RoleOrDefault(g.Role) == netsys_v1.RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hantaowang/dispatch/pkg/chargeback"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// reports serves the cost report of everyone to report viewers. The query
+// takes from, to, by (user, group or namespace) and format (json or csv).
+func (s *Server) reports(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if !s.isReportViewer(id) {
+		http.Error(w, "only report viewers may read the reports of everyone", http.StatusForbidden)
+		return
+	}
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = chargeback.ByUser
+	}
+	s.writeReport(w, r, by, nil)
+}
+
+// myReport serves the cost of the logged in user's namespaces
+func (s *Server) myReport(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	s.writeReport(w, r, chargeback.ByNamespace, func(rec chargeback.Record) bool {
+		return rec.User == id.UserID
+	})
+}
+
+// writeReport generates the report of the query's date range
+func (s *Server) writeReport(w http.ResponseWriter, r *http.Request, by string, keep func(chargeback.Record) bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+	from, to, err := chargeback.ParseRange(q.Get("from"), q.Get("to"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	store := chargeback.ConfigMapStore{Client: s.clientsets.OriginalClient, Namespace: s.namespace}
+	days, err := store.Load(from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	report, err := chargeback.Generate(days, from, to, by, keep)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition",
+			"attachment; filename=\"dispatch-"+by+"-"+report.From+"-"+report.To+".csv\"")
+		report.WriteCSV(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// isReportViewer returns true if id may read the reports of everyone
+func (s *Server) isReportViewer(id oidc.Identity) bool {
+	for _, v := range s.reportViewers {
+		if v == id.UserID {
+			return true
+		}
+	}
+	for _, g := range s.reportViewerGroups {
+		for _, group := range id.Groups {
+			if g == group {
+				return true
+			}
+		}
+	}
+	return false
+}
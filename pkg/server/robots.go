@@ -0,0 +1,185 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// robotBody is the body of POST /api/v1/me/robots
+type robotBody struct {
+	Name string `json:"name"`
+	// group that owns the robot instead of the logged in user
+	OwnerGroup string                 `json:"ownerGroup,omitempty"`
+	Grants     []netsys_v1.RobotGrant `json:"grants"`
+	TokenTTL   *meta_v1.Duration      `json:"tokenTTL,omitempty"`
+}
+
+// robotNameBody is the body of POST /api/v1/me/robots/token and
+// /api/v1/me/robots/delete
+type robotNameBody struct {
+	Name string `json:"name"`
+}
+
+// robotToken is the current token of a robot
+type robotToken struct {
+	Robot          string        `json:"robot"`
+	ServiceAccount string        `json:"serviceAccount"`
+	Namespace      string        `json:"namespace"`
+	Token          string        `json:"token"`
+	CA             []byte        `json:"ca,omitempty"`
+	IssuedAt       *meta_v1.Time `json:"issuedAt,omitempty"`
+}
+
+// myRobots lists the robots the logged in user owns, alone or through a
+// group, on GET and creates one on POST
+func (s *Server) myRobots(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	robots := s.clientsets.NetsysClient.NetsysV1().DispatchRobots(s.namespace)
+	switch r.Method {
+	case http.MethodGet:
+		list, err := robots.List(meta_v1.ListOptions{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		mine := []netsys_v1.DispatchRobot{}
+		for _, robot := range list.Items {
+			if robot.IsOwner(id.UserID, id.Groups) {
+				mine = append(mine, robot)
+			}
+		}
+		writeJSON(w, http.StatusOK, mine)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "use GET or POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body robotBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if body.Name == "" || len(body.Grants) == 0 {
+		http.Error(w, "name and grants are required", http.StatusBadRequest)
+		return
+	}
+	spec := netsys_v1.DispatchRobotSpec{Owner: id.UserID, Grants: body.Grants, TokenTTL: body.TokenTTL}
+	if body.OwnerGroup != "" {
+		// the robot holds at most what its creator holds
+		spec.Owner, spec.OwnerGroup, spec.CreatedBy = "", body.OwnerGroup, id.UserID
+	}
+	robot := &netsys_v1.DispatchRobot{
+		ObjectMeta: meta_v1.ObjectMeta{Name: body.Name, Namespace: s.namespace},
+		Spec:       spec,
+	}
+	if !robot.IsOwner(id.UserID, id.Groups) {
+		http.Error(w, fmt.Sprintf("%s is not a member of group %s", id.UserID, body.OwnerGroup), http.StatusForbidden)
+		return
+	}
+	// the controller only grants what the owners hold
+	robot, err := robots.Create(robot)
+	if errors.IsAlreadyExists(err) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logging.Info("Created robot", "user", id.UserID, "robot", robot.Name)
+	writeJSON(w, http.StatusCreated, robot)
+}
+
+// robotTokenOf returns the current token of a robot the logged in user
+// owns. Jobs fetch it again after each rotation.
+func (s *Server) robotTokenOf(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	robot, ok := s.robotOf(w, r, id)
+	if !ok {
+		return
+	}
+	if robot.Status.Phase != netsys_v1.RobotActive || robot.Status.TokenSecret == "" {
+		http.Error(w, fmt.Sprintf("robot %s has no token: %s", robot.Name, orPending(robot.Status.Message)), http.StatusConflict)
+		return
+	}
+	secret, err := s.clientsets.OriginalClient.CoreV1().Secrets(s.namespace).Get(robot.Status.TokenSecret, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		http.Error(w, fmt.Sprintf("robot %s is rotating its token, try again", robot.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(secret.Data[core_v1.ServiceAccountTokenKey]) == 0 {
+		http.Error(w, fmt.Sprintf("the token of robot %s is not issued yet, try again", robot.Name), http.StatusConflict)
+		return
+	}
+	logging.Info("Fetched robot token", "user", id.UserID, "robot", robot.Name)
+	writeJSON(w, http.StatusOK, robotToken{
+		Robot:          robot.Name,
+		ServiceAccount: robot.Status.ServiceAccount,
+		Namespace:      s.namespace,
+		Token:          string(secret.Data[core_v1.ServiceAccountTokenKey]),
+		CA:             secret.Data[core_v1.ServiceAccountRootCAKey],
+		IssuedAt:       robot.Status.TokenIssuedAt,
+	})
+}
+
+// deleteRobot deletes a robot the logged in user owns, which revokes its
+// tokens and bindings
+func (s *Server) deleteRobot(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	robot, ok := s.robotOf(w, r, id)
+	if !ok {
+		return
+	}
+	err := s.clientsets.NetsysClient.NetsysV1().DispatchRobots(s.namespace).Delete(robot.Name, nil)
+	if err != nil && !errors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logging.Info("Deleted robot", "user", id.UserID, "robot", robot.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// robotOf returns the robot named in the body of r if the logged in user
+// owns it, or writes why not
+func (s *Server) robotOf(w http.ResponseWriter, r *http.Request, id oidc.Identity) (*netsys_v1.DispatchRobot, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	var body robotNameBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		http.Error(w, "invalid request: a name is required", http.StatusBadRequest)
+		return nil, false
+	}
+	robot, err := s.clientsets.NetsysClient.NetsysV1().DispatchRobots(s.namespace).Get(body.Name, meta_v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if err != nil || !robot.IsOwner(id.UserID, id.Groups) {
+		// do not tell others which robots exist
+		http.Error(w, fmt.Sprintf("no robot %s", body.Name), http.StatusNotFound)
+		return nil, false
+	}
+	return robot, true
+}
+
+// orPending returns message, or that the robot is not synced yet
+func orPending(message string) string {
+	if message == "" {
+		return "not synced yet"
+	}
+	return message
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/controller"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+	"github.com/hantaowang/dispatch/pkg/schedule"
+)
+
+// keepAwakeBody is the body of POST /api/v1/me/schedules/keep-awake
+type keepAwakeBody struct {
+	Namespace string `json:"namespace"`
+	// a time of day in the schedule's time zone like 22:00, or RFC 3339
+	Until string `json:"until"`
+}
+
+// mySchedules lists the scheduled namespaces of the logged in user
+func (s *Server) mySchedules(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	list, err := s.clientsets.NetsysClient.NetsysV1().OwnedNamespaces(s.namespace).List(meta_v1.ListOptions{
+		LabelSelector: "ownerID=" + id.UserID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scheduled := []netsys_v1.OwnedNamespace{}
+	for _, on := range list.Items {
+		if on.Spec.Schedule != nil {
+			scheduled = append(scheduled, on)
+		}
+	}
+	writeJSON(w, http.StatusOK, scheduled)
+}
+
+// keepAwake keeps one of the logged in user's scheduled namespaces
+// available past the end of its window
+func (s *Server) keepAwake(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var body keepAwakeBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Namespace == "" || body.Until == "" {
+		http.Error(w, "invalid request: a namespace and until are required", http.StatusBadRequest)
+		return
+	}
+
+	owned := s.clientsets.NetsysClient.NetsysV1().OwnedNamespaces(s.namespace)
+	status := http.StatusInternalServerError
+	var on *netsys_v1.OwnedNamespace
+	var until time.Time
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		on, err = owned.Get(controller.OwnedNamespaceName(id.UserID, body.Namespace), meta_v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			status = http.StatusNotFound
+			return fmt.Errorf("you have no grant in namespace %s", body.Namespace)
+		}
+		if err != nil {
+			return err
+		}
+		if until, err = schedule.KeepAwake(on, body.Until, time.Now()); err != nil {
+			status = http.StatusBadRequest
+			return err
+		}
+		on, err = owned.Update(on)
+		return err
+	})
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	logging.Info("Kept namespace awake", "user", id.UserID, "namespace", body.Namespace, "until", until.UTC().Format(time.RFC3339))
+	writeJSON(w, http.StatusOK, on)
+}
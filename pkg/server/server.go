@@ -0,0 +1,327 @@
+// Package server implements the dispatch self-service server. People log in
+// through an OpenID Connect provider and manage their own DispatchUser.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// Config configures the self-service server
+type Config struct {
+	// namespace holding DispatchUsers, defaults to "dispatch"
+	DispatchNamespace string
+
+	// OpenID Connect issuer URL, used for discovery
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// URL of the /callback endpoint as registered with the provider
+	RedirectURL string
+	// extra scopes to request besides "openid"
+	Scopes []string
+
+	ClaimMapping oidc.ClaimMapping
+
+	// key used to sign session cookies
+	SessionKey []byte
+	// lifetime of a session, defaults to 12 hours
+	SessionMaxAge time.Duration
+	// only send cookies over HTTPS
+	SecureCookies bool
+
+	// role of grants without one, has to match the defaultRole of the
+	// controller. Defaults to edit.
+	DefaultRole string
+
+	// client used to talk to the provider, defaults to http.DefaultClient
+	HTTPClient *http.Client
+
+	// users and groups that may read the cost reports of everyone
+	ReportViewers      []string
+	ReportViewerGroups []string
+}
+
+// Server serves the login flow and the self-service API
+type Server struct {
+	clientsets client.ClientSets
+	namespace  string
+	duLister   netsys_lister.DispatchUserLister
+
+	oauth    oauth2.Config
+	verifier *oidc.Verifier
+	mapping  oidc.ClaimMapping
+	sessions sessionCodec
+
+	httpClient *http.Client
+
+	reportViewers      []string
+	reportViewerGroups []string
+
+	defaultRole string
+}
+
+// loginState is stored in a short lived cookie during the login redirect
+type loginState struct {
+	State string `json:"state"`
+	Nonce string `json:"nonce"`
+}
+
+// NewServer discovers the identity provider and creates a Server. duLister
+// has to be synced, it is where requests look their DispatchUser up.
+func NewServer(cfg Config, clientSets client.ClientSets, duLister netsys_lister.DispatchUserLister) (*Server, error) {
+	if len(cfg.SessionKey) < 32 {
+		return nil, fmt.Errorf("session key must be at least 32 bytes")
+	}
+	if redirect, err := url.Parse(cfg.RedirectURL); err != nil {
+		return nil, fmt.Errorf("invalid redirect URL: %v", err)
+	} else if cfg.SecureCookies && redirect.Scheme != "https" {
+		// browsers would drop the login state cookie on the way back
+		return nil, fmt.Errorf("secure cookies need an https redirect URL, got %s", cfg.RedirectURL)
+	}
+	if cfg.DispatchNamespace == "" {
+		cfg.DispatchNamespace = "dispatch"
+	}
+	if cfg.DefaultRole == "" {
+		cfg.DefaultRole = netsys_v1.DefaultRole
+	}
+	if !netsys_v1.ValidRole(cfg.DefaultRole) {
+		return nil, fmt.Errorf("invalid default role %q", cfg.DefaultRole)
+	}
+	if cfg.SessionMaxAge == 0 {
+		cfg.SessionMaxAge = 12 * time.Hour
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	provider, err := oidc.Discover(httpClient, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		clientsets: clientSets,
+		namespace:  cfg.DispatchNamespace,
+		duLister:   duLister,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{"openid", "email", "profile"}, cfg.Scopes...),
+		},
+		verifier: provider.Verifier(cfg.ClientID),
+		mapping:  cfg.ClaimMapping,
+		sessions: sessionCodec{
+			key:    cfg.SessionKey,
+			maxAge: cfg.SessionMaxAge,
+			secure: cfg.SecureCookies,
+		},
+		httpClient:         httpClient,
+		reportViewers:      cfg.ReportViewers,
+		reportViewerGroups: cfg.ReportViewerGroups,
+		defaultRole:        cfg.DefaultRole,
+	}, nil
+}
+
+// Handler returns the HTTP handler of the server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", s.login)
+	mux.HandleFunc("/callback", s.callback)
+	mux.HandleFunc("/logout", s.logout)
+	// expired users may still look at themselves and ask for more time
+	mux.HandleFunc("/api/v1/me", s.authenticatedOrExpired(s.me))
+	mux.HandleFunc("/api/v1/me/extensions", s.authenticatedOrExpired(s.requestExtension))
+	mux.HandleFunc("/api/v1/me/leases", s.authenticated(s.myLeases))
+	mux.HandleFunc("/api/v1/me/leases/renew", s.authenticated(s.renewLease))
+	mux.HandleFunc("/api/v1/me/wake", s.authenticated(s.wake))
+	mux.HandleFunc("/api/v1/me/schedules", s.authenticated(s.mySchedules))
+	mux.HandleFunc("/api/v1/me/schedules/keep-awake", s.authenticated(s.keepAwake))
+	mux.HandleFunc("/api/v1/me/usage", s.authenticated(s.myUsage))
+	mux.HandleFunc("/api/v1/me/report", s.authenticated(s.myReport))
+	mux.HandleFunc("/api/v1/reports", s.authenticated(s.reports))
+	mux.HandleFunc("/api/v1/recertifications", s.authenticated(s.recertifications))
+	mux.HandleFunc("/api/v1/recertifications/confirm", s.authenticated(s.confirmRecertification))
+	mux.HandleFunc("/api/v1/me/elevations", s.authenticated(s.myElevations))
+	mux.HandleFunc("/api/v1/elevations", s.authenticated(s.elevations))
+	mux.HandleFunc("/api/v1/elevations/approve", s.authenticated(s.decideElevation))
+	mux.HandleFunc("/api/v1/elevations/deny", s.authenticated(s.decideElevation))
+	mux.HandleFunc("/api/v1/me/subnamespaces", s.authenticated(s.mySubNamespaces))
+	mux.HandleFunc("/api/v1/me/subnamespaces/delete", s.authenticated(s.deleteSubNamespace))
+	mux.HandleFunc("/api/v1/me/invitations", s.authenticated(s.myInvitations))
+	mux.HandleFunc("/api/v1/me/invitations/accept", s.authenticated(s.acceptInvitation))
+	mux.HandleFunc("/api/v1/me/invitations/revoke", s.authenticated(s.revokeInvitation))
+	mux.HandleFunc("/api/v1/me/robots", s.authenticated(s.myRobots))
+	mux.HandleFunc("/api/v1/me/robots/token", s.authenticated(s.robotTokenOf))
+	mux.HandleFunc("/api/v1/me/robots/delete", s.authenticated(s.deleteRobot))
+	return mux
+}
+
+// login starts the authorization code flow
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	st := loginState{State: randomString(), Nonce: randomString()}
+	value, err := s.sessions.encode(st)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.sessions.setCookie(w, stateCookie, value, time.Now().Add(10*time.Minute))
+	http.Redirect(w, r, s.oauth.AuthCodeURL(st.State, oauth2.SetAuthURLParam("nonce", st.Nonce)), http.StatusFound)
+}
+
+// callback completes the authorization code flow and starts a session
+func (s *Server) callback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil {
+		http.Error(w, "missing login state, start again at /login", http.StatusBadRequest)
+		return
+	}
+	s.sessions.clearCookie(w, stateCookie)
+
+	var st loginState
+	if err := s.sessions.decode(cookie.Value, &st); err != nil || st.State != r.URL.Query().Get("state") {
+		http.Error(w, "invalid login state", http.StatusBadRequest)
+		return
+	}
+	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
+		http.Error(w, fmt.Sprintf("login failed: %s", errMsg), http.StatusUnauthorized)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), oauth2.HTTPClient, s.httpClient)
+	token, err := s.oauth.Exchange(ctx, r.URL.Query().Get("code"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("exchanging code: %s", err), http.StatusUnauthorized)
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		http.Error(w, "provider returned no id_token", http.StatusUnauthorized)
+		return
+	}
+
+	idToken, err := s.verifier.Verify(rawIDToken)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("verifying id_token: %s", err), http.StatusUnauthorized)
+		return
+	}
+	if idToken.Nonce != st.Nonce {
+		http.Error(w, "id_token nonce does not match", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := s.mapping.Map(idToken.Claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	du, err := s.ensureDispatchUser(id)
+	if err != nil {
+		logging.Error("Provisioning DispatchUser failed", "user", id.UserID, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if du.Spec.Suspended {
+		http.Error(w, fmt.Sprintf("access of %s is suspended", id.UserID), http.StatusForbidden)
+		return
+	}
+
+	if err := s.sessions.setSession(w, id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/api/v1/me", http.StatusFound)
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	s.sessions.clearCookie(w, sessionCookie)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// me returns the DispatchUser of the logged in user
+func (s *Server) me(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	du, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if du == nil {
+		http.Error(w, "no DispatchUser for this session, log in again", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, du)
+}
+
+// authenticated rejects requests without a valid session and requests of
+// users that have no DispatchUser, are suspended or expired, whose sessions
+// may predate the deletion, suspension or expiry. Only logging in creates a
+// DispatchUser.
+func (s *Server) authenticated(h func(http.ResponseWriter, *http.Request, oidc.Identity)) http.HandlerFunc {
+	return s.authenticate(h, false)
+}
+
+// authenticatedOrExpired is authenticated but lets expired users through
+func (s *Server) authenticatedOrExpired(h func(http.ResponseWriter, *http.Request, oidc.Identity)) http.HandlerFunc {
+	return s.authenticate(h, true)
+}
+
+func (s *Server) authenticate(h func(http.ResponseWriter, *http.Request, oidc.Identity), allowExpired bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := s.sessions.getSession(r)
+		if err != nil {
+			http.Error(w, "not logged in", http.StatusUnauthorized)
+			return
+		}
+		du, err := s.findDispatchUser(id.UserID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if du == nil {
+			http.Error(w, "no DispatchUser for this session, log in again", http.StatusForbidden)
+			return
+		}
+		if du.Spec.Suspended {
+			http.Error(w, fmt.Sprintf("access of %s is suspended", id.UserID), http.StatusForbidden)
+			return
+		}
+		if !allowExpired && (du.Status.Expired || du.Expired(time.Now())) {
+			http.Error(w, fmt.Sprintf("access of %s expired, ask for an extension", id.UserID), http.StatusForbidden)
+			return
+		}
+		h(w, r, id)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Error("Encoding response failed", "error", err)
+	}
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("reading random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
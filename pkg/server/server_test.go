@@ -0,0 +1,232 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client"
+	netsys_fake "github.com/hantaowang/dispatch/pkg/client/clientset/versioned/fake"
+	"github.com/hantaowang/dispatch/pkg/client/informers/externalversions"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+	"github.com/hantaowang/dispatch/pkg/oidc/oidctest"
+)
+
+const testClientID = "dispatch"
+
+// newTestServer serves a Server logging in through an oidctest provider
+func newTestServer(t *testing.T) (*oidctest.Provider, *httptest.Server, *netsys_fake.Clientset) {
+	op, err := oidctest.NewProvider(testClientID)
+	if err != nil {
+		t.Fatalf("starting provider: %v", err)
+	}
+	t.Cleanup(op.Close)
+	op.SetClaims(map[string]interface{}{"sub": "alice", "email": "alice@example.com", "groups": []string{"dev"}})
+
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	netsys := netsys_fake.NewSimpleClientset()
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	duInformer := externalversions.NewSharedInformerFactory(netsys, 0).Netsys().V1().DispatchUsers()
+	go duInformer.Informer().Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, duInformer.Informer().HasSynced) {
+		t.Fatal("syncing DispatchUsers failed")
+	}
+
+	s, err := NewServer(Config{
+		Issuer:       op.Issuer(),
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  ts.URL + "/callback",
+		ClaimMapping: oidc.DefaultClaimMapping(),
+		SessionKey:   []byte(strings.Repeat("k", 32)),
+	}, client.ClientSets{NetsysClient: netsys}, duInformer.Lister())
+	if err != nil {
+		t.Fatalf("creating server: %v", err)
+	}
+	mux.Handle("/", s.Handler())
+	return op, ts, netsys
+}
+
+// newBrowser returns a client that keeps cookies and, unless follow is
+// set, stops at redirects
+func newBrowser(t *testing.T, follow bool) *http.Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &http.Client{Jar: jar}
+	if !follow {
+		c.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+	return c
+}
+
+func TestLogin(t *testing.T) {
+	_, ts, netsys := newTestServer(t)
+
+	resp, err := newBrowser(t, true).Get(ts.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		t.Fatalf("expected to end up logged in, got %s: %s", resp.Status, body)
+	}
+
+	var du netsys_v1.DispatchUser
+	if err := json.NewDecoder(resp.Body).Decode(&du); err != nil {
+		t.Fatal(err)
+	}
+	if du.Spec.UserID != "alice" || len(du.Spec.Groups) != 1 || du.Spec.Groups[0] != "dev" {
+		t.Errorf("unexpected DispatchUser %+v", du.Spec)
+	}
+	if _, err := netsys.NetsysV1().DispatchUsers("dispatch").Get("alice", meta_v1.GetOptions{}); err != nil {
+		t.Errorf("expected the DispatchUser to be created on first login: %v", err)
+	}
+}
+
+func TestExpiredAndDeletedUsers(t *testing.T) {
+	_, ts, netsys := newTestServer(t)
+	browser := newBrowser(t, true)
+	resp, err := browser.Get(ts.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected to end up logged in, got %s", resp.Status)
+	}
+
+	users := netsys.NetsysV1().DispatchUsers("dispatch")
+	du, err := users.Get("alice", meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := meta_v1.NewTime(time.Now().Add(-time.Hour))
+	du.Spec.ExpiresAt = &expired
+	if _, err := users.Update(du); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/api/v1/me", http.StatusOK},
+		{"/api/v1/me/leases", http.StatusForbidden},
+		{"/api/v1/elevations", http.StatusForbidden},
+		{"/api/v1/recertifications", http.StatusForbidden},
+	}
+	// the cache catches up with the update eventually
+	eventually(t, func() bool { return get(t, browser, ts.URL+"/api/v1/me/leases") == http.StatusForbidden })
+	for _, test := range tests {
+		if status := get(t, browser, ts.URL+test.path); status != test.status {
+			t.Errorf("expired user: GET %s: expected %d, got %d", test.path, test.status, status)
+		}
+	}
+
+	// a session outlives the DispatchUser it was started for
+	if err := users.Delete("alice", nil); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool { return get(t, browser, ts.URL+"/api/v1/me") == http.StatusForbidden })
+	if status := get(t, browser, ts.URL+"/api/v1/me/leases"); status != http.StatusForbidden {
+		t.Errorf("deleted user: expected %d, got %d", http.StatusForbidden, status)
+	}
+}
+
+// eventually fails the test unless cond turns true within a few seconds
+func eventually(t *testing.T, cond func() bool) {
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+	}
+}
+
+// get requests rawurl and returns the status code
+func get(t *testing.T, c *http.Client, rawurl string) int {
+	resp, err := c.Get(rawurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestLoginNonceMismatch(t *testing.T) {
+	_, ts, netsys := newTestServer(t)
+	browser := newBrowser(t, false)
+
+	// a token minted for another login attempt must not complete this one
+	authorize := redirect(t, browser, ts.URL+"/login")
+	u, err := url.Parse(authorize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("nonce") == "" {
+		t.Fatal("expected the authorization request to carry a nonce")
+	}
+	q.Set("nonce", "replayed")
+	u.RawQuery = q.Encode()
+	callback := redirect(t, browser, u.String())
+
+	resp, err := browser.Get(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusUnauthorized || !strings.Contains(string(body), "nonce") {
+		t.Errorf("expected the nonce to be rejected, got %s: %s", resp.Status, body)
+	}
+	if list, _ := netsys.NetsysV1().DispatchUsers("dispatch").List(meta_v1.ListOptions{}); len(list.Items) != 0 {
+		t.Errorf("expected no DispatchUser, got %d", len(list.Items))
+	}
+}
+
+func TestCallbackStateMismatch(t *testing.T) {
+	_, ts, _ := newTestServer(t)
+	browser := newBrowser(t, false)
+
+	authorize := redirect(t, browser, ts.URL+"/login")
+	callback := redirect(t, browser, strings.Replace(authorize, "state=", "state=forged", 1))
+
+	resp, err := browser.Get(callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a forged state to be rejected, got %s", resp.Status)
+	}
+}
+
+// redirect requests rawurl and returns where it redirects to
+func redirect(t *testing.T, c *http.Client, rawurl string) string {
+	resp, err := c.Get(rawurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected %s to redirect, got %s", rawurl, resp.Status)
+	}
+	return resp.Header.Get("Location")
+}
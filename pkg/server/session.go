@@ -0,0 +1,113 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+const (
+	sessionCookie = "dispatch_session"
+	stateCookie   = "dispatch_oauth_state"
+)
+
+// session is the content of the signed session cookie
+type session struct {
+	oidc.Identity
+	Expiry int64 `json:"exp"`
+}
+
+// sessionCodec signs and verifies cookie values with an HMAC key so that
+// sessions need no server side storage
+type sessionCodec struct {
+	key    []byte
+	maxAge time.Duration
+	secure bool
+}
+
+func (c sessionCodec) encode(v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding.EncodeToString(payload)
+	return enc + "." + c.sign(enc), nil
+}
+
+func (c sessionCodec) decode(value string, v interface{}) error {
+	parts := strings.SplitN(value, ".", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("malformed cookie")
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(c.sign(parts[0]))) {
+		return fmt.Errorf("invalid cookie signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
+
+func (c sessionCodec) sign(s string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// setSession writes the session cookie for an identity
+func (c sessionCodec) setSession(w http.ResponseWriter, id oidc.Identity) error {
+	expiry := time.Now().Add(c.maxAge)
+	value, err := c.encode(session{Identity: id, Expiry: expiry.Unix()})
+	if err != nil {
+		return err
+	}
+	c.setCookie(w, sessionCookie, value, expiry)
+	return nil
+}
+
+// getSession returns the identity of a valid, unexpired session cookie
+func (c sessionCodec) getSession(r *http.Request) (oidc.Identity, error) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return oidc.Identity{}, err
+	}
+	var s session
+	if err := c.decode(cookie.Value, &s); err != nil {
+		return oidc.Identity{}, err
+	}
+	if time.Now().Unix() > s.Expiry {
+		return oidc.Identity{}, fmt.Errorf("session expired")
+	}
+	return s.Identity, nil
+}
+
+func (c sessionCodec) setCookie(w http.ResponseWriter, name, value string, expiry time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expiry,
+		HttpOnly: true,
+		Secure:   c.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (c sessionCodec) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.secure,
+	})
+}
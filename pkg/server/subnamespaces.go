@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// subNamespaceBody is the body of POST /api/v1/me/subnamespaces
+type subNamespaceBody struct {
+	Parent string `json:"parent"`
+	// name of the child namespace, starting with parent-
+	Name string `json:"name"`
+	// slice of the parent's quota, e.g. {"requests.cpu": "2"}
+	Quota   map[string]string `json:"quota,omitempty"`
+	Cascade string            `json:"cascade,omitempty"`
+}
+
+// deleteSubNamespaceBody is the body of POST /api/v1/me/subnamespaces/delete
+type deleteSubNamespaceBody struct {
+	Name string `json:"name"`
+}
+
+// mySubNamespaces lists the sub-namespaces under the namespaces of the
+// logged in user on GET and creates one on POST
+func (s *Server) mySubNamespaces(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	subs := s.clientsets.NetsysClient.NetsysV1().SubNamespaces(s.namespace)
+	list, err := subs.List(meta_v1.ListOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		mine := []netsys_v1.SubNamespace{}
+		for _, sn := range list.Items {
+			if sn.IsOwner(id.UserID) || sn.Spec.CreatedBy == id.UserID {
+				mine = append(mine, sn)
+			}
+		}
+		writeJSON(w, http.StatusOK, mine)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "use GET or POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body subNamespaceBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if body.Parent == "" || body.Name == "" {
+		http.Error(w, "parent and name are required", http.StatusBadRequest)
+		return
+	}
+	if body.Cascade != "" && !netsys_v1.ValidCascade(body.Cascade) {
+		http.Error(w, "cascade must be Delete or Orphan", http.StatusBadRequest)
+		return
+	}
+	quota := core_v1.ResourceList{}
+	for name, value := range body.Quota {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid quota %s=%s: %v", name, value, err), http.StatusBadRequest)
+			return
+		}
+		quota[core_v1.ResourceName(name)] = q
+	}
+	du, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if du == nil {
+		http.Error(w, "no DispatchUser for this session, log in again", http.StatusNotFound)
+		return
+	}
+	if !mayCreateUnder(du, body.Parent, list.Items) {
+		http.Error(w, fmt.Sprintf("you need edit or admin in namespace %s", body.Parent), http.StatusForbidden)
+		return
+	}
+
+	// the controller checks the name, owners and quota slice
+	sn, err := subs.Create(&netsys_v1.SubNamespace{
+		ObjectMeta: meta_v1.ObjectMeta{Name: body.Name, Namespace: s.namespace},
+		Spec: netsys_v1.SubNamespaceSpec{
+			Parent:    body.Parent,
+			Quota:     quota,
+			Cascade:   body.Cascade,
+			CreatedBy: id.UserID,
+		},
+	})
+	if errors.IsAlreadyExists(err) {
+		http.Error(w, fmt.Sprintf("sub-namespace %s already exists", body.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logging.Info("Created sub-namespace", "user", id.UserID, "namespace", body.Name, "parent", body.Parent)
+	writeJSON(w, http.StatusCreated, sn)
+}
+
+// deleteSubNamespace deletes a sub-namespace under one of the logged in
+// user's namespaces. The controller deletes its namespace.
+func (s *Server) deleteSubNamespace(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var body deleteSubNamespaceBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		http.Error(w, "invalid request: a name is required", http.StatusBadRequest)
+		return
+	}
+	subs := s.clientsets.NetsysClient.NetsysV1().SubNamespaces(s.namespace)
+	list, err := subs.List(meta_v1.ListOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	du, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var sn *netsys_v1.SubNamespace
+	for i := range list.Items {
+		if list.Items[i].Name == body.Name {
+			sn = &list.Items[i]
+		}
+	}
+	if sn == nil {
+		http.Error(w, fmt.Sprintf("no sub-namespace %s", body.Name), http.StatusNotFound)
+		return
+	}
+	if du == nil || !mayCreateUnder(du, sn.Spec.Parent, list.Items) {
+		http.Error(w, fmt.Sprintf("you need edit or admin in namespace %s", sn.Spec.Parent), http.StatusForbidden)
+		return
+	}
+	if err := subs.Delete(sn.Name, nil); err != nil && !errors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logging.Info("Deleted sub-namespace", "user", id.UserID, "namespace", sn.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mayCreateUnder returns true if du holds namespace with edit or admin,
+// directly or through the namespaces the sub-namespace namespace is under
+func mayCreateUnder(du *netsys_v1.DispatchUser, namespace string, subs []netsys_v1.SubNamespace) bool {
+	parents := map[string]string{}
+	for _, sn := range subs {
+		if sn.Status.Phase != netsys_v1.SubNamespaceOrphaned {
+			parents[sn.Name] = sn.Spec.Parent
+		}
+	}
+	grants := du.Spec.EffectiveGrants()
+	// a child's name is longer than its parent's, so this ends
+	for namespace != "" {
+		for _, g := range grants {
+			if g.Key() == namespace && netsys_v1.RoleOrDefault(g.Role) != netsys_v1.RoleView {
+				return true
+			}
+		}
+		namespace = parents[namespace]
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"net/http"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// myUsage shows what the namespaces of the logged in user consume
+func (s *Server) myUsage(w http.ResponseWriter, r *http.Request, id oidc.Identity) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	du, err := s.findDispatchUser(id.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if du == nil {
+		http.Error(w, "no DispatchUser for this session, log in again", http.StatusNotFound)
+		return
+	}
+	usage := du.Status.Usage
+	if usage == nil {
+		usage = &netsys_v1.UsageStatus{}
+	}
+	writeJSON(w, http.StatusOK, usage)
+}
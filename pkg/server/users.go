@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/logging"
+	"github.com/hantaowang/dispatch/pkg/oidc"
+)
+
+// findDispatchUser returns the DispatchUser with the given user ID from the
+// cache, or nil if there is none. A user created moments ago may not be
+// cached yet, so misses are looked up on the API server. The result is
+// shared with the cache and must not be modified.
+func (s *Server) findDispatchUser(userID string) (*netsys_v1.DispatchUser, error) {
+	users, err := s.duLister.DispatchUsers(s.namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, du := range users {
+		if du.Spec.UserID == userID {
+			return du, nil
+		}
+	}
+	return s.getDispatchUser(userID)
+}
+
+// getDispatchUser returns the DispatchUser with the given user ID from the
+// API server, or nil if there is none. Changes start from it so they do
+// not conflict with a stale cache.
+func (s *Server) getDispatchUser(userID string) (*netsys_v1.DispatchUser, error) {
+	list, err := s.clientsets.NetsysClient.NetsysV1().DispatchUsers(s.namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if list.Items[i].Spec.UserID == userID {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ensureDispatchUser returns the DispatchUser of an identity, creating it on
+// first login. Groups are refreshed from the identity provider on every login.
+func (s *Server) ensureDispatchUser(id oidc.Identity) (*netsys_v1.DispatchUser, error) {
+	// the user ID doubles as the name of the user's ServiceAccount
+	if errs := validation.IsDNS1123Subdomain(id.UserID); len(errs) > 0 {
+		return nil, fmt.Errorf("user ID %q cannot be used as a ServiceAccount name: %s", id.UserID, strings.Join(errs, ", "))
+	}
+
+	du, err := s.getDispatchUser(id.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if du == nil {
+		du = &netsys_v1.DispatchUser{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      id.UserID,
+				Namespace: s.namespace,
+			},
+			Spec: netsys_v1.DispatchUserSpec{
+				UserID:     id.UserID,
+				Namespaces: []string{},
+				Groups:     id.Groups,
+			},
+		}
+		created, err := s.clientsets.NetsysClient.NetsysV1().DispatchUsers(s.namespace).Create(du)
+		if errors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("a DispatchUser named %s already exists for another user", id.UserID)
+		}
+		if err == nil {
+			logging.Info("Created DispatchUser on first login", "user", id.UserID)
+		}
+		return created, err
+	}
+
+	if !sameStrings(du.Spec.Groups, id.Groups) {
+		du = du.DeepCopy()
+		du.Spec.Groups = id.Groups
+		return s.clientsets.NetsysClient.NetsysV1().DispatchUsers(s.namespace).Update(du)
+	}
+	return du, nil
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+// Package transfer moves the namespace grants of one DispatchUser to another
+// user or to the members of a group. The grants keep their role, lease,
+// schedule and expiry. Either every user is changed or, if a step fails,
+// the users already changed are restored. The DispatchUser controller
+// provisions the changes and records them in the audit log with the
+// transfer as the reason.
+package transfer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/client/clientset/versioned"
+)
+
+// Request asks to move grants of From to ToUser or to the members of
+// ToGroup, exactly one of which is set
+type Request struct {
+	// From is the user ID the grants are taken from
+	From string
+	// Namespaces are the grant keys to move, all grants of From if empty
+	Namespaces []string
+	ToUser     string
+	ToGroup    string
+	// By is who asked for the transfer, recorded in the audit log
+	By     string
+	Reason string
+}
+
+// Result is what a transfer moved
+type Result struct {
+	Namespaces []string
+	// To are the user IDs that received the grants
+	To []string
+}
+
+// Refused is returned when a transfer is not possible as asked, e.g. when
+// the receiving user already holds one of the namespaces
+type Refused struct {
+	msg string
+}
+
+func (r Refused) Error() string {
+	return r.msg
+}
+
+func refused(format string, args ...interface{}) error {
+	return Refused{msg: fmt.Sprintf(format, args...)}
+}
+
+// IsRefused returns true if err is a Refused
+func IsRefused(err error) bool {
+	_, ok := err.(Refused)
+	return ok
+}
+
+// Transfer moves the grants of req.From as asked. The receiving users get
+// them first so access is never lost in between, then they are removed
+// from req.From. If any update fails, the receiving users updated so far
+// lose the grants again and the error is returned, along with any that
+// occurred while rolling back.
+func Transfer(client versioned.Interface, namespace string, req Request, now time.Time) (*Result, error) {
+	if (req.ToUser == "") == (req.ToGroup == "") {
+		return nil, refused("transfer to either a user or a group")
+	}
+	users, err := client.NetsysV1().DispatchUsers(namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var from *netsys_v1.DispatchUser
+	var to []*netsys_v1.DispatchUser
+	for i := range users.Items {
+		u := &users.Items[i]
+		switch {
+		case u.Spec.UserID == req.From:
+			from = u
+		case req.ToUser != "" && u.Spec.UserID == req.ToUser:
+			to = append(to, u)
+		case req.ToGroup != "" && contains(u.Spec.Groups, req.ToGroup) && !u.Status.Expired && !u.Spec.Suspended:
+			// members without access would not use the grants
+			to = append(to, u)
+		}
+	}
+	if from == nil {
+		return nil, refused("no DispatchUser %s", req.From)
+	}
+	if req.ToUser == req.From {
+		return nil, refused("%s cannot transfer to themselves", req.From)
+	}
+	if len(to) == 0 {
+		if req.ToUser != "" {
+			return nil, refused("no DispatchUser %s", req.ToUser)
+		}
+		return nil, refused("group %s has no active members other than %s", req.ToGroup, req.From)
+	}
+	if req.ToUser != "" && (to[0].Status.Expired || to[0].Spec.Suspended) {
+		return nil, refused("the access of %s is expired or suspended", req.ToUser)
+	}
+
+	grants, err := selectGrants(from, req.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+	moved, err := carryOver(client, namespace, from, grants, now)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	for _, g := range grants {
+		result.Namespaces = append(result.Namespaces, g.Key())
+	}
+	for _, u := range to {
+		for _, g := range grants {
+			if u.Spec.HasNamespace(g.Key()) {
+				return nil, refused("%s already has access to namespace %s", u.Spec.UserID, g.Key())
+			}
+		}
+		result.To = append(result.To, u.Spec.UserID)
+	}
+	sort.Strings(result.To)
+
+	target := req.ToUser
+	if target == "" {
+		target = "group " + req.ToGroup
+	}
+	reason := fmt.Sprintf("transferred from %s to %s by %s", req.From, target, req.By)
+	if req.Reason != "" {
+		reason += ": " + req.Reason
+	}
+
+	var done []string
+	for _, u := range to {
+		if err := update(client, namespace, u.Name, moved, true, reason); err != nil {
+			return nil, rollback(client, namespace, done, moved, req, err)
+		}
+		done = append(done, u.Name)
+	}
+	if err := update(client, namespace, from.Name, grants, false, reason); err != nil {
+		return nil, rollback(client, namespace, done, moved, req, err)
+	}
+	return result, nil
+}
+
+// selectGrants returns the grants of u with keys, all of them if keys is
+// empty
+func selectGrants(u *netsys_v1.DispatchUser, keys []string) ([]netsys_v1.NamespaceGrant, error) {
+	grants := u.Spec.EffectiveGrants()
+	if len(keys) == 0 {
+		if len(grants) == 0 {
+			return nil, refused("%s holds no namespaces", u.Spec.UserID)
+		}
+		return grants, nil
+	}
+	var selected []netsys_v1.NamespaceGrant
+	for _, k := range keys {
+		found := false
+		for _, g := range grants {
+			if g.Key() == k {
+				selected = append(selected, g)
+				found = true
+			}
+		}
+		if !found {
+			return nil, refused("%s does not hold namespace %s", u.Spec.UserID, k)
+		}
+	}
+	return selected, nil
+}
+
+// carryOver returns grants as the receiving users get them: a TTL becomes
+// the expiry it has for from, and a lease keeps when it was last renewed
+func carryOver(client versioned.Interface, namespace string, from *netsys_v1.DispatchUser, grants []netsys_v1.NamespaceGrant, now time.Time) ([]netsys_v1.NamespaceGrant, error) {
+	owned, err := client.NetsysV1().OwnedNamespaces(namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	renewed := map[string]*meta_v1.Time{}
+	for _, on := range owned.Items {
+		if on.Spec.OwnerID == from.Spec.UserID && on.Status.Lease != nil {
+			t := on.Status.Lease.RenewedAt
+			renewed[on.Key()] = &t
+		}
+	}
+
+	var moved []netsys_v1.NamespaceGrant
+	for _, g := range grants {
+		g = *g.DeepCopy()
+		if g.TTL != nil {
+			if s := from.Status.GrantStatus(g.Key()); s != nil && s.ExpiresAt != nil {
+				g.ExpiresAt, g.TTL = s.ExpiresAt.DeepCopy(), nil
+			}
+		}
+		if g.ExpiresAt != nil && !g.ExpiresAt.After(now) {
+			return nil, refused("the grant of %s in namespace %s has expired", from.Spec.UserID, g.Key())
+		}
+		g.LeaseRenewedAt = nil
+		if g.Lease != nil {
+			g.LeaseRenewedAt = renewed[g.Key()]
+		}
+		moved = append(moved, g)
+	}
+	return moved, nil
+}
+
+// update adds grants to the DispatchUser name, or removes them
+func update(client versioned.Interface, namespace, name string, grants []netsys_v1.NamespaceGrant, add bool, reason string) error {
+	users := client.NetsysV1().DispatchUsers(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		u, err := users.Get(name, meta_v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		for _, g := range grants {
+			if !add {
+				u.Spec.RemoveNamespace(g.Key())
+				continue
+			}
+			if u.Spec.HasNamespace(g.Key()) {
+				// granted since the transfer was checked
+				return refused("%s already has access to namespace %s", u.Spec.UserID, g.Key())
+			}
+			u.Spec.Grants = append(u.Spec.Grants, g)
+		}
+		u.SetReason(reason)
+		_, err = users.Update(u)
+		return err
+	})
+}
+
+// rollback takes grants from the receiving users in done after the
+// transfer failed with cause. The giving user is changed last and so never
+// needs restoring.
+func rollback(client versioned.Interface, namespace string, done []string, grants []netsys_v1.NamespaceGrant, req Request, cause error) error {
+	reason := fmt.Sprintf("transfer from %s rolled back: %v", req.From, cause)
+	var errs []string
+	for i := len(done) - 1; i >= 0; i-- {
+		if err := update(client, namespace, done[i], grants, false, reason); err != nil {
+			errs = append(errs, fmt.Sprintf("restoring %s: %v", done[i], err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v, rolling back failed: %s", cause, strings.Join(errs, "; "))
+	}
+	return cause
+}
+
+// contains returns true if s is in list
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
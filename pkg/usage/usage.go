@@ -0,0 +1,212 @@
+// Package usage adds up what namespaces consume from informer caches, so
+// that the usage of every DispatchUser can be kept up to date without
+// listing each of their namespaces from the API server
+package usage
+
+import (
+	"fmt"
+
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	apps_informer "k8s.io/client-go/informers/apps/v1"
+	core_informer "k8s.io/client-go/informers/core/v1"
+	apps_lister "k8s.io/client-go/listers/apps/v1"
+	core_lister "k8s.io/client-go/listers/core/v1"
+)
+
+// Object counts, named as in a ResourceQuota. Compute and storage use the
+// core names requests.cpu, limits.memory, requests.storage and pods.
+const (
+	CountServices               core_v1.ResourceName = "count/services"
+	CountDeployments            core_v1.ResourceName = "count/deployments.apps"
+	CountStatefulSets           core_v1.ResourceName = "count/statefulsets.apps"
+	CountPersistentVolumeClaims core_v1.ResourceName = "count/persistentvolumeclaims"
+)
+
+// How a namespace held by several users is charged to them
+const (
+	// SplitEven charges every holder an equal share
+	SplitEven = "even"
+	// SplitFull charges every holder the whole namespace
+	SplitFull = "full"
+)
+
+// SplitRules are the valid split rules
+var SplitRules = []string{SplitEven, SplitFull}
+
+// ValidSplit returns true if rule is a known split rule
+func ValidSplit(rule string) bool {
+	for _, r := range SplitRules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Informers watch the objects counted in every namespace
+type Informers struct {
+	Pods                   core_informer.PodInformer
+	PersistentVolumeClaims core_informer.PersistentVolumeClaimInformer
+	Services               core_informer.ServiceInformer
+	Deployments            apps_informer.DeploymentInformer
+	StatefulSets           apps_informer.StatefulSetInformer
+}
+
+// NewInformers creates the informers of factory, which must not be limited
+// to a namespace
+func NewInformers(factory informers.SharedInformerFactory) Informers {
+	return Informers{
+		Pods:                   factory.Core().V1().Pods(),
+		PersistentVolumeClaims: factory.Core().V1().PersistentVolumeClaims(),
+		Services:               factory.Core().V1().Services(),
+		Deployments:            factory.Apps().V1().Deployments(),
+		StatefulSets:           factory.Apps().V1().StatefulSets(),
+	}
+}
+
+// Run starts the informers
+func (i Informers) Run(stopCh <-chan struct{}) {
+	go i.Pods.Informer().Run(stopCh)
+	go i.PersistentVolumeClaims.Informer().Run(stopCh)
+	go i.Services.Informer().Run(stopCh)
+	go i.Deployments.Informer().Run(stopCh)
+	go i.StatefulSets.Informer().Run(stopCh)
+}
+
+// HasSynced returns true once every informer synced
+func (i Informers) HasSynced() bool {
+	return i.Pods.Informer().HasSynced() &&
+		i.PersistentVolumeClaims.Informer().HasSynced() &&
+		i.Services.Informer().HasSynced() &&
+		i.Deployments.Informer().HasSynced() &&
+		i.StatefulSets.Informer().HasSynced()
+}
+
+// Listers returns the listers of the informers
+func (i Informers) Listers() Listers {
+	return Listers{
+		Pods:                   i.Pods.Lister(),
+		PersistentVolumeClaims: i.PersistentVolumeClaims.Lister(),
+		Services:               i.Services.Lister(),
+		Deployments:            i.Deployments.Lister(),
+		StatefulSets:           i.StatefulSets.Lister(),
+	}
+}
+
+// Listers read the objects counted
+type Listers struct {
+	Pods                   core_lister.PodLister
+	PersistentVolumeClaims core_lister.PersistentVolumeClaimLister
+	Services               core_lister.ServiceLister
+	Deployments            apps_lister.DeploymentLister
+	StatefulSets           apps_lister.StatefulSetLister
+}
+
+// Namespace returns the usage of namespace. Like a ResourceQuota it counts
+// the requests and limits of pods that have not terminated.
+func (l Listers) Namespace(namespace string) (core_v1.ResourceList, error) {
+	usage := core_v1.ResourceList{}
+	pods, err := l.Pods.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %v", err)
+	}
+	var running int64
+	for _, pod := range pods {
+		if pod.Status.Phase == core_v1.PodSucceeded || pod.Status.Phase == core_v1.PodFailed {
+			continue
+		}
+		running++
+		requests, limits := podResources(pod)
+		add(usage, requests[core_v1.ResourceCPU], core_v1.ResourceRequestsCPU)
+		add(usage, requests[core_v1.ResourceMemory], core_v1.ResourceRequestsMemory)
+		add(usage, limits[core_v1.ResourceCPU], core_v1.ResourceLimitsCPU)
+		add(usage, limits[core_v1.ResourceMemory], core_v1.ResourceLimitsMemory)
+	}
+	usage[core_v1.ResourcePods] = *resource.NewQuantity(running, resource.DecimalSI)
+
+	claims, err := l.PersistentVolumeClaims.PersistentVolumeClaims(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing persistentvolumeclaims: %v", err)
+	}
+	for _, pvc := range claims {
+		add(usage, pvc.Spec.Resources.Requests[core_v1.ResourceStorage], core_v1.ResourceRequestsStorage)
+	}
+	usage[CountPersistentVolumeClaims] = *resource.NewQuantity(int64(len(claims)), resource.DecimalSI)
+
+	services, err := l.Services.Services(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing services: %v", err)
+	}
+	usage[CountServices] = *resource.NewQuantity(int64(len(services)), resource.DecimalSI)
+
+	deployments, err := l.Deployments.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %v", err)
+	}
+	usage[CountDeployments] = *resource.NewQuantity(int64(len(deployments)), resource.DecimalSI)
+
+	statefulSets, err := l.StatefulSets.StatefulSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("listing statefulsets: %v", err)
+	}
+	usage[CountStatefulSets] = *resource.NewQuantity(int64(len(statefulSets)), resource.DecimalSI)
+	return usage, nil
+}
+
+// podResources returns the effective requests and limits of pod: the sum
+// of its containers, or the largest init container if that is more
+func podResources(pod *core_v1.Pod) (core_v1.ResourceList, core_v1.ResourceList) {
+	requests, limits := core_v1.ResourceList{}, core_v1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		for name, q := range c.Resources.Requests {
+			add(requests, q, name)
+		}
+		for name, q := range c.Resources.Limits {
+			add(limits, q, name)
+		}
+	}
+	for _, c := range pod.Spec.InitContainers {
+		for name, q := range c.Resources.Requests {
+			if cur, ok := requests[name]; !ok || q.Cmp(cur) > 0 {
+				requests[name] = q.DeepCopy()
+			}
+		}
+		for name, q := range c.Resources.Limits {
+			if cur, ok := limits[name]; !ok || q.Cmp(cur) > 0 {
+				limits[name] = q.DeepCopy()
+			}
+		}
+	}
+	return requests, limits
+}
+
+// add adds q to the resource name of list
+func add(list core_v1.ResourceList, q resource.Quantity, name core_v1.ResourceName) {
+	sum := list[name]
+	sum.Add(q)
+	list[name] = sum
+}
+
+// Share returns the part of usage charged to one of holders users holding
+// the namespace
+func Share(usage core_v1.ResourceList, holders int, rule string) core_v1.ResourceList {
+	share := make(core_v1.ResourceList, len(usage))
+	for name, q := range usage {
+		if rule == SplitFull || holders <= 1 {
+			share[name] = q.DeepCopy()
+			continue
+		}
+		share[name] = *resource.NewMilliQuantity(q.MilliValue()/int64(holders), q.Format)
+	}
+	return share
+}
+
+// Add adds usage to total
+func Add(total, usage core_v1.ResourceList) {
+	for name, q := range usage {
+		add(total, q, name)
+	}
+}
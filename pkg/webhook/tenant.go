@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+)
+
+// the user name ServiceAccounts authenticate with, followed by
+// namespace:name
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// reviewDispatchUser denies changes to the status of DispatchUsers, which
+// only dispatch writes, and denies tenant admins changes to DispatchUsers
+// outside the tenants they administer and changes that break their budget.
+// Other requests are left to RBAC.
+func (s *Server) reviewDispatchUser(req *admissionRequest) (string, error) {
+	if req.Namespace != s.config.DispatchNamespace {
+		return "", nil
+	}
+	for _, g := range req.UserInfo.Groups {
+		if contains(s.config.Webhook.ExemptGroups, g) {
+			return "", nil
+		}
+	}
+	// tenant admins are bound as their ServiceAccounts, which the proxy
+	// impersonates
+	userID := strings.TrimPrefix(req.UserInfo.Username, serviceAccountPrefix+s.config.DispatchNamespace+":")
+	if userID != req.UserInfo.Username && strings.HasPrefix(userID, "dispatch-") {
+		// dispatch itself, no DispatchUser can take its user IDs
+		return "", nil
+	}
+
+	old, du, err := s.decode(req)
+	if err != nil {
+		return "", err
+	}
+	if du != nil {
+		var oldStatus netsys_v1.DispatchUserStatus
+		if old != nil {
+			oldStatus = old.Status
+		}
+		if !equality.Semantic.DeepEqual(oldStatus, du.Status) {
+			// status holds what the controller granted and recorded
+			return "the status of a DispatchUser is written by dispatch only", nil
+		}
+	}
+
+	tenants, err := s.dtLister.DispatchTenants(s.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+	administered := map[string]*netsys_v1.DispatchTenant{}
+	var names []string
+	for _, t := range tenants {
+		if t.IsAdmin(userID, req.UserInfo.Groups) {
+			administered[t.Name] = t
+			names = append(names, t.Name)
+		}
+	}
+	if len(administered) == 0 {
+		return "", nil
+	}
+	sort.Strings(names)
+
+	if old != nil {
+		if _, ok := administered[old.Labels[netsys_v1.TenantLabel]]; !ok {
+			return fmt.Sprintf("DispatchUser %s is not in a tenant you administer (%s)",
+				old.Name, strings.Join(names, ", ")), nil
+		}
+	}
+	if du == nil {
+		return "", nil
+	}
+	t, ok := administered[du.Labels[netsys_v1.TenantLabel]]
+	if !ok {
+		return fmt.Sprintf("DispatchUser %s must be labeled %s with a tenant you administer (%s)",
+			du.Name, netsys_v1.TenantLabel, strings.Join(names, ", ")), nil
+	}
+	if netsys_v1.ReservedUserID(du.Spec.UserID) {
+		// it would be bound to a ServiceAccount of dispatch or a robot
+		return fmt.Sprintf("user ID %s is reserved for dispatch", du.Spec.UserID), nil
+	}
+	if conflict := t.Conflict(tenants); conflict != "" {
+		return fmt.Sprintf("tenant %s is refused: %s", t.Name, conflict), nil
+	}
+	var oldGroups []string
+	if old != nil {
+		oldGroups = old.Spec.Groups
+	}
+	if len(du.Spec.Groups) > 0 && !equality.Semantic.DeepEqual(oldGroups, du.Spec.Groups) {
+		// groups could make the user an admin of another tenant
+		return "the groups of a DispatchUser are managed by cluster admins", nil
+	}
+	return s.reviewBudget(t, old, du)
+}
+
+// reviewBudget returns why du does not fit into the budget of its tenant t
+// after the change from old, or "" if it fits
+func (s *Server) reviewBudget(t *netsys_v1.DispatchTenant, old, du *netsys_v1.DispatchUser) (string, error) {
+	users, err := s.duLister.DispatchUsers(s.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+	owned, err := s.onLister.OwnedNamespaces(s.config.DispatchNamespace).List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+
+	members := 0
+	tenantOf := map[string]string{}
+	// namespaces granted to the tenant's other users
+	granted := map[string]bool{}
+	for _, other := range users {
+		tenantOf[other.Spec.UserID] = other.Labels[netsys_v1.TenantLabel]
+		if other.Name == du.Name {
+			continue
+		}
+		if other.Spec.UserID == du.Spec.UserID {
+			// they would share a ServiceAccount
+			return fmt.Sprintf("user ID %s is taken by DispatchUser %s", du.Spec.UserID, other.Name), nil
+		}
+		if other.Labels[netsys_v1.TenantLabel] != t.Name {
+			continue
+		}
+		members++
+		for _, g := range other.Spec.EffectiveGrants() {
+			granted[g.Key()] = true
+		}
+	}
+	joins := old == nil || old.Labels[netsys_v1.TenantLabel] != t.Name
+	if max := t.Spec.MaxUsers; max > 0 && joins && members >= max {
+		return fmt.Sprintf("tenant %s has its maximum of %d users", t.Name, max), nil
+	}
+
+	before := len(granted)
+	if !joins {
+		before = count(granted, old.Spec.EffectiveGrants())
+	}
+	after := count(granted, du.Spec.EffectiveGrants())
+	if max := t.Spec.MaxNamespaces; max > 0 && after > max && after > before {
+		return fmt.Sprintf("tenant %s would hold %d namespaces, its maximum is %d", t.Name, after, max), nil
+	}
+
+	foreign := map[string]bool{}
+	for _, on := range owned {
+		if tenantOf[on.Spec.OwnerID] != t.Name {
+			foreign[on.Key()] = true
+		}
+	}
+	for _, g := range du.Spec.EffectiveGrants() {
+		if reason := t.Allows(g); reason != "" {
+			return reason, nil
+		}
+		if foreign[g.Key()] && !granted[g.Key()] {
+			return fmt.Sprintf("namespace %s is held by users outside tenant %s", g.Key(), t.Name), nil
+		}
+	}
+	return "", nil
+}
+
+// decode returns the DispatchUser of req before and after the change, nil
+// when it is created or deleted. API servers that do not send the old
+// object of a deletion have it looked up in the cache.
+func (s *Server) decode(req *admissionRequest) (old, du *netsys_v1.DispatchUser, err error) {
+	if len(req.Object) > 0 && req.Operation != "DELETE" {
+		du = &netsys_v1.DispatchUser{}
+		if err := json.Unmarshal(req.Object, du); err != nil {
+			return nil, nil, fmt.Errorf("decoding object: %v", err)
+		}
+	}
+	if req.Operation == "CREATE" {
+		return nil, du, nil
+	}
+	if len(req.OldObject) > 0 {
+		old = &netsys_v1.DispatchUser{}
+		if err := json.Unmarshal(req.OldObject, old); err != nil {
+			return nil, nil, fmt.Errorf("decoding old object: %v", err)
+		}
+		return old, du, nil
+	}
+	old, err = s.duLister.DispatchUsers(req.Namespace).Get(req.Name)
+	if errors.IsNotFound(err) {
+		return nil, du, nil
+	}
+	return old, du, err
+}
+
+// count returns how many namespaces granted and grants hold together
+func count(granted map[string]bool, grants []netsys_v1.NamespaceGrant) int {
+	n := len(granted)
+	seen := map[string]bool{}
+	for _, g := range grants {
+		if k := g.Key(); !granted[k] && !seen[k] {
+			seen[k] = true
+			n++
+		}
+	}
+	return n
+}
+
+// contains returns true if s is in list
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
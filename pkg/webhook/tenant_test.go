@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	auth_v1 "k8s.io/api/authentication/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	netsys_v1 "github.com/hantaowang/dispatch/pkg/apis/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+)
+
+// user returns a DispatchUser of tenant, none if empty, granted namespaces
+func user(userID, tenant string, namespaces ...string) *netsys_v1.DispatchUser {
+	du := &netsys_v1.DispatchUser{
+		ObjectMeta: meta_v1.ObjectMeta{Name: userID, Namespace: "dispatch", Labels: map[string]string{}},
+		Spec:       netsys_v1.DispatchUserSpec{UserID: userID},
+	}
+	if tenant != "" {
+		du.Labels[netsys_v1.TenantLabel] = tenant
+	}
+	for _, ns := range namespaces {
+		du.Spec.Grants = append(du.Spec.Grants, netsys_v1.NamespaceGrant{Namespace: ns})
+	}
+	return du
+}
+
+// newTestServer returns a Server whose caches hold two tenants: team-a,
+// administered by alice, with the users bob and erin, and team-b with
+// carol. mallory belongs to no tenant but holds team-a-secret.
+func newTestServer(t *testing.T) *Server {
+	indexer := func(objs ...interface{}) cache.Indexer {
+		i := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		for _, obj := range objs {
+			if err := i.Add(obj); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return i
+	}
+	tenants := indexer(
+		&netsys_v1.DispatchTenant{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "team-a", Namespace: "dispatch"},
+			Spec: netsys_v1.DispatchTenantSpec{Admins: []string{"alice"}, Prefix: "team-a-",
+				MaxUsers: 2, MaxNamespaces: 3},
+		},
+		&netsys_v1.DispatchTenant{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "team-b", Namespace: "dispatch"},
+			Spec:       netsys_v1.DispatchTenantSpec{Admins: []string{"carol"}, Prefix: "team-b-"},
+		},
+	)
+	users := indexer(
+		user("bob", "team-a", "team-a-web", "team-a-db"),
+		user("erin", "team-a"),
+		user("carol", "team-b", "team-b-api"),
+		user("mallory", ""),
+	)
+	owned := indexer(&netsys_v1.OwnedNamespace{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "mallory-team-a-secret", Namespace: "dispatch"},
+		Spec:       netsys_v1.OwnedNamespaceSpec{OwnerID: "mallory", Namespace: "team-a-secret"},
+	})
+	return &Server{
+		dtLister: netsys_lister.NewDispatchTenantLister(tenants),
+		duLister: netsys_lister.NewDispatchUserLister(users),
+		onLister: netsys_lister.NewOwnedNamespaceLister(owned),
+		synced:   func() bool { return true },
+		config:   config.Default(),
+	}
+}
+
+func TestReviewDispatchUser(t *testing.T) {
+	s := newTestServer(t)
+	const alice = serviceAccountPrefix + "dispatch:alice"
+
+	tests := []struct {
+		name     string
+		username string
+		groups   []string
+		old, du  *netsys_v1.DispatchUser
+		reason   string
+	}{{
+		name:     "grant within the budget",
+		username: alice,
+		old:      user("bob", "team-a", "team-a-web", "team-a-db"),
+		du:       user("bob", "team-a", "team-a-web", "team-a-db", "team-a-api"),
+	}, {
+		name:     "grant a namespace another member holds",
+		username: alice,
+		old:      user("erin", "team-a"),
+		du:       user("erin", "team-a", "team-a-web"),
+	}, {
+		name:     "too many namespaces",
+		username: alice,
+		old:      user("bob", "team-a", "team-a-web", "team-a-db"),
+		du:       user("bob", "team-a", "team-a-web", "team-a-db", "team-a-x", "team-a-y"),
+		reason:   "tenant team-a would hold 4 namespaces, its maximum is 3",
+	}, {
+		name:     "too many users",
+		username: alice,
+		du:       user("dave", "team-a"),
+		reason:   "tenant team-a has its maximum of 2 users",
+	}, {
+		name:     "namespace outside the prefix",
+		username: alice,
+		old:      user("erin", "team-a"),
+		du:       user("erin", "team-a", "team-b-api"),
+		reason:   "namespace team-b-api does not start with team-a-",
+	}, {
+		name:     "namespace held outside the tenant",
+		username: alice,
+		old:      user("erin", "team-a"),
+		du:       user("erin", "team-a", "team-a-secret"),
+		reason:   "namespace team-a-secret is held by users outside tenant team-a",
+	}, {
+		name:     "user ID taken",
+		username: alice,
+		du:       func() *netsys_v1.DispatchUser { du := user("carol", "team-a"); du.Name = "carol-2"; return du }(),
+		reason:   "user ID carol is taken by DispatchUser carol",
+	}, {
+		name:     "reserved user ID",
+		username: alice,
+		du:       user("dispatch-controller", "team-a"),
+		reason:   "user ID dispatch-controller is reserved",
+	}, {
+		name:     "groups",
+		username: alice,
+		old:      user("erin", "team-a"),
+		du: func() *netsys_v1.DispatchUser {
+			du := user("erin", "team-a")
+			du.Spec.Groups = []string{"admins"}
+			return du
+		}(),
+		reason: "the groups of a DispatchUser are managed by cluster admins",
+	}, {
+		name:     "status",
+		username: alice,
+		old:      user("erin", "team-a"),
+		du:       func() *netsys_v1.DispatchUser { du := user("erin", "team-a"); du.Status.Expired = true; return du }(),
+		reason:   "the status of a DispatchUser is written by dispatch only",
+	}, {
+		name:     "user of another tenant",
+		username: alice,
+		old:      user("carol", "team-b", "team-b-api"),
+		du:       user("carol", "team-b", "team-b-api", "team-b-web"),
+		reason:   "DispatchUser carol is not in a tenant you administer (team-a)",
+	}, {
+		name:     "label of another tenant",
+		username: alice,
+		du:       user("dave", "team-b"),
+		reason:   "DispatchUser dave must be labeled netsys.io/tenant with a tenant you administer (team-a)",
+	}, {
+		name:     "delete a member",
+		username: alice,
+		old:      user("bob", "team-a", "team-a-web", "team-a-db"),
+	}, {
+		name:     "not a tenant admin",
+		username: "zed",
+		du:       user("dave", "team-b", "anything"),
+	}, {
+		name:     "exempt group",
+		username: alice,
+		groups:   []string{"system:masters"},
+		du:       user("dave", "team-a", "team-a-x", "team-a-y"),
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &admissionRequest{
+				Namespace: "dispatch",
+				UserInfo:  auth_v1.UserInfo{Username: test.username, Groups: test.groups},
+			}
+			switch {
+			case test.old == nil:
+				req.Operation = "CREATE"
+			case test.du == nil:
+				req.Operation = "DELETE"
+			default:
+				req.Operation = "UPDATE"
+			}
+			if test.du != nil {
+				req.Name = test.du.Name
+				req.Object, _ = json.Marshal(test.du)
+			}
+			if test.old != nil {
+				req.Name = test.old.Name
+				req.OldObject, _ = json.Marshal(test.old)
+			}
+
+			reason, err := s.reviewDispatchUser(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case test.reason == "" && reason != "":
+				t.Errorf("expected the change to be allowed, got %q", reason)
+			case test.reason != "" && !strings.Contains(reason, test.reason):
+				t.Errorf("expected a denial containing %q, got %q", test.reason, reason)
+			}
+		})
+	}
+}
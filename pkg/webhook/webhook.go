@@ -0,0 +1,146 @@
+// Package webhook serves the validating admission webhook of dispatch. It
+// keeps tenant admins to the DispatchUsers of the tenants they administer
+// and to the budget of those tenants.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	auth_v1 "k8s.io/api/authentication/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	netsys_informer "github.com/hantaowang/dispatch/pkg/client/informers/externalversions/netsysio/v1"
+	netsys_lister "github.com/hantaowang/dispatch/pkg/client/listers/netsysio/v1"
+	"github.com/hantaowang/dispatch/pkg/config"
+	"github.com/hantaowang/dispatch/pkg/logging"
+)
+
+// DispatchUsersPath is where the API server sends reviews of DispatchUsers
+const DispatchUsersPath = "/validate/dispatchusers"
+
+// admissionReview is the part of an admission.k8s.io/v1beta1
+// AdmissionReview the webhook reads and answers
+type admissionReview struct {
+	APIVersion string             `json:"apiVersion,omitempty"`
+	Kind       string             `json:"kind,omitempty"`
+	Request    *admissionRequest  `json:"request,omitempty"`
+	Response   *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID       types.UID        `json:"uid"`
+	Name      string           `json:"name,omitempty"`
+	Namespace string           `json:"namespace,omitempty"`
+	Operation string           `json:"operation"`
+	UserInfo  auth_v1.UserInfo `json:"userInfo"`
+	// the object after and before the change, empty on DELETE and CREATE
+	Object    json.RawMessage `json:"object,omitempty"`
+	OldObject json.RawMessage `json:"oldObject,omitempty"`
+}
+
+type admissionResponse struct {
+	UID     types.UID       `json:"uid"`
+	Allowed bool            `json:"allowed"`
+	Result  *meta_v1.Status `json:"status,omitempty"`
+}
+
+// Server answers admission reviews from the caches of the controllers
+type Server struct {
+	dtLister netsys_lister.DispatchTenantLister
+	duLister netsys_lister.DispatchUserLister
+	onLister netsys_lister.OwnedNamespaceLister
+
+	// returns true once the caches are ready
+	synced func() bool
+
+	config *config.Config
+}
+
+// NewServer creates a new Server
+func NewServer(
+	dtInformer netsys_informer.DispatchTenantInformer,
+	duInformer netsys_informer.DispatchUserInformer,
+	onInformer netsys_informer.OwnedNamespaceInformer,
+	cfg *config.Config,
+) *Server {
+	return &Server{
+		dtLister: dtInformer.Lister(),
+		duLister: duInformer.Lister(),
+		onLister: onInformer.Lister(),
+		synced: func() bool {
+			return dtInformer.Informer().HasSynced() && duInformer.Informer().HasSynced() &&
+				onInformer.Informer().HasSynced()
+		},
+		config: cfg,
+	}
+}
+
+// Run serves the webhook over TLS on webhook.address
+func (s *Server) Run() {
+	mux := http.NewServeMux()
+	mux.Handle(DispatchUsersPath, s.handler(s.reviewDispatchUser))
+	logging.Info("Serving admission webhook", "address", s.config.Webhook.Address)
+	err := http.ListenAndServeTLS(s.config.Webhook.Address, s.config.Webhook.CertFile, s.config.Webhook.KeyFile, mux)
+	if err != nil {
+		logging.Error("Serving admission webhook failed", "error", err)
+	}
+}
+
+// handler decodes an AdmissionReview, lets review decide on its request and
+// writes the answer. review returns why the request is denied, or "".
+func (s *Server) handler(review func(*admissionRequest) (string, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var ar admissionReview
+		if err := json.Unmarshal(body, &ar); err != nil || ar.Request == nil {
+			http.Error(w, fmt.Sprintf("invalid AdmissionReview: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		req := ar.Request
+		resp := &admissionResponse{UID: req.UID, Allowed: true}
+		var reason string
+		if !s.synced() {
+			// empty caches would let every request pass
+			err = fmt.Errorf("caches not synced")
+		} else {
+			reason, err = review(req)
+		}
+		if err != nil {
+			// the API server applies the failure policy of the webhook
+			logging.Error("Admission review failed", "user", req.UserInfo.Username, "name", req.Name, "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if reason != "" {
+			logging.Warn("Denied admission", "user", req.UserInfo.Username, "operation", req.Operation,
+				"name", req.Name, "reason", reason)
+			resp.Allowed = false
+			resp.Result = &meta_v1.Status{
+				Status:  meta_v1.StatusFailure,
+				Message: reason,
+				Reason:  meta_v1.StatusReasonForbidden,
+				Code:    http.StatusForbidden,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(admissionReview{
+			APIVersion: ar.APIVersion,
+			Kind:       ar.Kind,
+			Response:   resp,
+		})
+	})
+}